@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	stdErrors "errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/iamNilotpal/ignite/internal/httpapi"
+	"github.com/iamNilotpal/ignite/internal/resp"
+	"github.com/iamNilotpal/ignite/pkg/errors"
+	"github.com/iamNilotpal/ignite/pkg/ignite"
+	"github.com/iamNilotpal/ignite/pkg/logger"
+	"github.com/iamNilotpal/ignite/pkg/options"
+	"go.uber.org/zap"
+)
+
+// shutdownTimeout bounds how long the daemon waits for clients to finish their
+// requests before their connections are closed.
+const shutdownTimeout = 10 * time.Second
+
+// envPrefix starts the names of the environment variables the daemon reads options
+// from.
+const envPrefix = "IGNITE_"
+
+// server is a protocol front end the daemon serves the instance through.
+type server interface {
+	ListenAndServe(addr string) error
+	Shutdown(ctx context.Context) error
+}
+
+// listener is a server together with the address it is served on.
+type listener struct {
+	addr   string
+	server server
+}
+
+// runDaemon implements "ignite" without a command: it opens the data directory and
+// serves it over the Redis protocol and HTTP until it receives SIGINT or SIGTERM.
+// SIGHUP reloads the settings that can change at runtime.
+func runDaemon(args []string) int {
+	flags := flag.NewFlagSet("ignite", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: ignite [flags]\n\nFlags:\n")
+		flags.PrintDefaults()
+	}
+
+	addr := flags.String("addr", ":6379", "address to serve the Redis protocol on; empty disables it")
+	httpAddr := flags.String("http-addr", ":8080", "address to serve the HTTP API on; empty disables it")
+	dataDir := flags.String("data-dir", options.DefaultDataDir, "data directory")
+	config := flags.String("config", "", "configuration file (.json, .yaml or .toml)")
+	httpRead := flags.Duration("http-read-timeout", httpapi.DefaultReadTimeout, "longest time to read an HTTP request, body included")
+	httpWrite := flags.Duration("http-write-timeout", httpapi.DefaultWriteTimeout, "longest time to write an HTTP response")
+	httpIdle := flags.Duration("http-idle-timeout", httpapi.DefaultIdleTimeout, "longest time an idle HTTP keep-alive connection is kept open")
+	layout := layoutFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 0 || (*addr == "" && *httpAddr == "") {
+		flags.Usage()
+		return 2
+	}
+
+	log := logger.New("ignited")
+	defer log.Sync()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	opts, err := loadOptions(flags, *config, *dataDir, layout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ignite: invalid configuration:\n%s", describeOptionsError(err))
+		return 2
+	}
+
+	instance, err := ignite.NewInstance(ctx, "ignited", opts...)
+	if errors.IsValidationError(err) {
+		fmt.Fprintf(os.Stderr, "ignite: invalid configuration:\n%s", describeOptionsError(err))
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ignite: %v\n", err)
+		return 1
+	}
+
+	var servers []listener
+	if *addr != "" {
+		server, err := resp.New(&resp.Config{Instance: instance, Logger: log})
+		if err != nil {
+			instance.Close(context.Background())
+			fmt.Fprintf(os.Stderr, "ignite: %v\n", err)
+			return 1
+		}
+		servers = append(servers, listener{addr: *addr, server: server})
+	}
+	if *httpAddr != "" {
+		server, err := httpapi.New(&httpapi.Config{
+			Instance:     instance,
+			Logger:       log,
+			ReadTimeout:  *httpRead,
+			WriteTimeout: *httpWrite,
+			IdleTimeout:  *httpIdle,
+		})
+		if err != nil {
+			instance.Close(context.Background())
+			fmt.Fprintf(os.Stderr, "ignite: %v\n", err)
+			return 1
+		}
+		servers = append(servers, listener{addr: *httpAddr, server: server})
+	}
+
+	serveErr := make(chan error, len(servers))
+	for _, l := range servers {
+		go func() { serveErr <- l.server.ListenAndServe(l.addr) }()
+	}
+	log.Infow("Serving data directory", "addr", *addr, "httpAddr", *httpAddr)
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	// Run until a signal arrives or a server fails, reloading the configuration on
+	// SIGHUP, then stop every server before closing the instance they share.
+	code, pending := 0, len(servers)
+run:
+	for {
+		select {
+		case <-hup:
+			reload(log, instance, flags, *config, *dataDir, layout)
+		case err := <-serveErr:
+			fmt.Fprintf(os.Stderr, "ignite: %v\n", err)
+			code, pending = 1, pending-1
+			break run
+		case <-ctx.Done():
+			log.Infow("Shutting down")
+			break run
+		}
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	for _, l := range servers {
+		if err := l.server.Shutdown(shutdownCtx); err != nil && !isServerClosed(err) {
+			log.Warnw("Clients did not finish before the shutdown timeout", "error", err)
+		}
+	}
+	for ; pending > 0; pending-- {
+		if err := <-serveErr; err != nil && !isServerClosed(err) {
+			log.Warnw("Server stopped with an error", "error", err)
+		}
+	}
+
+	if err := instance.Close(context.Background()); err != nil {
+		fmt.Fprintf(os.Stderr, "ignite: %v\n", err)
+		return 1
+	}
+	return code
+}
+
+// isServerClosed reports whether err only says that a server was shut down.
+func isServerClosed(err error) bool {
+	return stdErrors.Is(err, resp.ErrServerClosed) || stdErrors.Is(err, httpapi.ErrServerClosed)
+}
+
+// reload re-reads the configuration file and the environment and applies the result
+// to instance, as on startup. Settings that cannot change while the store is open
+// must keep their values, or nothing is applied; settings changed with CONFIG SET
+// revert to the configured values. A failed reload leaves the daemon running with
+// its current configuration.
+func reload(log *zap.SugaredLogger, instance *ignite.Instance, flags *flag.FlagSet, config, dataDir string, layout func() []options.OptionFunc) {
+	log.Infow("Reloading configuration", "config", config)
+
+	opts, err := loadOptions(flags, config, dataDir, layout)
+	if err == nil {
+		err = instance.Reconfigure(append([]options.OptionFunc{options.WithDefaultOptions()}, opts...)...)
+	}
+	if err != nil {
+		log.Warnw("Configuration was not reloaded", "error", strings.TrimSpace(describeOptionsError(err)))
+		return
+	}
+	log.Infow("Configuration reloaded")
+}
+
+// loadOptions gathers the options of the daemon by precedence: the defaults, then the
+// configuration file, then IGNITE_ environment variables, then the flags given on the
+// command line.
+func loadOptions(flags *flag.FlagSet, config, dataDir string, layout func() []options.OptionFunc) ([]options.OptionFunc, error) {
+	var opts []options.OptionFunc
+	if config != "" {
+		opt, err := options.LoadFile(config)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, opt)
+	}
+
+	opt, err := options.FromEnv(envPrefix)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, opt)
+
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "data-dir" {
+			opts = append(opts, options.WithDataDir(dataDir))
+		}
+	})
+	return append(opts, layout()...), nil
+}
+
+// describeOptionsError lists every problem in an error returned by the options
+// loaders or by option validation, one per line, with the field, value and rule of each validation error.
+func describeOptionsError(err error) string {
+	var b strings.Builder
+
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+
+	for _, err := range errs {
+		ve, ok := errors.AsValidationError(err)
+		if !ok {
+			fmt.Fprintf(&b, "  %v", err)
+			if cause := stdErrors.Unwrap(err); cause != nil {
+				fmt.Fprintf(&b, ": %v", cause)
+			}
+			b.WriteString("\n")
+			continue
+		}
+
+		fmt.Fprintf(&b, "  %s: %s", ve.Field(), ve.Error())
+		if provided := ve.Provided(); provided != nil {
+			fmt.Fprintf(&b, " (got %v)", provided)
+		}
+		details := errors.GetErrorDetails(err)
+		switch {
+		case ve.Expected() != nil:
+			fmt.Fprintf(&b, ", expected %v", ve.Expected())
+		case ve.Rule() == "range" && details["maxValue"] != nil:
+			fmt.Fprintf(&b, ", allowed %v to %v", details["minValue"], details["maxValue"])
+		case ve.Rule() == "range":
+			fmt.Fprintf(&b, ", minimum %v", details["minValue"])
+		}
+		if issue, ok := details["validationIssue"]; ok {
+			fmt.Fprintf(&b, ": %v", issue)
+		}
+		if cause := stdErrors.Unwrap(err); cause != nil {
+			fmt.Fprintf(&b, ": %v", cause)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
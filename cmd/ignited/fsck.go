@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/iamNilotpal/ignite/pkg/fsck"
+	"github.com/iamNilotpal/ignite/pkg/options"
+)
+
+// runFsck implements "ignite fsck". It prints the report as JSON on stdout and exits
+// with 0 when the directory is healthy, 1 when it is not, and 2 when the check could
+// not run.
+func runFsck(args []string) int {
+	flags := flag.NewFlagSet("fsck", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: ignite fsck [flags] <dataDir>\n\nFlags:\n")
+		flags.PrintDefaults()
+	}
+
+	layout := layoutFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	report, err := fsck.Check(ctx, flags.Arg(0), layout()...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ignite fsck: %v\n", err)
+		return 2
+	}
+
+	if err := printJSON(report); err != nil {
+		fmt.Fprintf(os.Stderr, "ignite fsck: %v\n", err)
+		return 2
+	}
+
+	if !report.Healthy {
+		return 1
+	}
+	return 0
+}
+
+// layoutFlags registers the flags describing where a data directory keeps its files
+// and returns a function producing the matching options once the flags are parsed.
+// Only flags given on the command line produce an option, so that they override a
+// configuration file without their defaults doing so too.
+func layoutFlags(flags *flag.FlagSet) func() []options.OptionFunc {
+	segmentDir := flags.String("segment-dir", options.DefaultSegmentDirectory, "segment directory within the data directory")
+	segmentPrefix := flags.String("segment-prefix", options.DefaultSegmentPrefix, "file name prefix of segment files")
+	blobDir := flags.String("blob-dir", options.DefaultBlobDirectory, "blob directory within the data directory")
+
+	return func() []options.OptionFunc {
+		var opts []options.OptionFunc
+		flags.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "segment-dir":
+				opts = append(opts, options.WithSegmentDir(*segmentDir))
+			case "segment-prefix":
+				opts = append(opts, options.WithSegmentPrefix(*segmentPrefix))
+			case "blob-dir":
+				opts = append(opts, options.WithBlobDir(*blobDir))
+			}
+		})
+		return opts
+	}
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/iamNilotpal/ignite/pkg/inspect"
+)
+
+// inspectUsage describes the subcommands of "ignite inspect".
+const inspectUsage = `Usage:
+  ignite inspect segment [flags] <file>      List the entries of a segment file.
+  ignite inspect key [flags] <dir> <key>     Show the record pointer and on-disk versions of a key.
+  ignite inspect hint [flags] <file>         List the records of a hint file and compare them with its segment.
+
+Run "ignite inspect <command> -h" for the flags of a command.
+`
+
+// runInspect implements "ignite inspect". Every subcommand prints a table, or JSON with
+// --json, and exits with 2 when it could not run.
+func runInspect(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, inspectUsage)
+		return 2
+	}
+
+	switch args[0] {
+	case "segment":
+		return runInspectSegment(args[1:])
+	case "key":
+		return runInspectKey(args[1:])
+	case "hint":
+		return runInspectHint(args[1:])
+	case "help", "-h", "-help", "--help":
+		fmt.Print(inspectUsage)
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "ignite inspect: unknown command %q\n\n%s", args[0], inspectUsage)
+		return 2
+	}
+}
+
+// runInspectSegment implements "ignite inspect segment". It exits with 0 when the
+// segment is intact and 1 when any part of it is damaged.
+func runInspectSegment(args []string) int {
+	flags := flag.NewFlagSet("inspect segment", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: ignite inspect segment [flags] <file>\n\nFlags:\n")
+		flags.PrintDefaults()
+	}
+
+	layout := layoutFlags(flags)
+	asJSON := flags.Bool("json", false, "print JSON instead of a table")
+	keyPattern := flags.String("key", "", "only list entries whose key matches this regular expression")
+	from := flags.Int64("from", 0, "only list entries starting at or after this offset")
+	to := flags.Int64("to", 0, "only list entries starting before this offset (0 for no limit)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	filter := inspect.Filter{From: *from, To: *to}
+	if *keyPattern != "" {
+		pattern, err := regexp.Compile(*keyPattern)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ignite inspect segment: invalid key pattern: %v\n", err)
+			return 2
+		}
+		filter.Key = pattern
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	dump, err := inspect.Segment(ctx, flags.Arg(0), filter, layout()...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ignite inspect segment: %v\n", err)
+		return 2
+	}
+
+	if *asJSON {
+		err = printJSON(dump)
+	} else {
+		err = printSegment(os.Stdout, dump)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ignite inspect segment: %v\n", err)
+		return 2
+	}
+
+	if dump.Damaged {
+		return 1
+	}
+	return 0
+}
+
+// runInspectKey implements "ignite inspect key". It exits with 0 when the key exists
+// and 1 when it does not.
+func runInspectKey(args []string) int {
+	flags := flag.NewFlagSet("inspect key", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: ignite inspect key [flags] <dataDir> <key>\n\nFlags:\n")
+		flags.PrintDefaults()
+	}
+
+	layout := layoutFlags(flags)
+	asJSON := flags.Bool("json", false, "print JSON instead of a table")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	history, err := inspect.Key(ctx, flags.Arg(0), flags.Arg(1), layout()...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ignite inspect key: %v\n", err)
+		return 2
+	}
+
+	if *asJSON {
+		err = printJSON(history)
+	} else {
+		err = printKey(os.Stdout, history)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ignite inspect key: %v\n", err)
+		return 2
+	}
+
+	if history.Pointer == nil {
+		return 1
+	}
+	return 0
+}
+
+// runInspectHint implements "ignite inspect hint". The file may be the hint file or
+// the segment file it belongs to. It exits with 0 when the hint matches its segment and
+// 1 when the store would not trust it.
+func runInspectHint(args []string) int {
+	flags := flag.NewFlagSet("inspect hint", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: ignite inspect hint [flags] <file>\n\nFlags:\n")
+		flags.PrintDefaults()
+	}
+
+	layout := layoutFlags(flags)
+	asJSON := flags.Bool("json", false, "print JSON instead of a table")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	dump, err := inspect.Hint(ctx, flags.Arg(0), layout()...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ignite inspect hint: %v\n", err)
+		return 2
+	}
+
+	if *asJSON {
+		err = printJSON(dump)
+	} else {
+		err = printHint(os.Stdout, dump)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ignite inspect hint: %v\n", err)
+		return 2
+	}
+
+	if dump.Stale != "" {
+		return 1
+	}
+	return 0
+}
+
+// printJSON writes v to stdout as indented JSON.
+func printJSON(v any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// printSegment writes a segment dump as a table.
+func printSegment(w io.Writer, dump *inspect.SegmentDump) error {
+	fmt.Fprintf(w, "file:       %s\n", dump.File)
+	fmt.Fprintf(w, "segment:    %d\n", dump.ID)
+	fmt.Fprintf(w, "size:       %d\n", dump.Size)
+	if dump.Encrypted {
+		fmt.Fprintf(w, "encrypted:  key %s, entries from offset %d\n", dump.KeyID, dump.DataStart)
+	}
+	fmt.Fprintf(w, "damaged:    %t\n\n", dump.Damaged)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "OFFSET\tSIZE\tTIMESTAMP\tVERSION\tFLAGS\tKEY\tVALUE SIZE\tCHECKSUM")
+	for _, entry := range dump.Entries {
+		writeEntry(tw, &entry)
+	}
+	return tw.Flush()
+}
+
+// printHint writes a hint dump as a table.
+func printHint(w io.Writer, dump *inspect.HintDump) error {
+	fmt.Fprintf(w, "file:       %s\n", dump.File)
+	fmt.Fprintf(w, "segment:    %d (%s, %d bytes)\n", dump.ID, dump.Segment, dump.SegmentSize)
+	fmt.Fprintf(w, "sealed:     %t\n", dump.Sealed)
+	if dump.Stale != "" {
+		fmt.Fprintf(w, "stale:      %s\n\n", dump.Stale)
+	} else {
+		fmt.Fprintf(w, "stale:      no\n\n")
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "OFFSET\tSIZE\tTIMESTAMP\tVERSION\tFLAGS\tKEY\tVALUE SIZE")
+	for _, record := range dump.Records {
+		key := fmt.Sprintf("%q", record.Key)
+		if record.Encrypted {
+			key = "(sealed)"
+		}
+		fmt.Fprintf(
+			tw, "%d\t%d\t%s\t%d\t%s\t%s\t%d\n",
+			record.Offset, record.Size, record.Timestamp.Format(time.RFC3339Nano), record.Version,
+			entryFlags(&record), key, record.ValueSize,
+		)
+	}
+	return tw.Flush()
+}
+
+// printKey writes a key history as a table.
+func printKey(w io.Writer, history *inspect.KeyHistory) error {
+	fmt.Fprintf(w, "key:      %q\n", history.Key)
+	if p := history.Pointer; p != nil {
+		fmt.Fprintf(
+			w, "pointer:  segment %d, offset %d, entry size %d, value size %d, written %s\n",
+			p.SegmentID, p.Offset, p.EntrySize, p.ValueSize, p.Timestamp.Format(time.RFC3339Nano),
+		)
+	} else {
+		fmt.Fprintln(w, "pointer:  none (the key does not exist)")
+	}
+	if len(history.Sealed) > 0 {
+		fmt.Fprintf(w, "sealed:   segments %v are encrypted and were not searched\n", history.Sealed)
+	}
+	fmt.Fprintln(w)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SEGMENT\tOFFSET\tSIZE\tTIMESTAMP\tVERSION\tFLAGS\tKEY\tVALUE SIZE\tCHECKSUM\tLIVE")
+	for _, version := range history.Versions {
+		fmt.Fprintf(tw, "%d\t", version.SegmentID)
+		writeEntry(tw, &version.EntryInfo, fmt.Sprintf("%t", version.Live))
+	}
+	return tw.Flush()
+}
+
+// writeEntry writes one table row for an entry, followed by any extra columns.
+func writeEntry(w io.Writer, entry *inspect.EntryInfo, extra ...string) {
+	if entry.Checksum == inspect.ChecksumUnreadable {
+		fmt.Fprintf(w, "%d\t%d\t-\t-\t-\t-\t-\t%s", entry.Offset, entry.Size, entry.Checksum)
+	} else {
+		key := fmt.Sprintf("%q", entry.Key)
+		if entry.Encrypted {
+			key = "(sealed)"
+		}
+		fmt.Fprintf(
+			w, "%d\t%d\t%s\t%d\t%s\t%s\t%d\t%s",
+			entry.Offset, entry.Size, entry.Timestamp.Format(time.RFC3339Nano), entry.Version,
+			entryFlags(entry), key, entry.ValueSize, entry.Checksum,
+		)
+	}
+	for _, column := range extra {
+		fmt.Fprintf(w, "\t%s", column)
+	}
+	fmt.Fprintln(w)
+}
+
+// entryFlags renders the flags of an entry as a comma-separated list.
+func entryFlags(entry *inspect.EntryInfo) string {
+	var flags []string
+	if entry.Tombstone {
+		flags = append(flags, "tombstone")
+	}
+	if entry.Blob {
+		flags = append(flags, "blob")
+	}
+	if entry.Encrypted {
+		flags = append(flags, "encrypted")
+	}
+	if entry.Codec != "" {
+		flags = append(flags, entry.Codec)
+	}
+	if !entry.ExpiresAt.IsZero() {
+		flags = append(flags, "expires="+entry.ExpiresAt.Format(time.RFC3339))
+	}
+	if len(flags) == 0 {
+		return "-"
+	}
+	return strings.Join(flags, ",")
+}
@@ -1,5 +1,39 @@
 package main
 
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// usage describes the commands the binary understands.
+const usage = `Usage:
+  ignite [flags]               Serve a data directory over the Redis protocol and HTTP.
+  ignite fsck [flags] <dir>    Verify an offline data directory and print a JSON report.
+  ignite repair [flags] <dir>  Salvage damaged segments of an offline data directory.
+  ignite inspect <command>     Show the entries of a segment file or the versions of a key.
+
+Run "ignite <command> -h" for the flags of a command.
+`
+
 func main() {
-	println("Running ignite daemon...")
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		command, args := os.Args[1], os.Args[2:]
+		switch command {
+		case "fsck":
+			os.Exit(runFsck(args))
+		case "repair":
+			os.Exit(runRepair(args))
+		case "inspect":
+			os.Exit(runInspect(args))
+		case "help":
+			fmt.Print(usage)
+			return
+		default:
+			fmt.Fprintf(os.Stderr, "ignite: unknown command %q\n\n%s", command, usage)
+			os.Exit(2)
+		}
+	}
+
+	os.Exit(runDaemon(os.Args[1:]))
 }
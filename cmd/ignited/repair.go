@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/iamNilotpal/ignite/pkg/fsck"
+)
+
+// runRepair implements "ignite repair". It prints the report as JSON on stdout and
+// exits with 0 when no key was lost, 1 when keys were or may have been lost, and 2
+// when the repair could not run.
+func runRepair(args []string) int {
+	flags := flag.NewFlagSet("repair", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: ignite repair [flags] <dataDir>\n\nFlags:\n")
+		flags.PrintDefaults()
+	}
+
+	layout := layoutFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	report, err := fsck.Repair(ctx, flags.Arg(0), layout()...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ignite repair: %v\n", err)
+		return 2
+	}
+
+	if err := printJSON(report); err != nil {
+		fmt.Fprintf(os.Stderr, "ignite repair: %v\n", err)
+		return 2
+	}
+
+	if report.KeysLost > 0 || report.UnidentifiedEntries > 0 || report.UnidentifiedBytes > 0 {
+		return 1
+	}
+	return 0
+}
@@ -2,6 +2,10 @@ module github.com/iamNilotpal/ignite
 
 go 1.24.2
 
-require go.uber.org/zap v1.27.0
+require (
+	github.com/BurntSushi/toml v1.6.0
+	go.uber.org/zap v1.27.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require go.uber.org/multierr v1.10.0 // indirect
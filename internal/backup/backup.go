@@ -0,0 +1,161 @@
+// Package backup writes and restores copies of an Ignite data directory.
+//
+// A backup holds the sealed segment files of the store, the blob files their entries
+// refer to, and a MANIFEST listing exactly those segments, laid out like a data
+// directory with default directory names:
+//
+//	segments/<segment file>
+//	blobs/<blob file>
+//	MANIFEST
+//	BACKUP.json
+//
+// BACKUP.json always comes last. It lists every other file with its size and SHA-256
+// checksum, so a backup that was cut short or altered is detected before anything is
+// restored. Backups are written either as a tar stream or as a plain directory, and
+// both forms are restored the same way.
+//
+// Segment and blob files are copied as they are on disk, so backups of an encrypted
+// store stay encrypted and need the same master keys to be restored and read.
+package backup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/iamNilotpal/ignite/internal/manifest"
+	"github.com/iamNilotpal/ignite/pkg/errors"
+)
+
+// Add copies exactly size bytes from r into the backup under name, which must be
+// one of the names described in the package documentation.
+//
+// Close records chain in the metadata, writes the metadata file that completes the
+// backup and returns the metadata. A backup that is not closed is incomplete and
+// cannot be restored.
+type Writer interface {
+	Add(name string, size int64, r io.Reader) error
+	Close(chain Chain) (*Metadata, error)
+}
+
+// checksummer tracks the files written to a backup and builds its metadata.
+type checksummer struct {
+	metadata Metadata
+}
+
+// copyFile copies exactly size bytes from r to w, recording the file in the metadata.
+func (c *checksummer) copyFile(w io.Writer, name string, size int64, r io.Reader) error {
+	if !validName(name) || name == MetadataFile {
+		return errors.NewBackupError(name, "unsupported file name", nil).WithDetail("operation", "backup")
+	}
+
+	hasher := sha256.New()
+	n, err := io.Copy(io.MultiWriter(w, hasher), io.LimitReader(r, size))
+	if err != nil {
+		return err
+	}
+	if n != size {
+		return errors.NewBackupError(name, "file shorter than expected", io.ErrUnexpectedEOF).
+			WithDetail("operation", "backup").
+			WithDetail("expected_size", size).
+			WithDetail("actual_size", n)
+	}
+
+	c.metadata.Files = append(c.metadata.Files, File{Name: name, Size: size, SHA256: hex.EncodeToString(hasher.Sum(nil))})
+	return nil
+}
+
+// finish records chain in the metadata and returns its encoded form.
+func (c *checksummer) finish(chain Chain) ([]byte, error) {
+	c.metadata.Chain = chain
+	return json.MarshalIndent(&c.metadata, "", "  ")
+}
+
+// verifier checks the files of a backup against its metadata as they are restored.
+type verifier struct {
+	seen map[string]File // Files read so far, with their actual size and checksum.
+}
+
+// newVerifier creates an empty verifier.
+func newVerifier() *verifier {
+	return &verifier{seen: make(map[string]File)}
+}
+
+// track wraps the reader of a backup file so that its size and checksum are recorded.
+func (v *verifier) track(name string, r io.Reader) (io.Reader, func()) {
+	hasher := sha256.New()
+	counter := &countingWriter{hash: hasher}
+	return io.TeeReader(r, counter), func() {
+		v.seen[name] = File{Name: name, Size: counter.n, SHA256: hex.EncodeToString(hasher.Sum(nil))}
+	}
+}
+
+// verify checks that the files read match the metadata exactly: none missing, none
+// extra, and every size and checksum equal.
+func (v *verifier) verify(metadata *Metadata) error {
+	if metadata.Version != FormatVersion {
+		return errors.NewBackupError(MetadataFile, "unsupported backup format version", nil).
+			WithDetail("version", metadata.Version)
+	}
+
+	listed := make(map[string]struct{}, len(metadata.Files))
+	hasManifest := false
+	for _, file := range metadata.Files {
+		listed[file.Name] = struct{}{}
+		if file.Name == manifest.FileName {
+			hasManifest = true
+		}
+
+		seen, ok := v.seen[file.Name]
+		if !ok {
+			return errors.NewBackupError(file.Name, "file listed in metadata is missing", nil)
+		}
+		if seen.Size != file.Size || seen.SHA256 != file.SHA256 {
+			return errors.NewBackupError(file.Name, "checksum mismatch", nil).
+				WithDetail("expected_size", file.Size).
+				WithDetail("actual_size", seen.Size)
+		}
+	}
+
+	for name := range v.seen {
+		if _, ok := listed[name]; !ok {
+			return errors.NewBackupError(name, "file not listed in metadata", nil)
+		}
+	}
+
+	if !hasManifest {
+		return errors.NewBackupError(manifest.FileName, "backup has no manifest", nil)
+	}
+	return nil
+}
+
+// countingWriter feeds a hash and counts the bytes written to it.
+type countingWriter struct {
+	hash hash.Hash
+	n    int64
+}
+
+// Write implements io.Writer.
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return c.hash.Write(p)
+}
+
+// validName reports whether name is a file a backup may contain. Anything else is
+// rejected, which also keeps a crafted archive from writing outside the target.
+func validName(name string) bool {
+	switch name {
+	case MetadataFile, manifest.FileName:
+		return true
+	}
+
+	dir, file := path.Split(name)
+	if dir != SegmentsDir+"/" && dir != BlobsDir+"/" {
+		return false
+	}
+	return file != "" && file != "." && file != ".." && !strings.ContainsAny(file, `/\`)
+}
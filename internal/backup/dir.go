@@ -0,0 +1,173 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/iamNilotpal/ignite/pkg/errors"
+)
+
+// dirWriter writes a backup as a plain directory.
+type dirWriter struct {
+	checksummer
+	dir string
+}
+
+// NewDirWriter returns a Writer that writes a backup into dir, which must not exist
+// or be empty. Every file is synced to stable storage before the next one is started.
+func NewDirWriter(dir string) (Writer, error) {
+	if err := ensureEmptyDir(dir); err != nil {
+		return nil, err
+	}
+
+	return &dirWriter{
+		checksummer: checksummer{metadata: Metadata{Version: FormatVersion, Created: time.Now().UTC()}},
+		dir:         dir,
+	}, nil
+}
+
+// Add implements Writer.
+func (d *dirWriter) Add(name string, size int64, r io.Reader) error {
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- writeFile(filepath.Join(d.dir, filepath.FromSlash(name)), pr)
+	}()
+
+	err := d.copyFile(pw, name, size, r)
+	pw.CloseWithError(err)
+	if writeErr := <-done; err == nil {
+		err = writeErr
+	}
+	return err
+}
+
+// Close implements Writer.
+func (d *dirWriter) Close(chain Chain) (*Metadata, error) {
+	data, err := d.finish(chain)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeFile(filepath.Join(d.dir, MetadataFile), bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+
+	for _, dir := range []string{filepath.Join(d.dir, SegmentsDir), filepath.Join(d.dir, BlobsDir), d.dir} {
+		if err := syncDir(dir); err != nil && !os.IsNotExist(err) {
+			return nil, errors.NewFileAccessError(dir, filepath.Base(dir), "backup_dir_sync", err)
+		}
+	}
+	return &d.metadata, nil
+}
+
+// extractDir copies a directory backup into dir and verifies it against its metadata.
+func extractDir(ctx context.Context, src, dir string) (*Metadata, error) {
+	metadata, err := readMetadataFile(src)
+	if err != nil {
+		return nil, err
+	}
+
+	v := newVerifier()
+	for _, sub := range []string{SegmentsDir, BlobsDir, "."} {
+		entries, err := os.ReadDir(filepath.Join(src, sub))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, errors.NewFileAccessError(filepath.Join(src, sub), sub, "backup_list", err)
+		}
+
+		for _, entry := range entries {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if entry.IsDir() {
+				continue
+			}
+
+			name := entry.Name()
+			if sub != "." {
+				name = sub + "/" + name
+			}
+			if name == MetadataFile {
+				continue
+			}
+			if !validName(name) {
+				return nil, errors.NewBackupError(name, "unsupported file name", nil)
+			}
+
+			if err := copyTracked(v, name, filepath.Join(src, filepath.FromSlash(name)), filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := v.verify(metadata); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+// readMetadataFile reads the metadata file of a directory backup.
+func readMetadataFile(dir string) (*Metadata, error) {
+	path := filepath.Join(dir, MetadataFile)
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.NewBackupError(MetadataFile, "backup metadata missing, backup is incomplete", err)
+		}
+		return nil, errors.ClassifyFileOpenError(err, path, MetadataFile)
+	}
+	defer file.Close()
+
+	return readMetadata(io.LimitReader(file, maxMetadataSize))
+}
+
+// copyTracked copies a single backup file, recording its size and checksum.
+func copyTracked(v *verifier, name, src, dst string) error {
+	file, err := os.Open(src)
+	if err != nil {
+		return errors.ClassifyFileOpenError(err, src, filepath.Base(src))
+	}
+	defer file.Close()
+
+	tracked, done := v.track(name, file)
+	if err := writeFile(dst, tracked); err != nil {
+		return err
+	}
+	done()
+	return nil
+}
+
+// ensureEmptyDir creates dir if it does not exist and fails if it holds any file.
+func ensureEmptyDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.NewFileAccessError(dir, filepath.Base(dir), "backup_target_check", err)
+	}
+	if len(entries) > 0 {
+		return errors.NewValidationError(
+			nil, errors.ErrorCodeInvalidInput, "target directory is not empty",
+		).WithField("dir").WithRule("empty").WithProvided(dir)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.ClassifyDirectoryCreationError(err, dir)
+	}
+	return nil
+}
+
+// syncDir fsyncs a directory so that file creations and renames inside it are durable.
+func syncDir(dir string) error {
+	file, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return file.Sync()
+}
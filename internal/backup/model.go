@@ -0,0 +1,49 @@
+package backup
+
+import "time"
+
+const (
+	// FormatVersion is the current backup format version, recorded in the metadata.
+	FormatVersion = 1
+
+	// MetadataFile is the name of the file that completes a backup.
+	MetadataFile = "BACKUP.json"
+
+	// SegmentsDir is the directory holding segment files within a backup.
+	SegmentsDir = "segments"
+
+	// BlobsDir is the directory holding blob files within a backup.
+	BlobsDir = "blobs"
+)
+
+// Metadata describes a complete backup.
+type Metadata struct {
+	Chain
+	Version int       `json:"version"` // Backup format version.
+	Created time.Time `json:"created"` // Time the backup was started.
+	Files   []File    `json:"files"`   // Every file of the backup other than the metadata.
+}
+
+// Chain places a backup within a chain of incremental backups. A full backup has no
+// parent; an incremental backup holds only the segments and blobs its parent chain
+// does not, and is restored by replaying the chain from the full backup onwards.
+type Chain struct {
+	ID          string   `json:"id"`               // Random identifier of the backup.
+	Parent      string   `json:"parent,omitempty"` // ID of the backup this one builds on; empty for a full backup.
+	LastSegment uint64   `json:"lastSegment"`      // Highest sealed segment ID covered by the chain up to this backup.
+	Blobs       []string `json:"blobs,omitempty"`  // Live blob files covered by the chain up to this backup, sorted.
+}
+
+// File describes a single file of a backup.
+type File struct {
+	Name   string `json:"name"`   // Path of the file within the backup.
+	Size   int64  `json:"size"`   // Size of the file in bytes.
+	SHA256 string `json:"sha256"` // Hex-encoded SHA-256 checksum of the contents.
+}
+
+// Layout names the directories a backup is restored into.
+type Layout struct {
+	DataDir    string // Data directory, which receives the MANIFEST.
+	SegmentDir string // Directory receiving segment files.
+	BlobDir    string // Directory receiving blob files.
+}
@@ -0,0 +1,206 @@
+package backup
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/iamNilotpal/ignite/internal/manifest"
+	"github.com/iamNilotpal/ignite/pkg/errors"
+)
+
+// stagingSuffix names the directory next to the data directory that backups are
+// unpacked into before they are moved into place.
+const stagingSuffix = ".restore"
+
+// Source is a backup to restore or inspect, either a tar archive or a directory.
+type Source interface {
+	// extract unpacks the backup into dir and verifies it against its metadata.
+	extract(ctx context.Context, dir string) (*Metadata, error)
+
+	// metadata reads the metadata of the backup without verifying its files.
+	metadata(ctx context.Context) (*Metadata, error)
+}
+
+// tarSource is a backup written by NewTarWriter.
+type tarSource struct {
+	r io.Reader
+}
+
+// TarSource returns a Source reading a tar backup from r. The archive is read once,
+// from start to end.
+func TarSource(r io.Reader) Source {
+	return &tarSource{r: r}
+}
+
+func (t *tarSource) extract(ctx context.Context, dir string) (*Metadata, error) {
+	return extractTar(ctx, t.r, dir)
+}
+
+func (t *tarSource) metadata(ctx context.Context) (*Metadata, error) {
+	return scanTar(ctx, t.r)
+}
+
+// dirSource is a backup written by NewDirWriter.
+type dirSource struct {
+	dir string
+}
+
+// DirSource returns a Source reading a directory backup from dir.
+func DirSource(dir string) Source {
+	return &dirSource{dir: dir}
+}
+
+func (d *dirSource) extract(ctx context.Context, dir string) (*Metadata, error) {
+	return extractDir(ctx, d.dir, dir)
+}
+
+func (d *dirSource) metadata(ctx context.Context) (*Metadata, error) {
+	return readMetadataFile(d.dir)
+}
+
+// ReadMetadata returns the metadata of a backup, for example to continue a chain of
+// incremental backups from it. The files of the backup are not verified.
+func ReadMetadata(ctx context.Context, source Source) (*Metadata, error) {
+	return source.metadata(ctx)
+}
+
+// Restore rebuilds a data directory from a chain of backups into the directories
+// named by layout and returns the metadata of the last backup. The chain starts with a
+// full backup, and every following backup must be an incremental backup of the one
+// before it; a single full backup is a chain of its own. The data directory must not
+// exist or be empty.
+//
+// Every backup is unpacked and fully verified in a staging directory first. The
+// MANIFEST of the last backup then decides which segments make up the store, and each
+// of them, along with every blob the last backup lists, is taken from the newest
+// backup holding it. Nothing reaches the data directory unless the whole chain is
+// intact and complete.
+func Restore(ctx context.Context, chain []Source, layout Layout) (*Metadata, error) {
+	if len(chain) == 0 {
+		return nil, errors.NewRequiredFieldError("backup")
+	}
+	if err := ensureEmptyDir(layout.DataDir); err != nil {
+		return nil, err
+	}
+
+	staging := filepath.Clean(layout.DataDir) + stagingSuffix
+	if err := os.RemoveAll(staging); err != nil {
+		return nil, errors.NewFileAccessError(staging, filepath.Base(staging), "restore_staging_cleanup", err)
+	}
+	defer os.RemoveAll(staging)
+
+	dirs := make([]string, len(chain))
+	var previous *Metadata
+	for i, source := range chain {
+		dirs[i] = filepath.Join(staging, strconv.Itoa(i))
+
+		metadata, err := source.extract(ctx, dirs[i])
+		if err != nil {
+			return nil, err
+		}
+		if err := checkLink(previous, metadata); err != nil {
+			return nil, err
+		}
+		previous = metadata
+	}
+
+	if err := install(dirs, previous, layout); err != nil {
+		return nil, err
+	}
+	return previous, nil
+}
+
+// checkLink verifies that metadata continues the chain ending with previous, or
+// starts a chain if previous is nil.
+func checkLink(previous, metadata *Metadata) error {
+	switch {
+	case previous == nil && metadata.Parent != "":
+		return errors.NewBackupError(MetadataFile, "incremental backup restored without its parent", nil).
+			WithDetail("backup", metadata.ID).
+			WithDetail("parent", metadata.Parent)
+
+	case previous != nil && metadata.Parent != previous.ID:
+		return errors.NewBackupError(MetadataFile, "backup does not follow the previous one in the chain", nil).
+			WithDetail("backup", metadata.ID).
+			WithDetail("parent", metadata.Parent).
+			WithDetail("previous", previous.ID)
+	}
+	return nil
+}
+
+// install moves the files of a verified chain from the staging directories into
+// place. The MANIFEST is moved last, so a data directory with a manifest always holds
+// every segment it lists.
+func install(dirs []string, last *Metadata, layout Layout) error {
+	manifestPath := filepath.Join(dirs[len(dirs)-1], manifest.FileName)
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return errors.NewFileAccessError(manifestPath, manifest.FileName, "restore_manifest_read", err)
+	}
+	segments, err := manifest.Decode(data)
+	if err != nil {
+		return err
+	}
+
+	type move struct{ name, to string }
+	moves := make([]move, 0, len(segments)+len(last.Blobs))
+	for _, segment := range segments {
+		moves = append(moves, move{name: SegmentsDir + "/" + segment.File, to: layout.SegmentDir})
+	}
+	for _, blob := range last.Blobs {
+		moves = append(moves, move{name: BlobsDir + "/" + blob + ".blob", to: layout.BlobDir})
+	}
+
+	for _, dir := range []string{layout.SegmentDir, layout.BlobDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return errors.ClassifyDirectoryCreationError(err, dir)
+		}
+	}
+
+	for _, m := range moves {
+		if !validName(m.name) {
+			return errors.NewBackupError(m.name, "unsupported file name", nil)
+		}
+
+		from, err := newest(dirs, m.name)
+		if err != nil {
+			return err
+		}
+		if err := os.Rename(from, filepath.Join(m.to, baseName(m.name))); err != nil {
+			return errors.NewFileAccessError(m.to, baseName(m.name), "restore_move", err)
+		}
+	}
+
+	for _, dir := range []string{layout.SegmentDir, layout.BlobDir} {
+		if err := syncDir(dir); err != nil {
+			return errors.NewFileAccessError(dir, filepath.Base(dir), "restore_dir_sync", err)
+		}
+	}
+
+	if err := os.Rename(manifestPath, filepath.Join(layout.DataDir, manifest.FileName)); err != nil {
+		return errors.NewFileAccessError(layout.DataDir, manifest.FileName, "restore_move", err)
+	}
+	if err := syncDir(layout.DataDir); err != nil {
+		return errors.NewFileAccessError(layout.DataDir, filepath.Base(layout.DataDir), "restore_dir_sync", err)
+	}
+	return nil
+}
+
+// newest returns the path of the named file in the newest staged backup holding it.
+func newest(dirs []string, name string) (string, error) {
+	for i := len(dirs) - 1; i >= 0; i-- {
+		candidate := filepath.Join(dirs[i], filepath.FromSlash(name))
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", errors.NewBackupError(name, "file missing from every backup of the chain", nil)
+}
+
+// baseName returns the file name of a backup file name without its directory.
+func baseName(name string) string {
+	return filepath.Base(filepath.FromSlash(name))
+}
@@ -0,0 +1,210 @@
+package backup
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/iamNilotpal/ignite/pkg/errors"
+)
+
+// maxMetadataSize bounds the metadata file read from an archive.
+const maxMetadataSize = 64 * 1024 * 1024
+
+// tarWriter writes a backup as a tar stream.
+type tarWriter struct {
+	checksummer
+	tw *tar.Writer
+}
+
+// NewTarWriter returns a Writer that streams a backup to w as a tar archive. Nothing
+// is buffered beyond what archive/tar needs, so w can be a network connection.
+func NewTarWriter(w io.Writer) Writer {
+	return &tarWriter{
+		checksummer: checksummer{metadata: Metadata{Version: FormatVersion, Created: time.Now().UTC()}},
+		tw:          tar.NewWriter(w),
+	}
+}
+
+// Add implements Writer.
+func (t *tarWriter) Add(name string, size int64, r io.Reader) error {
+	if err := t.writeHeader(name, size); err != nil {
+		return err
+	}
+	return t.copyFile(t.tw, name, size, r)
+}
+
+// Close implements Writer.
+func (t *tarWriter) Close(chain Chain) (*Metadata, error) {
+	data, err := t.finish(chain)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.writeHeader(MetadataFile, int64(len(data))); err != nil {
+		return nil, err
+	}
+	if _, err := t.tw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := t.tw.Close(); err != nil {
+		return nil, err
+	}
+	return &t.metadata, nil
+}
+
+// writeHeader starts a regular file entry in the archive.
+func (t *tarWriter) writeHeader(name string, size int64) error {
+	return t.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     size,
+		Mode:     0644,
+		ModTime:  time.Now(),
+		Format:   tar.FormatPAX,
+	})
+}
+
+// extractTar unpacks a backup archive into dir and verifies it against its metadata.
+func extractTar(ctx context.Context, r io.Reader, dir string) (*Metadata, error) {
+	tr := tar.NewReader(r)
+	v := newVerifier()
+
+	var metadata *Metadata
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.NewBackupError("", "unreadable archive", err)
+		}
+
+		name := header.Name
+		switch {
+		case header.Typeflag != tar.TypeReg:
+			return nil, errors.NewBackupError(name, "unsupported archive entry type", nil).
+				WithDetail("type", string(header.Typeflag))
+		case !validName(name):
+			return nil, errors.NewBackupError(name, "unsupported file name", nil)
+		case metadata != nil:
+			return nil, errors.NewBackupError(name, "file after backup metadata", nil)
+		}
+
+		if name == MetadataFile {
+			if metadata, err = readMetadata(io.LimitReader(tr, maxMetadataSize)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		source := &sourceReader{r: tr}
+		tracked, done := v.track(name, source)
+		if err := writeFile(filepath.Join(dir, filepath.FromSlash(name)), tracked); err != nil {
+			if source.err != nil {
+				return nil, errors.NewBackupError(name, "archive ends in the middle of a file", source.err)
+			}
+			return nil, err
+		}
+		done()
+	}
+
+	if metadata == nil {
+		return nil, errors.NewBackupError(MetadataFile, "backup metadata missing, archive is incomplete", nil)
+	}
+	if err := v.verify(metadata); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+// scanTar reads a backup archive to its end and returns its metadata, without
+// verifying the files in front of it.
+func scanTar(ctx context.Context, r io.Reader) (*Metadata, error) {
+	tr := tar.NewReader(r)
+
+	var metadata *Metadata
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.NewBackupError("", "unreadable archive", err)
+		}
+
+		if header.Name == MetadataFile {
+			if metadata, err = readMetadata(io.LimitReader(tr, maxMetadataSize)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if metadata == nil {
+		return nil, errors.NewBackupError(MetadataFile, "backup metadata missing, archive is incomplete", nil)
+	}
+	return metadata, nil
+}
+
+// readMetadata decodes a metadata file.
+func readMetadata(r io.Reader) (*Metadata, error) {
+	var metadata Metadata
+	if err := json.NewDecoder(r).Decode(&metadata); err != nil {
+		return nil, errors.NewBackupError(MetadataFile, "unreadable backup metadata", err)
+	}
+	return &metadata, nil
+}
+
+// writeFile copies r into a new file at path, creating parent directories, and syncs
+// it to stable storage.
+func writeFile(path string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.ClassifyDirectoryCreationError(err, filepath.Dir(path))
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return errors.ClassifyFileOpenError(err, path, filepath.Base(path))
+	}
+
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		return errors.NewFileAccessError(path, filepath.Base(path), "backup_file_write", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return errors.ClassifySyncError(err, filepath.Base(path), path, 0)
+	}
+	if err := file.Close(); err != nil {
+		return errors.NewFileAccessError(path, filepath.Base(path), "backup_file_close", err)
+	}
+	return nil
+}
+
+// sourceReader remembers the error its reader failed with, so that a damaged archive
+// can be told apart from a failure to write the restored file.
+type sourceReader struct {
+	r   io.Reader
+	err error
+}
+
+// Read implements io.Reader.
+func (s *sourceReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	if err != nil && err != io.EOF {
+		s.err = err
+	}
+	return n, err
+}
@@ -0,0 +1,336 @@
+// Package cache provides the hot value cache that sits in front of segment reads.
+//
+// Every lookup that misses the cache costs a ReadAt against a segment file. For the
+// strongly skewed access patterns typical of key-value workloads, a small cache that
+// retains the most popular values removes the majority of that disk traffic.
+//
+// The cache implements W-TinyLFU. Values enter a small LRU admission window sized at
+// one percent of capacity. When the window overflows, its least recently used value
+// becomes a candidate for the main region and is admitted only if its estimated
+// access frequency beats that of the main region's eviction victim. The main region
+// is a segmented LRU: values start in probation and are promoted to the protected
+// area on their next access. Frequencies are tracked with a count-min sketch whose
+// counters are periodically halved so that popularity decays over time.
+//
+// The cache is split into independently locked shards to keep lock contention low
+// under concurrent reads. It stores copies of the values it is given and returns
+// copies to callers, so cached data can never be mutated from the outside.
+//
+// The cache holds values keyed by their user key, and it never talks to storage on
+// its own. Callers are responsible for invalidating a key with Delete whenever the
+// value it maps to changes or moves on disk, which includes Set, Delete and any
+// compaction pass that relocates the entry.
+package cache
+
+import (
+	"container/list"
+	"hash/maphash"
+	"math/bits"
+)
+
+const (
+	// DefaultShards is the number of shards used when the configuration does not specify one.
+	DefaultShards = 16
+
+	// windowPercent is the share of each shard's capacity given to the admission window.
+	windowPercent = 1
+
+	// protectedPercent is the share of the main region reserved for protected items.
+	protectedPercent = 80
+
+	// averageItemBytes is the assumed average item size used to dimension the sketches.
+	averageItemBytes = 256
+)
+
+// New creates a cache with the given byte budget spread evenly over its shards.
+// It returns nil when the configured size is zero, which callers treat as the cache
+// being disabled.
+func New(config *Config) *Cache {
+	if config == nil || config.MaxBytes == 0 {
+		return nil
+	}
+
+	shardCount := uint64(config.Shards)
+	if shardCount == 0 {
+		shardCount = DefaultShards
+	}
+	shardCount = 1 << bits.Len64(shardCount-1)
+
+	perShard := int64(config.MaxBytes / shardCount)
+	perShard = max(perShard, 1)
+
+	c := &Cache{
+		shards: make([]*shard, shardCount),
+		mask:   shardCount - 1,
+		seed:   maphash.MakeSeed(),
+	}
+
+	for i := range c.shards {
+		windowCapacity := max(perShard*windowPercent/100, 1)
+		mainCapacity := max(perShard-windowCapacity, 1)
+
+		c.shards[i] = &shard{
+			items:             make(map[string]*list.Element),
+			window:            list.New(),
+			probation:         list.New(),
+			protected:         list.New(),
+			sketch:            newSketch(uint64(perShard / averageItemBytes)),
+			windowCapacity:    windowCapacity,
+			mainCapacity:      mainCapacity,
+			protectedCapacity: mainCapacity * protectedPercent / 100,
+		}
+	}
+
+	return c
+}
+
+// Get returns a copy of the cached value for key and whether it was present.
+func (c *Cache) Get(key string) ([]byte, bool) {
+	hash := maphash.String(c.seed, key)
+	s := c.shards[hash&c.mask]
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sketch.increment(hash)
+
+	elem, ok := s.items[key]
+	if !ok {
+		c.misses.Add(1)
+		return nil, false
+	}
+
+	c.hits.Add(1)
+	s.touch(elem)
+
+	value := elem.Value.(*item).value
+	result := make([]byte, len(value))
+	copy(result, value)
+	return result, true
+}
+
+// Set stores a copy of value under key. The value may be rejected by the admission
+// filter, in which case the cache is left unchanged for that key.
+func (c *Cache) Set(key string, value []byte) {
+	hash := maphash.String(c.seed, key)
+	s := c.shards[hash&c.mask]
+
+	stored := make([]byte, len(value))
+	copy(stored, value)
+	cost := int64(len(key) + len(value))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Replace the value in place if the key is already cached.
+	if elem, ok := s.items[key]; ok {
+		it := elem.Value.(*item)
+		s.adjust(it.segment, cost-it.cost)
+		it.value = stored
+		it.cost = cost
+		s.touch(elem)
+		c.evictions.Add(s.shrinkMain())
+		c.drainShardWindow(s)
+		return
+	}
+
+	it := &item{key: key, value: stored, hash: hash, cost: cost, segment: segmentWindow}
+	s.items[key] = s.window.PushFront(it)
+	s.windowBytes += cost
+
+	c.drainShardWindow(s)
+}
+
+// Delete removes key from the cache. It must be called whenever the value behind
+// a key changes or the entry is relocated on disk.
+func (c *Cache) Delete(key string) {
+	hash := maphash.String(c.seed, key)
+	s := c.shards[hash&c.mask]
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.items[key]; ok {
+		s.remove(elem)
+	}
+}
+
+// Clear removes every value from the cache while keeping the collected statistics.
+func (c *Cache) Clear() {
+	for _, s := range c.shards {
+		s.mu.Lock()
+		clear(s.items)
+		s.window.Init()
+		s.probation.Init()
+		s.protected.Init()
+		s.windowBytes, s.probationBytes, s.protectedBytes = 0, 0, 0
+		s.mu.Unlock()
+	}
+}
+
+// Stats returns a snapshot of the cache counters and current occupancy.
+func (c *Cache) Stats() Stats {
+	stats := Stats{
+		Hits:       c.hits.Load(),
+		Misses:     c.misses.Load(),
+		Evictions:  c.evictions.Load(),
+		Rejections: c.rejections.Load(),
+	}
+
+	for _, s := range c.shards {
+		s.mu.Lock()
+		stats.Entries += uint64(len(s.items))
+		stats.Bytes += uint64(s.windowBytes + s.probationBytes + s.protectedBytes)
+		stats.Capacity += uint64(s.windowCapacity + s.mainCapacity)
+		s.mu.Unlock()
+	}
+
+	return stats
+}
+
+// drainShardWindow moves items out of an overflowing window and runs each one
+// through the admission filter. The caller must hold the shard lock.
+func (c *Cache) drainShardWindow(s *shard) {
+	for s.windowBytes > s.windowCapacity {
+		elem := s.window.Back()
+		if elem == nil {
+			return
+		}
+
+		candidate := elem.Value.(*item)
+		s.window.Remove(elem)
+		s.windowBytes -= candidate.cost
+		delete(s.items, candidate.key)
+
+		admitted, evicted := s.admit(candidate)
+		c.evictions.Add(evicted)
+		if !admitted {
+			c.rejections.Add(1)
+		}
+	}
+}
+
+// admit decides whether a candidate leaving the window enters the main region.
+// Victims are taken from the tail of probation, falling back to protected, and the
+// candidate wins only if it has been accessed more often than every victim it
+// would displace. It reports whether the candidate was admitted and how many
+// items were evicted to make room.
+func (s *shard) admit(candidate *item) (bool, uint64) {
+	if candidate.cost > s.mainCapacity {
+		return false, 0
+	}
+
+	candidateFreq := s.sketch.estimate(candidate.hash)
+
+	// First make sure the candidate wins against every victim it would displace,
+	// without evicting anything yet.
+	needed := s.probationBytes + s.protectedBytes + candidate.cost - s.mainCapacity
+	for _, region := range []*list.List{s.probation, s.protected} {
+		for elem := region.Back(); elem != nil && needed > 0; elem = elem.Prev() {
+			victim := elem.Value.(*item)
+			if candidateFreq <= s.sketch.estimate(victim.hash) {
+				return false, 0
+			}
+			needed -= victim.cost
+		}
+	}
+
+	var evicted uint64
+	for s.probationBytes+s.protectedBytes+candidate.cost > s.mainCapacity {
+		victim := s.probation.Back()
+		if victim == nil {
+			victim = s.protected.Back()
+		}
+		s.remove(victim)
+		evicted++
+	}
+
+	candidate.segment = segmentProbation
+	s.items[candidate.key] = s.probation.PushFront(candidate)
+	s.probationBytes += candidate.cost
+
+	return true, evicted
+}
+
+// touch records an access to an existing item, promoting it within its region.
+// The caller must hold the shard lock.
+func (s *shard) touch(elem *list.Element) {
+	it := elem.Value.(*item)
+
+	switch it.segment {
+	case segmentWindow:
+		s.window.MoveToFront(elem)
+	case segmentProtected:
+		s.protected.MoveToFront(elem)
+	case segmentProbation:
+		// A second access promotes the item into the protected region.
+		s.probation.Remove(elem)
+		s.probationBytes -= it.cost
+
+		it.segment = segmentProtected
+		s.items[it.key] = s.protected.PushFront(it)
+		s.protectedBytes += it.cost
+
+		// Demote the least recently used protected items back to probation so the
+		// protected region stays within its budget.
+		for s.protectedBytes > s.protectedCapacity {
+			tail := s.protected.Back()
+			demoted := tail.Value.(*item)
+			s.protected.Remove(tail)
+			s.protectedBytes -= demoted.cost
+
+			demoted.segment = segmentProbation
+			s.items[demoted.key] = s.probation.PushFront(demoted)
+			s.probationBytes += demoted.cost
+		}
+	}
+}
+
+// shrinkMain evicts items from the main region until it fits its capacity again,
+// which is needed after an in-place update grows an item. It returns the number of
+// evicted items. The caller must hold the shard lock.
+func (s *shard) shrinkMain() uint64 {
+	var evicted uint64
+	for s.probationBytes+s.protectedBytes > s.mainCapacity {
+		victim := s.probation.Back()
+		if victim == nil {
+			victim = s.protected.Back()
+		}
+		if victim == nil {
+			break
+		}
+		s.remove(victim)
+		evicted++
+	}
+	return evicted
+}
+
+// adjust applies a change in cost to the byte counter of the given region.
+func (s *shard) adjust(segment uint8, delta int64) {
+	switch segment {
+	case segmentWindow:
+		s.windowBytes += delta
+	case segmentProbation:
+		s.probationBytes += delta
+	case segmentProtected:
+		s.protectedBytes += delta
+	}
+}
+
+// remove unlinks an item from whichever region holds it. The caller must hold the
+// shard lock.
+func (s *shard) remove(elem *list.Element) {
+	it := elem.Value.(*item)
+
+	switch it.segment {
+	case segmentWindow:
+		s.window.Remove(elem)
+	case segmentProbation:
+		s.probation.Remove(elem)
+	case segmentProtected:
+		s.protected.Remove(elem)
+	}
+
+	s.adjust(it.segment, -it.cost)
+	delete(s.items, it.key)
+}
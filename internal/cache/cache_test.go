@@ -0,0 +1,169 @@
+package cache
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// newTestCache returns a single shard cache with the given budget, one percent of
+// which goes to the admission window.
+func newTestCache(t *testing.T, maxBytes uint64) *Cache {
+	t.Helper()
+
+	c := New(&Config{MaxBytes: maxBytes, Shards: 1})
+	if c == nil {
+		t.Fatal("New() = nil, want a cache")
+	}
+	return c
+}
+
+// fill stores n values of 100 bytes under keys made of prefix and a counter.
+func fill(c *Cache, prefix string, n int) {
+	for i := range n {
+		c.Set(fmt.Sprintf("%s-%03d", prefix, i), bytes.Repeat([]byte{'v'}, 100))
+	}
+}
+
+// expectWithinBudget fails the test if c holds more bytes than its capacity.
+func expectWithinBudget(t *testing.T, c *Cache) {
+	t.Helper()
+	if stats := c.Stats(); stats.Bytes > stats.Capacity {
+		t.Fatalf("cache holds %d bytes, capacity %d", stats.Bytes, stats.Capacity)
+	}
+}
+
+func TestNewDisabled(t *testing.T) {
+	if c := New(nil); c != nil {
+		t.Fatal("New(nil) returned a cache")
+	}
+	if c := New(&Config{Shards: 4}); c != nil {
+		t.Fatal("New() with a zero budget returned a cache")
+	}
+}
+
+func TestAdmissionPrefersFrequentKeys(t *testing.T) {
+	// A budget large enough for a sketch in which hot and cold keys rarely collide.
+	c := newTestCache(t, 100_000)
+
+	// Values nobody reads fill the main region, and then lose every contest against
+	// its equally cold victims.
+	fill(c, "cold", 1200)
+	stats := c.Stats()
+	if stats.Rejections == 0 || stats.Evictions != 0 {
+		t.Fatalf("Stats() = %+v, want rejections and no evictions", stats)
+	}
+	expectWithinBudget(t, c)
+
+	// Keys that were asked for before they are stored win against the cold ones.
+	for i := range 20 {
+		key := fmt.Sprintf("hot-%03d", i)
+		for range 3 {
+			c.Get(key)
+		}
+		c.Set(key, bytes.Repeat([]byte{'h'}, 100))
+	}
+
+	// A second wave of one-off values pushes the last hot keys out of the window, but
+	// not out of the cache.
+	fill(c, "scan", 1200)
+	for i := range 20 {
+		if _, ok := c.Get(fmt.Sprintf("hot-%03d", i)); !ok {
+			t.Fatalf("hot-%03d is not cached", i)
+		}
+	}
+	if stats := c.Stats(); stats.Evictions < 20 {
+		t.Fatalf("Stats().Evictions = %d, want every hot key to evict a cold one", stats.Evictions)
+	}
+	expectWithinBudget(t, c)
+}
+
+func TestEvictionKeepsBudget(t *testing.T) {
+	c := newTestCache(t, 10_000)
+	fill(c, "key", 90)
+
+	// Growing a cached value in place evicts others until the region fits again.
+	big := bytes.Repeat([]byte{'b'}, 5000)
+	c.Get("key-000")
+	c.Set("key-000", big)
+
+	stats := c.Stats()
+	if stats.Evictions == 0 {
+		t.Fatalf("Stats() = %+v, want evictions", stats)
+	}
+	expectWithinBudget(t, c)
+	if got, ok := c.Get("key-000"); !ok || !bytes.Equal(got, big) {
+		t.Fatal("grown value is not cached")
+	}
+
+	// A value larger than the main region is never cached.
+	rejections := stats.Rejections
+	c.Set("huge", make([]byte, 20_000))
+	if _, ok := c.Get("huge"); ok {
+		t.Fatal("value larger than the cache was cached")
+	}
+	if got := c.Stats().Rejections; got != rejections+1 {
+		t.Fatalf("Stats().Rejections = %d, want %d", got, rejections+1)
+	}
+	expectWithinBudget(t, c)
+}
+
+func TestDeleteAndClear(t *testing.T) {
+	c := newTestCache(t, 10_000)
+	c.Set("a", []byte("1"))
+	c.Set("b", []byte("2"))
+
+	c.Delete("a")
+	c.Delete("missing")
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("deleted key is still cached")
+	}
+	if got, ok := c.Get("b"); !ok || string(got) != "2" {
+		t.Fatalf("Get(b) = %q, %t", got, ok)
+	}
+	if stats := c.Stats(); stats.Entries != 1 || stats.Bytes != 2 {
+		t.Fatalf("Stats() = %+v, want one entry of 2 bytes", stats)
+	}
+
+	c.Clear()
+	stats := c.Stats()
+	if stats.Entries != 0 || stats.Bytes != 0 {
+		t.Fatalf("Stats() = %+v after Clear, want an empty cache", stats)
+	}
+	// Counters survive a clear.
+	if stats.Hits != 1 || stats.Misses != 1 {
+		t.Fatalf("Stats() = %+v after Clear, want 1 hit and 1 miss", stats)
+	}
+	if _, ok := c.Get("b"); ok {
+		t.Fatal("cleared key is still cached")
+	}
+}
+
+func TestStatsCounters(t *testing.T) {
+	c := newTestCache(t, 10_000)
+
+	c.Get("k")
+	c.Set("k", []byte("value"))
+	c.Get("k")
+	c.Get("k")
+
+	want := Stats{Hits: 2, Misses: 1, Entries: 1, Bytes: 6, Capacity: 10_000}
+	if got := c.Stats(); got != want {
+		t.Fatalf("Stats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestValuesAreCopied(t *testing.T) {
+	c := newTestCache(t, 10_000)
+
+	value := []byte("original")
+	c.Set("k", value)
+	value[0] = 'X'
+
+	got, _ := c.Get("k")
+	got[1] = 'Y'
+
+	if again, _ := c.Get("k"); string(again) != "original" {
+		t.Fatalf("Get(k) = %q, want the value as stored", again)
+	}
+}
@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"container/list"
+	"hash/maphash"
+	"sync"
+	"sync/atomic"
+)
+
+// Segment identifiers describe which region of a shard an item currently lives in.
+// W-TinyLFU splits each shard into a small admission window followed by a main
+// region, which is itself divided into probation and protected areas.
+const (
+	segmentWindow uint8 = iota
+	segmentProbation
+	segmentProtected
+)
+
+// Cache is a sharded, size-bounded value cache that sits in front of segment reads.
+// It uses the W-TinyLFU policy: new values enter a small LRU window, and when they
+// are pushed out of it they must win a frequency contest against the main region's
+// eviction victim before being admitted. Frequencies are tracked approximately with
+// a count-min sketch, which keeps the memory overhead per tracked key tiny.
+//
+// This policy is designed for skewed, Zipfian access patterns, where a plain LRU
+// would let a burst of one-off reads flush the genuinely hot values.
+type Cache struct {
+	shards []*shard     // Independent cache partitions, each protected by its own lock.
+	mask   uint64       // Bit mask used to map a key hash to a shard index.
+	seed   maphash.Seed // Seed for key hashing, shared by shard selection and the sketches.
+
+	hits       atomic.Uint64 // Number of lookups that found a cached value.
+	misses     atomic.Uint64 // Number of lookups that did not find a cached value.
+	evictions  atomic.Uint64 // Number of values evicted to make room for others.
+	rejections atomic.Uint64 // Number of values refused admission by the frequency filter.
+}
+
+// shard is a single independent W-TinyLFU partition of the cache.
+type shard struct {
+	mu        sync.Mutex               // Guards every field of the shard.
+	items     map[string]*list.Element // Maps keys to their list element in one of the regions.
+	window    *list.List               // Admission window, ordered from most to least recently used.
+	probation *list.List               // Main region items that have not been re-accessed since admission.
+	protected *list.List               // Main region items that have been accessed at least twice.
+	sketch    *sketch                  // Approximate access frequency for keys in this shard.
+
+	windowBytes    int64 // Bytes currently held by the window region.
+	probationBytes int64 // Bytes currently held by the probation region.
+	protectedBytes int64 // Bytes currently held by the protected region.
+
+	windowCapacity    int64 // Maximum bytes allowed in the window region.
+	mainCapacity      int64 // Maximum bytes allowed in probation and protected combined.
+	protectedCapacity int64 // Maximum bytes allowed in the protected region.
+}
+
+// item is a single cached key/value pair.
+type item struct {
+	key     string // Key the value belongs to.
+	value   []byte // Cached copy of the value.
+	hash    uint64 // Precomputed key hash used for frequency lookups.
+	cost    int64  // Number of bytes charged against the shard's capacity.
+	segment uint8  // Region the item currently lives in.
+}
+
+// Stats is a point-in-time snapshot of cache activity.
+type Stats struct {
+	Hits       uint64 `json:"hits"`       // Lookups served from the cache.
+	Misses     uint64 `json:"misses"`     // Lookups that fell through to the segment files.
+	Evictions  uint64 `json:"evictions"`  // Values removed to make room for others.
+	Rejections uint64 `json:"rejections"` // Values the admission filter refused to cache.
+	Entries    uint64 `json:"entries"`    // Number of values currently cached.
+	Bytes      uint64 `json:"bytes"`      // Number of bytes currently charged against capacity.
+	Capacity   uint64 `json:"capacity"`   // Maximum number of bytes the cache may hold.
+}
+
+// Config encapsulates the parameters required to initialize a Cache.
+type Config struct {
+	MaxBytes uint64 // Total byte budget across all shards.
+	Shards   uint   // Number of shards; rounded up to the next power of two.
+}
@@ -0,0 +1,78 @@
+package cache
+
+import "math/bits"
+
+// sketchDepth is the number of independent counter rows in the count-min sketch.
+const sketchDepth = 4
+
+// sketchMaxCount is the saturation point of each counter. Four bits of resolution
+// is enough to separate hot keys from cold ones and matches the TinyLFU paper.
+const sketchMaxCount = 15
+
+// sketchSeeds decorrelate the rows of the sketch so that a collision in one row is
+// unlikely to repeat in another.
+var sketchSeeds = [sketchDepth]uint64{
+	0xc3a5c85c97cb3127, 0xb492b66fbe98f273, 0x9ae16a3b2f90404f, 0xcbf29ce484222325,
+}
+
+// sketch is a count-min sketch that estimates how often each key has been accessed.
+// Counters are periodically halved so that the estimates follow recent popularity
+// rather than all-time popularity, which lets the cache adapt to shifting workloads.
+type sketch struct {
+	counters   [sketchDepth][]uint8 // One row of saturating counters per hash function.
+	mask       uint64               // Bit mask mapping a hash to a column index.
+	additions  uint64               // Increments since the last reset.
+	sampleSize uint64               // Number of increments that triggers a reset.
+}
+
+// newSketch creates a sketch sized for roughly the given number of distinct keys.
+func newSketch(width uint64) *sketch {
+	width = max(width, 64)
+	width = 1 << bits.Len64(width-1)
+
+	s := &sketch{mask: width - 1, sampleSize: 10 * width}
+	for i := range s.counters {
+		s.counters[i] = make([]uint8, width)
+	}
+	return s
+}
+
+// increment records one access for the key with the given hash.
+func (s *sketch) increment(hash uint64) {
+	for i := range s.counters {
+		idx := s.index(hash, i)
+		if s.counters[i][idx] < sketchMaxCount {
+			s.counters[i][idx]++
+		}
+	}
+
+	s.additions++
+	if s.additions >= s.sampleSize {
+		s.reset()
+	}
+}
+
+// estimate returns the approximate access count for the key with the given hash.
+func (s *sketch) estimate(hash uint64) uint8 {
+	minimum := uint8(sketchMaxCount)
+	for i := range s.counters {
+		minimum = min(minimum, s.counters[i][s.index(hash, i)])
+	}
+	return minimum
+}
+
+// reset halves every counter, ageing out stale popularity.
+func (s *sketch) reset() {
+	for i := range s.counters {
+		for j := range s.counters[i] {
+			s.counters[i][j] >>= 1
+		}
+	}
+	s.additions /= 2
+}
+
+// index computes the column of the given row for a hash.
+func (s *sketch) index(hash uint64, row int) uint64 {
+	h := (hash ^ sketchSeeds[row]) * 0x9e3779b97f4a7c15
+	return (h >> 32) & s.mask
+}
@@ -1,8 +1,95 @@
+// Package compaction decides which segments are worth rewriting and keeps the record
+// of the compaction runs performed since the store was opened. The engine performs
+// the rewrite itself, since only it knows which entries the index still points at.
 package compaction
 
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// historySize is the number of most recent runs kept for Stats.
+	historySize = 16
+
+	// MinDeadRatio is the share of a sealed segment's bytes that must be dead, taken by
+	// overwritten values, tombstones and expired keys, before compaction rewrites it.
+	// Rewriting a mostly live segment costs nearly a full copy and frees little.
+	MinDeadRatio = 0.5
+)
+
 type Compaction struct {
+	runs      atomic.Uint64 // Number of compaction runs completed.
+	reclaimed atomic.Uint64 // Bytes of disk space freed by those runs.
+	history   []Run         // Most recent runs, oldest first, at most historySize.
+	mu        sync.Mutex    // Guards history.
+}
+
+// Run describes one completed compaction run.
+type Run struct {
+	Started        time.Time     // Time the run started.
+	Duration       time.Duration // How long the run took.
+	Segments       int           // Number of segments compacted.
+	ReclaimedBytes uint64        // Bytes of disk space freed.
+}
+
+// Stats is a point-in-time snapshot of the work compaction has done since the store
+// was opened.
+type Stats struct {
+	Runs           uint64 // Number of compaction runs completed.
+	ReclaimedBytes uint64 // Bytes of disk space freed by those runs.
+	History        []Run  // Most recent runs, oldest first.
+}
+
+// Candidate describes a sealed segment that compaction may rewrite.
+type Candidate struct {
+	ID        uint64 // Segment file ID.
+	Size      int64  // Size of the segment file in bytes.
+	LiveBytes int64  // Bytes of the entries the index points at.
+	Rekey     bool   // Whether the segment is not protected by the current master key.
 }
 
 func New() *Compaction {
 	return &Compaction{}
 }
+
+// Select returns the IDs of the candidates worth compacting, in ascending order:
+// those protected by a master key other than the current one, so that every segment
+// ends up under the current key, and those with at least minDeadRatio of their bytes
+// dead.
+func Select(candidates []Candidate, minDeadRatio float64) []uint64 {
+	var ids []uint64
+	for _, c := range candidates {
+		if c.Rekey || (c.Size > 0 && float64(c.Size-c.LiveBytes) >= minDeadRatio*float64(c.Size)) {
+			ids = append(ids, c.ID)
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// Stats returns a snapshot of the compaction counters and recent runs.
+func (c *Compaction) Stats() Stats {
+	c.mu.Lock()
+	history := slices.Clone(c.history)
+	c.mu.Unlock()
+
+	return Stats{Runs: c.runs.Load(), ReclaimedBytes: c.reclaimed.Load(), History: history}
+}
+
+// Record counts a completed run and adds it to the history, dropping the oldest
+// run once the history is full.
+func (c *Compaction) Record(run Run) {
+	c.runs.Add(1)
+	c.reclaimed.Add(run.ReclaimedBytes)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.history) == historySize {
+		c.history = slices.Delete(c.history, 0, 1)
+	}
+	c.history = append(c.history, run)
+}
@@ -0,0 +1,36 @@
+package compaction
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSelect(t *testing.T) {
+	candidates := []Candidate{
+		{ID: 3, Size: 100, LiveBytes: 40},
+		{ID: 1, Size: 100, LiveBytes: 90},
+		{ID: 2, Size: 100, LiveBytes: 100, Rekey: true},
+		{ID: 4, Size: 0},
+	}
+
+	got := Select(candidates, MinDeadRatio)
+	if fmt.Sprint(got) != "[2 3]" {
+		t.Fatalf("Select() = %v, want [2 3]", got)
+	}
+}
+
+func TestRecordKeepsRecentHistory(t *testing.T) {
+	c := New()
+	for i := range historySize + 4 {
+		c.Record(Run{Started: time.Unix(int64(i), 0), Segments: 1, ReclaimedBytes: 10})
+	}
+
+	stats := c.Stats()
+	if stats.Runs != historySize+4 || stats.ReclaimedBytes != 10*(historySize+4) {
+		t.Fatalf("Stats() = %+v, want every run counted", stats)
+	}
+	if len(stats.History) != historySize || stats.History[len(stats.History)-1].Started.Unix() != historySize+3 {
+		t.Fatalf("Stats().History = %+v, want the last %d runs", stats.History, historySize)
+	}
+}
@@ -0,0 +1,114 @@
+// Package compression provides the value codecs that can be applied to entries before
+// they are written to a segment.
+//
+// Every codec has a small numeric ID that is recorded in the header of each entry it
+// compressed, so an entry can always be decoded regardless of which codec the store is
+// currently configured with. IDs are therefore part of the on-disk format: once a codec
+// has been assigned an ID, that ID must never be reused for a different algorithm.
+//
+// IDs occupy three bits of the entry flags. ID 0 means the value is stored as-is, which
+// leaves seven IDs for codecs. Built-in codecs are registered at init time; additional
+// codecs can be plugged in with Register before a store is opened.
+package compression
+
+import (
+	stdErrors "errors"
+	"fmt"
+	"sync"
+)
+
+const (
+	// None is the codec ID of values stored without compression.
+	None uint8 = 0
+
+	// Flate is the codec ID of the built-in DEFLATE codec backed by compress/flate.
+	Flate uint8 = 1
+
+	// MaxID is the largest codec ID that fits into the entry header.
+	MaxID uint8 = 7
+)
+
+var (
+	// ErrUnknownCodec is returned when a value references a codec that is not registered.
+	ErrUnknownCodec = stdErrors.New("compression: unknown codec")
+
+	// ErrCorruptValue is returned when a compressed value cannot be decoded.
+	ErrCorruptValue = stdErrors.New("compression: corrupt compressed value")
+)
+
+// Codec compresses and decompresses entry values. Implementations must be safe for
+// concurrent use.
+type Codec interface {
+	// ID returns the codec ID recorded in the header of every entry the codec compressed.
+	ID() uint8
+
+	// Name returns the name used to select the codec in the configuration.
+	Name() string
+
+	// Compress returns the compressed form of src.
+	Compress(src []byte) ([]byte, error)
+
+	// Decompress reverses Compress. It must reject input it cannot decode with an
+	// error wrapping ErrCorruptValue.
+	Decompress(src []byte) ([]byte, error)
+}
+
+var (
+	registryMu sync.RWMutex
+	byID       = make(map[uint8]Codec)
+	byName     = make(map[string]Codec)
+)
+
+func init() {
+	if err := Register(&flateCodec{}); err != nil {
+		panic(err)
+	}
+}
+
+// Register makes a codec available for writing by name and for reading by ID. It fails
+// if the ID is out of range or either the ID or the name is already taken.
+func Register(codec Codec) error {
+	id, name := codec.ID(), codec.Name()
+	if id == None || id > MaxID {
+		return fmt.Errorf("compression: codec %q has ID %d outside 1..%d", name, id, MaxID)
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if existing, ok := byID[id]; ok {
+		return fmt.Errorf("compression: codec ID %d is already used by %q", id, existing.Name())
+	}
+	if _, ok := byName[name]; ok {
+		return fmt.Errorf("compression: codec name %q is already registered", name)
+	}
+
+	byID[id] = codec
+	byName[name] = codec
+	return nil
+}
+
+// Lookup returns the codec registered under the given ID.
+func Lookup(id uint8) (Codec, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	codec, ok := byID[id]
+	return codec, ok
+}
+
+// LookupName returns the codec registered under the given name.
+func LookupName(name string) (Codec, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	codec, ok := byName[name]
+	return codec, ok
+}
+
+// Decompress decodes a value that was compressed by the codec with the given ID.
+func Decompress(id uint8, src []byte) ([]byte, error) {
+	codec, ok := Lookup(id)
+	if !ok {
+		return nil, fmt.Errorf("%w: ID %d", ErrUnknownCodec, id)
+	}
+	return codec.Decompress(src)
+}
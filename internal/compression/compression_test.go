@@ -0,0 +1,127 @@
+package compression
+
+import (
+	"bytes"
+	"encoding/binary"
+	stdErrors "errors"
+	"strings"
+	"testing"
+)
+
+func TestFlateRoundTrip(t *testing.T) {
+	codec, ok := LookupName("flate")
+	if !ok || codec.ID() != Flate {
+		t.Fatal("flate codec is not registered")
+	}
+
+	values := map[string][]byte{
+		"empty":          {},
+		"text":           []byte(strings.Repeat(`{"name":"ignite","kind":"store"}`, 64)),
+		"incompressible": bytes.Repeat([]byte{0, 1, 2, 3, 5, 8, 13, 21, 34, 55, 89, 144}, 17),
+	}
+	for name, value := range values {
+		t.Run(name, func(t *testing.T) {
+			compressed, err := codec.Compress(value)
+			if err != nil {
+				t.Fatalf("Compress() error = %v", err)
+			}
+
+			got, err := Decompress(Flate, compressed)
+			if err != nil {
+				t.Fatalf("Decompress() error = %v", err)
+			}
+			if !bytes.Equal(got, value) {
+				t.Fatalf("Decompress() = %q, want %q", got, value)
+			}
+		})
+	}
+
+	text := values["text"]
+	if compressed, _ := codec.Compress(text); len(compressed) >= len(text)/4 {
+		t.Fatalf("Compress() of repetitive text = %d bytes, want much less than %d", len(compressed), len(text))
+	}
+}
+
+func TestFlateRejectsCorruptValues(t *testing.T) {
+	codec, _ := Lookup(Flate)
+	compressed, err := codec.Compress([]byte(strings.Repeat("ignite ", 100)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A length prefix one byte short of the stream, encoded in as many bytes.
+	short := append([]byte{}, compressed...)
+	binary.PutUvarint(short, 699)
+
+	values := map[string][]byte{
+		"empty":        {},
+		"truncated":    compressed[:len(compressed)/2],
+		"short prefix": short,
+		"huge prefix":  {0xff, 0xff, 0xff, 0xff, 0x0f, 0x00},
+	}
+	for name, value := range values {
+		t.Run(name, func(t *testing.T) {
+			if _, err := codec.Decompress(value); !stdErrors.Is(err, ErrCorruptValue) {
+				t.Fatalf("Decompress() error = %v, want %v", err, ErrCorruptValue)
+			}
+		})
+	}
+}
+
+func TestDecompressUnknownCodec(t *testing.T) {
+	if _, err := Decompress(MaxID, []byte("value")); !stdErrors.Is(err, ErrUnknownCodec) {
+		t.Fatalf("Decompress() error = %v, want %v", err, ErrUnknownCodec)
+	}
+}
+
+// testCodec is a codec that stores values reversed, for registry tests.
+type testCodec struct {
+	id   uint8
+	name string
+}
+
+func (c testCodec) ID() uint8    { return c.id }
+func (c testCodec) Name() string { return c.name }
+
+func (c testCodec) Compress(src []byte) ([]byte, error) {
+	out := make([]byte, len(src))
+	for i, b := range src {
+		out[len(src)-1-i] = b
+	}
+	return out, nil
+}
+
+func (c testCodec) Decompress(src []byte) ([]byte, error) {
+	return c.Compress(src)
+}
+
+func TestRegister(t *testing.T) {
+	rejected := map[string]Codec{
+		"none ID":      testCodec{None, "test-none"},
+		"ID too large": testCodec{MaxID + 1, "test-large"},
+		"taken ID":     testCodec{Flate, "test-taken"},
+		"taken name":   testCodec{MaxID - 1, "flate"},
+	}
+	for name, codec := range rejected {
+		if err := Register(codec); err == nil {
+			t.Fatalf("Register() of a codec with a %s succeeded", name)
+		}
+	}
+
+	if err := Register(testCodec{MaxID, "test-reverse"}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	t.Cleanup(func() {
+		registryMu.Lock()
+		delete(byID, MaxID)
+		delete(byName, "test-reverse")
+		registryMu.Unlock()
+	})
+
+	if codec, ok := LookupName("test-reverse"); !ok || codec.ID() != MaxID {
+		t.Fatal("LookupName() does not find the registered codec")
+	}
+	if got, err := Decompress(MaxID, []byte("cba")); err != nil || string(got) != "abc" {
+		t.Fatalf("Decompress() = %q, %v, want %q", got, err, "abc")
+	}
+}
@@ -0,0 +1,94 @@
+package compression
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// maxFlateRatio is the largest expansion DEFLATE can achieve. A length prefix claiming
+// more than this relative to the compressed size is corrupt, which keeps a damaged
+// prefix from triggering a huge allocation.
+const maxFlateRatio = 1032
+
+// flateCodec compresses values with DEFLATE from the standard library.
+//
+// Each compressed value starts with the uvarint-encoded length of the original value,
+// which lets Decompress allocate the output exactly once, followed by a raw DEFLATE
+// stream. Writers and readers are pooled since both carry sizeable internal state.
+type flateCodec struct {
+	writers sync.Pool
+	readers sync.Pool
+}
+
+// ID returns the codec ID of the flate codec.
+func (c *flateCodec) ID() uint8 {
+	return Flate
+}
+
+// Name returns the configuration name of the flate codec.
+func (c *flateCodec) Name() string {
+	return "flate"
+}
+
+// Compress returns the length-prefixed DEFLATE encoding of src.
+func (c *flateCodec) Compress(src []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Grow(binary.MaxVarintLen64 + len(src)/2)
+
+	var prefix [binary.MaxVarintLen64]byte
+	buf.Write(prefix[:binary.PutUvarint(prefix[:], uint64(len(src)))])
+
+	w, _ := c.writers.Get().(*flate.Writer)
+	if w == nil {
+		var err error
+		if w, err = flate.NewWriter(&buf, flate.DefaultCompression); err != nil {
+			return nil, err
+		}
+	} else {
+		w.Reset(&buf)
+	}
+	defer c.writers.Put(w)
+
+	if _, err := w.Write(src); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Decompress decodes a value produced by Compress.
+func (c *flateCodec) Decompress(src []byte) ([]byte, error) {
+	size, n := binary.Uvarint(src)
+	if n <= 0 || size > uint64(len(src)-n)*maxFlateRatio+64 {
+		return nil, fmt.Errorf("%w: invalid flate length prefix", ErrCorruptValue)
+	}
+
+	input := bytes.NewReader(src[n:])
+	r, _ := c.readers.Get().(io.ReadCloser)
+	if r == nil {
+		r = flate.NewReader(input)
+	} else if err := r.(flate.Resetter).Reset(input, nil); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptValue, err)
+	}
+	defer c.readers.Put(r)
+
+	out := make([]byte, size)
+	if _, err := io.ReadFull(r, out); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptValue, err)
+	}
+
+	// The stream must end exactly where the prefix said it would.
+	var extra [1]byte
+	if n, err := r.Read(extra[:]); n != 0 || (err != nil && err != io.EOF) {
+		return nil, fmt.Errorf("%w: flate stream longer than its length prefix", ErrCorruptValue)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,245 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/iamNilotpal/ignite/internal/backup"
+	igniteErrors "github.com/iamNilotpal/ignite/pkg/errors"
+	"github.com/iamNilotpal/ignite/pkg/options"
+	"github.com/iamNilotpal/ignite/pkg/seginfo"
+)
+
+// restoreInto restores chain into a fresh data directory laid out like opts, and
+// returns the options to open it with.
+func restoreInto(t *testing.T, opts *options.Options, chain ...backup.Source) (*options.Options, error) {
+	t.Helper()
+
+	restored := *opts
+	restored.DataDir = filepath.Join(t.TempDir(), "restored")
+	_, err := backup.Restore(context.Background(), chain, backup.Layout{
+		DataDir:    restored.DataDir,
+		SegmentDir: filepath.Join(restored.DataDir, restored.SegmentOptions.Directory),
+		BlobDir:    filepath.Join(restored.DataDir, restored.BlobOptions.Directory),
+	})
+	return &restored, err
+}
+
+// tarBackup takes a backup of e as a tar archive, incremental if parent is set.
+func tarBackup(t *testing.T, e *Engine, parent *backup.Metadata) ([]byte, *backup.Metadata) {
+	t.Helper()
+
+	var chain *backup.Chain
+	if parent != nil {
+		chain = &parent.Chain
+	}
+
+	var buf bytes.Buffer
+	metadata, err := e.Backup(context.Background(), backup.NewTarWriter(&buf), chain)
+	if err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+	return buf.Bytes(), metadata
+}
+
+// writeBackupState fills e with inline values, a blob and a delete, and returns the
+// value every key holds afterwards; deleted keys map to nil.
+func writeBackupState(t *testing.T, e *Engine) map[string][]byte {
+	t.Helper()
+
+	want := make(map[string][]byte)
+	for i := range 100 {
+		key := fmt.Sprintf("key-%02d", i%30)
+		want[key] = value(key, i, 100)
+		mustSet(t, e, key, want[key])
+	}
+	want["blob"] = value("blob", 0, 4096)
+	mustSet(t, e, "blob", want["blob"])
+
+	if err := e.Delete(context.Background(), "key-05"); err != nil {
+		t.Fatal(err)
+	}
+	want["key-05"] = nil
+	return want
+}
+
+// expectState fails the test unless e holds exactly the values in want.
+func expectState(t *testing.T, e *Engine, want map[string][]byte) {
+	t.Helper()
+
+	for key, v := range want {
+		if v == nil {
+			expectMissing(t, e, key)
+			continue
+		}
+		expectValue(t, e, key, v)
+	}
+}
+
+func TestBackupRestoreRoundTrip(t *testing.T) {
+	opts := testOptions(t.TempDir())
+	e := openEngine(t, opts)
+	want := writeBackupState(t, e)
+
+	archive, _ := tarBackup(t, e, nil)
+
+	dir := filepath.Join(t.TempDir(), "backup")
+	w, err := backup.NewDirWriter(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.Backup(context.Background(), w, nil); err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+
+	// Writes after the backup are not part of it.
+	mustSet(t, e, "after", []byte("after"))
+	mustSet(t, e, "key-00", []byte("changed"))
+	want["after"] = nil
+
+	sources := map[string]backup.Source{
+		"tar": backup.TarSource(bytes.NewReader(archive)),
+		"dir": backup.DirSource(dir),
+	}
+	for name, source := range sources {
+		t.Run(name, func(t *testing.T) {
+			restored, err := restoreInto(t, opts, source)
+			if err != nil {
+				t.Fatalf("Restore() error = %v", err)
+			}
+
+			r := openEngine(t, restored)
+			expectState(t, r, want)
+		})
+	}
+}
+
+func TestRestoreRejectsDamagedArchive(t *testing.T) {
+	opts := testOptions(t.TempDir())
+	e := openEngine(t, opts)
+	writeBackupState(t, e)
+	archive, _ := tarBackup(t, e, nil)
+
+	// A flipped byte inside the blob file.
+	flipped := bytes.Clone(archive)
+	flipped[bytes.Index(archive, value("blob", 0, 64))] ^= 0xff
+
+	cases := map[string][]byte{
+		"flipped":   flipped,
+		"truncated": archive[:len(archive)/2],
+	}
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			restored, err := restoreInto(t, opts, backup.TarSource(bytes.NewReader(data)))
+			expectCode(t, err, igniteErrors.ErrorCodeBackupInvalid)
+
+			// Nothing reaches the data directory.
+			entries, _ := os.ReadDir(restored.DataDir)
+			if len(entries) != 0 {
+				t.Fatalf("data directory holds %d entries after a failed restore", len(entries))
+			}
+		})
+	}
+}
+
+func TestRestoreRequiresEmptyDataDir(t *testing.T) {
+	opts := testOptions(t.TempDir())
+	e := openEngine(t, opts)
+	writeBackupState(t, e)
+	archive, _ := tarBackup(t, e, nil)
+
+	_, err := backup.Restore(context.Background(), []backup.Source{backup.TarSource(bytes.NewReader(archive))}, backup.Layout{
+		DataDir:    opts.DataDir,
+		SegmentDir: filepath.Join(opts.DataDir, opts.SegmentOptions.Directory),
+		BlobDir:    filepath.Join(opts.DataDir, opts.BlobOptions.Directory),
+	})
+	if err == nil {
+		t.Fatal("Restore() into a live data directory succeeded")
+	}
+	expectState(t, e, map[string][]byte{"blob": value("blob", 0, 4096)})
+}
+
+func TestIncrementalBackupChain(t *testing.T) {
+	opts := testOptions(t.TempDir())
+	e := openEngine(t, opts)
+	want := writeBackupState(t, e)
+	full, fullMeta := tarBackup(t, e, nil)
+
+	// Overwrites, a delete, a replaced blob and a compaction between the backups.
+	for i := range 60 {
+		key := fmt.Sprintf("key-%02d", i%30)
+		want[key] = value(key, 1000+i, 100)
+		mustSet(t, e, key, want[key])
+	}
+	if err := e.Delete(context.Background(), "key-10"); err != nil {
+		t.Fatal(err)
+	}
+	want["key-10"] = nil
+	want["blob"] = value("blob", 1, 4096)
+	mustSet(t, e, "blob", want["blob"])
+	if _, err := e.Compact(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	first, firstMeta := tarBackup(t, e, fullMeta)
+	atFirst := make(map[string][]byte, len(want))
+	for key, v := range want {
+		atFirst[key] = v
+	}
+
+	// Only segments sealed since the full backup are copied.
+	copied := 0
+	for _, file := range firstMeta.Files {
+		if !strings.HasPrefix(file.Name, backup.SegmentsDir+"/") {
+			continue
+		}
+		id, err := seginfo.ParseSegmentID(file.Name, opts.SegmentOptions.Prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id <= fullMeta.LastSegment {
+			t.Fatalf("incremental backup copied %s, already covered by the full backup", file.Name)
+		}
+		copied++
+	}
+	if copied == 0 {
+		t.Fatal("incremental backup copied no segments")
+	}
+
+	want["key-20"] = []byte("second")
+	mustSet(t, e, "key-20", want["key-20"])
+	second, _ := tarBackup(t, e, firstMeta)
+
+	source := func(archive []byte) backup.Source { return backup.TarSource(bytes.NewReader(archive)) }
+
+	t.Run("whole chain", func(t *testing.T) {
+		restored, err := restoreInto(t, opts, source(full), source(first), source(second))
+		if err != nil {
+			t.Fatalf("Restore() error = %v", err)
+		}
+		expectState(t, openEngine(t, restored), want)
+	})
+
+	t.Run("prefix of the chain", func(t *testing.T) {
+		restored, err := restoreInto(t, opts, source(full), source(first))
+		if err != nil {
+			t.Fatalf("Restore() error = %v", err)
+		}
+		expectState(t, openEngine(t, restored), atFirst)
+	})
+
+	t.Run("incremental without parent", func(t *testing.T) {
+		_, err := restoreInto(t, opts, source(first))
+		expectCode(t, err, igniteErrors.ErrorCodeBackupInvalid)
+	})
+
+	t.Run("gap in the chain", func(t *testing.T) {
+		_, err := restoreInto(t, opts, source(full), source(second))
+		expectCode(t, err, igniteErrors.ErrorCodeBackupInvalid)
+	})
+}
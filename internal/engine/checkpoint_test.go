@@ -0,0 +1,56 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckpointIsOpenableClone(t *testing.T) {
+	opts := testOptions(t.TempDir())
+	e := openEngine(t, opts)
+	want := writeBackupState(t, e)
+
+	dir := filepath.Join(t.TempDir(), "checkpoint")
+	if err := e.Checkpoint(context.Background(), dir); err != nil {
+		t.Fatalf("Checkpoint() error = %v", err)
+	}
+
+	clone := *opts
+	clone.DataDir = dir
+	files := segmentFiles(t, &clone)
+	if len(files) == 0 {
+		t.Fatal("checkpoint holds no segments")
+	}
+
+	// Sealed segments are shared with the store rather than copied.
+	linked := 0
+	for _, path := range files {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, original := range segmentFiles(t, opts) {
+			if other, err := os.Stat(original); err == nil && os.SameFile(info, other) {
+				linked++
+			}
+		}
+	}
+	if linked == 0 {
+		t.Fatal("no checkpoint segment is linked to the store")
+	}
+
+	// Changes after the checkpoint, including a compaction that retires segments the
+	// checkpoint links to, do not reach it.
+	mustSet(t, e, "key-00", []byte("changed"))
+	mustSet(t, e, "after", []byte("after"))
+	if _, err := e.Compact(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want["after"] = nil
+
+	c := openEngine(t, &clone)
+	expectState(t, c, want)
+	expectValue(t, e, "key-00", []byte("changed"))
+}
@@ -0,0 +1,440 @@
+package engine
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/iamNilotpal/ignite/internal/compaction"
+	"github.com/iamNilotpal/ignite/internal/index"
+	"github.com/iamNilotpal/ignite/internal/storage"
+	igniteErrors "github.com/iamNilotpal/ignite/pkg/errors"
+)
+
+// Compact reclaims the disk space taken by dead entries and moves data protected by
+// an old master key under the current one. It runs on its own every CompactInterval,
+// and can be called at any time; runs never overlap.
+//
+// A run proceeds in three steps. Blob files wrapped by a master key other than the
+// current one are rewritten first, and the active segment is sealed if its key is
+// not current, so that it can be compacted too. Then every sealed segment that is
+// either wrapped by an old key, or plaintext while encryption is enabled, or has at
+// least compaction.MinDeadRatio of its bytes dead, is walked: each entry the index
+// still points at is appended again to the active segment, sealed under the current
+// key, and the index is moved to the copy. Finally the copies are synced and the
+// walked segments are retired in a single manifest record.
+//
+// Superseded entries are dropped. Tombstones and expired entries are dropped too when
+// no older segment survives the run; otherwise a tombstone takes their place, so that
+// the older version they hide cannot come back on replay. That includes expired
+// entries whose key has already been reclaimed from the index.
+// A segment that turns out to be damaged is left in place for fsck and repair. When a
+// CompactRateLimit is set, the walk is slowed down to that many bytes per second.
+func (e *Engine) Compact(ctx context.Context) (compaction.Run, error) {
+	run := compaction.Run{Started: time.Now()}
+
+	if e.closed.Load() {
+		return run, ErrEngineClosed
+	}
+	if e.options.ReadOnly {
+		return run, igniteErrors.NewReadOnlyError("compact")
+	}
+
+	e.compactMu.Lock()
+	defer e.compactMu.Unlock()
+
+	// Close may have finished while this run waited for the previous one.
+	if e.closed.Load() {
+		return run, ErrEngineClosed
+	}
+
+	// Close cancels the run between two entries rather than waiting for it to finish.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer context.AfterFunc(e.lifetime, cancel)()
+
+	keyID, err := e.storage.CurrentKeyID()
+	if err != nil {
+		return run, err
+	}
+
+	if keyID != "" {
+		if err := e.rekeyBlobs(ctx, keyID); err != nil {
+			return run, err
+		}
+		if err := e.storage.RotateKey(keyID); err != nil {
+			return run, err
+		}
+	}
+
+	candidates, err := e.compactionCandidates(keyID)
+	if err != nil {
+		return run, err
+	}
+	inputs := compaction.Select(candidates, compaction.MinDeadRatio)
+
+	// Segments that stay are the ones an input's tombstones may still have to hide
+	// older versions in.
+	var kept []uint64
+	for _, segment := range e.storage.Segments() {
+		if !slices.Contains(inputs, segment.ID) {
+			kept = append(kept, segment.ID)
+		}
+	}
+
+	var retired []uint64
+	var inputBytes, written int64
+	var pace pacer
+	for _, id := range inputs {
+		keepTombstones := slices.ContainsFunc(kept, func(other uint64) bool { return other < id })
+
+		err := e.storage.ScanSegment(id, func(pos *storage.Position, entry *storage.Entry) error {
+			if err := pace.wait(ctx, e.compactRate.Load(), int64(pos.Size)); err != nil {
+				return err
+			}
+			n, err := e.relocate(id, pos, entry, keepTombstones)
+			written += n
+			return err
+		})
+		if err != nil {
+			if igniteErrors.GetErrorCode(err) != igniteErrors.ErrorCodeSegmentCorrupted {
+				return run, err
+			}
+
+			// The entries copied so far are harmless duplicates of what stays.
+			e.log.Errorw("Skipping damaged segment during compaction", "segmentID", id, "error", err)
+			kept = append(kept, id)
+			continue
+		}
+
+		retired = append(retired, id)
+		inputBytes += sizeOf(candidates, id)
+	}
+
+	if len(retired) > 0 {
+		// The copies must survive a crash before the originals are gone.
+		if err := e.storage.Sync(); err != nil {
+			return run, err
+		}
+
+		e.mu.Lock()
+		err := e.storage.RetireSegments(retired)
+		for _, id := range retired {
+			e.index.ReleaseSegment(id)
+		}
+		e.mu.Unlock()
+		if err != nil {
+			return run, err
+		}
+	}
+
+	run.Duration = time.Since(run.Started)
+	run.Segments = len(retired)
+	run.ReclaimedBytes = uint64(max(inputBytes-written, 0))
+	e.compaction.Record(run)
+
+	e.log.Infow(
+		"Compaction finished",
+		"segments", retired,
+		"reclaimedBytes", run.ReclaimedBytes,
+		"duration", run.Duration,
+	)
+	return run, nil
+}
+
+// SetCompactInterval changes how often compaction runs on its own. The next run is
+// scheduled a full interval from now. An interval of zero or less stops automatic
+// compaction.
+func (e *Engine) SetCompactInterval(interval time.Duration) {
+	e.compactInterval.Store(int64(interval))
+
+	select {
+	case e.compactReset <- struct{}{}:
+	default:
+	}
+}
+
+// SetCompactRateLimit changes how many bytes of segment data compaction walks per
+// second, including in a run already in progress. Zero removes the limit.
+func (e *Engine) SetCompactRateLimit(bytesPerSecond uint64) {
+	e.compactRate.Store(bytesPerSecond)
+}
+
+// compactLoop runs Compact every CompactInterval until the engine is closed.
+func (e *Engine) compactLoop() {
+	defer close(e.compactDone)
+
+	timer := time.NewTimer(e.nextCompaction())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-e.lifetime.Done():
+			return
+
+		case <-e.compactReset:
+			timer.Reset(e.nextCompaction())
+
+		case <-timer.C:
+			if _, err := e.Compact(e.lifetime); err != nil && e.lifetime.Err() == nil {
+				e.log.Errorw("Compaction failed", "error", err)
+			}
+			timer.Reset(e.nextCompaction())
+		}
+	}
+}
+
+// nextCompaction returns how long to wait for the next automatic run. A disabled
+// schedule waits for as long as a timer can.
+func (e *Engine) nextCompaction() time.Duration {
+	if interval := time.Duration(e.compactInterval.Load()); interval > 0 {
+		return interval
+	}
+	return time.Duration(1<<63 - 1)
+}
+
+// compactionCandidates describes every sealed segment for compaction.Select. When
+// encryption is enabled, segments not wrapped by keyID are marked for rekeying.
+func (e *Engine) compactionCandidates(keyID string) ([]compaction.Candidate, error) {
+	activeID, _ := e.storage.ActiveSegment()
+	live := e.index.LiveBytes()
+
+	var candidates []compaction.Candidate
+	for _, segment := range e.storage.Segments() {
+		if segment.ID == activeID {
+			continue
+		}
+
+		candidate := compaction.Candidate{ID: segment.ID, Size: segment.Size, LiveBytes: live[segment.ID]}
+		if keyID != "" {
+			segmentKey, err := e.storage.SegmentKeyID(segment.ID)
+			if err != nil {
+				return nil, err
+			}
+			candidate.Rekey = segmentKey != keyID
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates, nil
+}
+
+// relocate appends a copy of an entry of a segment being compacted to the active
+// segment if the entry is still needed, and returns how many bytes it wrote. The
+// check and the append happen under the write lock, so a concurrent write of the
+// same key either lands before the check, and the entry is dropped as superseded, or
+// after the copy.
+func (e *Engine) relocate(segmentID uint64, pos *storage.Position, entry *storage.Entry, keepTombstones bool) (int64, error) {
+	key := string(entry.Key)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	current := false
+	rp, err := e.index.Get(key)
+	switch {
+	case err == nil:
+		fileID, err := e.index.ResolveSegment(rp.SegmentID, key)
+		if err != nil {
+			return 0, err
+		}
+		current = fileID == segmentID && rp.Offset == pos.Offset
+	case igniteErrors.GetErrorCode(err) != igniteErrors.ErrorCodeIndexKeyNotFound:
+		return 0, err
+	}
+
+	expired := entry.ExpiresAt != 0 && entry.ExpiresAt <= time.Now().UnixNano()
+
+	var moved *storage.Entry
+	switch {
+	case current && expired:
+		e.reclaimLocked(key)
+		if keepTombstones {
+			moved = storage.NewEntry(entry.Key, nil, storage.FlagTombstone)
+		}
+
+	case current:
+		moved = entry.Relocated()
+
+	case rp == nil && entry.IsTombstone() && keepTombstones:
+		moved = entry.Relocated()
+
+	case rp == nil && expired && keepTombstones:
+		// The key expired and was reclaimed since. This entry is all that still hides
+		// the versions in older segments, so a tombstone takes its place.
+		moved = storage.NewEntry(entry.Key, nil, storage.FlagTombstone)
+	}
+	if moved == nil {
+		return 0, nil
+	}
+
+	newPos, err := e.storage.Append(moved)
+	if err != nil {
+		return 0, err
+	}
+	if current && !moved.IsTombstone() {
+		if err := e.putPointer(key, newPos, moved.Timestamp, uint32(len(moved.Value))); err != nil {
+			return int64(newPos.Size), err
+		}
+		if c := e.cache.Load(); c != nil {
+			c.Delete(key)
+		}
+	}
+	return int64(newPos.Size), nil
+}
+
+// rekeyBlobs rewrites every blob file of a live key that is not wrapped by keyID, and
+// points its key at the new blob.
+func (e *Engine) rekeyBlobs(ctx context.Context, keyID string) error {
+	e.mu.RLock()
+	blobs := make(map[string]storage.BlobID, len(e.blobs))
+	for key, id := range e.blobs {
+		blobs[key] = id
+	}
+	e.mu.RUnlock()
+
+	for key, id := range blobs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		blobKey, err := e.storage.BlobKeyID(id)
+		if err != nil {
+			e.log.Errorw("Skipping unreadable blob during compaction", "key", key, "blob", id, "error", err)
+			continue
+		}
+		if blobKey == keyID {
+			continue
+		}
+
+		if err := e.rekeyBlob(key, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rekeyBlob copies the blob key refers to into a new blob under the current master
+// key. The copy is written without holding the engine lock; the entry pointing at it
+// is only appended if key still refers to the same version afterwards.
+func (e *Engine) rekeyBlob(key string, id storage.BlobID) error {
+	e.mu.RLock()
+	segmentID, rp, entry, err := e.currentEntry(key)
+	if err != nil || !entry.IsBlob() || e.blobs[key] != id {
+		e.mu.RUnlock()
+		return ignoreNotFound(err)
+	}
+	blob, err := e.openBlob(entry, segmentID, rp.Offset)
+	e.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	ref, err := e.storage.WriteBlob(blob)
+	blob.Close()
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now, err := e.index.Get(key)
+	if err != nil || now.SegmentID != rp.SegmentID || now.Offset != rp.Offset {
+		e.removeBlob(ref.ID)
+		return ignoreNotFound(err)
+	}
+
+	moved := entry.Relocated()
+	moved.Value = ref.Encode()
+	pos, err := e.storage.Append(moved)
+	if err != nil {
+		e.removeBlob(ref.ID)
+		return err
+	}
+	if err := e.putPointer(key, pos, moved.Timestamp, uint32(len(moved.Value))); err != nil {
+		return err
+	}
+	if c := e.cache.Load(); c != nil {
+		c.Delete(key)
+	}
+
+	e.replaceBlob(key, &ref.ID)
+	return nil
+}
+
+// currentEntry reads the entry the index points at for key. The caller must hold e.mu.
+func (e *Engine) currentEntry(key string) (uint64, *index.RecordPointer, *storage.Entry, error) {
+	rp, err := e.index.Get(key)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	segmentID, err := e.index.ResolveSegment(rp.SegmentID, key)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	entry, err := e.storage.ReadEntry(segmentID, rp.Offset, rp.EntrySize)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	return segmentID, rp, entry, nil
+}
+
+// ignoreNotFound drops key-not-found errors, which only mean that a key was deleted
+// while compaction was working on it.
+func ignoreNotFound(err error) error {
+	if igniteErrors.GetErrorCode(err) == igniteErrors.ErrorCodeIndexKeyNotFound {
+		return nil
+	}
+	return err
+}
+
+// pacer throttles a compaction run to a rate limit. Bytes walked are owed until they
+// add up to minPause at the current rate, and then paid off in a single sleep, so
+// that small entries do not each cost a timer.
+type pacer struct {
+	owed int64 // Bytes walked since the last pause.
+}
+
+// minPause is the shortest pause a pacer takes.
+const minPause = 10 * time.Millisecond
+
+// wait records n more bytes walked and, when a limit of rate bytes per second is set,
+// sleeps for as long as the owed bytes take at that rate. It returns early with the
+// context's error if ctx is cancelled first.
+func (p *pacer) wait(ctx context.Context, rate uint64, n int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if rate == 0 {
+		p.owed = 0
+		return nil
+	}
+
+	p.owed += n
+	pause := time.Duration(float64(p.owed) / float64(rate) * float64(time.Second))
+	if pause < minPause {
+		return nil
+	}
+	p.owed = 0
+
+	timer := time.NewTimer(pause)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// sizeOf returns the size of the candidate with the given ID.
+func sizeOf(candidates []compaction.Candidate, id uint64) int64 {
+	for _, c := range candidates {
+		if c.ID == id {
+			return c.Size
+		}
+	}
+	return 0
+}
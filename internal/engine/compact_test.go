@@ -0,0 +1,226 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/iamNilotpal/ignite/internal/index"
+)
+
+// totalSize returns the combined size of the live segments of e.
+func totalSize(e *Engine) int64 {
+	var size int64
+	for _, segment := range e.storage.Segments() {
+		size += segment.Size
+	}
+	return size
+}
+
+func TestCompactReclaimsOverwrites(t *testing.T) {
+	opts := testOptions(t.TempDir())
+	e := openEngine(t, opts)
+
+	for i := range 400 {
+		mustSet(t, e, fmt.Sprintf("key-%02d", i%20), value("key", i, 100))
+	}
+	before := totalSize(e)
+
+	run, err := e.Compact(context.Background())
+	if err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+	if run.Segments == 0 || run.ReclaimedBytes == 0 {
+		t.Fatalf("Compact() = %+v, want segments compacted and bytes reclaimed", run)
+	}
+	if after := totalSize(e); after >= before {
+		t.Fatalf("store size %d after compaction, was %d", after, before)
+	}
+
+	stats := e.Stats().Compaction
+	if stats.Runs != 1 || stats.ReclaimedBytes != run.ReclaimedBytes || len(stats.History) != 1 {
+		t.Fatalf("Stats().Compaction = %+v, want the run recorded", stats)
+	}
+
+	for i := 380; i < 400; i++ {
+		expectValue(t, e, fmt.Sprintf("key-%02d", i%20), value("key", i, 100))
+	}
+	closeEngine(t, e)
+
+	e = openEngine(t, opts)
+	for i := 380; i < 400; i++ {
+		expectValue(t, e, fmt.Sprintf("key-%02d", i%20), value("key", i, 100))
+	}
+}
+
+func TestCompactKeepsTombstonesHidingOlderSegments(t *testing.T) {
+	opts := testOptions(t.TempDir())
+	e := openEngine(t, opts)
+
+	// The first segment stays mostly live, so it survives compaction with the old
+	// version of "doomed" in it.
+	mustSet(t, e, "doomed", value("doomed", 0, 100))
+	for i := range 30 {
+		mustSet(t, e, fmt.Sprintf("stable-%02d", i), value("stable", i, 100))
+	}
+
+	// Later segments are mostly overwrites, and hold the tombstone.
+	for i := range 100 {
+		mustSet(t, e, "churn", value("churn", i, 100))
+		if i == 50 {
+			if err := e.Delete(context.Background(), "doomed"); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if _, err := e.Compact(context.Background()); err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+	closeEngine(t, e)
+
+	e = openEngine(t, opts)
+	expectMissing(t, e, "doomed")
+	expectValue(t, e, "churn", value("churn", 99, 100))
+	for i := range 30 {
+		expectValue(t, e, fmt.Sprintf("stable-%02d", i), value("stable", i, 100))
+	}
+}
+
+func TestCompactDropsExpiredKeys(t *testing.T) {
+	opts := testOptions(t.TempDir())
+	e := openEngine(t, opts)
+
+	ok, err := e.SetWith(context.Background(), "short", value("short", 0, 100), time.Millisecond, Always)
+	if err != nil || !ok {
+		t.Fatalf("SetWith() = %v, %v", ok, err)
+	}
+	for i := range 200 {
+		mustSet(t, e, "churn", value("churn", i, 100))
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	if _, err := e.Compact(context.Background()); err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+	if _, ok := e.expiries["short"]; ok {
+		t.Fatal("expired key still tracked after compaction")
+	}
+	closeEngine(t, e)
+
+	e = openEngine(t, opts)
+	expectMissing(t, e, "short")
+}
+
+func TestCompactWithConcurrentWrites(t *testing.T) {
+	e := openEngine(t, testOptions(t.TempDir()))
+
+	for i := range 300 {
+		mustSet(t, e, fmt.Sprintf("key-%02d", i%30), value("key", i, 100))
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := e.Compact(context.Background())
+		done <- err
+	}()
+	for i := 300; i < 600; i++ {
+		mustSet(t, e, fmt.Sprintf("key-%02d", i%30), value("key", i, 100))
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+
+	for i := 570; i < 600; i++ {
+		expectValue(t, e, fmt.Sprintf("key-%02d", i%30), value("key", i, 100))
+	}
+}
+
+func TestCompactDoesNotResurrectReclaimedKeys(t *testing.T) {
+	opts := testOptions(t.TempDir())
+	e := openEngine(t, opts)
+
+	// The first segment stays mostly live, so it survives compaction with the old
+	// version of "fleeting" in it.
+	mustSet(t, e, "fleeting", value("fleeting", 0, 100))
+	for i := range 30 {
+		mustSet(t, e, fmt.Sprintf("stable-%02d", i), value("stable", i, 100))
+	}
+
+	// Later segments are mostly overwrites, and hold the version that expires.
+	for i := range 100 {
+		mustSet(t, e, "churn", value("churn", i, 100))
+		if i == 50 {
+			ok, err := e.SetWith(context.Background(), "fleeting", value("fleeting", 1, 100), 50*time.Millisecond, Always)
+			if err != nil || !ok {
+				t.Fatalf("SetWith() = %v, %v", ok, err)
+			}
+		}
+	}
+
+	// Reading the key after it expired drops it from the index before compaction
+	// gets to its record.
+	time.Sleep(60 * time.Millisecond)
+	expectMissing(t, e, "fleeting")
+
+	if _, err := e.Compact(context.Background()); err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+	expectMissing(t, e, "fleeting")
+	closeEngine(t, e)
+
+	e = openEngine(t, opts)
+	expectMissing(t, e, "fleeting")
+	for i := range 30 {
+		expectValue(t, e, fmt.Sprintf("stable-%02d", i), value("stable", i, 100))
+	}
+}
+
+func TestCompactInvalidatesCachedValues(t *testing.T) {
+	opts := testOptions(t.TempDir())
+	opts.CacheOptions.Size = 1 << 20
+	e := openEngine(t, opts)
+
+	for i := range 400 {
+		mustSet(t, e, fmt.Sprintf("key-%02d", i%20), value("key", i, 100))
+	}
+
+	// Read every key once so that the cache holds it, and note where it lives.
+	before := make(map[string]index.RecordPointer)
+	for i := 380; i < 400; i++ {
+		key := fmt.Sprintf("key-%02d", i%20)
+		expectValue(t, e, key, value("key", i, 100))
+		if _, ok := e.cache.Load().Get(key); !ok {
+			t.Fatalf("cache does not hold %q after a read", key)
+		}
+
+		rp, err := e.index.Get(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		before[key] = *rp
+	}
+
+	if _, err := e.Compact(context.Background()); err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+
+	moved := 0
+	for key, was := range before {
+		rp, err := e.index.Get(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rp.SegmentID == was.SegmentID && rp.Offset == was.Offset {
+			continue
+		}
+		moved++
+		if _, ok := e.cache.Load().Get(key); ok {
+			t.Fatalf("cache still holds %q after compaction moved it", key)
+		}
+	}
+	if moved == 0 {
+		t.Fatal("compaction moved no cached key")
+	}
+}
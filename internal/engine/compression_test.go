@@ -0,0 +1,91 @@
+package engine
+
+import (
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/iamNilotpal/ignite/internal/compression"
+	"github.com/iamNilotpal/ignite/pkg/options"
+)
+
+// storedCodec returns the codec ID recorded in the entry key currently points at.
+func storedCodec(t *testing.T, e *Engine, key string) uint8 {
+	t.Helper()
+
+	rp, err := e.index.Get(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	segmentID, err := e.index.ResolveSegment(rp.SegmentID, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, err := e.storage.ReadEntry(segmentID, rp.Offset, rp.EntrySize)
+	if err != nil {
+		t.Fatalf("ReadEntry() error = %v", err)
+	}
+	return entry.Codec()
+}
+
+func TestCompressionRespectsMinSize(t *testing.T) {
+	opts := testOptions(t.TempDir())
+	opts.CompressionOptions.Codec = options.CompressionFlate
+	opts.CompressionOptions.MinSize = 256
+	e := openEngine(t, opts)
+
+	text := []byte(strings.Repeat("compressible ", 64))
+	random := make([]byte, 512)
+	rand.Read(random)
+
+	values := map[string]struct {
+		value []byte
+		codec uint8
+	}{
+		"small":      {text[:255], compression.None},
+		"at-minimum": {text[:256], compression.Flate},
+		"large":      {text, compression.Flate},
+		// Values that do not shrink are stored as-is.
+		"random": {random, compression.None},
+	}
+	for key, v := range values {
+		mustSet(t, e, key, v.value)
+	}
+	for key, v := range values {
+		if got := storedCodec(t, e, key); got != v.codec {
+			t.Fatalf("%s is stored with codec %d, want %d", key, got, v.codec)
+		}
+		expectValue(t, e, key, v.value)
+	}
+}
+
+func TestCompressedValuesSurviveReopen(t *testing.T) {
+	opts := testOptions(t.TempDir())
+	opts.CompressionOptions.Codec = options.CompressionFlate
+	opts.CompressionOptions.MinSize = 64
+
+	text := []byte(strings.Repeat("compressible ", 64))
+	e := openEngine(t, opts)
+	mustSet(t, e, "compressed", text)
+	closeEngine(t, e)
+
+	// The codec is read from each entry, so turning compression off does not affect
+	// values that were already written compressed.
+	opts.CompressionOptions.Codec = options.CompressionNone
+	e = openEngine(t, opts)
+	if got := storedCodec(t, e, "compressed"); got != compression.Flate {
+		t.Fatalf("stored codec = %d after reopen, want %d", got, compression.Flate)
+	}
+	expectValue(t, e, "compressed", text)
+
+	mustSet(t, e, "plain", text)
+	if got := storedCodec(t, e, "plain"); got != compression.None {
+		t.Fatalf("stored codec = %d with compression off, want %d", got, compression.None)
+	}
+	closeEngine(t, e)
+
+	opts.CompressionOptions.Codec = options.CompressionFlate
+	e = openEngine(t, opts)
+	expectValue(t, e, "compressed", text)
+	expectValue(t, e, "plain", text)
+}
@@ -0,0 +1,184 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	igniteErrors "github.com/iamNilotpal/ignite/pkg/errors"
+	"github.com/iamNilotpal/ignite/pkg/options"
+)
+
+// encryptedOptions returns test options with a file key provider holding the given
+// key IDs, the last one current.
+func encryptedOptions(t *testing.T, ids ...string) (*options.Options, string) {
+	t.Helper()
+
+	opts := testOptions(t.TempDir())
+	keyFile := filepath.Join(t.TempDir(), "keys")
+	writeKeyFile(t, keyFile, ids...)
+	opts.KeyProvider = options.NewFileKeyProvider(keyFile)
+	return opts, keyFile
+}
+
+// fillStore writes overwritten and blob values, enough to span several segments,
+// and returns the value each key ends up with.
+func fillStore(t *testing.T, e *Engine) map[string][]byte {
+	t.Helper()
+
+	want := make(map[string][]byte)
+	for i := range 120 {
+		key := fmt.Sprintf("secret-%02d", i%40)
+		want[key] = value("plaintext-marker", i, 150)
+		mustSet(t, e, key, want[key])
+	}
+	want["large"] = value("plaintext-marker", 0, 3000)
+	mustSet(t, e, "large", want["large"])
+	return want
+}
+
+// expectNoPlaintext fails the test if any segment or blob file of the store contains
+// the marker written by fillStore.
+func expectNoPlaintext(t *testing.T, opts *options.Options) {
+	t.Helper()
+
+	files := segmentFiles(t, opts)
+	blobs, _ := filepath.Glob(filepath.Join(opts.DataDir, opts.BlobOptions.Directory, "*"))
+	for _, path := range append(files, blobs...) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(data, []byte("plaintext-marker")) {
+			t.Fatalf("%s holds plaintext", filepath.Base(path))
+		}
+	}
+}
+
+func TestEncryptedRoundTrip(t *testing.T) {
+	opts, _ := encryptedOptions(t, "k1")
+	e := openEngine(t, opts)
+	want := fillStore(t, e)
+
+	for key, v := range want {
+		expectValue(t, e, key, v)
+	}
+	closeEngine(t, e)
+	expectNoPlaintext(t, opts)
+
+	e = openEngine(t, opts)
+	for key, v := range want {
+		expectValue(t, e, key, v)
+	}
+}
+
+func TestEncryptedStoreRejectsWrongKey(t *testing.T) {
+	opts, keyFile := encryptedOptions(t, "k1")
+	e := openEngine(t, opts)
+	fillStore(t, e)
+	closeEngine(t, e)
+
+	// Same key ID, different key material.
+	writeKeyFile(t, keyFile, "k1")
+	_, err := New(context.Background(), &Config{Options: opts, Logger: e.log})
+	expectCode(t, err, igniteErrors.ErrorCodeEncryptionKeyUnavailable)
+
+	// Key ID no longer listed at all.
+	writeKeyFile(t, keyFile, "k2")
+	_, err = New(context.Background(), &Config{Options: opts, Logger: e.log})
+	expectCode(t, err, igniteErrors.ErrorCodeEncryptionKeyUnavailable)
+}
+
+func TestEncryptedStoreRequiresKeyProvider(t *testing.T) {
+	opts, _ := encryptedOptions(t, "k1")
+	e := openEngine(t, opts)
+	fillStore(t, e)
+	closeEngine(t, e)
+
+	opts.KeyProvider = nil
+	_, err := New(context.Background(), &Config{Options: opts, Logger: e.log})
+	expectCode(t, err, igniteErrors.ErrorCodeEncryptionKeyUnavailable)
+}
+
+func TestCompactionRotatesKeys(t *testing.T) {
+	opts, keyFile := encryptedOptions(t, "k1")
+	e := openEngine(t, opts)
+	want := fillStore(t, e)
+
+	appendKey(t, keyFile, "k2")
+	if _, err := e.Compact(context.Background()); err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+
+	for _, segment := range e.storage.Segments() {
+		keyID, err := e.storage.SegmentKeyID(segment.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if keyID != "k2" {
+			t.Errorf("segment %d wrapped by %q after rotation, want k2", segment.ID, keyID)
+		}
+	}
+	for key, id := range e.blobs {
+		keyID, err := e.storage.BlobKeyID(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if keyID != "k2" {
+			t.Errorf("blob of %q wrapped by %q after rotation, want k2", key, keyID)
+		}
+	}
+	for key, v := range want {
+		expectValue(t, e, key, v)
+	}
+	closeEngine(t, e)
+
+	// Nothing needs the retired key anymore.
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	if err := os.WriteFile(keyFile, lines[1], 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	e = openEngine(t, opts)
+	for key, v := range want {
+		expectValue(t, e, key, v)
+	}
+	expectNoPlaintext(t, opts)
+}
+
+func TestEnablingEncryptionKeepsPlaintextReadable(t *testing.T) {
+	opts := testOptions(t.TempDir())
+	e := openEngine(t, opts)
+	want := fillStore(t, e)
+	closeEngine(t, e)
+
+	keyFile := filepath.Join(t.TempDir(), "keys")
+	writeKeyFile(t, keyFile, "k1")
+	opts.KeyProvider = options.NewFileKeyProvider(keyFile)
+
+	e = openEngine(t, opts)
+	for key, v := range want {
+		expectValue(t, e, key, v)
+	}
+
+	// New writes are encrypted, and compaction encrypts the plaintext left behind.
+	mustSet(t, e, "fresh", value("plaintext-marker", 1, 200))
+	want["fresh"] = value("plaintext-marker", 1, 200)
+	if _, err := e.Compact(context.Background()); err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+	closeEngine(t, e)
+	expectNoPlaintext(t, opts)
+
+	e = openEngine(t, opts)
+	for key, v := range want {
+		expectValue(t, e, key, v)
+	}
+}
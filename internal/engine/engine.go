@@ -12,13 +12,21 @@
 package engine
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"io"
+	"math"
+	"sync"
 	"sync/atomic"
+	"time"
 
+	"github.com/iamNilotpal/ignite/internal/cache"
 	"github.com/iamNilotpal/ignite/internal/compaction"
+	"github.com/iamNilotpal/ignite/internal/compression"
 	"github.com/iamNilotpal/ignite/internal/index"
 	"github.com/iamNilotpal/ignite/internal/storage"
+	igniteErrors "github.com/iamNilotpal/ignite/pkg/errors"
 	"github.com/iamNilotpal/ignite/pkg/options"
 	"go.uber.org/zap"
 )
@@ -33,12 +41,32 @@ var (
 // of all internal components. The engine is designed to be thread-safe and supports
 // concurrent operations while maintaining data consistency.
 type Engine struct {
-	options    *options.Options       // options contains all configuration parameters for the engine and its subsystems.
-	log        *zap.SugaredLogger     // log provides structured logging capabilities throughout the engine.
-	closed     atomic.Bool            // closed is an atomic boolean that tracks the engine's lifecycle state.
-	index      *index.Index           // index manages the in-memory data structures for fast data access.
-	storage    *storage.Storage       // storage handles all persistent data operations.
-	compaction *compaction.Compaction // compaction manages background processes that optimize storage efficiency.
+	options    *options.Options            // options contains all configuration parameters for the engine and its subsystems.
+	log        *zap.SugaredLogger          // log provides structured logging capabilities throughout the engine.
+	closed     atomic.Bool                 // closed is an atomic boolean that tracks the engine's lifecycle state.
+	index      *index.Index                // index manages the in-memory data structures for fast data access.
+	storage    *storage.Storage            // storage handles all persistent data operations.
+	compaction *compaction.Compaction      // compaction manages background processes that optimize storage efficiency.
+	cache      atomic.Pointer[cache.Cache] // cache holds hot values in memory; nil when the cache is disabled.
+	codec      compression.Codec           // codec compresses new values; nil when compression is disabled.
+	blobs      map[string]storage.BlobID   // blobs maps every key whose value lives in a blob file to that blob.
+	expiries   map[string]int64            // expiries maps every key that expires to its expiry time in Unix nanoseconds.
+	snapshots  int                         // snapshots counts the snapshots that have not been released.
+	doomed     []storage.BlobID            // doomed holds replaced blobs kept until the last snapshot is released.
+	opened     time.Time                   // opened is the time the engine was created, reported as its uptime.
+	recovery   atomic.Int64                // recovery is how long the last index rebuild took, in nanoseconds.
+	mu         sync.RWMutex                // mu orders writes against reads that populate the cache.
+
+	lifetime        context.Context    // lifetime is cancelled by Close to stop background work.
+	stop            context.CancelFunc // stop cancels lifetime.
+	compactMu       sync.Mutex         // compactMu keeps compaction runs from overlapping.
+	compactInterval atomic.Int64       // compactInterval is the time between automatic compaction runs, in nanoseconds.
+	compactReset    chan struct{}      // compactReset wakes the compaction loop when the interval changes.
+	compactDone     chan struct{}      // compactDone is closed when the compaction loop has exited.
+	compactRate     atomic.Uint64      // compactRate is the most bytes a compaction run walks per second; zero is unlimited.
+	syncInterval    atomic.Int64       // syncInterval is the time between background syncs, in nanoseconds; zero disables them.
+	syncReset       chan struct{}      // syncReset wakes the sync loop when the policy changes.
+	syncDone        chan struct{}      // syncDone is closed when the sync loop has exited.
 }
 
 // Config holds all the parameters needed to initialize a new Engine instance.
@@ -55,6 +83,13 @@ type Config struct {
 //   - *Engine: A fully initialized engine ready for use
 //   - error: Any error encountered during initialization, typically from storage setup
 func New(ctx context.Context, config *Config) (*Engine, error) {
+	// Resolve the compression codec before anything is opened, so that a bad codec
+	// name fails fast without side effects.
+	codec, err := resolveCodec(config.Options.CompressionOptions.Codec)
+	if err != nil {
+		return nil, err
+	}
+
 	// Initialize the index subsystem first since it has no external dependencies.
 	index, err := index.New(ctx, &index.Config{
 		Logger:  config.Logger,
@@ -78,17 +113,883 @@ func New(ctx context.Context, config *Config) (*Engine, error) {
 		return nil, err
 	}
 
-	// Create and return the engine with all subsystems properly initialized.
-	// At this point, all dependencies are satisfied and the engine is ready
-	// to handle database operations. The closed flag defaults to false,
-	// indicating the engine is in an active, usable state.
-	return &Engine{
+	// Create the engine with all subsystems properly initialized.
+	// The closed flag defaults to false, indicating the engine is in an
+	// active, usable state.
+	engine := &Engine{
 		index:      index,
 		storage:    storage,
 		compaction: compaction,
+		codec:      codec,
 		log:        config.Logger,
 		options:    config.Options,
-	}, nil
+		opened:     time.Now(),
+	}
+	engine.cache.Store(cache.New(&cache.Config{
+		MaxBytes: config.Options.CacheOptions.Size,
+		Shards:   config.Options.CacheOptions.Shards,
+	}))
+
+	// Rebuild the in-memory index from the segment files so that every key
+	// written before the last shutdown is reachable again.
+	if err := engine.recoverIndex(); err != nil {
+		engine.index.Close()
+		engine.storage.Close()
+		return nil, err
+	}
+
+	engine.lifetime, engine.stop = context.WithCancel(context.Background())
+	engine.compactInterval.Store(int64(config.Options.CompactInterval))
+	engine.compactReset = make(chan struct{}, 1)
+	engine.compactDone = make(chan struct{})
+	engine.compactRate.Store(config.Options.CompactRateLimit)
+	engine.syncReset = make(chan struct{}, 1)
+	engine.syncDone = make(chan struct{})
+
+	if !config.Options.ReadOnly {
+		engine.SetSyncPolicy(config.Options.SyncOptions.Policy, config.Options.SyncOptions.Interval)
+		engine.collectBlobs()
+		go engine.compactLoop()
+		go engine.syncLoop()
+	} else {
+		close(engine.compactDone)
+		close(engine.syncDone)
+	}
+
+	return engine, nil
+}
+
+// Set appends a new version of key to the active segment and points the index at it.
+// Any cached copy of the previous value is invalidated, and any expiry the key had is
+// cleared.
+func (e *Engine) Set(ctx context.Context, key string, value []byte) error {
+	_, err := e.set(key, value, 0, Always)
+	return err
+}
+
+// SetWith stores a new version of key like Set, but only if cond holds, and makes it
+// expire once ttl has passed unless ttl is zero. The condition is checked atomically
+// with the write. It reports whether the value was stored.
+func (e *Engine) SetWith(ctx context.Context, key string, value []byte, ttl time.Duration, cond Condition) (bool, error) {
+	if ttl < 0 {
+		return false, igniteErrors.NewFieldRangeError("ttl", ttl, 0, time.Duration(math.MaxInt64))
+	}
+
+	var expiresAt int64
+	if ttl > 0 {
+		expiresAt = expiryTime(ttl)
+	}
+	return e.set(key, value, expiresAt, cond)
+}
+
+// set stores a new version of key if cond holds, which expires at expiresAt, or never
+// when it is zero.
+func (e *Engine) set(key string, value []byte, expiresAt int64, cond Condition) (bool, error) {
+	if e.closed.Load() {
+		return false, ErrEngineClosed
+	}
+	if e.options.ReadOnly {
+		return false, igniteErrors.NewReadOnlyError("set")
+	}
+
+	if e.isBlobValue(uint64(len(value))) {
+		return e.setBlob(key, bytes.NewReader(value), expiresAt, cond)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if ok, err := e.holdsLocked(key, cond); !ok || err != nil {
+		return false, err
+	}
+
+	entry := e.encodeEntry(key, value)
+	entry.ExpiresAt = expiresAt
+	pos, err := e.storage.Append(entry)
+	if err != nil {
+		return false, err
+	}
+
+	if err := e.putPointer(key, pos, entry.Timestamp, uint32(len(entry.Value))); err != nil {
+		return false, err
+	}
+
+	if c := e.cache.Load(); c != nil {
+		c.Delete(key)
+	}
+
+	e.setExpiry(key, expiresAt)
+	e.replaceBlob(key, nil)
+	return true, nil
+}
+
+// SetReader stores the value read from r under key. Values that reach the blob
+// threshold are streamed into a blob file, so at most the threshold is held in memory
+// no matter how long the value is. Shorter values are stored inline, exactly as Set
+// would store them.
+func (e *Engine) SetReader(ctx context.Context, key string, r io.Reader) error {
+	if e.closed.Load() {
+		return ErrEngineClosed
+	}
+	if e.options.ReadOnly {
+		return igniteErrors.NewReadOnlyError("set")
+	}
+
+	threshold := e.options.BlobOptions.Threshold
+	if threshold == 0 {
+		value, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		return e.Set(ctx, key, value)
+	}
+
+	// Read up to the threshold to learn which side of it the value falls on. If the
+	// reader ends first the value is small and is stored inline.
+	prefix, err := io.ReadAll(io.LimitReader(r, int64(threshold)))
+	if err != nil {
+		return err
+	}
+	if uint64(len(prefix)) < threshold {
+		return e.Set(ctx, key, prefix)
+	}
+
+	_, err = e.setBlob(key, io.MultiReader(bytes.NewReader(prefix), r), 0, Always)
+	return err
+}
+
+// setBlob writes the value read from r to a new blob file and points key at it. The
+// blob is written without holding the engine lock, so a large value does not stall
+// other writers; only appending its reference is serialized. The reference is only
+// appended if cond holds, and expires at expiresAt, or never when it is zero.
+func (e *Engine) setBlob(key string, r io.Reader, expiresAt int64, cond Condition) (bool, error) {
+	ref, err := e.storage.WriteBlob(r)
+	if err != nil {
+		return false, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if ok, err := e.holdsLocked(key, cond); !ok || err != nil {
+		e.removeBlob(ref.ID)
+		return false, err
+	}
+
+	entry := storage.NewBlobEntry([]byte(key), ref.Encode())
+	entry.ExpiresAt = expiresAt
+	pos, err := e.storage.Append(entry)
+	if err != nil {
+		e.removeBlob(ref.ID)
+		return false, err
+	}
+
+	if err := e.putPointer(key, pos, entry.Timestamp, uint32(len(entry.Value))); err != nil {
+		return false, err
+	}
+
+	if c := e.cache.Load(); c != nil {
+		c.Delete(key)
+	}
+
+	e.setExpiry(key, expiresAt)
+	e.replaceBlob(key, &ref.ID)
+	return true, nil
+}
+
+// Get returns the current value of key. Cached values are served from memory;
+// otherwise the entry is read from its segment and offered to the cache.
+func (e *Engine) Get(ctx context.Context, key string) ([]byte, error) {
+	if e.closed.Load() {
+		return nil, ErrEngineClosed
+	}
+	if err := e.checkExpiry(key); err != nil {
+		return nil, err
+	}
+
+	if c := e.cache.Load(); c != nil {
+		if value, ok := c.Get(key); ok {
+			return value, nil
+		}
+	}
+
+	value, blob, err := e.read(key)
+	if err != nil || blob == nil {
+		return value, err
+	}
+	defer blob.Close()
+
+	return readBlob(blob)
+}
+
+// GetTo writes the current value of key to w and returns the number of bytes
+// written. Plaintext values are streamed from the segment file through a pooled
+// buffer with the entry checksum verified on the way, and values stored in blob files
+// are streamed in chunks, so neither is held in memory as a whole. Compressed values
+// are decompressed in memory. A value that fails verification is reported only after
+// the bytes read before the damage was detected have been written, so callers must
+// discard the output of a failed call. Values streamed through GetTo are not cached.
+func (e *Engine) GetTo(ctx context.Context, key string, w io.Writer) (int64, error) {
+	if e.closed.Load() {
+		return 0, ErrEngineClosed
+	}
+	if err := e.checkExpiry(key); err != nil {
+		return 0, err
+	}
+
+	if c := e.cache.Load(); c != nil {
+		if value, ok := c.Get(key); ok {
+			n, err := w.Write(value)
+			return int64(n), err
+		}
+	}
+
+	// Inline values are streamed while the read lock is held; they are bounded by the
+	// blob threshold. A blob is only opened under the lock, which keeps its handle
+	// readable even if the key is overwritten while the blob is streamed.
+	e.mu.RLock()
+
+	rp, err := e.index.Get(key)
+	if err != nil {
+		e.mu.RUnlock()
+		return 0, err
+	}
+
+	segmentID, err := e.index.ResolveSegment(rp.SegmentID, key)
+	if err != nil {
+		e.mu.RUnlock()
+		return 0, err
+	}
+
+	written, blob, err := e.streamValue(segmentID, rp, w)
+	e.mu.RUnlock()
+
+	if err != nil || blob == nil {
+		return written, err
+	}
+	defer blob.Close()
+
+	return storage.CopyBuffered(w, blob)
+}
+
+// streamValue writes the value of the entry rp points at to w. Plaintext values are
+// streamed straight from the segment; compressed values are decoded in memory. For a
+// blob entry nothing is written and the opened blob is returned instead, so that the
+// caller can stream it after releasing any locks. The caller must close it.
+func (e *Engine) streamValue(segmentID uint64, rp *index.RecordPointer, w io.Writer) (int64, *storage.BlobReader, error) {
+	var written int64
+	var blob *storage.BlobReader
+
+	err := e.storage.StreamEntry(segmentID, rp.Offset, rp.EntrySize, func(entry *storage.Entry, value io.Reader) error {
+		if !entry.IsBlob() && entry.Codec() == compression.None {
+			var err error
+			written, err = storage.CopyBuffered(w, value)
+			return err
+		}
+
+		// Blob references and compressed values are small enough to decode in memory.
+		raw, err := io.ReadAll(value)
+		if err != nil {
+			return err
+		}
+		entry.Value = raw
+
+		if entry.IsBlob() {
+			blob, err = e.openBlob(entry, segmentID, rp.Offset)
+			return err
+		}
+
+		decoded, err := decodeValue(entry, segmentID, rp.Offset)
+		if err != nil {
+			return err
+		}
+		n, err := w.Write(decoded)
+		written = int64(n)
+		return err
+	})
+	return written, blob, err
+}
+
+// SetFrom stores exactly size bytes read from r under key. Values that reach the blob
+// threshold are streamed into a blob file; smaller plaintext values are staged by the
+// storage layer and then copied into the active segment. Values that will be
+// compressed or encrypted are read into memory first, since both transform the value
+// as a whole. r is always consumed before any lock is taken, so a slow client never
+// stalls other writers. If r fails or ends before size bytes, nothing is stored.
+func (e *Engine) SetFrom(ctx context.Context, key string, r io.Reader, size int64) error {
+	_, err := e.SetFromWith(ctx, key, r, size, 0, Always)
+	return err
+}
+
+// SetFromWith stores a value read from r like SetFrom, but only if cond holds, and
+// makes it expire once ttl has passed unless ttl is zero. r is read in full before
+// the condition is checked, atomically with the write; if it does not hold, the value
+// is discarded. It reports whether the value was stored.
+func (e *Engine) SetFromWith(ctx context.Context, key string, r io.Reader, size int64, ttl time.Duration, cond Condition) (bool, error) {
+	if e.closed.Load() {
+		return false, ErrEngineClosed
+	}
+	if e.options.ReadOnly {
+		return false, igniteErrors.NewReadOnlyError("set")
+	}
+	if size < 0 {
+		return false, igniteErrors.NewFieldRangeError("size", size, 0, math.MaxInt64)
+	}
+	if ttl < 0 {
+		return false, igniteErrors.NewFieldRangeError("ttl", ttl, 0, time.Duration(math.MaxInt64))
+	}
+
+	var expiresAt int64
+	if ttl > 0 {
+		expiresAt = expiryTime(ttl)
+	}
+	value := &exactReader{r: r, remaining: size}
+
+	if e.isBlobValue(uint64(size)) {
+		return e.setBlob(key, value, expiresAt, cond)
+	}
+	if size > math.MaxUint32 {
+		return false, igniteErrors.NewFieldRangeError("size", size, 0, uint64(math.MaxUint32)).
+			WithDetail("suggestion", "enable blob storage for values larger than 4GB")
+	}
+
+	if (e.codec != nil && size >= int64(e.options.CompressionOptions.MinSize)) || e.options.KeyProvider != nil {
+		buf := make([]byte, size)
+		if _, err := io.ReadFull(value, buf); err != nil {
+			return false, err
+		}
+		return e.set(key, buf, expiresAt, cond)
+	}
+
+	staged, err := e.storage.StageEntry([]byte(key), value, uint32(size), expiresAt)
+	if err != nil {
+		return false, err
+	}
+	defer staged.Close()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if ok, err := e.holdsLocked(key, cond); !ok || err != nil {
+		return false, err
+	}
+
+	pos, err := e.storage.AppendStaged(staged)
+	if err != nil {
+		return false, err
+	}
+
+	if err := e.putPointer(key, pos, staged.Timestamp(), uint32(size)); err != nil {
+		return false, err
+	}
+
+	if c := e.cache.Load(); c != nil {
+		c.Delete(key)
+	}
+
+	e.setExpiry(key, expiresAt)
+	e.replaceBlob(key, nil)
+	return true, nil
+}
+
+// read looks up the current value of key. Inline values are returned and offered to
+// the cache; values stored in blob files are returned as an open reader instead, which
+// the caller must close. Blob values are never cached, since they are by definition
+// too large to be worth the memory.
+func (e *Engine) read(key string) ([]byte, *storage.BlobReader, error) {
+	// Hold the read lock across lookup, read and cache fill so that a concurrent
+	// Set cannot invalidate the key between our read and the cache insertion,
+	// which would leave a stale value behind. A blob is opened under the lock too,
+	// so the open handle stays readable even if the blob is replaced afterwards.
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	rp, err := e.index.Get(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	segmentID, err := e.index.ResolveSegment(rp.SegmentID, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entry, err := e.storage.ReadEntry(segmentID, rp.Offset, rp.EntrySize)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if entry.IsBlob() {
+		blob, err := e.openBlob(entry, segmentID, rp.Offset)
+		return nil, blob, err
+	}
+
+	value, err := decodeValue(entry, segmentID, rp.Offset)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if c := e.cache.Load(); c != nil {
+		c.Set(key, value)
+	}
+
+	return value, nil, nil
+}
+
+// GetView passes the current value of key to fn without copying it when the value
+// lives in a memory-mapped sealed segment. The slice handed to fn is read-only and
+// valid only until fn returns. Values read through GetView are not added to the
+// cache, since doing so would require the copy this API exists to avoid. Compressed
+// values and values stored in blob files are read into a fresh buffer, so they are
+// never zero-copy.
+//
+// For inline values fn runs while the engine's read lock is held, like the inline
+// path of GetTo, so it must not write to the engine.
+func (e *Engine) GetView(ctx context.Context, key string, fn func(value []byte) error) error {
+	if e.closed.Load() {
+		return ErrEngineClosed
+	}
+	if err := e.checkExpiry(key); err != nil {
+		return err
+	}
+
+	if c := e.cache.Load(); c != nil {
+		if value, ok := c.Get(key); ok {
+			return fn(value)
+		}
+	}
+
+	// The lookup, the segment read and a blob open happen under the read lock, so that
+	// a concurrent overwrite or compaction cannot release the segment or blob in between.
+	e.mu.RLock()
+
+	rp, err := e.index.Get(key)
+	if err != nil {
+		e.mu.RUnlock()
+		return err
+	}
+
+	segmentID, err := e.index.ResolveSegment(rp.SegmentID, key)
+	if err != nil {
+		e.mu.RUnlock()
+		return err
+	}
+
+	var blob *storage.BlobReader
+	err = e.storage.View(segmentID, rp.Offset, rp.EntrySize, func(entry *storage.Entry) error {
+		if entry.IsBlob() {
+			var err error
+			blob, err = e.openBlob(entry, segmentID, rp.Offset)
+			return err
+		}
+
+		if entry.Codec() == compression.None {
+			return fn(entry.Value)
+		}
+
+		value, err := decodeValue(entry, segmentID, rp.Offset)
+		if err != nil {
+			return err
+		}
+		return fn(value)
+	})
+	e.mu.RUnlock()
+
+	if err != nil || blob == nil {
+		return err
+	}
+	defer blob.Close()
+
+	// Blob values do not live in a segment, so they are read into a fresh buffer.
+	value, err := readBlob(blob)
+	if err != nil {
+		return err
+	}
+	return fn(value)
+}
+
+// Delete appends a tombstone for key and removes it from the index and cache.
+// Deleting a key that does not exist is a no-op.
+func (e *Engine) Delete(ctx context.Context, key string) error {
+	_, err := e.Remove(ctx, key)
+	return err
+}
+
+// Remove deletes key like Delete and reports whether it existed. A key that has
+// expired counts as absent; it is reclaimed without writing a tombstone, since its
+// entry is dropped whenever it is replayed anyway.
+func (e *Engine) Remove(ctx context.Context, key string) (bool, error) {
+	if e.closed.Load() {
+		return false, ErrEngineClosed
+	}
+	if e.options.ReadOnly {
+		return false, igniteErrors.NewReadOnlyError("delete")
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.expiredLocked(key, time.Now().UnixNano()) {
+		e.reclaimLocked(key)
+		return false, nil
+	}
+
+	if _, err := e.index.Get(key); err != nil {
+		if igniteErrors.GetErrorCode(err) == igniteErrors.ErrorCodeIndexKeyNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if _, err := e.storage.Append(storage.NewEntry([]byte(key), nil, storage.FlagTombstone)); err != nil {
+		return false, err
+	}
+
+	if err := e.index.Delete(key); err != nil {
+		return false, err
+	}
+
+	if c := e.cache.Load(); c != nil {
+		c.Delete(key)
+	}
+
+	e.setExpiry(key, 0)
+	e.replaceBlob(key, nil)
+	return true, nil
+}
+
+// CacheStats returns a snapshot of the value cache counters. When the cache is
+// disabled the returned statistics are all zero.
+func (e *Engine) CacheStats() cache.Stats {
+	c := e.cache.Load()
+	if c == nil {
+		return cache.Stats{}
+	}
+	return c.Stats()
+}
+
+// SetCacheSize replaces the value cache with an empty one holding up to size bytes,
+// or disables it when size is zero. The counters reported by CacheStats start over.
+//
+// The swap happens under the write lock, where no read can be filling the cache, and
+// the old cache is cleared before the lock is released. A Get that loaded the old
+// cache just before the swap therefore misses instead of returning a value that a
+// later write only invalidated in the new cache.
+func (e *Engine) SetCacheSize(size uint64) {
+	next := cache.New(&cache.Config{MaxBytes: size, Shards: e.options.CacheOptions.Shards})
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if old := e.cache.Swap(next); old != nil {
+		old.Clear()
+	}
+}
+
+// FileCacheStats returns a snapshot of the segment file handle cache counters.
+func (e *Engine) FileCacheStats() storage.FileCacheStats {
+	return e.storage.FileCacheStats()
+}
+
+// recoverIndex replays every entry in the segment files, in write order, into the
+// index. Later entries overwrite earlier ones and tombstones remove keys, so the
+// index ends up pointing at the latest live version of every key. The blob files
+// those keys refer to are tracked along the way.
+func (e *Engine) recoverIndex() error {
+	start := time.Now()
+	e.blobs = make(map[string]storage.BlobID)
+	e.expiries = make(map[string]int64)
+
+	if err := e.storage.Scan(e.replayEntry); err != nil {
+		return igniteErrors.NewIndexError(
+			err, igniteErrors.ErrorCodeIndexRecoveryFailed, "Failed to rebuild index from segment files",
+		).WithOperation("Recovery").WithIndexSize(e.index.Len())
+	}
+
+	duration := time.Since(start)
+	e.recovery.Store(int64(duration))
+
+	e.log.Infow(
+		"Index recovered from segment files",
+		"keys", e.index.Len(),
+		"duration", duration,
+	)
+
+	return nil
+}
+
+// Refresh brings a read-only engine up to date with the writer process that owns the
+// data directory. Entries the writer has appended since open or since the previous
+// Refresh are replayed into the index, and their cached values are invalidated. If the
+// writer removed segments in the meantime, the index is rebuilt from scratch.
+//
+// Refresh is a no-op for a writable engine, whose index is always current.
+func (e *Engine) Refresh(ctx context.Context) error {
+	if e.closed.Load() {
+		return ErrEngineClosed
+	}
+	if !e.options.ReadOnly {
+		return nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	start := time.Now()
+	keys := e.index.Len()
+
+	removed, err := e.storage.Refresh(e.replayEntry)
+	if err != nil {
+		return igniteErrors.NewIndexError(
+			err, igniteErrors.ErrorCodeIndexRecoveryFailed, "Failed to refresh index from segment files",
+		).WithOperation("Refresh").WithIndexSize(e.index.Len())
+	}
+
+	if len(removed) > 0 {
+		e.log.Infow("Segments were removed by the writer, rebuilding index", "removedSegments", removed)
+
+		if err := e.index.Clear(); err != nil {
+			return err
+		}
+		for _, segmentID := range removed {
+			e.index.ReleaseSegment(segmentID)
+		}
+		if c := e.cache.Load(); c != nil {
+			c.Clear()
+		}
+
+		return e.recoverIndex()
+	}
+
+	e.log.Debugw(
+		"Index refreshed from segment files",
+		"keys", e.index.Len(),
+		"delta", e.index.Len()-keys,
+		"duration", time.Since(start),
+	)
+
+	return nil
+}
+
+// replayEntry applies a single entry read back from a segment to the index and drops
+// any cached value for its key.
+func (e *Engine) replayEntry(pos *storage.Position, entry *storage.Entry) error {
+	key := string(entry.Key)
+	if c := e.cache.Load(); c != nil {
+		c.Delete(key)
+	}
+
+	// Entries that have expired by now are dropped like tombstones, so the keys they
+	// set take no memory.
+	if entry.IsTombstone() || (entry.ExpiresAt != 0 && entry.ExpiresAt <= time.Now().UnixNano()) {
+		delete(e.blobs, key)
+		delete(e.expiries, key)
+		return e.index.Delete(key)
+	}
+	e.setExpiry(key, entry.ExpiresAt)
+
+	if entry.IsBlob() {
+		ref, err := storage.DecodeBlobRef(entry.Value)
+		if err != nil {
+			return igniteErrors.NewSegmentCorruptionError(int(pos.SegmentID), int(pos.Offset), err).
+				WithDetail("corruption_type", "invalid_blob_reference")
+		}
+		e.blobs[key] = ref.ID
+	} else {
+		delete(e.blobs, key)
+	}
+
+	return e.putPointer(key, pos, entry.Timestamp, pos.ValueSize)
+}
+
+// putPointer points key at the entry written at pos.
+func (e *Engine) putPointer(key string, pos *storage.Position, timestamp int64, valueSize uint32) error {
+	slot, err := e.index.AssignSegment(pos.SegmentID)
+	if err != nil {
+		return err
+	}
+
+	return e.index.Put(&index.RecordPointer{
+		Key:       key,
+		Offset:    pos.Offset,
+		EntrySize: pos.Size,
+		Timestamp: timestamp,
+		SegmentID: slot,
+		ValueSize: valueSize,
+	})
+}
+
+// isBlobValue reports whether a value of the given size is stored in a blob file.
+func (e *Engine) isBlobValue(size uint64) bool {
+	threshold := e.options.BlobOptions.Threshold
+	return threshold > 0 && size >= threshold
+}
+
+// openBlob opens the blob a FlagBlob entry refers to.
+func (e *Engine) openBlob(entry *storage.Entry, segmentID uint64, offset int64) (*storage.BlobReader, error) {
+	ref, err := storage.DecodeBlobRef(entry.Value)
+	if err != nil {
+		return nil, igniteErrors.NewSegmentCorruptionError(int(segmentID), int(offset), err).
+			WithDetail("corruption_type", "invalid_blob_reference")
+	}
+	return e.storage.OpenBlob(ref)
+}
+
+// replaceBlob records that key now refers to the blob with the given ID, or to no blob
+// when id is nil, and removes the blob it referred to before. The new reference is
+// synced first: removing the old blob while the entry that supersedes it could still
+// be lost in a crash would leave the key pointing at a missing file. Failures are
+// logged rather than returned, since the write itself has succeeded and a leftover
+// blob is collected at the next open. The caller must hold e.mu.
+func (e *Engine) replaceBlob(key string, id *storage.BlobID) {
+	old, ok := e.blobs[key]
+	if id != nil {
+		e.blobs[key] = *id
+	} else {
+		delete(e.blobs, key)
+	}
+
+	if !ok || (id != nil && *id == old) {
+		return
+	}
+
+	// A snapshot may still read the old value.
+	if e.snapshots > 0 {
+		e.doomed = append(e.doomed, old)
+		return
+	}
+
+	if err := e.storage.Sync(); err != nil {
+		e.log.Warnw("Failed to sync segment, keeping replaced blob until next open", "blob", old, "error", err)
+		return
+	}
+	e.removeBlob(old)
+}
+
+// removeBlob deletes a blob file that is no longer referenced.
+func (e *Engine) removeBlob(id storage.BlobID) {
+	if err := e.storage.RemoveBlob(id); err != nil {
+		e.log.Warnw("Failed to remove unreferenced blob", "blob", id, "error", err)
+	}
+}
+
+// collectBlobs removes blob files that no live key refers to. They are left behind
+// when the process stops between superseding a blob and deleting it.
+func (e *Engine) collectBlobs() {
+	ids, err := e.storage.BlobIDs()
+	if err != nil {
+		e.log.Warnw("Failed to list blob files", "error", err)
+		return
+	}
+
+	live := make(map[storage.BlobID]struct{}, len(e.blobs))
+	for _, id := range e.blobs {
+		live[id] = struct{}{}
+	}
+
+	removed := 0
+	for _, id := range ids {
+		if _, ok := live[id]; ok {
+			continue
+		}
+		e.removeBlob(id)
+		removed++
+	}
+
+	if removed > 0 {
+		e.log.Infow("Removed unreferenced blob files", "count", removed, "liveBlobs", len(live))
+	}
+}
+
+// readBlob reads a whole blob value into memory, verifying it against its reference.
+func readBlob(blob *storage.BlobReader) ([]byte, error) {
+	value := bytes.NewBuffer(make([]byte, 0, blob.Size()))
+	if _, err := value.ReadFrom(blob); err != nil {
+		return nil, err
+	}
+	return value.Bytes(), nil
+}
+
+// encodeEntry builds the entry written for a Set, compressing the value when a codec
+// is configured and the value is large enough. Values that do not shrink are stored
+// as-is, since paying for decompression on every read would gain nothing.
+func (e *Engine) encodeEntry(key string, value []byte) *storage.Entry {
+	if e.codec == nil || len(value) < int(e.options.CompressionOptions.MinSize) {
+		return storage.NewEntry([]byte(key), value, 0)
+	}
+
+	compressed, err := e.codec.Compress(value)
+	if err != nil {
+		e.log.Warnw("Failed to compress value, storing it uncompressed", "codec", e.codec.Name(), "error", err)
+		return storage.NewEntry([]byte(key), value, 0)
+	}
+
+	if len(compressed) >= len(value) {
+		return storage.NewEntry([]byte(key), value, 0)
+	}
+
+	return storage.NewCompressedEntry([]byte(key), compressed, e.codec.ID())
+}
+
+// decodeValue returns the original value of an entry, decompressing it with the codec
+// recorded in its header. Uncompressed values are returned as-is.
+func decodeValue(entry *storage.Entry, segmentID uint64, offset int64) ([]byte, error) {
+	codec := entry.Codec()
+	if codec == compression.None {
+		return entry.Value, nil
+	}
+
+	value, err := compression.Decompress(codec, entry.Value)
+	if err != nil {
+		return nil, igniteErrors.NewSegmentCorruptionError(int(segmentID), int(offset), err).
+			WithDetail("corruption_type", "undecodable_value").
+			WithDetail("codec", codec)
+	}
+	return value, nil
+}
+
+// resolveCodec returns the codec configured for new values, or nil when compression
+// is disabled.
+func resolveCodec(name options.Compression) (compression.Codec, error) {
+	if name == "" || name == options.CompressionNone {
+		return nil, nil
+	}
+
+	codec, ok := compression.LookupName(string(name))
+	if !ok {
+		return nil, igniteErrors.NewConfigurationValidationError(
+			"compressionOptions.codec", "unknown compression codec",
+		).WithProvided(name)
+	}
+	return codec, nil
+}
+
+// exactReader reads exactly remaining bytes from r, reporting io.ErrUnexpectedEOF if
+// r ends early. It lets a blob write of a known size fail on a short reader instead
+// of storing a truncated value.
+type exactReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+// Read implements io.Reader.
+func (x *exactReader) Read(p []byte) (int, error) {
+	if x.remaining == 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > x.remaining {
+		p = p[:x.remaining]
+	}
+
+	n, err := x.r.Read(p)
+	x.remaining -= int64(n)
+	if err == io.EOF && x.remaining > 0 {
+		return n, io.ErrUnexpectedEOF
+	}
+	if err == io.EOF {
+		err = nil
+	}
+	return n, err
 }
 
 // Close gracefully shuts down the engine and releases all associated resources.
@@ -99,6 +1000,14 @@ func (e *Engine) Close() error {
 		return ErrEngineClosed
 	}
 
+	// Stop background compaction and syncing, and wait for a run in progress to
+	// notice.
+	e.stop()
+	<-e.compactDone
+	<-e.syncDone
+	e.compactMu.Lock()
+	defer e.compactMu.Unlock()
+
 	if err := e.index.Close(); err != nil {
 		return err
 	}
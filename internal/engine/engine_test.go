@@ -0,0 +1,243 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	igniteErrors "github.com/iamNilotpal/ignite/pkg/errors"
+	"github.com/iamNilotpal/ignite/pkg/options"
+	"go.uber.org/zap"
+)
+
+// testOptions returns options for a store in dir with segments small enough that a
+// test rolls over several of them, blobs from 1KB and no automatic compaction.
+func testOptions(dir string) *options.Options {
+	opts := options.NewDefaultOptions()
+	opts.DataDir = dir
+	opts.SegmentOptions.Size = 4096
+	opts.BlobOptions.Threshold = 1024
+	opts.CompactInterval = 0
+	return &opts
+}
+
+// openEngine opens an engine with opts and closes it when the test ends.
+func openEngine(t *testing.T, opts *options.Options) *Engine {
+	t.Helper()
+
+	e, err := New(context.Background(), &Config{Options: opts, Logger: zap.NewNop().Sugar()})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { e.Close() })
+	return e
+}
+
+// closeEngine closes e, failing the test on error.
+func closeEngine(t *testing.T, e *Engine) {
+	t.Helper()
+	if err := e.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+}
+
+// writeKeyFile writes a key file for options.FileKeyProvider with a fresh random
+// 256-bit key under each of the given IDs, the last one being current.
+func writeKeyFile(t *testing.T, path string, ids ...string) {
+	t.Helper()
+
+	var buf strings.Builder
+	for _, id := range ids {
+		key := make([]byte, 32)
+		rand.Read(key)
+		fmt.Fprintf(&buf, "%s:%s\n", id, hex.EncodeToString(key))
+	}
+	if err := os.WriteFile(path, []byte(buf.String()), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// appendKey adds a fresh key under id to the key file at path, making it current.
+func appendKey(t *testing.T, path, id string) {
+	t.Helper()
+
+	existing, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := make([]byte, 32)
+	rand.Read(key)
+	line := fmt.Sprintf("%s:%s\n", id, hex.EncodeToString(key))
+	if err := os.WriteFile(path, append(existing, line...), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// value returns a deterministic value of the given size for key and version n.
+func value(key string, n, size int) []byte {
+	return bytes.Repeat([]byte(fmt.Sprintf("%s=%d;", key, n)), size/len(key)+1)[:size]
+}
+
+// mustSet stores value under key.
+func mustSet(t *testing.T, e *Engine, key string, value []byte) {
+	t.Helper()
+	if err := e.Set(context.Background(), key, value); err != nil {
+		t.Fatalf("Set(%q) error = %v", key, err)
+	}
+}
+
+// expectValue fails the test unless key holds want.
+func expectValue(t *testing.T, e *Engine, key string, want []byte) {
+	t.Helper()
+
+	got, err := e.Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Get(%q) error = %v", key, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("Get(%q) = %q, want %q", key, truncate(got), truncate(want))
+	}
+}
+
+// expectMissing fails the test unless key does not exist.
+func expectMissing(t *testing.T, e *Engine, key string) {
+	t.Helper()
+
+	_, err := e.Get(context.Background(), key)
+	if igniteErrors.GetErrorCode(err) != igniteErrors.ErrorCodeIndexKeyNotFound {
+		t.Fatalf("Get(%q) error = %v, want key not found", key, err)
+	}
+}
+
+// expectCode fails the test unless err carries the given error code.
+func expectCode(t *testing.T, err error, code igniteErrors.ErrorCode) {
+	t.Helper()
+	if got := igniteErrors.GetErrorCode(err); got != code {
+		t.Fatalf("error = %v (code %q), want code %q", err, got, code)
+	}
+}
+
+// segmentFiles returns the paths of every segment file in the store at opts.
+func segmentFiles(t *testing.T, opts *options.Options) []string {
+	t.Helper()
+
+	pattern := filepath.Join(opts.DataDir, opts.SegmentOptions.Directory, opts.SegmentOptions.Prefix+"*.seg")
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+// truncate shortens long values in failure messages.
+func truncate(b []byte) []byte {
+	if len(b) > 64 {
+		return append(b[:64:64], "..."...)
+	}
+	return b
+}
+
+func TestReopenRestoresEveryKey(t *testing.T) {
+	opts := testOptions(t.TempDir())
+	e := openEngine(t, opts)
+
+	// Enough writes to span several segments, including overwrites, deletes and blobs.
+	for i := range 200 {
+		mustSet(t, e, fmt.Sprintf("key-%03d", i%50), value("key", i, 100))
+	}
+	mustSet(t, e, "blob", value("blob", 0, 4096))
+	if err := e.Delete(context.Background(), "key-007"); err != nil {
+		t.Fatal(err)
+	}
+	closeEngine(t, e)
+
+	if n := len(segmentFiles(t, opts)); n < 3 {
+		t.Fatalf("wrote %d segments, want several", n)
+	}
+
+	e = openEngine(t, opts)
+	for i := 150; i < 200; i++ {
+		key := fmt.Sprintf("key-%03d", i%50)
+		if key == "key-007" {
+			expectMissing(t, e, key)
+			continue
+		}
+		expectValue(t, e, key, value("key", i, 100))
+	}
+	expectValue(t, e, "blob", value("blob", 0, 4096))
+}
+
+func TestClosedEngineRejectsCalls(t *testing.T) {
+	e := openEngine(t, testOptions(t.TempDir()))
+	closeEngine(t, e)
+
+	if _, err := e.Get(context.Background(), "k"); !errors.Is(err, ErrEngineClosed) {
+		t.Fatalf("Get() error = %v, want ErrEngineClosed", err)
+	}
+	if _, err := e.Compact(context.Background()); !errors.Is(err, ErrEngineClosed) {
+		t.Fatalf("Compact() error = %v, want ErrEngineClosed", err)
+	}
+}
+
+func TestGetViewDuringOverwritesAndCompaction(t *testing.T) {
+	opts := testOptions(t.TempDir())
+	opts.ReadOptions.Mode = options.ReadModeMmap
+	e := openEngine(t, opts)
+
+	keys := []string{"a", "b", "c", "d"}
+	for _, key := range keys {
+		mustSet(t, e, key, value(key, 0, 200))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	writerDone := make(chan error)
+	go func() {
+		defer close(writerDone)
+		for i := 1; ctx.Err() == nil; i++ {
+			for _, key := range keys {
+				if err := e.Set(ctx, key, value(key, i, 200)); err != nil && ctx.Err() == nil {
+					writerDone <- err
+					return
+				}
+			}
+			if i%50 == 0 {
+				if _, err := e.Compact(ctx); err != nil && ctx.Err() == nil {
+					writerDone <- err
+					return
+				}
+			}
+		}
+	}()
+
+	for range 2000 {
+		for _, key := range keys {
+			err := e.GetView(context.Background(), key, func(got []byte) error {
+				// Whatever version is seen must be intact, never bytes of a reused or
+				// unmapped segment.
+				version, _, _ := strings.Cut(strings.TrimPrefix(string(got), key+"="), ";")
+				var n int
+				if _, err := fmt.Sscan(version, &n); err != nil || !bytes.Equal(got, value(key, n, 200)) {
+					return fmt.Errorf("GetView(%q) saw %q", key, truncate(got))
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	cancel()
+	if err := <-writerDone; err != nil {
+		t.Fatal(err)
+	}
+}
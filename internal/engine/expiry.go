@@ -0,0 +1,219 @@
+package engine
+
+import (
+	"context"
+	"math"
+	"time"
+
+	igniteErrors "github.com/iamNilotpal/ignite/pkg/errors"
+)
+
+// Condition restricts a write made with SetWith to keys that do or do not exist.
+type Condition uint8
+
+const (
+	// Always writes the key whether it exists or not.
+	Always Condition = iota
+
+	// IfAbsent only writes the key if it does not exist.
+	IfAbsent
+
+	// IfExists only writes the key if it already exists.
+	IfExists
+)
+
+// holdsLocked reports whether cond holds for key. Expired keys count as absent. The
+// caller must hold the write lock.
+func (e *Engine) holdsLocked(key string, cond Condition) (bool, error) {
+	if cond == Always {
+		return true, nil
+	}
+
+	exists := true
+	if e.expiredLocked(key, time.Now().UnixNano()) {
+		exists = false
+	} else if _, err := e.index.Get(key); err != nil {
+		if igniteErrors.GetErrorCode(err) != igniteErrors.ErrorCodeIndexKeyNotFound {
+			return false, err
+		}
+		exists = false
+	}
+
+	return exists == (cond == IfExists), nil
+}
+
+// Expire makes key expire once ttl has passed, replacing any expiry it had. A ttl of
+// zero or less deletes the key right away. The current value is appended again with
+// the new expiry time; blob values keep their blob file, so no value is copied.
+func (e *Engine) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	if e.closed.Load() {
+		return ErrEngineClosed
+	}
+	if e.options.ReadOnly {
+		return igniteErrors.NewReadOnlyError("expire")
+	}
+	if ttl <= 0 {
+		removed, err := e.Remove(ctx, key)
+		if err == nil && !removed {
+			err = igniteErrors.NewKeyNotFoundError(key)
+		}
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.expiredLocked(key, time.Now().UnixNano()) {
+		e.reclaimLocked(key)
+		return igniteErrors.NewKeyNotFoundError(key)
+	}
+
+	rp, err := e.index.Get(key)
+	if err != nil {
+		return err
+	}
+
+	segmentID, err := e.index.ResolveSegment(rp.SegmentID, key)
+	if err != nil {
+		return err
+	}
+
+	current, err := e.storage.ReadEntry(segmentID, rp.Offset, rp.EntrySize)
+	if err != nil {
+		return err
+	}
+
+	// The value is written exactly as stored, so compressed values and blob references
+	// carry over unchanged.
+	entry := current.Restamped(expiryTime(ttl))
+
+	pos, err := e.storage.Append(entry)
+	if err != nil {
+		return err
+	}
+	if err := e.putPointer(key, pos, entry.Timestamp, uint32(len(entry.Value))); err != nil {
+		return err
+	}
+
+	e.setExpiry(key, entry.ExpiresAt)
+	return nil
+}
+
+// TTL returns how long key has left before it expires, or zero if it never expires.
+// It returns a key-not-found error if the key does not exist or has expired.
+func (e *Engine) TTL(ctx context.Context, key string) (time.Duration, error) {
+	if e.closed.Load() {
+		return 0, ErrEngineClosed
+	}
+	if err := e.checkExpiry(key); err != nil {
+		return 0, err
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if _, err := e.index.Get(key); err != nil {
+		return 0, err
+	}
+
+	expiresAt, ok := e.expiries[key]
+	if !ok {
+		return 0, nil
+	}
+
+	// A key that expires within the next nanosecond still reports a TTL, so that zero
+	// unambiguously means no expiry.
+	return max(time.Duration(expiresAt-time.Now().UnixNano()), 1), nil
+}
+
+// Exists reports whether key exists, without reading its value.
+func (e *Engine) Exists(ctx context.Context, key string) (bool, error) {
+	if e.closed.Load() {
+		return false, ErrEngineClosed
+	}
+	if err := e.checkExpiry(key); err != nil {
+		if igniteErrors.GetErrorCode(err) == igniteErrors.ErrorCodeIndexKeyNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if _, err := e.index.Get(key); err != nil {
+		if igniteErrors.GetErrorCode(err) == igniteErrors.ErrorCodeIndexKeyNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// checkExpiry returns a key-not-found error if key has expired, and reclaims the
+// memory it still takes in the index. Expired keys are only reclaimed when they are
+// accessed or the store is reopened; until then they are invisible to reads.
+func (e *Engine) checkExpiry(key string) error {
+	e.mu.RLock()
+	expired := e.expiredLocked(key, time.Now().UnixNano())
+	e.mu.RUnlock()
+	if !expired {
+		return nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	// The key may have been written again in the meantime.
+	if e.expiredLocked(key, time.Now().UnixNano()) {
+		e.reclaimLocked(key)
+	}
+	return igniteErrors.NewKeyNotFoundError(key)
+}
+
+// expiredLocked reports whether key has an expiry time at or before now. The caller
+// must hold e.mu.
+func (e *Engine) expiredLocked(key string, now int64) bool {
+	expiresAt, ok := e.expiries[key]
+	return ok && expiresAt <= now
+}
+
+// reclaimLocked drops an expired key from the index, the cache and the blob map. No
+// tombstone is written here, since replay skips the expired entry as long as it is on
+// disk; compaction writes one in its place when it drops the entry while an older
+// segment, which may hold a previous value of the key, survives the run.
+// The caller must hold the write lock.
+func (e *Engine) reclaimLocked(key string) {
+	if err := e.index.Delete(key); err != nil {
+		e.log.Warnw("Failed to drop expired key from index", "key", key, "error", err)
+		return
+	}
+	if c := e.cache.Load(); c != nil {
+		c.Delete(key)
+	}
+	delete(e.expiries, key)
+
+	// A read-only engine must leave the writer's blob files alone.
+	if e.options.ReadOnly {
+		delete(e.blobs, key)
+		return
+	}
+	e.replaceBlob(key, nil)
+}
+
+// setExpiry records when key expires, or that it never does when expiresAt is zero.
+// The caller must hold the write lock.
+func (e *Engine) setExpiry(key string, expiresAt int64) {
+	if expiresAt == 0 {
+		delete(e.expiries, key)
+		return
+	}
+	e.expiries[key] = expiresAt
+}
+
+// expiryTime returns the time, in Unix nanoseconds, at which a key written now with
+// the given ttl expires. Times too far ahead to represent are clamped.
+func expiryTime(ttl time.Duration) int64 {
+	now := time.Now().UnixNano()
+	if int64(ttl) > math.MaxInt64-now {
+		return math.MaxInt64
+	}
+	return now + int64(ttl)
+}
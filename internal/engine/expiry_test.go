@@ -0,0 +1,65 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/iamNilotpal/ignite/pkg/options"
+)
+
+func TestExpireRewritesEntry(t *testing.T) {
+	for _, mode := range []options.ReadMode{options.ReadModeStandard, options.ReadModeMmap} {
+		t.Run(string(mode), func(t *testing.T) {
+			opts := testOptions(t.TempDir())
+			opts.ReadOptions.Mode = mode
+			e := openEngine(t, opts)
+
+			if _, err := e.SetWith(context.Background(), "ttl", value("ttl", 0, 100), time.Hour, Always); err != nil {
+				t.Fatal(err)
+			}
+			mustSet(t, e, "blob", value("blob", 0, 4096))
+			// Push both into a sealed segment, which mmap mode reads through its mapping.
+			for i := range 40 {
+				mustSet(t, e, fmt.Sprintf("fill-%02d", i), value("fill", i, 100))
+			}
+
+			rp, err := e.index.Get("ttl")
+			if err != nil {
+				t.Fatal(err)
+			}
+			segmentID, err := e.index.ResolveSegment(rp.SegmentID, "ttl")
+			if err != nil {
+				t.Fatal(err)
+			}
+			entry, err := e.storage.ReadEntry(segmentID, rp.Offset, rp.EntrySize)
+			if err != nil {
+				t.Fatalf("ReadEntry() error = %v", err)
+			}
+			if entry.ExpiresAt != e.expiries["ttl"] {
+				t.Fatalf("ReadEntry() expiry = %d, want %d", entry.ExpiresAt, e.expiries["ttl"])
+			}
+
+			if err := e.Expire(context.Background(), "ttl", 2*time.Hour); err != nil {
+				t.Fatalf("Expire() error = %v", err)
+			}
+			if err := e.Expire(context.Background(), "blob", time.Hour); err != nil {
+				t.Fatalf("Expire() error = %v", err)
+			}
+			closeEngine(t, e)
+
+			e = openEngine(t, opts)
+			expectValue(t, e, "ttl", value("ttl", 0, 100))
+			expectValue(t, e, "blob", value("blob", 0, 4096))
+
+			ttl, err := e.TTL(context.Background(), "ttl")
+			if err != nil || ttl <= time.Hour || ttl > 2*time.Hour {
+				t.Fatalf("TTL() after Expire() and reopening = %v, %v; want just under 2h", ttl, err)
+			}
+			if ttl, err := e.TTL(context.Background(), "blob"); err != nil || ttl <= 0 || ttl > time.Hour {
+				t.Fatalf("TTL() of the blob = %v, %v; want just under 1h", ttl, err)
+			}
+		})
+	}
+}
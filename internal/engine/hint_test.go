@@ -0,0 +1,172 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iamNilotpal/ignite/internal/storage"
+	"github.com/iamNilotpal/ignite/pkg/options"
+)
+
+// waitForHints waits until every segment of the store at opts but the most recent
+// one has a hint file, and returns the hint paths.
+func waitForHints(t *testing.T, opts *options.Options) []string {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		files := segmentFiles(t, opts)
+		var hints []string
+		for _, file := range files[:len(files)-1] {
+			if _, err := os.Stat(storage.HintPath(file)); err == nil {
+				hints = append(hints, storage.HintPath(file))
+			}
+		}
+		if len(hints) == len(files)-1 {
+			return hints
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d of %d sealed segments have a hint file", len(hints), len(files)-1)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReopenFromHints(t *testing.T) {
+	opts := testOptions(t.TempDir())
+	e := openEngine(t, opts)
+
+	for i := range 200 {
+		mustSet(t, e, fmt.Sprintf("key-%03d", i%50), value("key", i, 100))
+	}
+	mustSet(t, e, "blob", value("blob", 0, 4096))
+	if _, err := e.SetWith(context.Background(), "ttl", value("ttl", 0, 100), time.Hour, Always); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Delete(context.Background(), "key-007"); err != nil {
+		t.Fatal(err)
+	}
+	// Push everything above into sealed segments.
+	for i := range 40 {
+		mustSet(t, e, fmt.Sprintf("tail-%02d", i), value("tail", i, 100))
+	}
+
+	hints := waitForHints(t, opts)
+	if len(hints) < 3 {
+		t.Fatalf("wrote %d hint files, want several", len(hints))
+	}
+	closeEngine(t, e)
+
+	e = openEngine(t, opts)
+	for i := 150; i < 200; i++ {
+		key := fmt.Sprintf("key-%03d", i%50)
+		if key == "key-007" {
+			expectMissing(t, e, key)
+			continue
+		}
+		expectValue(t, e, key, value("key", i, 100))
+
+		rp, err := e.index.Get(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rp.ValueSize != 100 {
+			t.Fatalf("value size of %q = %d after replaying hints, want 100", key, rp.ValueSize)
+		}
+	}
+	expectValue(t, e, "blob", value("blob", 0, 4096))
+	expectValue(t, e, "ttl", value("ttl", 0, 100))
+	if _, ok := e.expiries["ttl"]; !ok {
+		t.Fatal("expiry time of \"ttl\" lost when replaying hints")
+	}
+}
+
+func TestEncryptedHintsHoldNoKeys(t *testing.T) {
+	opts, _ := encryptedOptions(t, "k1")
+	e := openEngine(t, opts)
+	want := fillStore(t, e)
+
+	hints := waitForHints(t, opts)
+	closeEngine(t, e)
+
+	for _, path := range hints {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(data, []byte("secret-")) {
+			t.Fatalf("%s holds plaintext keys", filepath.Base(path))
+		}
+	}
+
+	e = openEngine(t, opts)
+	for key, v := range want {
+		expectValue(t, e, key, v)
+	}
+}
+
+func TestStaleHintIsReplaced(t *testing.T) {
+	opts := testOptions(t.TempDir())
+	e := openEngine(t, opts)
+	for i := range 200 {
+		mustSet(t, e, fmt.Sprintf("key-%03d", i%50), value("key", i, 100))
+	}
+	hints := waitForHints(t, opts)
+	closeEngine(t, e)
+
+	// A hint describing other entries must not be trusted, even with a valid checksum.
+	other, err := os.ReadFile(hints[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(hints[0], other, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	e = openEngine(t, opts)
+	for i := 150; i < 200; i++ {
+		expectValue(t, e, fmt.Sprintf("key-%03d", i%50), value("key", i, 100))
+	}
+
+	waitForHints(t, opts)
+	hint, err := storage.ReadHint(hints[0])
+	if err != nil {
+		t.Fatalf("ReadHint() error = %v", err)
+	}
+	info, err := os.Stat(segmentFiles(t, opts)[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hint.SegmentSize != info.Size() {
+		t.Fatalf("rewritten hint records %d bytes, segment has %d", hint.SegmentSize, info.Size())
+	}
+}
+
+func TestCompactionRemovesHints(t *testing.T) {
+	opts := testOptions(t.TempDir())
+	e := openEngine(t, opts)
+	for i := range 400 {
+		mustSet(t, e, fmt.Sprintf("key-%02d", i%20), value("key", i, 100))
+	}
+	waitForHints(t, opts)
+
+	if _, err := e.Compact(context.Background()); err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+	closeEngine(t, e)
+
+	hints, err := filepath.Glob(filepath.Join(opts.DataDir, opts.SegmentOptions.Directory, "*.hint"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, hint := range hints {
+		if _, err := os.Stat(hint[:len(hint)-len(".hint")]); err != nil {
+			t.Fatalf("hint %s outlived its segment", filepath.Base(hint))
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	igniteErrors "github.com/iamNilotpal/ignite/pkg/errors"
+	"github.com/iamNilotpal/ignite/pkg/options"
+)
+
+// openReader opens a read-only engine on the data directory of opts, next to any
+// writer that may have it open.
+func openReader(t *testing.T, opts *options.Options) *Engine {
+	t.Helper()
+
+	readOpts := *opts
+	readOpts.ReadOnly = true
+	return openEngine(t, &readOpts)
+}
+
+func TestReadOnlyRejectsWrites(t *testing.T) {
+	opts := testOptions(t.TempDir())
+	w := openEngine(t, opts)
+	mustSet(t, w, "key", []byte("value"))
+	closeEngine(t, w)
+
+	files := segmentFiles(t, opts)
+	info, err := os.Stat(files[len(files)-1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := openReader(t, opts)
+	ctx := context.Background()
+
+	expectCode(t, e.Set(ctx, "key", []byte("other")), igniteErrors.ErrorCodeReadOnly)
+	_, err = e.SetWith(ctx, "key", []byte("other"), time.Minute, Always)
+	expectCode(t, err, igniteErrors.ErrorCodeReadOnly)
+	expectCode(t, e.Delete(ctx, "key"), igniteErrors.ErrorCodeReadOnly)
+	expectCode(t, e.Expire(ctx, "key", time.Minute), igniteErrors.ErrorCodeReadOnly)
+	_, err = e.Compact(ctx)
+	expectCode(t, err, igniteErrors.ErrorCodeReadOnly)
+
+	expectValue(t, e, "key", []byte("value"))
+
+	// Nothing on disk was touched.
+	after, err := os.Stat(files[len(files)-1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Size() != info.Size() || !after.ModTime().Equal(info.ModTime()) {
+		t.Fatalf("read-only engine modified %s", files[len(files)-1])
+	}
+	if got := segmentFiles(t, opts); len(got) != len(files) {
+		t.Fatalf("read-only engine changed the segment files: %v, want %v", got, files)
+	}
+}
+
+func TestRefreshPicksUpWriterChanges(t *testing.T) {
+	opts := testOptions(t.TempDir())
+	w := openEngine(t, opts)
+	mustSet(t, w, "kept", []byte("v1"))
+	mustSet(t, w, "changed", []byte("v1"))
+	mustSet(t, w, "deleted", []byte("v1"))
+
+	r := openReader(t, opts)
+	expectValue(t, r, "changed", []byte("v1"))
+
+	// The writer keeps going: it overwrites and deletes keys, and seals several more
+	// segments while it rewrites a set of new ones.
+	before := len(segmentFiles(t, opts))
+	mustSet(t, w, "changed", []byte("v2"))
+	if err := w.Delete(context.Background(), "deleted"); err != nil {
+		t.Fatal(err)
+	}
+	for i := range 400 {
+		mustSet(t, w, fmt.Sprintf("new-%02d", i%20), value("new", i, 200))
+	}
+	if after := len(segmentFiles(t, opts)); after < before+2 {
+		t.Fatalf("writer has %d segment files, want at least %d", after, before+2)
+	}
+
+	// Until it refreshes, the reader sees the state it opened with.
+	expectValue(t, r, "changed", []byte("v1"))
+	expectMissing(t, r, "new-00")
+
+	if err := r.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	expectValue(t, r, "kept", []byte("v1"))
+	expectValue(t, r, "changed", []byte("v2"))
+	expectMissing(t, r, "deleted")
+	for i := range 20 {
+		expectValue(t, r, fmt.Sprintf("new-%02d", i), value("new", 380+i, 200))
+	}
+
+	// Segments removed by a compaction of the writer make the reader start over.
+	if run, err := w.Compact(context.Background()); err != nil || run.Segments == 0 {
+		t.Fatalf("Compact() = %+v, %v, want segments compacted", run, err)
+	}
+	mustSet(t, w, "after-compaction", []byte("v1"))
+	if err := r.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() after compaction error = %v", err)
+	}
+	expectValue(t, r, "changed", []byte("v2"))
+	expectValue(t, r, "after-compaction", []byte("v1"))
+	expectValue(t, r, "new-19", value("new", 399, 200))
+	expectMissing(t, r, "deleted")
+}
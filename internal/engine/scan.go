@@ -0,0 +1,27 @@
+package engine
+
+import (
+	"context"
+	"time"
+)
+
+// Scan returns, in sorted order, up to limit keys that sort after the key after and
+// for which match returns true; a nil match selects every key. Expired keys are left
+// out. Passing the last key of a page as after returns the next one, and keys that
+// exist throughout the paging are returned exactly once.
+func (e *Engine) Scan(ctx context.Context, after string, limit int, match func(key string) bool) ([]string, error) {
+	if e.closed.Load() {
+		return nil, ErrEngineClosed
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	now := time.Now().UnixNano()
+	return e.index.Scan(after, limit, func(key string) bool {
+		if e.expiredLocked(key, now) {
+			return false
+		}
+		return match == nil || match(key)
+	})
+}
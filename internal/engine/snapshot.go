@@ -0,0 +1,204 @@
+package engine
+
+import (
+	"context"
+	"io"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/iamNilotpal/ignite/internal/index"
+	"github.com/iamNilotpal/ignite/internal/storage"
+	igniteErrors "github.com/iamNilotpal/ignite/pkg/errors"
+)
+
+// Snapshot is a read-only, point-in-time view of the engine. Reads through a
+// snapshot see every key as it was when the snapshot was taken, however the store
+// changes afterwards. The segments and blob files holding those values are kept on
+// disk until the snapshot is released.
+type Snapshot struct {
+	engine   *Engine         // Engine the snapshot was taken from.
+	index    *index.Snapshot // Point-in-time view of the index.
+	segments []uint64        // Segments pinned for the lifetime of the snapshot.
+	expired  map[string]bool // Keys that had expired, unreclaimed, when the snapshot was taken.
+	released atomic.Bool     // Whether Release has been called.
+}
+
+// Snapshot captures the current state of the store. The snapshot must be released
+// once it is no longer needed; until then, the index keeps the previous version of
+// every key written in the meantime, and segment and blob removal is deferred.
+//
+// On a read-only engine, segments the writer process deletes cannot be held back,
+// so reads of values stored in them fail after such a Refresh.
+func (e *Engine) Snapshot(ctx context.Context) (*Snapshot, error) {
+	if e.closed.Load() {
+		return nil, ErrEngineClosed
+	}
+
+	// Taking the write lock orders the snapshot against writes, which update the
+	// index and replace blobs under the same lock.
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.snapshotLocked()
+}
+
+// snapshotLocked takes a snapshot. The caller must hold the write lock.
+func (e *Engine) snapshotLocked() (*Snapshot, error) {
+	snapshot, err := e.index.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	// Keys that expire later stay visible through the snapshot, as they were visible
+	// when it was taken.
+	expired := make(map[string]bool)
+	now := time.Now().UnixNano()
+	for key, expiresAt := range e.expiries {
+		if expiresAt <= now {
+			expired[key] = true
+		}
+	}
+
+	e.snapshots++
+	return &Snapshot{engine: e, index: snapshot, segments: e.storage.PinSegments(), expired: expired}, nil
+}
+
+// Get returns the value key had when the snapshot was taken. The value cache only
+// holds current values, so snapshot reads always go to disk.
+func (s *Snapshot) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+
+	rp, segmentID, err := s.lookup(key)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := s.engine.storage.ReadEntry(segmentID, rp.Offset, rp.EntrySize)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.IsBlob() {
+		blob, err := s.engine.openBlob(entry, segmentID, rp.Offset)
+		if err != nil {
+			return nil, err
+		}
+		defer blob.Close()
+		return readBlob(blob)
+	}
+
+	return decodeValue(entry, segmentID, rp.Offset)
+}
+
+// GetTo writes the value key had when the snapshot was taken to w, streaming it like
+// Engine.GetTo does.
+func (s *Snapshot) GetTo(ctx context.Context, key string, w io.Writer) (int64, error) {
+	if err := s.check(); err != nil {
+		return 0, err
+	}
+
+	rp, segmentID, err := s.lookup(key)
+	if err != nil {
+		return 0, err
+	}
+
+	written, blob, err := s.engine.streamValue(segmentID, rp, w)
+	if err != nil || blob == nil {
+		return written, err
+	}
+	defer blob.Close()
+
+	return storage.CopyBuffered(w, blob)
+}
+
+// Keys returns every key that existed when the snapshot was taken, in sorted order.
+func (s *Snapshot) Keys(ctx context.Context) ([]string, error) {
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+
+	keys, err := s.index.Keys()
+	if err != nil || len(s.expired) == 0 {
+		return keys, err
+	}
+	return slices.DeleteFunc(keys, func(key string) bool { return s.expired[key] }), nil
+}
+
+// lookup resolves key as it was when the snapshot was taken.
+func (s *Snapshot) lookup(key string) (*index.RecordPointer, uint64, error) {
+	if s.expired[key] {
+		return nil, 0, igniteErrors.NewKeyNotFoundError(key)
+	}
+	return s.index.Get(key)
+}
+
+// Iterate calls fn with every key and value that existed when the snapshot was
+// taken, in key order. Iteration stops at the first error, from reading a value or
+// returned by fn, and that error is returned. It also stops when ctx is done.
+func (s *Snapshot) Iterate(ctx context.Context, fn func(key string, value []byte) error) error {
+	keys, err := s.Keys(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		value, err := s.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+		if err := fn(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Release ends the snapshot. Segments and blob files that were only kept for it are
+// removed once no other snapshot needs them. Releasing a snapshot more than once is
+// not an error.
+func (s *Snapshot) Release() error {
+	if !s.released.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	e := s.engine
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	s.index.Release()
+	err := e.storage.UnpinSegments(s.segments)
+
+	e.snapshots--
+	if e.snapshots == 0 && len(e.doomed) > 0 {
+		if syncErr := e.storage.Sync(); syncErr != nil {
+			e.log.Warnw("Failed to sync segment, keeping replaced blobs until next open", "blobs", len(e.doomed), "error", syncErr)
+		} else {
+			for _, id := range e.doomed {
+				e.removeBlob(id)
+			}
+		}
+		e.doomed = nil
+	}
+
+	return err
+}
+
+// check rejects reads through a released snapshot or a closed engine.
+func (s *Snapshot) check() error {
+	if s.engine.closed.Load() {
+		return ErrEngineClosed
+	}
+	if s.released.Load() {
+		return igniteErrors.NewValidationError(
+			nil, igniteErrors.ErrorCodeInvalidInput, "snapshot has been released",
+		).WithField("snapshot").WithRule("not_released")
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	igniteErrors "github.com/iamNilotpal/ignite/pkg/errors"
+)
+
+// blobFiles returns the paths of the finished blob files of e.
+func blobFiles(t *testing.T, e *Engine) []string {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join(e.options.DataDir, e.options.BlobOptions.Directory, "*.blob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestSnapshotSurvivesOverwritesAndDeletes(t *testing.T) {
+	e := openEngine(t, testOptions(t.TempDir()))
+
+	for i := range 20 {
+		mustSet(t, e, fmt.Sprintf("key-%02d", i), value("old", i, 100))
+	}
+	mustSet(t, e, "blob", value("old-blob", 0, 4096))
+
+	snapshot, err := e.Snapshot(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer snapshot.Release()
+
+	for i := range 20 {
+		if i%2 == 0 {
+			if err := e.Delete(context.Background(), fmt.Sprintf("key-%02d", i)); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		mustSet(t, e, fmt.Sprintf("key-%02d", i), value("new", i, 100))
+	}
+	mustSet(t, e, "blob", value("new-blob", 0, 4096))
+	mustSet(t, e, "later", []byte("later"))
+
+	for i := range 20 {
+		got, err := snapshot.Get(context.Background(), fmt.Sprintf("key-%02d", i))
+		if err != nil || string(got) != string(value("old", i, 100)) {
+			t.Fatalf("snapshot Get(key-%02d) = %q, %v", i, truncate(got), err)
+		}
+	}
+	got, err := snapshot.Get(context.Background(), "blob")
+	if err != nil || string(got) != string(value("old-blob", 0, 4096)) {
+		t.Fatalf("snapshot Get(blob) = %q, %v", truncate(got), err)
+	}
+	_, err = snapshot.Get(context.Background(), "later")
+	expectCode(t, err, igniteErrors.ErrorCodeIndexKeyNotFound)
+
+	keys, err := snapshot.Keys(context.Background())
+	if err != nil || len(keys) != 21 || slices.Contains(keys, "later") {
+		t.Fatalf("snapshot Keys() = %v, %v", keys, err)
+	}
+
+	// The replaced blob is kept for the snapshot, and removed once it is released.
+	if n := len(blobFiles(t, e)); n != 2 {
+		t.Fatalf("%d blob files while the snapshot is held, want 2", n)
+	}
+	if err := snapshot.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(blobFiles(t, e)); n != 1 {
+		t.Fatalf("%d blob files after release, want 1", n)
+	}
+
+	_, err = snapshot.Get(context.Background(), "blob")
+	expectCode(t, err, igniteErrors.ErrorCodeInvalidInput)
+	expectValue(t, e, "blob", value("new-blob", 0, 4096))
+}
+
+func TestSnapshotPinsCompactedSegments(t *testing.T) {
+	opts := testOptions(t.TempDir())
+	e := openEngine(t, opts)
+
+	for i := range 40 {
+		mustSet(t, e, fmt.Sprintf("key-%02d", i), value("old", i, 100))
+	}
+
+	snapshot, err := e.Snapshot(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer snapshot.Release()
+	pinned := segmentFiles(t, opts)
+
+	for round := range 5 {
+		for i := range 40 {
+			mustSet(t, e, fmt.Sprintf("key-%02d", i), value("new", round, 100))
+		}
+	}
+	run, err := e.Compact(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.Segments == 0 {
+		t.Fatal("Compact() retired no segments")
+	}
+
+	// Every segment the snapshot reads from is still on disk and readable.
+	for _, path := range pinned {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("pinned segment removed by compaction: %v", err)
+		}
+	}
+	for i := range 40 {
+		got, err := snapshot.Get(context.Background(), fmt.Sprintf("key-%02d", i))
+		if err != nil || string(got) != string(value("old", i, 100)) {
+			t.Fatalf("snapshot Get(key-%02d) = %q, %v", i, truncate(got), err)
+		}
+	}
+
+	if err := snapshot.Release(); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range pinned {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Fatalf("compacted segment %s kept after the snapshot was released", filepath.Base(path))
+		}
+	}
+	for i := range 40 {
+		expectValue(t, e, fmt.Sprintf("key-%02d", i), value("new", 4, 100))
+	}
+}
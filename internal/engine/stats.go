@@ -0,0 +1,70 @@
+package engine
+
+import (
+	"time"
+
+	"github.com/iamNilotpal/ignite/internal/cache"
+	"github.com/iamNilotpal/ignite/internal/compaction"
+	"github.com/iamNilotpal/ignite/internal/storage"
+)
+
+// Stats is a point-in-time snapshot of the engine's internal state, gathered from
+// the index, the storage, the cache and compaction. Each part is read separately,
+// so under concurrent writes they may be a few operations apart.
+type Stats struct {
+	Keys              int              // Number of keys in the index, including expired keys not yet dropped.
+	Expiring          int              // Number of those keys that have an expiry time.
+	IndexMemory       int64            // Estimated bytes of memory held by the index.
+	ActiveSegmentID   uint64           // Segment new entries are appended to.
+	ActiveSegmentSize int64            // Size of the active segment in bytes.
+	Segments          []SegmentStats   // Every live segment, ordered by ascending ID.
+	IO                storage.IOStats  // Bytes written and read, and fsync latencies.
+	Cache             cache.Stats      // Value cache counters; all zero when the cache is disabled.
+	Compaction        compaction.Stats // Compaction counters and recent runs.
+	Uptime            time.Duration    // Time since the engine was opened.
+	RecoveryDuration  time.Duration    // How long the last rebuild of the index took, on open or by Refresh.
+}
+
+// SegmentStats describes how much of a segment file is still referenced.
+type SegmentStats struct {
+	ID        uint64 // Segment file ID.
+	File      string // Filename within the segment directory.
+	Size      int64  // Size of the file in bytes.
+	LiveBytes int64  // Bytes of the entries the index points at.
+}
+
+// DeadBytes returns the bytes of the segment taken by overwritten values, tombstones,
+// expired keys and headers, which compaction can reclaim.
+func (s SegmentStats) DeadBytes() int64 {
+	return max(s.Size-s.LiveBytes, 0)
+}
+
+// Stats returns a snapshot of the engine's internal state.
+func (e *Engine) Stats() Stats {
+	live := e.index.LiveBytes()
+	files := e.storage.Segments()
+
+	segments := make([]SegmentStats, len(files))
+	for i, file := range files {
+		segments[i] = SegmentStats{ID: file.ID, File: file.File, Size: file.Size, LiveBytes: live[file.ID]}
+	}
+
+	e.mu.RLock()
+	expiring := len(e.expiries)
+	e.mu.RUnlock()
+
+	activeID, activeSize := e.storage.ActiveSegment()
+	return Stats{
+		Keys:              e.index.Len(),
+		Expiring:          expiring,
+		IndexMemory:       e.index.MemoryUsage(),
+		ActiveSegmentID:   activeID,
+		ActiveSegmentSize: activeSize,
+		Segments:          segments,
+		IO:                e.storage.IOStats(),
+		Cache:             e.CacheStats(),
+		Compaction:        e.compaction.Stats(),
+		Uptime:            time.Since(e.opened),
+		RecoveryDuration:  time.Duration(e.recovery.Load()),
+	}
+}
@@ -0,0 +1,147 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSetFromRoundTrip(t *testing.T) {
+	opts := testOptions(t.TempDir())
+	opts.BlobOptions.Threshold = 1 << 20
+	e := openEngine(t, opts)
+
+	sizes := map[string]int{"empty": 0, "small": 100, "staged": 100_000}
+	for key, size := range sizes {
+		if err := e.SetFrom(context.Background(), key, bytes.NewReader(value(key, 0, size)), int64(size)); err != nil {
+			t.Fatalf("SetFrom(%q) error = %v", key, err)
+		}
+	}
+	for key, size := range sizes {
+		expectValue(t, e, key, value(key, 0, size))
+	}
+	closeEngine(t, e)
+
+	staging, _ := filepath.Glob(filepath.Join(opts.DataDir, opts.BlobOptions.Directory, "*.tmp"))
+	if len(staging) != 0 {
+		t.Fatalf("staging files left behind: %v", staging)
+	}
+
+	e = openEngine(t, opts)
+	for key, size := range sizes {
+		expectValue(t, e, key, value(key, 0, size))
+	}
+}
+
+func TestSetFromShortReaderStoresNothing(t *testing.T) {
+	opts := testOptions(t.TempDir())
+	opts.BlobOptions.Threshold = 1 << 20
+	e := openEngine(t, opts)
+
+	for _, size := range []int{100, 100_000} {
+		short := bytes.NewReader(value("short", 0, size-1))
+		if err := e.SetFrom(context.Background(), "short", short, int64(size)); err == nil {
+			t.Fatalf("SetFrom() with %d of %d bytes succeeded", size-1, size)
+		}
+	}
+	expectMissing(t, e, "short")
+
+	mustSet(t, e, "after", value("after", 0, 100))
+	closeEngine(t, e)
+
+	e = openEngine(t, opts)
+	expectMissing(t, e, "short")
+	expectValue(t, e, "after", value("after", 0, 100))
+}
+
+func TestSetFromSlowReaderDoesNotBlockWriters(t *testing.T) {
+	opts := testOptions(t.TempDir())
+	opts.BlobOptions.Threshold = 1 << 20
+	e := openEngine(t, opts)
+
+	want := value("slow", 0, 100_000)
+	r, w := io.Pipe()
+	done := make(chan error)
+	go func() {
+		done <- e.SetFrom(context.Background(), "slow", r, int64(len(want)))
+	}()
+
+	// Half of the value is in; the rest has not been sent yet.
+	if _, err := w.Write(want[:len(want)/2]); err != nil {
+		t.Fatal(err)
+	}
+
+	written := make(chan error)
+	go func() {
+		written <- e.Set(context.Background(), "fast", []byte("value"))
+	}()
+	select {
+	case err := <-written:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Set() blocked behind a slow SetFrom reader")
+	}
+
+	if _, err := w.Write(want[len(want)/2:]); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("SetFrom() error = %v", err)
+	}
+
+	expectValue(t, e, "slow", want)
+	expectValue(t, e, "fast", []byte("value"))
+}
+
+func TestSetFromWithConditionsAndExpiry(t *testing.T) {
+	opts := testOptions(t.TempDir())
+	opts.BlobOptions.Threshold = 1 << 20
+	e := openEngine(t, opts)
+
+	// One value held in memory, one copied through a staging file and one blob.
+	sizes := map[string]int{"small": 100, "staged": 100_000, "blob": 2 << 20}
+	setFrom := func(key string, n int, ttl time.Duration, cond Condition) bool {
+		t.Helper()
+		size := sizes[key]
+		stored, err := e.SetFromWith(context.Background(), key, bytes.NewReader(value(key, n, size)), int64(size), ttl, cond)
+		if err != nil {
+			t.Fatalf("SetFromWith(%q) error = %v", key, err)
+		}
+		return stored
+	}
+
+	for key := range sizes {
+		if setFrom(key, 0, time.Hour, IfExists) {
+			t.Fatalf("SetFromWith(%q, IfExists) stored a missing key", key)
+		}
+		if !setFrom(key, 1, time.Hour, IfAbsent) {
+			t.Fatalf("SetFromWith(%q, IfAbsent) did not store a missing key", key)
+		}
+		if setFrom(key, 2, 0, IfAbsent) {
+			t.Fatalf("SetFromWith(%q, IfAbsent) overwrote an existing key", key)
+		}
+	}
+	closeEngine(t, e)
+
+	e = openEngine(t, opts)
+	for key, size := range sizes {
+		expectValue(t, e, key, value(key, 1, size))
+		if ttl, err := e.TTL(context.Background(), key); err != nil || ttl <= 0 || ttl > time.Hour {
+			t.Fatalf("TTL(%q) after reopening = %v, %v; want just under 1h", key, ttl, err)
+		}
+	}
+
+	staging, _ := filepath.Glob(filepath.Join(opts.DataDir, opts.BlobOptions.Directory, "*.tmp"))
+	if len(staging) != 0 {
+		t.Fatalf("staging files left behind: %v", staging)
+	}
+	blobs, _ := filepath.Glob(filepath.Join(opts.DataDir, opts.BlobOptions.Directory, "*.blob"))
+	if len(blobs) != 1 {
+		t.Fatalf("%d blob files after rejected writes, want 1", len(blobs))
+	}
+}
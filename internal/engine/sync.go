@@ -0,0 +1,58 @@
+package engine
+
+import (
+	"time"
+
+	"github.com/iamNilotpal/ignite/pkg/options"
+)
+
+// SetSyncPolicy changes when writes are synced to stable storage. Under
+// options.SyncAlways every append syncs before it returns; under options.SyncInterval
+// the active segment is synced in the background every interval, the first time a
+// full interval from now; options.SyncNone leaves flushing to the operating system.
+func (e *Engine) SetSyncPolicy(policy options.SyncPolicy, interval time.Duration) {
+	e.storage.SetSyncWrites(policy == options.SyncAlways)
+
+	if policy != options.SyncInterval {
+		interval = 0
+	}
+	e.syncInterval.Store(int64(interval))
+
+	select {
+	case e.syncReset <- struct{}{}:
+	default:
+	}
+}
+
+// syncLoop syncs the active segment every sync interval until the engine is closed.
+func (e *Engine) syncLoop() {
+	defer close(e.syncDone)
+
+	timer := time.NewTimer(e.nextSync())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-e.lifetime.Done():
+			return
+
+		case <-e.syncReset:
+			timer.Reset(e.nextSync())
+
+		case <-timer.C:
+			if err := e.storage.Sync(); err != nil && e.lifetime.Err() == nil {
+				e.log.Errorw("Background sync failed", "error", err)
+			}
+			timer.Reset(e.nextSync())
+		}
+	}
+}
+
+// nextSync returns how long to wait for the next background sync. Without the
+// interval policy it waits for as long as a timer can.
+func (e *Engine) nextSync() time.Duration {
+	if interval := time.Duration(e.syncInterval.Load()); interval > 0 {
+		return interval
+	}
+	return time.Duration(1<<63 - 1)
+}
@@ -0,0 +1,156 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/iamNilotpal/ignite/pkg/options"
+)
+
+// fsyncs returns how many fsyncs e has performed since it was opened.
+func fsyncs(e *Engine) uint64 {
+	return e.storage.IOStats().Fsync.Count
+}
+
+// eventually fails the test unless cond becomes true within two seconds.
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); {
+		if cond() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("%s did not happen", what)
+}
+
+// largeSegments returns test options whose segments do not rotate, and therefore do
+// not sync, during a test.
+func largeSegments(t *testing.T) *options.Options {
+	opts := testOptions(t.TempDir())
+	opts.SegmentOptions.Size = 1 << 20
+	return opts
+}
+
+func TestSyncPolicyAlways(t *testing.T) {
+	opts := largeSegments(t)
+	opts.SyncOptions.Policy = options.SyncAlways
+	e := openEngine(t, opts)
+
+	before := fsyncs(e)
+	for i := range 10 {
+		mustSet(t, e, fmt.Sprintf("key-%d", i), value("key", i, 50))
+	}
+	if got := fsyncs(e) - before; got < 10 {
+		t.Fatalf("%d fsyncs for 10 writes under the always policy", got)
+	}
+
+	e.SetSyncPolicy(options.SyncNone, 0)
+	before = fsyncs(e)
+	for i := range 10 {
+		mustSet(t, e, fmt.Sprintf("key-%d", i), value("key", i, 50))
+	}
+	if got := fsyncs(e) - before; got != 0 {
+		t.Fatalf("%d fsyncs for 10 writes under the none policy", got)
+	}
+}
+
+func TestSyncPolicyInterval(t *testing.T) {
+	e := openEngine(t, largeSegments(t))
+	mustSet(t, e, "key", []byte("v"))
+
+	before := fsyncs(e)
+	e.SetSyncPolicy(options.SyncInterval, 10*time.Millisecond)
+	eventually(t, "a background sync", func() bool { return fsyncs(e) > before })
+
+	// Writes themselves do not sync under the interval policy.
+	e.SetSyncPolicy(options.SyncInterval, time.Hour)
+	before = fsyncs(e)
+	for i := range 10 {
+		mustSet(t, e, fmt.Sprintf("key-%d", i), value("key", i, 50))
+	}
+	if got := fsyncs(e) - before; got != 0 {
+		t.Fatalf("%d fsyncs for 10 writes with an hourly sync interval", got)
+	}
+}
+
+func TestSetCompactIntervalSchedulesRuns(t *testing.T) {
+	e := openEngine(t, testOptions(t.TempDir()))
+	for i := range 400 {
+		mustSet(t, e, fmt.Sprintf("key-%02d", i%20), value("key", i, 100))
+	}
+
+	// testOptions disables automatic compaction.
+	time.Sleep(20 * time.Millisecond)
+	if runs := e.Stats().Compaction.Runs; runs != 0 {
+		t.Fatalf("%d compaction runs with automatic compaction disabled", runs)
+	}
+
+	e.SetCompactInterval(10 * time.Millisecond)
+	eventually(t, "an automatic compaction run", func() bool { return e.Stats().Compaction.Runs > 0 })
+	e.SetCompactInterval(0)
+}
+
+func TestCompactRateLimit(t *testing.T) {
+	e := openEngine(t, testOptions(t.TempDir()))
+	for i := range 400 {
+		mustSet(t, e, fmt.Sprintf("key-%02d", i%20), value("key", i, 100))
+	}
+
+	// At 1KB/s the segments to compact take tens of seconds to walk.
+	e.SetCompactRateLimit(1024)
+	done := make(chan error, 1)
+	go func() {
+		_, err := e.Compact(context.Background())
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf("Compact() finished at once under a rate limit, error = %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Lifting the limit speeds up the run in progress.
+	e.SetCompactRateLimit(0)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Compact() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Compact() did not finish after the rate limit was lifted")
+	}
+	for i := 380; i < 400; i++ {
+		expectValue(t, e, fmt.Sprintf("key-%02d", i%20), value("key", i, 100))
+	}
+}
+
+func TestCloseInterruptsPacedCompaction(t *testing.T) {
+	e := openEngine(t, testOptions(t.TempDir()))
+	for i := range 400 {
+		mustSet(t, e, fmt.Sprintf("key-%02d", i%20), value("key", i, 100))
+	}
+
+	e.SetCompactRateLimit(1024)
+	done := make(chan error, 1)
+	go func() {
+		_, err := e.Compact(context.Background())
+		done <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	closed := make(chan error, 1)
+	go func() { closed <- e.Close() }()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close() waited for a paced compaction run")
+	}
+	if err := <-done; err == nil {
+		t.Fatal("Compact() interrupted by Close returned no error")
+	}
+}
@@ -0,0 +1,317 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iamNilotpal/ignite/pkg/errors"
+	"github.com/iamNilotpal/ignite/pkg/ignite"
+)
+
+const (
+	// TTLHeader carries the time to live of a key: on PUT, as a duration such as
+	// "30s" or a number of seconds; on GET, as the whole seconds the key has left.
+	TTLHeader = "Ignite-TTL"
+
+	// defaultListLimit and maxListLimit bound the page size of GET /v1/keys.
+	defaultListLimit = 100
+	maxListLimit     = 1000
+
+	// maxBatchSize is the largest number of keys or entries a batch may hold.
+	maxBatchSize = 1000
+)
+
+// get handles GET /v1/keys/{key}, answering with the raw value. A key that expires
+// carries its remaining time in the Ignite-TTL header.
+func (s *Server) get(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+
+	ttl, err := s.instance.TTL(r.Context(), key)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	// GetView hands over the value only after its checksum has been verified, so a
+	// damaged value is reported as an error instead of being sent with a 200 status.
+	err = s.instance.GetView(r.Context(), key, func(value []byte) error {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Length", strconv.Itoa(len(value)))
+		if ttl > 0 {
+			w.Header().Set(TTLHeader, strconv.FormatInt(int64((ttl+time.Second/2)/time.Second), 10))
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write(value)
+		return nil
+	})
+	if err != nil {
+		s.writeError(w, err)
+	}
+}
+
+// put handles PUT /v1/keys/{key}, storing the request body as the value. The body is
+// streamed into the store through SetFromWith, so it is never held in memory as a
+// whole, and must therefore come with a Content-Length; chunked bodies are answered
+// with 411. The TTL is read from the Ignite-TTL header or the ttl query parameter.
+// "If-None-Match: *" only writes a key that does not exist and "If-Match: *" only one
+// that does; when the condition does not hold the answer is 412.
+func (s *Server) put(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+
+	switch {
+	case r.ContentLength < 0:
+		writeJSON(w, http.StatusLengthRequired, &errorResponse{Error: &errorBody{
+			Code:    codeLengthRequired,
+			Message: "a Content-Length is required to store a value",
+		}})
+		return
+	case r.ContentLength > s.maxBody:
+		s.writeBodyError(w, &http.MaxBytesError{Limit: s.maxBody}, "")
+		return
+	}
+
+	var opts ignite.SetOptions
+	raw := r.Header.Get(TTLHeader)
+	if raw == "" {
+		raw = r.URL.Query().Get("ttl")
+	}
+	if raw != "" {
+		ttl, err := parseTTL(raw)
+		if err != nil {
+			s.writeError(w, err)
+			return
+		}
+		opts.TTL = ttl
+	}
+
+	switch match, noneMatch := r.Header.Get("If-Match"), r.Header.Get("If-None-Match"); {
+	case match != "" && noneMatch != "":
+		s.writeError(w, errors.NewValidationError(nil, errors.ErrorCodeInvalidInput, "If-Match and If-None-Match are mutually exclusive").
+			WithField("If-Match").WithRule("exclusive"))
+		return
+	case match == "*":
+		opts.Condition = ignite.SetIfExists
+	case noneMatch == "*":
+		opts.Condition = ignite.SetIfAbsent
+	case match != "" || noneMatch != "":
+		s.writeError(w, errors.NewFieldFormatError("If-Match", match+noneMatch, "*"))
+		return
+	}
+
+	body := &bodyReader{r: r.Body}
+	stored, err := s.instance.SetFromWith(r.Context(), key, body, r.ContentLength, opts)
+	if body.err != nil {
+		// The client went away or sent less than it announced.
+		s.writeBodyError(w, body.err, "Request body could not be read")
+		return
+	}
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	if !stored {
+		writeJSON(w, http.StatusPreconditionFailed, &errorResponse{Error: &errorBody{
+			Code:    codeConditionNotMet,
+			Message: "the write condition does not hold",
+			Details: map[string]any{"key": key},
+		}})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// delete handles DELETE /v1/keys/{key}. Deleting a key that does not exist is
+// answered with 404.
+func (s *Server) delete(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+
+	removed, err := s.instance.Remove(r.Context(), key)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	if !removed {
+		s.writeError(w, errors.NewKeyNotFoundError(key))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// list handles GET /v1/keys?prefix=&after=&limit=, answering with up to limit keys
+// that start with prefix, in sorted order after the key after. Paging by the next
+// key of each answer returns every key that exists throughout exactly once.
+func (s *Server) list(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	prefix, after := query.Get("prefix"), query.Get("after")
+
+	limit := defaultListLimit
+	if raw := query.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxListLimit {
+			s.writeError(w, errors.NewFieldRangeError("limit", raw, 1, maxListLimit))
+			return
+		}
+		limit = n
+	}
+
+	var match func(key string) bool
+	if prefix != "" {
+		match = func(key string) bool { return strings.HasPrefix(key, prefix) }
+	}
+
+	keys, err := s.instance.Scan(r.Context(), after, limit, match)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	response := &listResponse{Keys: keys}
+	if response.Keys == nil {
+		response.Keys = []string{}
+	}
+	if len(keys) == limit {
+		response.Next = keys[len(keys)-1]
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// batchGet handles POST /v1/batch/get, reading every key in the request.
+func (s *Server) batchGet(w http.ResponseWriter, r *http.Request) {
+	var request batchKeysRequest
+	if !s.decode(w, r, &request) || !s.checkBatch(w, len(request.Keys)) {
+		return
+	}
+
+	results := make([]batchResult, len(request.Keys))
+	for i, key := range request.Keys {
+		results[i].Key = key
+
+		value, err := s.instance.Get(r.Context(), key)
+		found := err == nil
+		switch {
+		case found:
+			results[i].Found, results[i].Value = &found, value
+		case errors.GetErrorCode(err) == errors.ErrorCodeIndexKeyNotFound:
+			results[i].Found = &found
+		default:
+			results[i].Error = s.describe(err)
+		}
+	}
+	writeJSON(w, http.StatusOK, &batchResponse{Results: results})
+}
+
+// batchPut handles POST /v1/batch/put, writing every entry in the request in order.
+// Entries are written one by one, not atomically: one failing does not undo the
+// others or stop the rest.
+func (s *Server) batchPut(w http.ResponseWriter, r *http.Request) {
+	var request batchPutRequest
+	if !s.decode(w, r, &request) || !s.checkBatch(w, len(request.Entries)) {
+		return
+	}
+
+	results := make([]batchResult, len(request.Entries))
+	for i, entry := range request.Entries {
+		results[i].Key = entry.Key
+
+		var opts ignite.SetOptions
+		if entry.TTL != "" {
+			ttl, err := parseTTL(entry.TTL)
+			if err != nil {
+				results[i].Error = s.describe(err)
+				continue
+			}
+			opts.TTL = ttl
+		}
+
+		if _, err := s.instance.SetWith(r.Context(), entry.Key, entry.Value, opts); err != nil {
+			results[i].Error = s.describe(err)
+		}
+	}
+	writeJSON(w, http.StatusOK, &batchResponse{Results: results})
+}
+
+// batchDelete handles POST /v1/batch/delete, deleting every key in the request.
+func (s *Server) batchDelete(w http.ResponseWriter, r *http.Request) {
+	var request batchKeysRequest
+	if !s.decode(w, r, &request) || !s.checkBatch(w, len(request.Keys)) {
+		return
+	}
+
+	results := make([]batchResult, len(request.Keys))
+	for i, key := range request.Keys {
+		results[i].Key = key
+
+		removed, err := s.instance.Remove(r.Context(), key)
+		if err != nil {
+			results[i].Error = s.describe(err)
+			continue
+		}
+		results[i].Deleted = &removed
+	}
+	writeJSON(w, http.StatusOK, &batchResponse{Results: results})
+}
+
+// notFound answers requests for paths and methods the API does not have.
+func (s *Server) notFound(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusNotFound, &errorResponse{Error: &errorBody{
+		Code:    codeRouteNotFound,
+		Message: "no such endpoint",
+		Details: map[string]any{"method": r.Method, "path": r.URL.Path},
+	}})
+}
+
+// decode reads the JSON body of a batch request into v, answering the request itself
+// and returning false when the body is too large or malformed.
+func (s *Server) decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.maxBody))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(v); err != nil {
+		s.writeBodyError(w, err, "Request body is not valid JSON")
+		return false
+	}
+	return true
+}
+
+// checkBatch answers the request and returns false when a batch holds more than
+// maxBatchSize keys or entries.
+func (s *Server) checkBatch(w http.ResponseWriter, size int) bool {
+	if size > maxBatchSize {
+		s.writeError(w, errors.NewFieldRangeError("batch", size, 0, maxBatchSize))
+		return false
+	}
+	return true
+}
+
+// bodyReader reads a request body and remembers the first error reading it failed
+// with, so that a broken upload can be told apart from a failing store.
+type bodyReader struct {
+	r   io.Reader
+	err error
+}
+
+// Read implements io.Reader.
+func (b *bodyReader) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	if err != nil && err != io.EOF && b.err == nil {
+		b.err = err
+	}
+	return n, err
+}
+
+// parseTTL parses a time to live given either as a duration such as "1m30s" or as
+// a whole number of seconds. It must be positive.
+func parseTTL(raw string) (time.Duration, error) {
+	if seconds, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		if seconds > 0 && seconds <= int64(time.Duration(1<<63-1)/time.Second) {
+			return time.Duration(seconds) * time.Second, nil
+		}
+	} else if ttl, err := time.ParseDuration(raw); err == nil && ttl > 0 {
+		return ttl, nil
+	}
+	return 0, errors.NewFieldFormatError("ttl", raw, "a positive duration such as 30s, or whole seconds")
+}
@@ -0,0 +1,91 @@
+package httpapi
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/iamNilotpal/ignite/pkg/ignite"
+	"go.uber.org/zap"
+)
+
+// Server serves an ignite.Instance as a JSON API over HTTP.
+type Server struct {
+	instance *ignite.Instance   // Store the requests operate on.
+	log      *zap.SugaredLogger // Structured logger for server errors and shutdown events.
+	maxBody  int64              // Largest request body accepted.
+	http     *http.Server       // Underlying HTTP server.
+	closed   atomic.Bool        // Whether Shutdown has been called.
+}
+
+// Config holds the parameters of a new Server.
+type Config struct {
+	Instance *ignite.Instance   // Store to serve; required.
+	Logger   *zap.SugaredLogger // Logger for server errors and shutdown events; required.
+
+	// MaxBodySize is the largest request body, and so the largest value or batch, a
+	// request may carry. Zero selects DefaultMaxBodySize.
+	MaxBodySize int64
+
+	// ReadTimeout, WriteTimeout and IdleTimeout bound reading a request, writing its
+	// response and waiting for the next request on a keep-alive connection, like the
+	// fields of http.Server with the same names. Zero selects DefaultReadTimeout,
+	// DefaultWriteTimeout and DefaultIdleTimeout.
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+}
+
+// errorResponse is the body of every failed request.
+type errorResponse struct {
+	Error *errorBody `json:"error"`
+}
+
+// errorBody describes an error by its pkg/errors code, its message and the details
+// attached to it.
+type errorBody struct {
+	Code    string         `json:"code"`
+	Message string         `json:"message"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+// listResponse is the body of GET /v1/keys.
+type listResponse struct {
+	Keys []string `json:"keys"`
+	// Next is passed as the after parameter to fetch the following page. It is
+	// omitted on the last page.
+	Next string `json:"next,omitempty"`
+}
+
+// batchKeysRequest is the body of POST /v1/batch/get and POST /v1/batch/delete.
+type batchKeysRequest struct {
+	Keys []string `json:"keys"`
+}
+
+// batchPutRequest is the body of POST /v1/batch/put.
+type batchPutRequest struct {
+	Entries []batchEntry `json:"entries"`
+}
+
+// batchEntry is a single write of a batch. Value is base64 encoded in JSON.
+type batchEntry struct {
+	Key   string `json:"key"`
+	Value []byte `json:"value"`
+	TTL   string `json:"ttl,omitempty"` // Duration such as "30s", or whole seconds.
+}
+
+// batchResponse is the body of every batch request, with one result per key or entry
+// in request order.
+type batchResponse struct {
+	Results []batchResult `json:"results"`
+}
+
+// batchResult is the outcome of one key or entry of a batch. Found and Value are set
+// by gets and Deleted by deletes; Error is set when that key failed.
+type batchResult struct {
+	Key     string     `json:"key"`
+	Found   *bool      `json:"found,omitempty"`
+	Value   []byte     `json:"value,omitempty"`
+	Deleted *bool      `json:"deleted,omitempty"`
+	Error   *errorBody `json:"error,omitempty"`
+}
@@ -0,0 +1,114 @@
+package httpapi
+
+import (
+	"encoding/json"
+	stdErrors "errors"
+	"net/http"
+
+	"github.com/iamNilotpal/ignite/pkg/errors"
+)
+
+// Error codes of failures that come from HTTP rather than from the store.
+const (
+	codeConditionNotMet  = "CONDITION_NOT_MET"
+	codeRouteNotFound    = "ROUTE_NOT_FOUND"
+	codeMethodNotAllowed = "METHOD_NOT_ALLOWED"
+	codeBodyTooLarge     = "BODY_TOO_LARGE"
+	codeLengthRequired   = "LENGTH_REQUIRED"
+)
+
+// statusFor maps an error code to the HTTP status that answers it.
+func statusFor(code errors.ErrorCode) int {
+	switch code {
+	case errors.ErrorCodeIndexKeyNotFound:
+		return http.StatusNotFound
+	case errors.ErrorCodeInvalidInput, errors.ErrorCodeIndexValidationFailed:
+		return http.StatusBadRequest
+	case errors.ErrorCodeReadOnly:
+		return http.StatusForbidden
+	case errors.ErrorCodeDiskFull:
+		return http.StatusInsufficientStorage
+	case errors.ErrorCodeFilesystemReadonly, errors.ErrorCodePermissionDenied,
+		errors.ErrorCodeDirectoryLocked, errors.ErrorCodeEncryptionKeyUnavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeError answers a request with err, using the status its code maps to.
+func (s *Server) writeError(w http.ResponseWriter, err error) {
+	body := s.describe(err)
+	writeJSON(w, statusFor(errors.ErrorCode(body.Code)), &errorResponse{Error: body})
+}
+
+// writeBodyError answers a request whose body could not be read or decoded: 413 when
+// it exceeds the size limit and 400 otherwise, with the reason in the details.
+func (s *Server) writeBodyError(w http.ResponseWriter, err error, msg string) {
+	var tooLarge *http.MaxBytesError
+	if stdErrors.As(err, &tooLarge) {
+		writeJSON(w, http.StatusRequestEntityTooLarge, &errorResponse{Error: &errorBody{
+			Code:    codeBodyTooLarge,
+			Message: "request body exceeds the size limit",
+			Details: map[string]any{"maxValue": tooLarge.Limit},
+		}})
+		return
+	}
+
+	s.writeError(w, errors.NewValidationError(err, errors.ErrorCodeInvalidInput, msg).
+		WithField("body").WithDetail("reason", err.Error()))
+}
+
+// methodNotAllowed returns a handler answering requests for a path with a method it
+// does not support.
+func (s *Server) methodNotAllowed(allow string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", allow)
+		writeJSON(w, http.StatusMethodNotAllowed, &errorResponse{Error: &errorBody{
+			Code:    codeMethodNotAllowed,
+			Message: "method not allowed",
+			Details: map[string]any{"method": r.Method, "allow": allow},
+		}})
+	}
+}
+
+// describe converts err into an error body. The field, rule and values of a
+// validation error and the key of an index error are added to its details, and
+// details that are errors are replaced by their message, since they do not encode
+// as JSON.
+func (s *Server) describe(err error) *errorBody {
+	code := errors.GetErrorCode(err)
+	if statusFor(code) >= http.StatusInternalServerError {
+		s.log.Warnw("Request failed", "code", code, "error", err)
+	}
+
+	details := make(map[string]any)
+	for key, value := range errors.GetErrorDetails(err) {
+		if detail, ok := value.(error); ok {
+			value = detail.Error()
+		}
+		details[key] = value
+	}
+
+	if ve, ok := errors.AsValidationError(err); ok {
+		for key, value := range map[string]any{
+			"field": ve.Field(), "rule": ve.Rule(), "provided": ve.Provided(), "expected": ve.Expected(),
+		} {
+			if value != nil && value != "" {
+				details[key] = value
+			}
+		}
+	}
+	if ie, ok := errors.AsIndexError(err); ok && ie.Key() != "" {
+		details["key"] = ie.Key()
+	}
+
+	return &errorBody{Code: string(code), Message: err.Error(), Details: details}
+}
+
+// writeJSON answers a request with v encoded as JSON.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
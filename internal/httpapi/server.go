@@ -0,0 +1,143 @@
+// Package httpapi serves an Ignite store as a JSON API over HTTP, for clients such as
+// shell scripts and browsers that do not speak the Redis protocol.
+//
+// Values are read and written as raw request and response bodies under
+// /v1/keys/{key}; listings and batches are JSON. Every error is answered with a JSON
+// body carrying the pkg/errors code, message and details, and an HTTP status derived
+// from the code. Metrics are served on /metrics in the Prometheus text format.
+package httpapi
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/iamNilotpal/ignite/pkg/errors"
+	"github.com/iamNilotpal/ignite/pkg/ignite"
+)
+
+const (
+	// DefaultMaxBodySize is the largest request body accepted unless
+	// Config.MaxBodySize says otherwise.
+	DefaultMaxBodySize = 64 * 1024 * 1024
+
+	// DefaultReadTimeout bounds how long a client may take to send a whole request,
+	// body included, unless Config.ReadTimeout says otherwise. It leaves room for a
+	// value of DefaultMaxBodySize over a slow link.
+	DefaultReadTimeout = 5 * time.Minute
+
+	// DefaultWriteTimeout bounds how long a response may take to send, measured from
+	// the end of the request headers, unless Config.WriteTimeout says otherwise.
+	DefaultWriteTimeout = 5 * time.Minute
+
+	// DefaultIdleTimeout bounds how long a keep-alive connection may wait for its next
+	// request unless Config.IdleTimeout says otherwise.
+	DefaultIdleTimeout = 2 * time.Minute
+
+	// readHeaderTimeout bounds how long a client may take to send request headers,
+	// so idle connections cannot hold the server open.
+	readHeaderTimeout = 10 * time.Second
+)
+
+// ErrServerClosed is returned by Serve and ListenAndServe once Shutdown is called.
+var ErrServerClosed = http.ErrServerClosed
+
+// New creates a server for the instance in config. The server does not own the
+// instance: after Shutdown returns, the caller closes it.
+func New(config *Config) (*Server, error) {
+	if config == nil || config.Instance == nil {
+		return nil, errors.NewRequiredFieldError("instance")
+	}
+	if config.Logger == nil {
+		return nil, errors.NewRequiredFieldError("logger")
+	}
+	if config.MaxBodySize < 0 {
+		return nil, errors.NewFieldRangeError("maxBodySize", config.MaxBodySize, 0, int64(DefaultMaxBodySize))
+	}
+
+	for field, timeout := range map[string]time.Duration{
+		"readTimeout": config.ReadTimeout, "writeTimeout": config.WriteTimeout, "idleTimeout": config.IdleTimeout,
+	} {
+		if timeout < 0 {
+			return nil, errors.NewFieldRangeError(field, timeout, 0, time.Duration(math.MaxInt64))
+		}
+	}
+
+	maxBody := config.MaxBodySize
+	if maxBody == 0 {
+		maxBody = DefaultMaxBodySize
+	}
+
+	s := &Server{instance: config.Instance, log: config.Logger, maxBody: maxBody}
+	s.http = &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       cmp.Or(config.ReadTimeout, DefaultReadTimeout),
+		WriteTimeout:      cmp.Or(config.WriteTimeout, DefaultWriteTimeout),
+		IdleTimeout:       cmp.Or(config.IdleTimeout, DefaultIdleTimeout),
+	}
+	return s, nil
+}
+
+// Handler returns the routes of the API, for mounting on another HTTP server.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/keys", s.list)
+	mux.HandleFunc("GET /v1/keys/{key...}", s.get)
+	mux.HandleFunc("PUT /v1/keys/{key...}", s.put)
+	mux.HandleFunc("DELETE /v1/keys/{key...}", s.delete)
+	mux.HandleFunc("POST /v1/batch/get", s.batchGet)
+	mux.HandleFunc("POST /v1/batch/put", s.batchPut)
+	mux.HandleFunc("POST /v1/batch/delete", s.batchDelete)
+	mux.Handle("GET /metrics", ignite.NewCollector(s.instance))
+	mux.HandleFunc("/v1/keys", s.methodNotAllowed("GET, HEAD"))
+	mux.HandleFunc("/v1/keys/{key...}", s.methodNotAllowed("GET, HEAD, PUT, DELETE"))
+	for _, operation := range []string{"get", "put", "delete"} {
+		mux.HandleFunc("/v1/batch/"+operation, s.methodNotAllowed("POST"))
+	}
+	mux.HandleFunc("/metrics", s.methodNotAllowed("GET, HEAD"))
+	mux.HandleFunc("/", s.notFound)
+	return mux
+}
+
+// ListenAndServe listens on the TCP address addr and serves requests on it.
+func (s *Server) ListenAndServe(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(listener)
+}
+
+// Serve accepts connections on listener until Shutdown is called, after which it
+// returns ErrServerClosed. Serve closes the listener when it returns.
+func (s *Server) Serve(listener net.Listener) error {
+	if s.closed.Load() {
+		listener.Close()
+		return ErrServerClosed
+	}
+
+	s.log.Infow("Serving HTTP requests", "addr", listener.Addr().String())
+	return s.http.Serve(listener)
+}
+
+// Shutdown stops the server gracefully. It stops accepting connections, lets every
+// request in flight finish, and closes idle connections. If ctx ends first, the
+// remaining connections are closed at once and ctx's error is returned.
+func (s *Server) Shutdown(ctx context.Context) error {
+	if !s.closed.CompareAndSwap(false, true) {
+		return ErrServerClosed
+	}
+
+	if err := s.http.Shutdown(ctx); err != nil {
+		s.http.Close()
+		s.log.Warnw("HTTP server shutdown timed out, closed remaining connections", "error", err)
+		return err
+	}
+
+	s.log.Infow("HTTP server shut down")
+	return nil
+}
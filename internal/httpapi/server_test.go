@@ -0,0 +1,225 @@
+package httpapi
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iamNilotpal/ignite/pkg/ignite"
+	"github.com/iamNilotpal/ignite/pkg/options"
+	"go.uber.org/zap"
+)
+
+// startServer serves a fresh store with config and returns the server and its base
+// URL. Both are shut down when the test ends.
+func startServer(t *testing.T, config Config) (*Server, string) {
+	t.Helper()
+
+	instance, err := ignite.NewInstance(
+		context.Background(), "http-test",
+		options.WithDataDir(t.TempDir()), options.WithLogLevel("error"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { instance.Close(context.Background()) })
+
+	config.Instance, config.Logger = instance, zap.NewNop().Sugar()
+	s, err := New(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(s.Handler())
+	t.Cleanup(server.Close)
+	return s, server.URL
+}
+
+// do sends a request and returns the response with its body read.
+func do(t *testing.T, method, url string, body io.Reader, header http.Header) (*http.Response, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, string(data)
+}
+
+// expectStatus sends a request and fails the test unless it is answered with status.
+// It returns the body.
+func expectStatus(t *testing.T, status int, method, url string, body io.Reader, header http.Header) string {
+	t.Helper()
+
+	resp, data := do(t, method, url, body, header)
+	if resp.StatusCode != status {
+		t.Fatalf("%s %s = %d %s, want %d", method, url, resp.StatusCode, data, status)
+	}
+	return data
+}
+
+// errorCode returns the code of a JSON error body.
+func errorCode(t *testing.T, body string) string {
+	t.Helper()
+
+	var response errorResponse
+	if err := json.Unmarshal([]byte(body), &response); err != nil || response.Error == nil {
+		t.Fatalf("not an error body: %q", body)
+	}
+	return response.Error.Code
+}
+
+func TestPutAndGet(t *testing.T) {
+	_, url := startServer(t, Config{})
+	key := url + "/v1/keys/dir/photo"
+
+	expectStatus(t, http.StatusNoContent, "PUT", key, strings.NewReader("v1"), http.Header{TTLHeader: {"90s"}})
+	resp, body := do(t, "GET", key, nil, nil)
+	if resp.StatusCode != http.StatusOK || body != "v1" || resp.Header.Get(TTLHeader) != "90" {
+		t.Fatalf("GET = %d %q with TTL %q, want 200 \"v1\" with TTL 90", resp.StatusCode, body, resp.Header.Get(TTLHeader))
+	}
+
+	// A value above the staging buffer takes the streaming path.
+	large := strings.Repeat("x", 100_000)
+	expectStatus(t, http.StatusNoContent, "PUT", key+"?ttl=60", strings.NewReader(large), nil)
+	resp, body = do(t, "GET", key, nil, nil)
+	if body != large || resp.Header.Get(TTLHeader) != "60" {
+		t.Fatalf("GET after large PUT = %d bytes with TTL %q", len(body), resp.Header.Get(TTLHeader))
+	}
+
+	// Conditions are checked atomically with the write.
+	body = expectStatus(t, http.StatusPreconditionFailed, "PUT", key, strings.NewReader("v2"), http.Header{"If-None-Match": {"*"}})
+	if code := errorCode(t, body); code != codeConditionNotMet {
+		t.Fatalf("failed condition answered with %q", code)
+	}
+	expectStatus(t, http.StatusPreconditionFailed, "PUT", url+"/v1/keys/other", strings.NewReader("v"), http.Header{"If-Match": {"*"}})
+	expectStatus(t, http.StatusNotFound, "GET", url+"/v1/keys/other", nil, nil)
+	expectStatus(t, http.StatusNoContent, "PUT", key, strings.NewReader("v3"), http.Header{"If-Match": {"*"}})
+
+	expectStatus(t, http.StatusBadRequest, "PUT", key, strings.NewReader("v"), http.Header{TTLHeader: {"soon"}})
+	expectStatus(t, http.StatusBadRequest, "PUT", key, strings.NewReader("v"), http.Header{"If-Match": {"etag"}})
+	if body := expectStatus(t, http.StatusOK, "GET", key, nil, nil); body != "v3" {
+		t.Fatalf("GET after rejected writes = %q, want \"v3\"", body)
+	}
+
+	expectStatus(t, http.StatusNoContent, "DELETE", key, nil, nil)
+	body = expectStatus(t, http.StatusNotFound, "DELETE", key, nil, nil)
+	if code := errorCode(t, body); code != "INDEX_KEY_NOT_FOUND" {
+		t.Fatalf("DELETE of a missing key answered with %q", code)
+	}
+}
+
+func TestPutBodyLimits(t *testing.T) {
+	_, url := startServer(t, Config{MaxBodySize: 10})
+	key := url + "/v1/keys/k"
+
+	body := expectStatus(t, http.StatusRequestEntityTooLarge, "PUT", key, strings.NewReader(strings.Repeat("x", 11)), nil)
+	if code := errorCode(t, body); code != codeBodyTooLarge {
+		t.Fatalf("oversized body answered with %q", code)
+	}
+
+	// Without a length the value cannot be streamed.
+	chunked := io.MultiReader(strings.NewReader("x"))
+	body = expectStatus(t, http.StatusLengthRequired, "PUT", key, chunked, nil)
+	if code := errorCode(t, body); code != codeLengthRequired {
+		t.Fatalf("chunked body answered with %q", code)
+	}
+
+	expectStatus(t, http.StatusNoContent, "PUT", key, strings.NewReader(strings.Repeat("x", 10)), nil)
+	expectStatus(t, http.StatusNoContent, "PUT", url+"/v1/keys/empty", strings.NewReader(""), nil)
+	if body := expectStatus(t, http.StatusOK, "GET", url+"/v1/keys/empty", nil, nil); body != "" {
+		t.Fatalf("GET of an empty value = %q", body)
+	}
+}
+
+func TestPutTruncatedBodyStoresNothing(t *testing.T) {
+	_, url := startServer(t, Config{})
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(url, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	fmt.Fprintf(conn, "PUT /v1/keys/k HTTP/1.1\r\nHost: test\r\nContent-Length: 100\r\n\r\n%s", strings.Repeat("x", 10))
+	conn.(*net.TCPConn).CloseWrite()
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("PUT with a truncated body = %d, want 400", resp.StatusCode)
+	}
+	expectStatus(t, http.StatusNotFound, "GET", url+"/v1/keys/k", nil, nil)
+}
+
+func TestListPages(t *testing.T) {
+	_, url := startServer(t, Config{})
+	for i := range 25 {
+		expectStatus(t, http.StatusNoContent, "PUT", fmt.Sprintf("%s/v1/keys/user/%02d", url, i), strings.NewReader("v"), nil)
+	}
+	expectStatus(t, http.StatusNoContent, "PUT", url+"/v1/keys/other", strings.NewReader("v"), nil)
+
+	var keys []string
+	after := ""
+	for range 10 {
+		var page listResponse
+		body := expectStatus(t, http.StatusOK, "GET", url+"/v1/keys?prefix=user/&limit=10&after="+after, nil, nil)
+		if err := json.Unmarshal([]byte(body), &page); err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, page.Keys...)
+		if page.Next == "" {
+			break
+		}
+		after = page.Next
+	}
+	if len(keys) != 25 || keys[0] != "user/00" || keys[24] != "user/24" {
+		t.Fatalf("listed %v, want user/00 to user/24", keys)
+	}
+
+	expectStatus(t, http.StatusBadRequest, "GET", url+"/v1/keys?limit=0", nil, nil)
+}
+
+func TestTimeouts(t *testing.T) {
+	s, _ := startServer(t, Config{})
+	if s.http.ReadTimeout != DefaultReadTimeout || s.http.WriteTimeout != DefaultWriteTimeout || s.http.IdleTimeout != DefaultIdleTimeout {
+		t.Fatalf("default timeouts = %v, %v, %v", s.http.ReadTimeout, s.http.WriteTimeout, s.http.IdleTimeout)
+	}
+	if s.http.ReadHeaderTimeout != readHeaderTimeout {
+		t.Fatalf("ReadHeaderTimeout = %v, want %v", s.http.ReadHeaderTimeout, readHeaderTimeout)
+	}
+
+	s, _ = startServer(t, Config{ReadTimeout: time.Second, WriteTimeout: 2 * time.Second, IdleTimeout: 3 * time.Second})
+	if s.http.ReadTimeout != time.Second || s.http.WriteTimeout != 2*time.Second || s.http.IdleTimeout != 3*time.Second {
+		t.Fatalf("configured timeouts = %v, %v, %v", s.http.ReadTimeout, s.http.WriteTimeout, s.http.IdleTimeout)
+	}
+
+	if _, err := New(&Config{Instance: &ignite.Instance{}, Logger: zap.NewNop().Sugar(), IdleTimeout: -time.Second}); err == nil {
+		t.Fatal("New() accepted a negative idle timeout")
+	}
+}
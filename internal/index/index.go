@@ -15,6 +15,8 @@ package index
 import (
 	"context"
 	stdErrors "errors"
+	"maps"
+	"unsafe"
 
 	"github.com/iamNilotpal/ignite/pkg/errors"
 )
@@ -23,6 +25,16 @@ var (
 	ErrIndexClosed = stdErrors.New("operation failed: cannot access closed index")
 )
 
+const (
+	// recordPointerSize is the size of a RecordPointer, excluding its key.
+	recordPointerSize = int64(unsafe.Sizeof(RecordPointer{}))
+
+	// mapEntrySize approximates what one entry of the recordPointer map costs: the
+	// string header of the key, the pointer to the RecordPointer and the bucket's
+	// per-slot hash byte, spread over a load factor of about 80%.
+	mapEntrySize = 32
+)
+
 // New creates and initializes a new Index instance configured according to the
 // provided parameters. The returned Index is immediately ready for concurrent
 // use and includes optimizations like pre-allocated map capacity.
@@ -36,6 +48,9 @@ func New(ctx context.Context, config *Config) (*Index, error) {
 	return &Index{
 		log:           config.Logger,
 		dataDir:       config.DataDir,
+		segments:      newSegmentTable(),
+		snapshots:     make(map[*Snapshot]struct{}),
+		live:          make(map[uint16]int64),
 		recordPointer: make(map[string]*RecordPointer, 2046),
 	}, nil
 }
@@ -61,3 +76,155 @@ func (idx *Index) Close() error {
 	idx.log.Infow("Index system closed successfully")
 	return nil
 }
+
+// Put records the disk location of a key, replacing any previous pointer for the same key.
+func (idx *Index) Put(rp *RecordPointer) error {
+	if idx.closed.Load() {
+		return ErrIndexClosed
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.preserve(rp.Key)
+	if old, ok := idx.recordPointer[rp.Key]; ok {
+		idx.account(old, -1)
+	}
+	idx.recordPointer[rp.Key] = rp
+	idx.account(rp, 1)
+	return nil
+}
+
+// Get returns the record pointer for the given key, or a key-not-found error
+// when the key is not present in the index.
+func (idx *Index) Get(key string) (*RecordPointer, error) {
+	if idx.closed.Load() {
+		return nil, ErrIndexClosed
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	rp, ok := idx.recordPointer[key]
+	if !ok {
+		return nil, errors.NewKeyNotFoundError(key).WithIndexSize(len(idx.recordPointer))
+	}
+
+	return rp, nil
+}
+
+// Delete removes the given key from the index. Removing a key that does not
+// exist is not an error.
+func (idx *Index) Delete(key string) error {
+	if idx.closed.Load() {
+		return ErrIndexClosed
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.preserve(key)
+	if old, ok := idx.recordPointer[key]; ok {
+		idx.account(old, -1)
+		delete(idx.recordPointer, key)
+	}
+	return nil
+}
+
+// Clear removes every key from the index while keeping segment slot assignments.
+// It is used before rebuilding the index from scratch.
+func (idx *Index) Clear() error {
+	if idx.closed.Load() {
+		return ErrIndexClosed
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if len(idx.snapshots) > 0 {
+		for key := range idx.recordPointer {
+			idx.preserve(key)
+		}
+	}
+	clear(idx.recordPointer)
+	clear(idx.live)
+	idx.keyBytes = 0
+	return nil
+}
+
+// Len returns the number of keys currently tracked by the index.
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.recordPointer)
+}
+
+// AssignSegment returns the compact slot used in RecordPointer.SegmentID for the
+// given segment file ID, allocating a slot the first time the segment is seen.
+func (idx *Index) AssignSegment(fileID uint64) (uint16, error) {
+	if idx.closed.Load() {
+		return 0, ErrIndexClosed
+	}
+	return idx.segments.assign(fileID)
+}
+
+// ResolveSegment translates a RecordPointer segment slot back to the segment's
+// file ID. It returns an invalid segment ID error if the slot is not in use.
+func (idx *Index) ResolveSegment(slot uint16, key string) (uint64, error) {
+	if idx.closed.Load() {
+		return 0, ErrIndexClosed
+	}
+
+	fileID, ok := idx.segments.resolve(slot)
+	if !ok {
+		return 0, errors.NewSegmentIDError(slot, key)
+	}
+	return fileID, nil
+}
+
+// ReleaseSegment frees the slot held by a deleted segment so it can be reused.
+// It must only be called once no RecordPointer references the segment anymore.
+func (idx *Index) ReleaseSegment(fileID uint64) {
+	idx.segments.release(fileID)
+}
+
+// LiveBytes returns, for every segment file ID, the number of bytes taken by the
+// entries the index points at in that segment. The rest of a segment holds
+// overwritten values, tombstones and expired keys, which compaction can reclaim.
+// Segments without any live entry are left out.
+func (idx *Index) LiveBytes() map[uint64]int64 {
+	idx.mu.RLock()
+	slots := maps.Clone(idx.live)
+	idx.mu.RUnlock()
+
+	live := make(map[uint64]int64, len(slots))
+	for slot, bytes := range slots {
+		if fileID, ok := idx.segments.resolve(slot); ok {
+			live[fileID] += bytes
+		}
+	}
+	return live
+}
+
+// MemoryUsage estimates the bytes of memory held by the index: a RecordPointer and
+// a map entry per key, plus the key itself, which the map and the RecordPointer
+// share. Allocator rounding and map growth are not accounted for, so the estimate
+// is a lower bound.
+func (idx *Index) MemoryUsage() int64 {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return int64(len(idx.recordPointer))*(recordPointerSize+mapEntrySize) + idx.keyBytes
+}
+
+// account adds (sign 1) or removes (sign -1) a pointer to or from the byte counts.
+// The caller must hold the write lock.
+func (idx *Index) account(rp *RecordPointer, sign int64) {
+	idx.keyBytes += sign * int64(len(rp.Key))
+
+	live := idx.live[rp.SegmentID] + sign*int64(rp.EntrySize)
+	if live == 0 {
+		delete(idx.live, rp.SegmentID)
+		return
+	}
+	idx.live[rp.SegmentID] = live
+}
@@ -100,9 +100,12 @@ type RecordPointer struct {
 	// consume 250MB of memory just for segment identification, while segment IDs
 	// consume only 20MB for the same information.
 	//
-	// The uint16 range supports up to 65,535 distinct segments, which provides
-	// ample capacity for most real-world workloads while maintaining the compact
-	// memory footprint that makes this optimization valuable.
+	// The value is a slot in the index's segment table rather than the segment's
+	// file ID. File IDs grow without bound over the lifetime of a data directory,
+	// whereas slots are recycled when segments are deleted, so the uint16 range
+	// limits only the number of segments that are live at the same time (65,536)
+	// while maintaining the compact memory footprint that makes this optimization
+	// valuable. Use Index.ResolveSegment to translate a slot back to a file ID.
 	SegmentID uint16
 }
 
@@ -118,7 +121,11 @@ type Index struct {
 	dataDir       string                    // Contains the filesystem path where segment files are stored.
 	log           *zap.SugaredLogger        // Provides structured logging capabilities.
 	recordPointer map[string]*RecordPointer // Maintains the core mapping from keys to their disk locations.
-	mu            sync.RWMutex              // Protects concurrent access to the recordPointer map.
+	segments      *segmentTable             // Translates RecordPointer segment slots to segment file IDs.
+	snapshots     map[*Snapshot]struct{}    // Snapshots that still need to see the index as it was when taken.
+	live          map[uint16]int64          // Bytes of the entries the index points at, by segment slot.
+	keyBytes      int64                     // Total length of the keys in the index.
+	mu            sync.RWMutex              // Protects concurrent access to the recordPointer map, snapshots and byte counts.
 	closed        atomic.Bool               // Indicates whether the index has been closed.
 }
 
@@ -127,3 +134,21 @@ type Config struct {
 	DataDir string             // Specifies the filesystem directory containing segment files.
 	Logger  *zap.SugaredLogger // Provides structured logging capabilities for Index operations.
 }
+
+// Snapshot is a point-in-time view of the index. Rather than copying the whole index
+// when it is taken, a snapshot records the previous pointer of every key the index
+// changes afterwards, the first time it changes. Keys it has not recorded are read
+// from the live index, since they still hold the value they had when it was taken.
+// The cost of a snapshot is therefore proportional to the writes made while it is
+// held, not to the size of the index.
+type Snapshot struct {
+	idx   *Index                  // Index the snapshot was taken from.
+	saved map[string]savedPointer // Pointers as they were when taken, for keys changed since.
+}
+
+// savedPointer is the state of a key when a snapshot was taken. The segment file ID is
+// resolved up front, since the segment's slot may be reused once the segment is gone.
+type savedPointer struct {
+	rp     *RecordPointer // Pointer at snapshot time; nil if the key did not exist.
+	fileID uint64         // Segment file ID of rp.
+}
@@ -0,0 +1,63 @@
+package index
+
+import (
+	"container/heap"
+	"slices"
+)
+
+// Scan returns, in sorted order, up to limit keys that sort after the key after and
+// for which match returns true; a nil match selects every key. Passing the last key
+// of one page as after returns the next page, so a caller can page through the index
+// without holding it still: keys that exist throughout are returned exactly once.
+//
+// The index is a hash table, so every page takes a pass over all keys. Only the
+// limit smallest candidates are kept, in a heap, so a page costs O(n log limit) time
+// and O(limit) memory.
+func (idx *Index) Scan(after string, limit int, match func(key string) bool) ([]string, error) {
+	if idx.closed.Load() {
+		return nil, ErrIndexClosed
+	}
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	page := make(keyHeap, 0, min(limit, len(idx.recordPointer)))
+	for key := range idx.recordPointer {
+		if key <= after || (len(page) == limit && key >= page[0]) {
+			continue
+		}
+		if match != nil && !match(key) {
+			continue
+		}
+
+		if len(page) < limit {
+			heap.Push(&page, key)
+			continue
+		}
+		page[0] = key
+		heap.Fix(&page, 0)
+	}
+
+	keys := []string(page)
+	slices.Sort(keys)
+	return keys, nil
+}
+
+// keyHeap is a max-heap of keys, which keeps the largest of the selected keys at the
+// root so that it can be replaced by a smaller one.
+type keyHeap []string
+
+func (h keyHeap) Len() int           { return len(h) }
+func (h keyHeap) Less(i, j int) bool { return h[i] > h[j] }
+func (h keyHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *keyHeap) Push(x any)        { *h = append(*h, x.(string)) }
+
+func (h *keyHeap) Pop() any {
+	old := *h
+	key := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return key
+}
@@ -0,0 +1,101 @@
+package index
+
+import (
+	"math"
+	"sync"
+
+	"github.com/iamNilotpal/ignite/pkg/errors"
+)
+
+// segmentTable translates between the compact 16-bit segment slots stored in every
+// RecordPointer and the 64-bit segment file IDs used by storage.
+//
+// File IDs grow monotonically for the lifetime of a data directory and can never
+// wrap, while RecordPointer keeps its 2-byte SegmentID to preserve the memory
+// savings described on that field. The table bridges the two: each live segment
+// file is assigned a free slot, and the slot is returned to the free list once the
+// segment is deleted (for example by compaction), so 65,536 slots bound the number
+// of segments that are live at the same time rather than the number ever created.
+type segmentTable struct {
+	mu     sync.RWMutex      // Guards every field below.
+	files  []uint64          // Maps a slot to the file ID occupying it; only valid for used slots.
+	used   []bool            // Marks which slots are currently assigned.
+	slots  map[uint64]uint16 // Maps a file ID to its assigned slot.
+	free   []uint16          // Previously released slots available for reuse.
+	cursor int               // Next never-used slot.
+}
+
+// newSegmentTable creates an empty segment table.
+func newSegmentTable() *segmentTable {
+	return &segmentTable{
+		files: make([]uint64, math.MaxUint16+1),
+		used:  make([]bool, math.MaxUint16+1),
+		slots: make(map[uint64]uint16),
+	}
+}
+
+// assign returns the slot of the given file ID, allocating one if the segment has
+// not been seen before. Recycled slots are preferred over fresh ones.
+func (t *segmentTable) assign(fileID uint64) (uint16, error) {
+	t.mu.RLock()
+	slot, ok := t.slots[fileID]
+	t.mu.RUnlock()
+	if ok {
+		return slot, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if slot, ok := t.slots[fileID]; ok {
+		return slot, nil
+	}
+
+	switch {
+	case len(t.free) > 0:
+		slot = t.free[len(t.free)-1]
+		t.free = t.free[:len(t.free)-1]
+	case t.cursor <= math.MaxUint16:
+		slot = uint16(t.cursor)
+		t.cursor++
+	default:
+		return 0, errors.NewIndexError(
+			nil, errors.ErrorCodeIndexInvalidSegmentID, "no free segment slots left in the segment table",
+		).WithOperation("AssignSegment").
+			WithDetail("fileID", fileID).
+			WithDetail("liveSegments", len(t.slots)).
+			WithDetail("suggestion", "run compaction to reduce the number of live segments")
+	}
+
+	t.files[slot] = fileID
+	t.used[slot] = true
+	t.slots[fileID] = slot
+	return slot, nil
+}
+
+// resolve returns the file ID currently occupying a slot.
+func (t *segmentTable) resolve(slot uint16) (uint64, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if !t.used[slot] {
+		return 0, false
+	}
+	return t.files[slot], true
+}
+
+// release frees the slot of a deleted segment so that it can be reused. Callers
+// must have repointed or removed every RecordPointer that references the segment.
+func (t *segmentTable) release(fileID uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	slot, ok := t.slots[fileID]
+	if !ok {
+		return
+	}
+
+	delete(t.slots, fileID)
+	t.used[slot] = false
+	t.free = append(t.free, slot)
+}
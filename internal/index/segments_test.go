@@ -0,0 +1,87 @@
+package index
+
+import (
+	"math"
+	"testing"
+
+	"github.com/iamNilotpal/ignite/pkg/errors"
+)
+
+// mustAssign assigns a slot to fileID, failing the test on error.
+func mustAssign(t *testing.T, table *segmentTable, fileID uint64) uint16 {
+	t.Helper()
+
+	slot, err := table.assign(fileID)
+	if err != nil {
+		t.Fatalf("assign(%d) error = %v", fileID, err)
+	}
+	return slot
+}
+
+func TestSegmentTableAssignsStableSlots(t *testing.T) {
+	table := newSegmentTable()
+
+	first := mustAssign(t, table, 100_000)
+	second := mustAssign(t, table, 7)
+	if first == second {
+		t.Fatalf("two segments share slot %d", first)
+	}
+	if again := mustAssign(t, table, 100_000); again != first {
+		t.Fatalf("assign() of a known segment = slot %d, want %d", again, first)
+	}
+
+	if fileID, ok := table.resolve(first); !ok || fileID != 100_000 {
+		t.Fatalf("resolve(%d) = %d, %t", first, fileID, ok)
+	}
+	if _, ok := table.resolve(second + 1); ok {
+		t.Fatal("resolve() of an unused slot succeeded")
+	}
+}
+
+func TestSegmentTableReusesReleasedSlots(t *testing.T) {
+	table := newSegmentTable()
+
+	slots := make([]uint16, 3)
+	for i := range slots {
+		slots[i] = mustAssign(t, table, uint64(i+1))
+	}
+
+	table.release(2)
+	table.release(2) // Releasing twice is harmless.
+	if _, ok := table.resolve(slots[1]); ok {
+		t.Fatal("released slot still resolves")
+	}
+
+	// The next new segment takes the released slot rather than a fresh one.
+	if slot := mustAssign(t, table, 4); slot != slots[1] {
+		t.Fatalf("assign() after release = slot %d, want reused slot %d", slot, slots[1])
+	}
+	if fileID, ok := table.resolve(slots[1]); !ok || fileID != 4 {
+		t.Fatalf("resolve(%d) = %d, %t, want segment 4", slots[1], fileID, ok)
+	}
+	if slot := mustAssign(t, table, 5); slot != 3 {
+		t.Fatalf("assign() with no free slot = slot %d, want 3", slot)
+	}
+}
+
+func TestSegmentTableRunsOutOfSlots(t *testing.T) {
+	table := newSegmentTable()
+
+	// File IDs well past the 16-bit range each take one slot.
+	const base = 1 << 40
+	for i := range uint64(math.MaxUint16 + 1) {
+		mustAssign(t, table, base+i)
+	}
+
+	_, err := table.assign(base + math.MaxUint16 + 1)
+	if code := errors.GetErrorCode(err); code != errors.ErrorCodeIndexInvalidSegmentID {
+		t.Fatalf("assign() with every slot used error = %v, want code %q", err, errors.ErrorCodeIndexInvalidSegmentID)
+	}
+
+	// Releasing a segment makes room again.
+	table.release(base + 10)
+	slot := mustAssign(t, table, base+math.MaxUint16+1)
+	if fileID, _ := table.resolve(slot); fileID != base+math.MaxUint16+1 {
+		t.Fatalf("resolve(%d) = %d after reuse", slot, fileID)
+	}
+}
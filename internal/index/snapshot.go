@@ -0,0 +1,118 @@
+package index
+
+import (
+	"slices"
+
+	"github.com/iamNilotpal/ignite/pkg/errors"
+)
+
+// Snapshot captures the current state of the index. Until it is released, every
+// change to the index preserves the previous pointer of the changed key for the
+// snapshot, so the snapshot keeps resolving keys as they were when it was taken.
+// Snapshots must be released, or the pointers they preserve are held forever.
+func (idx *Index) Snapshot() (*Snapshot, error) {
+	if idx.closed.Load() {
+		return nil, ErrIndexClosed
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	snapshot := &Snapshot{idx: idx, saved: make(map[string]savedPointer)}
+	idx.snapshots[snapshot] = struct{}{}
+	return snapshot, nil
+}
+
+// Get returns the record pointer key had when the snapshot was taken, together with
+// the file ID of the segment it points into. It returns a key-not-found error if the
+// key did not exist at that time.
+func (s *Snapshot) Get(key string) (*RecordPointer, uint64, error) {
+	idx := s.idx
+	if idx.closed.Load() {
+		return nil, 0, ErrIndexClosed
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	if _, ok := idx.snapshots[s]; !ok {
+		return nil, 0, errors.NewIndexError(
+			nil, errors.ErrorCodeInvalidInput, "snapshot has been released",
+		).WithOperation("SnapshotGet").WithKey(key)
+	}
+
+	if saved, ok := s.saved[key]; ok {
+		if saved.rp == nil {
+			return nil, 0, errors.NewKeyNotFoundError(key)
+		}
+		return saved.rp, saved.fileID, nil
+	}
+
+	rp, ok := idx.recordPointer[key]
+	if !ok {
+		return nil, 0, errors.NewKeyNotFoundError(key)
+	}
+
+	fileID, ok := idx.segments.resolve(rp.SegmentID)
+	if !ok {
+		return nil, 0, errors.NewSegmentIDError(rp.SegmentID, key)
+	}
+	return rp, fileID, nil
+}
+
+// Keys returns every key that existed when the snapshot was taken, in sorted order.
+func (s *Snapshot) Keys() ([]string, error) {
+	idx := s.idx
+	if idx.closed.Load() {
+		return nil, ErrIndexClosed
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	keys := make([]string, 0, len(idx.recordPointer))
+	for key := range idx.recordPointer {
+		if _, changed := s.saved[key]; !changed {
+			keys = append(keys, key)
+		}
+	}
+	for key, saved := range s.saved {
+		if saved.rp != nil {
+			keys = append(keys, key)
+		}
+	}
+
+	slices.Sort(keys)
+	return keys, nil
+}
+
+// Release stops preserving pointers for the snapshot and frees the ones it holds.
+// Releasing a snapshot more than once is not an error.
+func (s *Snapshot) Release() {
+	idx := s.idx
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	delete(idx.snapshots, s)
+	s.saved = nil
+}
+
+// preserve records the current pointer of key in every snapshot that has not yet
+// recorded it, before the key is changed. The caller must hold the write lock.
+func (idx *Index) preserve(key string) {
+	if len(idx.snapshots) == 0 {
+		return
+	}
+
+	saved := savedPointer{rp: idx.recordPointer[key]}
+	if saved.rp != nil {
+		saved.fileID, _ = idx.segments.resolve(saved.rp.SegmentID)
+	}
+
+	for snapshot := range idx.snapshots {
+		if _, ok := snapshot.saved[key]; !ok {
+			snapshot.saved[key] = saved
+		}
+	}
+}
@@ -0,0 +1,559 @@
+// Package manifest maintains the MANIFEST file, the append-only log that is the source of
+// truth for which segment files belong to a data directory.
+//
+// Discovering segments by globbing the segment directory cannot tell a live segment apart
+// from a half-written compaction output left behind by a crash, nor notice a segment that
+// went missing. The manifest closes that gap: every segment lifecycle event (creation,
+// sealing, the swap of compaction inputs for outputs, and deletion) is appended and fsynced
+// before the change it describes is relied upon. On startup the log is replayed to rebuild
+// the set of live segments, and any segment file the manifest does not reference can be
+// garbage-collected safely.
+//
+// Record Format:
+//
+// Each record is framed as a little-endian CRC32 (IEEE) of the payload, followed by the
+// payload length and a JSON-encoded Record:
+//
+//	+----------+--------+-----------------+
+//	| Checksum | Length | Payload (JSON)  |
+//	| 4 bytes  | 4 bytes| Length bytes    |
+//	+----------+--------+-----------------+
+//
+// A record that is truncated or fails its checksum can only be the result of a torn
+// append, since records are never modified in place. Replay stops there and the tail is
+// truncated, which makes every append atomic from the point of view of recovery.
+//
+// A manifest opened read-only never touches the file. It is meant for processes that share
+// a data directory with a live writer: a torn tail is then most likely an append still in
+// progress, so replay simply stops before it, and Reload picks up records appended since.
+//
+// To keep the log from growing forever, Rewrite replaces it with a minimal snapshot of the
+// current state. The snapshot is written to a temporary file, fsynced and renamed over the
+// manifest, so a crash leaves either the old or the new manifest in place.
+package manifest
+
+import (
+	"cmp"
+	"encoding/binary"
+	"encoding/json"
+	stdErrors "errors"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/iamNilotpal/ignite/pkg/errors"
+)
+
+const (
+	// FileName is the name of the manifest file inside its directory.
+	FileName = "MANIFEST"
+
+	// tempFileName is the name used while a rewritten manifest is being prepared.
+	tempFileName = "MANIFEST.tmp"
+
+	// frameHeaderSize is the size of the checksum and length prefix of every record.
+	frameHeaderSize = 8
+)
+
+var (
+	ErrManifestClosed   = stdErrors.New("operation failed: cannot access closed manifest")
+	ErrManifestReadOnly = stdErrors.New("operation failed: manifest is opened read-only")
+)
+
+// Open opens the manifest in the configured directory, creating an empty one if
+// none exists, and replays it to rebuild the set of live segments. A read-only
+// manifest that does not exist yet is treated as empty and is not created.
+func Open(config *Config) (*Manifest, error) {
+	if config == nil || config.Dir == "" || config.Logger == nil {
+		return nil, errors.NewValidationError(
+			nil, errors.ErrorCodeInvalidInput, "Manifest configuration is required",
+		).WithField("config").WithRule("required").WithProvided(config)
+	}
+
+	m := &Manifest{
+		log:      config.Logger,
+		path:     filepath.Join(config.Dir, FileName),
+		live:     make(map[uint64]Segment),
+		obsolete: make(map[uint64]Segment),
+		readOnly: config.ReadOnly,
+	}
+
+	if m.readOnly {
+		if err := m.Reload(); err != nil {
+			return nil, err
+		}
+
+		m.log.Infow(
+			"Manifest opened read-only",
+			"path", m.path,
+			"exists", !m.created,
+			"liveSegments", len(m.live),
+		)
+		return m, nil
+	}
+
+	// A leftover temporary file means a rewrite was interrupted before the rename,
+	// so the original manifest is still authoritative.
+	if err := os.Remove(filepath.Join(config.Dir, tempFileName)); err != nil && !os.IsNotExist(err) {
+		return nil, errors.NewFileAccessError(
+			filepath.Join(config.Dir, tempFileName), tempFileName, "manifest_temp_cleanup", err,
+		)
+	}
+
+	data, err := os.ReadFile(m.path)
+	switch {
+	case os.IsNotExist(err):
+		m.created = true
+	case err != nil:
+		return nil, errors.NewFileAccessError(m.path, FileName, "manifest_read", err)
+	}
+
+	validSize, err := m.replay(data)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(m.path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, errors.ClassifyFileOpenError(err, m.path, FileName)
+	}
+
+	if m.created {
+		if err := syncDir(config.Dir); err != nil {
+			file.Close()
+			return nil, errors.NewFileAccessError(config.Dir, "", "manifest_dir_sync", err)
+		}
+	}
+
+	if validSize < int64(len(data)) {
+		m.log.Warnw(
+			"Truncating torn record at the end of the manifest",
+			"path", m.path,
+			"validSize", validSize,
+			"fileSize", len(data),
+		)
+
+		if err := file.Truncate(validSize); err != nil {
+			file.Close()
+			return nil, errors.NewStorageError(
+				err, errors.ErrorCodeRecoveryFailed, "Failed to truncate torn manifest record",
+			).WithPath(m.path).WithFileName(FileName).WithOffset(int(validSize))
+		}
+	}
+
+	m.file = file
+
+	m.log.Infow(
+		"Manifest opened",
+		"path", m.path,
+		"created", m.created,
+		"liveSegments", len(m.live),
+		"obsoleteSegments", len(m.obsolete),
+	)
+
+	return m, nil
+}
+
+// Reload discards the in-memory state and replays the manifest file again, picking up
+// records appended by another process since the last read. It is only useful for
+// read-only manifests, since a writable manifest is always up to date.
+func (m *Manifest) Reload() error {
+	if m.closed.Load() {
+		return ErrManifestClosed
+	}
+
+	data, err := os.ReadFile(m.path)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.NewFileAccessError(m.path, FileName, "manifest_read", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.created = os.IsNotExist(err)
+	clear(m.live)
+	clear(m.obsolete)
+
+	_, err = m.replay(data)
+	return err
+}
+
+// Created reports whether the manifest did not exist before it was opened. Callers use
+// this to bootstrap the manifest from segment files written by older versions.
+func (m *Manifest) Created() bool {
+	return m.created
+}
+
+// Segments returns every live segment ordered by ascending ID.
+func (m *Manifest) Segments() []Segment {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return sortedSegments(m.live)
+}
+
+// Obsolete returns compaction inputs that are no longer live but whose files have not
+// been deleted yet, ordered by ascending ID.
+func (m *Manifest) Obsolete() []Segment {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return sortedSegments(m.obsolete)
+}
+
+// References reports whether the named file belongs to a live or obsolete segment.
+func (m *Manifest) References(fileName string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, set := range []map[uint64]Segment{m.live, m.obsolete} {
+		for _, segment := range set {
+			if segment.File == fileName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// LogCreate records that a new, writable segment was created.
+func (m *Manifest) LogCreate(id uint64, fileName string) error {
+	return m.append(&Record{Op: OpCreate, Segment: &Segment{ID: id, File: fileName}})
+}
+
+// LogSeal records that a segment became immutable at the given size.
+func (m *Manifest) LogSeal(id uint64, size int64) error {
+	return m.append(&Record{Op: OpSeal, Segment: &Segment{ID: id, Size: size, Sealed: true}})
+}
+
+// LogCompaction atomically replaces the input segments with the output segments.
+// Output files must be fully written and fsynced before this is called; until the
+// record is durable they are unreferenced and would be garbage-collected on recovery.
+func (m *Manifest) LogCompaction(inputs []uint64, outputs []Segment) error {
+	return m.append(&Record{Op: OpCompact, Inputs: inputs, Outputs: outputs})
+}
+
+// LogDelete records that a segment file was removed from disk.
+func (m *Manifest) LogDelete(id uint64) error {
+	return m.append(&Record{Op: OpDelete, Segment: &Segment{ID: id}})
+}
+
+// Replace swaps the file of a live segment for another file holding the same
+// segment ID, such as a repaired copy, and rewrites the manifest so that the swap is
+// atomic. The segment keeps its place in the replay order.
+func (m *Manifest) Replace(segment Segment) error {
+	if m.closed.Load() {
+		return ErrManifestClosed
+	}
+	if m.readOnly {
+		return ErrManifestReadOnly
+	}
+
+	m.mu.Lock()
+	previous, ok := m.live[segment.ID]
+	if !ok {
+		m.mu.Unlock()
+		return errors.NewValidationError(
+			nil, errors.ErrorCodeInvalidInput, "segment to replace is not live",
+		).WithField("segment.id").WithRule("live").WithProvided(segment.ID)
+	}
+	m.live[segment.ID] = segment
+	m.mu.Unlock()
+
+	if err := m.Rewrite(); err != nil {
+		m.mu.Lock()
+		m.live[segment.ID] = previous
+		m.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Rewrite replaces the manifest with a minimal snapshot of the current state, dropping
+// the history of segments that no longer exist.
+func (m *Manifest) Rewrite() error {
+	if m.closed.Load() {
+		return ErrManifestClosed
+	}
+	if m.readOnly {
+		return ErrManifestReadOnly
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().UnixNano()
+
+	buf, err := Encode(sortedSegments(m.live))
+	if err != nil {
+		return err
+	}
+
+	// Obsolete segments are re-created and immediately compacted away so that their
+	// filenames survive the rewrite and their deletion can still be completed.
+	obsolete := sortedSegments(m.obsolete)
+	if len(obsolete) > 0 {
+		inputs := make([]uint64, 0, len(obsolete))
+		for _, segment := range obsolete {
+			record := &Record{Op: OpCreate, Timestamp: now, Segment: &segment}
+			frame, err := encodeRecord(record)
+			if err != nil {
+				return err
+			}
+			buf = append(buf, frame...)
+			inputs = append(inputs, segment.ID)
+		}
+
+		frame, err := encodeRecord(&Record{Op: OpCompact, Timestamp: now, Inputs: inputs})
+		if err != nil {
+			return err
+		}
+		buf = append(buf, frame...)
+	}
+
+	dir := filepath.Dir(m.path)
+	tempPath := filepath.Join(dir, tempFileName)
+
+	if err := writeFileSync(tempPath, buf); err != nil {
+		return errors.NewFileAccessError(tempPath, tempFileName, "manifest_rewrite", err)
+	}
+
+	if err := os.Rename(tempPath, m.path); err != nil {
+		return errors.NewFileAccessError(m.path, FileName, "manifest_rename", err)
+	}
+
+	if err := syncDir(dir); err != nil {
+		return errors.NewFileAccessError(dir, "", "manifest_dir_sync", err)
+	}
+
+	file, err := os.OpenFile(m.path, os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return errors.ClassifyFileOpenError(err, m.path, FileName)
+	}
+
+	previous := m.file
+	m.file = file
+	if err := previous.Close(); err != nil {
+		m.log.Warnw("Failed to close previous manifest handle", "error", err)
+	}
+
+	m.log.Infow("Manifest rewritten", "path", m.path, "size", len(buf), "liveSegments", len(m.live))
+	return nil
+}
+
+// Encode returns the contents of a manifest in which exactly the given segments are
+// live, for example to accompany a copy of those segments in a backup.
+func Encode(segments []Segment) ([]byte, error) {
+	var buf []byte
+	now := time.Now().UnixNano()
+
+	for _, segment := range segments {
+		record := &Record{Op: OpCreate, Timestamp: now, Segment: &segment}
+		frame, err := encodeRecord(record)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, frame...)
+	}
+	return buf, nil
+}
+
+// Decode replays the contents of a manifest file and returns its live segments,
+// ordered by ascending ID. Unlike Open, it does not tolerate a torn tail, since it is
+// meant for complete files such as the manifest of a backup.
+func Decode(data []byte) ([]Segment, error) {
+	m := &Manifest{path: FileName, live: make(map[uint64]Segment), obsolete: make(map[uint64]Segment)}
+
+	validSize, err := m.replay(data)
+	if err != nil {
+		return nil, err
+	}
+	if validSize != int64(len(data)) {
+		return nil, errors.NewStorageError(
+			nil, errors.ErrorCodeRecoveryFailed, "Manifest ends with a damaged record",
+		).WithFileName(FileName).WithOffset(int(validSize))
+	}
+
+	return sortedSegments(m.live), nil
+}
+
+// Close releases the manifest file handle.
+func (m *Manifest) Close() error {
+	if !m.closed.CompareAndSwap(false, true) {
+		return ErrManifestClosed
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.file == nil {
+		return nil
+	}
+
+	if err := m.file.Close(); err != nil {
+		return errors.NewFileAccessError(m.path, FileName, "manifest_close", err)
+	}
+	return nil
+}
+
+// append durably writes a record and applies it to the in-memory state.
+func (m *Manifest) append(record *Record) error {
+	if m.closed.Load() {
+		return ErrManifestClosed
+	}
+	if m.readOnly {
+		return ErrManifestReadOnly
+	}
+
+	record.Timestamp = time.Now().UnixNano()
+	frame, err := encodeRecord(record)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err := m.file.Write(frame); err != nil {
+		return errors.NewStorageError(err, errors.ErrorCodeIO, "Failed to append manifest record").
+			WithPath(m.path).
+			WithFileName(FileName).
+			WithDetail("operation", "manifest_append").
+			WithDetail("op", record.Op)
+	}
+
+	if err := m.file.Sync(); err != nil {
+		return errors.ClassifySyncError(err, FileName, m.path, 0)
+	}
+
+	m.apply(record)
+	return nil
+}
+
+// replay applies every valid record in data and returns the number of bytes that
+// were decoded successfully.
+func (m *Manifest) replay(data []byte) (int64, error) {
+	var offset int
+	for offset+frameHeaderSize <= len(data) {
+		checksum := binary.LittleEndian.Uint32(data[offset : offset+4])
+		length := int(binary.LittleEndian.Uint32(data[offset+4 : offset+8]))
+
+		end := offset + frameHeaderSize + length
+		if end > len(data) {
+			break
+		}
+
+		payload := data[offset+frameHeaderSize : end]
+		if crc32.ChecksumIEEE(payload) != checksum {
+			break
+		}
+
+		var record Record
+		if err := json.Unmarshal(payload, &record); err != nil {
+			return 0, errors.NewStorageError(
+				err, errors.ErrorCodeRecoveryFailed, "Manifest record has a valid checksum but cannot be decoded",
+			).WithPath(m.path).
+				WithFileName(FileName).
+				WithOffset(offset)
+		}
+
+		m.apply(&record)
+		offset = end
+	}
+
+	return int64(offset), nil
+}
+
+// apply updates the in-memory state to reflect a record.
+func (m *Manifest) apply(record *Record) {
+	switch record.Op {
+	case OpCreate:
+		if record.Segment != nil {
+			m.live[record.Segment.ID] = *record.Segment
+		}
+
+	case OpSeal:
+		if record.Segment != nil {
+			if segment, ok := m.live[record.Segment.ID]; ok {
+				segment.Sealed = true
+				segment.Size = record.Segment.Size
+				m.live[segment.ID] = segment
+			}
+		}
+
+	case OpCompact:
+		for _, output := range record.Outputs {
+			output.Sealed = true
+			m.live[output.ID] = output
+		}
+		for _, input := range record.Inputs {
+			if segment, ok := m.live[input]; ok {
+				delete(m.live, input)
+				m.obsolete[input] = segment
+			}
+		}
+
+	case OpDelete:
+		if record.Segment != nil {
+			delete(m.live, record.Segment.ID)
+			delete(m.obsolete, record.Segment.ID)
+		}
+	}
+}
+
+// encodeRecord frames a record for the manifest file.
+func encodeRecord(record *Record) ([]byte, error) {
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return nil, errors.NewStorageError(err, errors.ErrorCodeInternal, "Failed to encode manifest record").
+			WithDetail("op", record.Op)
+	}
+
+	frame := make([]byte, frameHeaderSize+len(payload))
+	binary.LittleEndian.PutUint32(frame[0:4], crc32.ChecksumIEEE(payload))
+	binary.LittleEndian.PutUint32(frame[4:8], uint32(len(payload)))
+	copy(frame[frameHeaderSize:], payload)
+	return frame, nil
+}
+
+// sortedSegments returns the values of a segment set ordered by ascending ID.
+func sortedSegments(set map[uint64]Segment) []Segment {
+	segments := make([]Segment, 0, len(set))
+	for _, segment := range set {
+		segments = append(segments, segment)
+	}
+	slices.SortFunc(segments, func(a, b Segment) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return segments
+}
+
+// writeFileSync writes data to a new file at path and fsyncs it before closing.
+func writeFileSync(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// syncDir fsyncs a directory so that renames and file creations inside it are durable.
+func syncDir(dir string) error {
+	file, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return file.Sync()
+}
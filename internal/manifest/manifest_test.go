@@ -0,0 +1,268 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/iamNilotpal/ignite/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// openManifest opens the manifest in dir and closes it when the test ends.
+func openManifest(t *testing.T, dir string, readOnly bool) *Manifest {
+	t.Helper()
+
+	m, err := Open(&Config{Dir: dir, ReadOnly: readOnly, Logger: zap.NewNop().Sugar()})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { m.Close() })
+	return m
+}
+
+// closeManifest closes m, failing the test on error.
+func closeManifest(t *testing.T, m *Manifest) {
+	t.Helper()
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+}
+
+// logHistory appends a create, seal, compaction and delete sequence to m: segments 1
+// and 2 are compacted into 3, segment 1 is deleted and segment 4 is active.
+func logHistory(t *testing.T, m *Manifest) {
+	t.Helper()
+
+	steps := []func() error{
+		func() error { return m.LogCreate(1, "seg-00001.seg") },
+		func() error { return m.LogSeal(1, 100) },
+		func() error { return m.LogCreate(2, "seg-00002.seg") },
+		func() error { return m.LogSeal(2, 200) },
+		func() error { return m.LogCreate(4, "seg-00004.seg") },
+		func() error {
+			return m.LogCompaction([]uint64{1, 2}, []Segment{{ID: 3, File: "seg-00003.seg", Size: 150}})
+		},
+		func() error { return m.LogDelete(1) },
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("step %d error = %v", i, err)
+		}
+	}
+}
+
+// wantLive and wantObsolete are the state logHistory leaves behind.
+var (
+	wantLive = []Segment{
+		{ID: 3, File: "seg-00003.seg", Sealed: true, Size: 150},
+		{ID: 4, File: "seg-00004.seg"},
+	}
+	wantObsolete = []Segment{{ID: 2, File: "seg-00002.seg", Sealed: true, Size: 200}}
+)
+
+// expectState fails the test if m does not hold the given live and obsolete segments.
+func expectState(t *testing.T, m *Manifest, live, obsolete []Segment) {
+	t.Helper()
+
+	if got := m.Segments(); !slices.Equal(got, live) {
+		t.Fatalf("Segments() = %+v, want %+v", got, live)
+	}
+	if got := m.Obsolete(); !slices.Equal(got, obsolete) {
+		t.Fatalf("Obsolete() = %+v, want %+v", got, obsolete)
+	}
+}
+
+// fileSize returns the size of the manifest in dir.
+func fileSize(t *testing.T, dir string) int64 {
+	t.Helper()
+
+	info, err := os.Stat(filepath.Join(dir, FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info.Size()
+}
+
+// appendBytes appends raw bytes to the manifest in dir.
+func appendBytes(t *testing.T, dir string, data []byte) {
+	t.Helper()
+
+	file, err := os.OpenFile(filepath.Join(dir, FileName), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := file.Write(data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	dir := t.TempDir()
+
+	m := openManifest(t, dir, false)
+	if !m.Created() {
+		t.Fatal("Created() = false for a new manifest")
+	}
+	logHistory(t, m)
+	expectState(t, m, wantLive, wantObsolete)
+	closeManifest(t, m)
+
+	m = openManifest(t, dir, false)
+	if m.Created() {
+		t.Fatal("Created() = true for an existing manifest")
+	}
+	expectState(t, m, wantLive, wantObsolete)
+
+	if !m.References("seg-00002.seg") || !m.References("seg-00004.seg") {
+		t.Fatal("References() = false for a live or obsolete segment")
+	}
+	if m.References("seg-00001.seg") {
+		t.Fatal("References() = true for a deleted segment")
+	}
+}
+
+func TestReplayStopsAtDamagedTail(t *testing.T) {
+	record, err := encodeRecord(&Record{Op: OpCreate, Segment: &Segment{ID: 9, File: "seg-00009.seg"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	badChecksum := slices.Clone(record)
+	badChecksum[len(badChecksum)-2] ^= 0xff
+
+	tails := map[string][]byte{
+		"torn header":  record[:frameHeaderSize-3],
+		"torn payload": record[:len(record)-5],
+		"bad checksum": badChecksum,
+	}
+	for name, tail := range tails {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			m := openManifest(t, dir, false)
+			logHistory(t, m)
+			closeManifest(t, m)
+
+			size := fileSize(t, dir)
+			appendBytes(t, dir, tail)
+
+			m = openManifest(t, dir, false)
+			expectState(t, m, wantLive, wantObsolete)
+			if got := fileSize(t, dir); got != size {
+				t.Fatalf("manifest size = %d after Open, want the tail truncated to %d", got, size)
+			}
+
+			// Appends land after the last valid record.
+			if err := m.LogSeal(4, 400); err != nil {
+				t.Fatal(err)
+			}
+			closeManifest(t, m)
+
+			m = openManifest(t, dir, false)
+			live := slices.Clone(wantLive)
+			live[1].Sealed, live[1].Size = true, 400
+			expectState(t, m, live, wantObsolete)
+		})
+	}
+}
+
+func TestReadOnlyLeavesDamagedTail(t *testing.T) {
+	dir := t.TempDir()
+
+	m := openManifest(t, dir, false)
+	logHistory(t, m)
+	closeManifest(t, m)
+
+	appendBytes(t, dir, []byte{1, 2, 3})
+	size := fileSize(t, dir)
+
+	ro := openManifest(t, dir, true)
+	expectState(t, ro, wantLive, wantObsolete)
+	if got := fileSize(t, dir); got != size {
+		t.Fatalf("manifest size = %d after a read-only Open, want %d", got, size)
+	}
+	if err := ro.LogDelete(2); err != ErrManifestReadOnly {
+		t.Fatalf("LogDelete() error = %v, want %v", err, ErrManifestReadOnly)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	dir := t.TempDir()
+
+	m := openManifest(t, dir, false)
+	logHistory(t, m)
+	size := fileSize(t, dir)
+
+	if err := m.Rewrite(); err != nil {
+		t.Fatalf("Rewrite() error = %v", err)
+	}
+	if got := fileSize(t, dir); got >= size {
+		t.Fatalf("manifest size = %d after Rewrite, want less than %d", got, size)
+	}
+	if _, err := os.Stat(filepath.Join(dir, tempFileName)); !os.IsNotExist(err) {
+		t.Fatalf("%s left behind after Rewrite: %v", tempFileName, err)
+	}
+	expectState(t, m, wantLive, wantObsolete)
+
+	// The rewritten manifest keeps accepting appends, including the deletion of the
+	// obsolete segment it carried over.
+	if err := m.LogDelete(2); err != nil {
+		t.Fatal(err)
+	}
+	closeManifest(t, m)
+
+	m = openManifest(t, dir, false)
+	expectState(t, m, wantLive, []Segment{})
+}
+
+func TestOpenIgnoresInterruptedRewrite(t *testing.T) {
+	dir := t.TempDir()
+
+	m := openManifest(t, dir, false)
+	logHistory(t, m)
+	closeManifest(t, m)
+
+	// A rewrite that crashed before its rename leaves a temporary file with a state
+	// that never became authoritative.
+	partial, err := Encode([]Segment{{ID: 7, File: "seg-00007.seg"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tempPath := filepath.Join(dir, tempFileName)
+	if err := os.WriteFile(tempPath, partial, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m = openManifest(t, dir, false)
+	expectState(t, m, wantLive, wantObsolete)
+	if _, err := os.Stat(tempPath); !os.IsNotExist(err) {
+		t.Fatalf("%s not removed by Open: %v", tempFileName, err)
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	segments := []Segment{
+		{ID: 1, File: "seg-00001.seg", Sealed: true, Size: 100},
+		{ID: 2, File: "seg-00002.seg"},
+	}
+
+	data, err := Encode(segments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Decode(data)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if !slices.Equal(got, segments) {
+		t.Fatalf("Decode() = %+v, want %+v", got, segments)
+	}
+
+	// Unlike Open, Decode rejects a file whose last record is damaged.
+	_, err = Decode(data[:len(data)-1])
+	if se, ok := errors.AsStorageError(err); !ok || se.Code() != errors.ErrorCodeRecoveryFailed {
+		t.Fatalf("Decode() of a torn manifest error = %v, want %s", err, errors.ErrorCodeRecoveryFailed)
+	}
+}
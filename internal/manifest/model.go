@@ -0,0 +1,66 @@
+package manifest
+
+import (
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+)
+
+// Op identifies the kind of change a manifest record describes.
+type Op string
+
+const (
+	// OpCreate records that a new segment file was created and is live.
+	OpCreate Op = "create"
+
+	// OpSeal records that a segment stopped accepting writes and became immutable.
+	OpSeal Op = "seal"
+
+	// OpCompact atomically swaps a set of input segments for a set of output
+	// segments. Outputs become live and sealed; inputs become obsolete and are
+	// deleted afterwards.
+	OpCompact Op = "compact"
+
+	// OpDelete records that a segment file was removed from disk.
+	OpDelete Op = "delete"
+)
+
+// Segment describes a single segment file as tracked by the manifest.
+type Segment struct {
+	ID     uint64 `json:"id"`             // Segment file ID.
+	File   string `json:"file"`           // Filename within the segment directory.
+	Sealed bool   `json:"sealed"`         // Whether the segment is immutable.
+	Size   int64  `json:"size,omitempty"` // Final size in bytes, known once sealed.
+}
+
+// Record is a single entry of the append-only manifest log.
+type Record struct {
+	Op        Op        `json:"op"`                // Kind of change.
+	Timestamp int64     `json:"ts"`                // Unix nanosecond time the record was written.
+	Segment   *Segment  `json:"segment,omitempty"` // Segment affected by create, seal and delete.
+	Inputs    []uint64  `json:"inputs,omitempty"`  // Segments consumed by a compaction.
+	Outputs   []Segment `json:"outputs,omitempty"` // Segments produced by a compaction.
+}
+
+// Manifest is the append-only log of segment lifecycle events and the source of
+// truth for which segment files are part of the store.
+type Manifest struct {
+	path     string             // Full path of the manifest file.
+	file     *os.File           // Open handle used to append records.
+	live     map[uint64]Segment // Segments currently part of the store.
+	obsolete map[uint64]Segment // Compaction inputs that still need to be deleted from disk.
+	created  bool               // Whether the manifest file did not exist before Open.
+	readOnly bool               // Whether the manifest is only read, never written.
+	mu       sync.Mutex         // Serializes appends and guards the in-memory state.
+	closed   atomic.Bool        // Whether the manifest has been closed.
+	log      *zap.SugaredLogger // Structured logger.
+}
+
+// Config encapsulates the parameters required to open a Manifest.
+type Config struct {
+	Dir      string             // Directory holding the MANIFEST file.
+	ReadOnly bool               // Open without creating, truncating or appending to the file.
+	Logger   *zap.SugaredLogger // Structured logger.
+}
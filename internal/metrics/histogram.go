@@ -0,0 +1,60 @@
+// Package metrics provides the instruments ignite keeps about itself and writes them
+// in the Prometheus text exposition format. It deliberately has no dependency on the
+// Prometheus client library: the store only needs counters, gauges sampled at scrape
+// time, and latency histograms, all of which are a few atomics each.
+package metrics
+
+import (
+	"slices"
+	"sync/atomic"
+	"time"
+)
+
+// LatencyBuckets are the default histogram bounds in seconds. They span from ten
+// microseconds, a cached read, to ten seconds, an fsync on a saturated disk.
+var LatencyBuckets = []float64{
+	0.00001, 0.000025, 0.00005, 0.0001, 0.00025, 0.0005,
+	0.001, 0.0025, 0.005, 0.01, 0.025, 0.05,
+	0.1, 0.25, 0.5, 1, 2.5, 5, 10,
+}
+
+// NewHistogram creates a histogram with the given upper bounds in seconds, which must
+// be sorted in ascending order. A +Inf bucket is always added.
+func NewHistogram(bounds []float64) *Histogram {
+	return &Histogram{
+		bounds: slices.Clone(bounds),
+		counts: make([]atomic.Uint64, len(bounds)+1),
+	}
+}
+
+// Observe records one duration.
+func (h *Histogram) Observe(d time.Duration) {
+	i, _ := slices.BinarySearch(h.bounds, d.Seconds())
+	h.counts[i].Add(1)
+	h.sum.Add(uint64(max(d, 0)))
+}
+
+// Since records the time elapsed since start. It is meant to be deferred:
+//
+//	defer h.Since(time.Now())
+func (h *Histogram) Since(start time.Time) {
+	h.Observe(time.Since(start))
+}
+
+// Snapshot returns the current state of the histogram with cumulative bucket counts.
+func (h *Histogram) Snapshot() HistogramSnapshot {
+	snapshot := HistogramSnapshot{
+		Bounds:     h.bounds,
+		Cumulative: make([]uint64, len(h.counts)),
+	}
+
+	var total uint64
+	for i := range h.counts {
+		total += h.counts[i].Load()
+		snapshot.Cumulative[i] = total
+	}
+
+	snapshot.Count = total
+	snapshot.Sum = time.Duration(h.sum.Load()).Seconds()
+	return snapshot
+}
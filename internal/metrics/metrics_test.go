@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// failingWriter fails every write.
+type failingWriter struct{ calls int }
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.calls++
+	return 0, errors.New("disk full")
+}
+
+func TestHistogramBuckets(t *testing.T) {
+	h := NewHistogram([]float64{0.001, 0.01, 0.1})
+	for _, d := range []time.Duration{
+		500 * time.Microsecond, time.Millisecond, 5 * time.Millisecond, 50 * time.Millisecond, time.Second,
+	} {
+		h.Observe(d)
+	}
+
+	s := h.Snapshot()
+	// An observation equal to a bound falls into that bound's bucket.
+	want := []uint64{2, 3, 4, 5}
+	for i, count := range want {
+		if s.Cumulative[i] != count {
+			t.Fatalf("Cumulative = %v, want %v", s.Cumulative, want)
+		}
+	}
+	if s.Count != 5 {
+		t.Fatalf("Count = %d, want 5", s.Count)
+	}
+	if sum := 1.0565; math.Abs(s.Sum-sum) > 1e-9 {
+		t.Fatalf("Sum = %v, want %v", s.Sum, sum)
+	}
+}
+
+func TestWriterFormat(t *testing.T) {
+	h := NewHistogram([]float64{0.5, 1})
+	h.Observe(250 * time.Millisecond)
+	h.Observe(2 * time.Second)
+
+	var b strings.Builder
+	w := NewWriter(&b)
+	w.Counter("ops_total", "Calls.\nAll of them, with a \\.", 7)
+	w.Gauge("ratio", "A share.", 0.25)
+	w.Family("errors_total", "Errors by code.", TypeCounter)
+	w.Sample("errors_total", 2, Label{Name: "code", Value: `say "no"`})
+	w.Family("latency_seconds", "Latency.", TypeHistogram)
+	w.Histogram("latency_seconds", h.Snapshot(), Label{Name: "op", Value: "get"})
+	w.Gauge("inf", "Infinite.", math.Inf(1))
+
+	n, err := w.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# HELP ops_total Calls.\nAll of them, with a \\.
+# TYPE ops_total counter
+ops_total 7
+# HELP ratio A share.
+# TYPE ratio gauge
+ratio 0.25
+# HELP errors_total Errors by code.
+# TYPE errors_total counter
+errors_total{code="say \"no\""} 2
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{op="get",le="0.5"} 1
+latency_seconds_bucket{op="get",le="1"} 1
+latency_seconds_bucket{op="get",le="+Inf"} 2
+latency_seconds_sum{op="get"} 2.25
+latency_seconds_count{op="get"} 2
+# HELP inf Infinite.
+# TYPE inf gauge
+inf +Inf
+`
+	if got := b.String(); got != want {
+		t.Fatalf("exposition =\n%s\nwant\n%s", got, want)
+	}
+	if n != int64(len(want)) {
+		t.Fatalf("Flush() = %d bytes, want %d", n, len(want))
+	}
+}
+
+func TestWriterKeepsFirstError(t *testing.T) {
+	fw := &failingWriter{}
+	w := NewWriter(fw)
+
+	// Enough output to overflow the buffer more than once.
+	for range 1000 {
+		w.Counter("ops_total", strings.Repeat("x", 100), 1)
+	}
+	if _, err := w.Flush(); err == nil || err.Error() != "disk full" {
+		t.Fatalf("Flush() error = %v, want the write error", err)
+	}
+	if fw.calls != 1 {
+		t.Fatalf("the destination was written %d times after failing", fw.calls)
+	}
+}
@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"bufio"
+	"sync/atomic"
+)
+
+// Histogram counts observed durations in fixed buckets, like a Prometheus histogram.
+// Every counter is updated atomically, so Observe never takes a lock and is safe to
+// call from hot paths concurrently with Snapshot.
+type Histogram struct {
+	bounds []float64       // Upper bounds of the buckets in seconds, ascending; +Inf is implied.
+	counts []atomic.Uint64 // Observations per bucket, not cumulative; the last one is +Inf.
+	sum    atomic.Uint64   // Sum of all observations in nanoseconds.
+}
+
+// HistogramSnapshot is a copy of a Histogram for exposition. The buckets are read one
+// after another, so observations made meanwhile may be only partly reflected, but the
+// count always matches the +Inf bucket. The sum is read last and may include a few
+// observations that the buckets do not.
+type HistogramSnapshot struct {
+	Bounds     []float64 // Upper bounds of the buckets in seconds, without +Inf.
+	Cumulative []uint64  // Observations at or below each bound, then the +Inf bucket.
+	Count      uint64    // Total number of observations, equal to the +Inf bucket.
+	Sum        float64   // Sum of all observations in seconds.
+}
+
+// Label is a name/value pair that tells apart the samples of one metric family.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Writer writes metric families in the Prometheus text exposition format (version
+// 0.0.4). The first write error is kept and every later call does nothing, so a
+// whole exposition can be written and the error checked once with Flush.
+type Writer struct {
+	w   *bufio.Writer // Buffered destination.
+	n   int64         // Bytes written so far.
+	err error         // First write error.
+}
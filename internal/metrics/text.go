@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ContentType is the media type of the text exposition format written by Writer.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Metric types, as announced by the TYPE line of a family.
+const (
+	TypeCounter   = "counter"
+	TypeGauge     = "gauge"
+	TypeHistogram = "histogram"
+)
+
+// helpEscaper escapes the text of a HELP line, and labelEscaper a label value.
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+// NewWriter creates a Writer that writes to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+// Family starts a metric family with its HELP and TYPE lines. The samples of the
+// family must follow before the next family is started.
+func (w *Writer) Family(name, help, typ string) {
+	w.write("# HELP ", name, " ", helpEscaper.Replace(help), "\n")
+	w.write("# TYPE ", name, " ", typ, "\n")
+}
+
+// Sample writes one sample of the current family.
+func (w *Writer) Sample(name string, value float64, labels ...Label) {
+	w.write(name)
+	w.labels(labels, "", "")
+	w.write(" ", formatFloat(value), "\n")
+}
+
+// Counter writes a family holding a single counter sample.
+func (w *Writer) Counter(name, help string, value uint64) {
+	w.Family(name, help, TypeCounter)
+	w.Sample(name, float64(value))
+}
+
+// Gauge writes a family holding a single gauge sample.
+func (w *Writer) Gauge(name, help string, value float64) {
+	w.Family(name, help, TypeGauge)
+	w.Sample(name, value)
+}
+
+// Histogram writes the bucket, sum and count samples of one histogram of the current
+// family, which must have been started with TypeHistogram.
+func (w *Writer) Histogram(name string, h HistogramSnapshot, labels ...Label) {
+	for i, count := range h.Cumulative {
+		le := "+Inf"
+		if i < len(h.Bounds) {
+			le = formatFloat(h.Bounds[i])
+		}
+
+		w.write(name, "_bucket")
+		w.labels(labels, "le", le)
+		w.write(" ", strconv.FormatUint(count, 10), "\n")
+	}
+
+	w.write(name, "_sum")
+	w.labels(labels, "", "")
+	w.write(" ", formatFloat(h.Sum), "\n")
+
+	w.write(name, "_count")
+	w.labels(labels, "", "")
+	w.write(" ", strconv.FormatUint(h.Count, 10), "\n")
+}
+
+// Flush writes out any buffered data and returns the first error that occurred,
+// together with the number of bytes written.
+func (w *Writer) Flush() (int64, error) {
+	if w.err == nil {
+		w.err = w.w.Flush()
+	}
+	return w.n, w.err
+}
+
+// labels writes a label set, with an extra label appended when extraName is set.
+// Nothing is written for an empty set.
+func (w *Writer) labels(labels []Label, extraName, extraValue string) {
+	if len(labels) == 0 && extraName == "" {
+		return
+	}
+
+	w.write("{")
+	for i, label := range labels {
+		if i > 0 {
+			w.write(",")
+		}
+		w.write(label.Name, `="`, labelEscaper.Replace(label.Value), `"`)
+	}
+	if extraName != "" {
+		if len(labels) > 0 {
+			w.write(",")
+		}
+		w.write(extraName, `="`, extraValue, `"`)
+	}
+	w.write("}")
+}
+
+// write writes strings unless an earlier write failed.
+func (w *Writer) write(parts ...string) {
+	for _, part := range parts {
+		if w.err != nil {
+			return
+		}
+
+		n, err := w.w.WriteString(part)
+		w.n += int64(n)
+		w.err = err
+	}
+}
+
+// formatFloat formats a sample value the way Prometheus parses it.
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
@@ -0,0 +1,602 @@
+package resp
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iamNilotpal/ignite/pkg/errors"
+	"github.com/iamNilotpal/ignite/pkg/ignite"
+	"github.com/iamNilotpal/ignite/pkg/options"
+)
+
+// scanDefaultCount is the number of keys SCAN returns per call without COUNT, as in
+// Redis.
+const scanDefaultCount = 10
+
+// commands maps lower-case command names to their implementation.
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"ping":    {arity: -1, handler: (*Server).ping},
+		"echo":    {arity: 2, handler: (*Server).echo},
+		"hello":   {arity: -1, handler: (*Server).hello},
+		"client":  {arity: -2, handler: (*Server).clientCommand},
+		"select":  {arity: 2, handler: (*Server).selectDB},
+		"quit":    {arity: -1, handler: (*Server).quit},
+		"command": {arity: -1, handler: (*Server).commandCommand},
+		"info":    {arity: -1, handler: (*Server).info},
+		"config":  {arity: -2, handler: (*Server).config},
+		"get":     {arity: 2, handler: (*Server).get},
+		"set":     {arity: -3, handler: (*Server).set},
+		"del":     {arity: -2, handler: (*Server).del},
+		"exists":  {arity: -2, handler: (*Server).exists},
+		"ttl":     {arity: 2, handler: (*Server).ttl},
+		"pttl":    {arity: 2, handler: (*Server).ttl},
+		"expire":  {arity: 3, handler: (*Server).expire},
+		"pexpire": {arity: 3, handler: (*Server).expire},
+		"scan":    {arity: -2, handler: (*Server).scan},
+	}
+}
+
+// execute runs a single request and buffers its reply.
+func (s *Server) execute(c *client, args [][]byte) {
+	s.commands.Add(1)
+
+	name := strings.ToLower(string(args[0]))
+	cmd, ok := commands[name]
+	if !ok {
+		c.writer.error(fmt.Sprintf("ERR unknown command '%s'", truncate(args[0])))
+		return
+	}
+	if (cmd.arity > 0 && len(args) != cmd.arity) || (cmd.arity < 0 && len(args) < -cmd.arity) {
+		c.writer.error(fmt.Sprintf("ERR wrong number of arguments for '%s' command", name))
+		return
+	}
+
+	cmd.handler(s, c, args)
+}
+
+// ping implements PING [message].
+func (s *Server) ping(c *client, args [][]byte) {
+	switch len(args) {
+	case 1:
+		c.writer.simple("PONG")
+	case 2:
+		c.writer.bulk(args[1])
+	default:
+		c.writer.error("ERR wrong number of arguments for 'ping' command")
+	}
+}
+
+// echo implements ECHO message.
+func (s *Server) echo(c *client, args [][]byte) {
+	c.writer.bulk(args[1])
+}
+
+// hello implements HELLO [protover [AUTH username password] [SETNAME name]], which
+// switches the protocol version and describes the server.
+func (s *Server) hello(c *client, args [][]byte) {
+	proto := c.writer.proto
+	if len(args) > 1 {
+		version, err := strconv.Atoi(string(args[1]))
+		if err != nil {
+			c.writer.error("ERR Protocol version is not an integer or out of range")
+			return
+		}
+		if version != 2 && version != 3 {
+			c.writer.error("NOPROTO unsupported protocol version")
+			return
+		}
+		proto = version
+	}
+
+	name := c.name
+	for i := 2; i < len(args); i++ {
+		switch option := strings.ToLower(string(args[i])); {
+		case option == "auth" && i+2 < len(args):
+			c.writer.error("ERR AUTH <password> called without any password configured for the default user")
+			return
+		case option == "setname" && i+1 < len(args):
+			name = string(args[i+1])
+			i++
+		default:
+			c.writer.error(fmt.Sprintf("ERR Syntax error in HELLO option '%s'", truncate(args[i])))
+			return
+		}
+	}
+
+	c.writer.proto = proto
+	c.name = name
+
+	c.writer.mapHeader(7)
+	c.writer.bulkString("server")
+	c.writer.bulkString("ignite")
+	c.writer.bulkString("version")
+	c.writer.bulkString("0.0.0")
+	c.writer.bulkString("proto")
+	c.writer.integer(int64(proto))
+	c.writer.bulkString("id")
+	c.writer.integer(int64(c.id))
+	c.writer.bulkString("mode")
+	c.writer.bulkString("standalone")
+	c.writer.bulkString("role")
+	c.writer.bulkString("master")
+	c.writer.bulkString("modules")
+	c.writer.array(0)
+}
+
+// clientCommand implements the CLIENT subcommands clients send when they connect.
+func (s *Server) clientCommand(c *client, args [][]byte) {
+	switch sub := strings.ToLower(string(args[1])); {
+	case sub == "id" && len(args) == 2:
+		c.writer.integer(int64(c.id))
+	case sub == "getname" && len(args) == 2:
+		if c.name == "" {
+			c.writer.null()
+			return
+		}
+		c.writer.bulkString(c.name)
+	case sub == "setname" && len(args) == 3:
+		c.name = string(args[2])
+		c.writer.simple("OK")
+	case sub == "setinfo" && len(args) == 4:
+		// Library names and versions are accepted but not recorded.
+		c.writer.simple("OK")
+	default:
+		c.writer.error(fmt.Sprintf("ERR unknown subcommand or wrong number of arguments for 'client|%s'", truncate(args[1])))
+	}
+}
+
+// selectDB implements SELECT index. A store has a single keyspace, database 0.
+func (s *Server) selectDB(c *client, args [][]byte) {
+	if string(args[1]) != "0" {
+		c.writer.error("ERR DB index is out of range")
+		return
+	}
+	c.writer.simple("OK")
+}
+
+// quit implements QUIT, closing the connection after the reply.
+func (s *Server) quit(c *client, args [][]byte) {
+	c.quit = true
+	c.writer.simple("OK")
+}
+
+// commandCommand implements COMMAND. Command introspection is not supported, so an
+// empty list is returned, which clients such as redis-cli accept.
+func (s *Server) commandCommand(c *client, args [][]byte) {
+	if len(args) == 2 && strings.EqualFold(string(args[1]), "count") {
+		c.writer.integer(int64(len(commands)))
+		return
+	}
+	c.writer.array(0)
+}
+
+// info implements INFO [section ...]. Besides the usual server, clients, stats and
+// keyspace sections, it reports the state of the store from Instance.Stats in the
+// storage, cache and compaction sections, and every live segment in the segments
+// section, which is left out unless asked for.
+func (s *Server) info(c *client, args [][]byte) {
+	sections := make(map[string]bool)
+	for _, arg := range args[1:] {
+		sections[strings.ToLower(string(arg))] = true
+	}
+	all := sections["all"] || sections["everything"]
+	defaults := len(sections) == 0 || sections["default"] || all
+
+	var b strings.Builder
+	header := func(name string) bool {
+		if b.Len() > 0 {
+			b.WriteString("\r\n")
+		}
+		fmt.Fprintf(&b, "# %s\r\n", strings.ToUpper(name[:1])+name[1:])
+		return true
+	}
+	section := func(name string) bool {
+		if !defaults && !sections[name] {
+			return false
+		}
+		return header(name)
+	}
+	// Sections that can grow long are only written when asked for by name or by
+	// "all", as Redis does.
+	extraSection := func(name string) bool {
+		if !all && !sections[name] {
+			return false
+		}
+		return header(name)
+	}
+	field := func(name string, value any) {
+		fmt.Fprintf(&b, "%s:%v\r\n", name, value)
+	}
+	stats := s.instance.Stats()
+
+	if section("server") {
+		uptime := time.Since(s.started)
+		field("ignite_mode", "standalone")
+		field("process_id", os.Getpid())
+		field("uptime_in_seconds", int64(uptime.Seconds()))
+		field("uptime_in_days", int64(uptime.Hours()/24))
+	}
+	if section("clients") {
+		field("connected_clients", s.clientCount())
+	}
+	if section("stats") {
+		field("total_connections_received", s.connections.Load())
+		field("total_commands_processed", s.commands.Load())
+	}
+	if section("storage") {
+		var ratio float64
+		if size := stats.LiveBytes + stats.DeadBytes; size > 0 {
+			ratio = float64(stats.DeadBytes) / float64(size)
+		}
+		field("store_uptime_in_seconds", int64(stats.Uptime.Seconds()))
+		field("recovery_duration_ms", stats.RecoveryDuration.Milliseconds())
+		field("active_segment_id", stats.ActiveSegmentID)
+		field("active_segment_offset", stats.ActiveSegmentOffset)
+		field("segments", len(stats.Segments))
+		field("live_bytes", stats.LiveBytes)
+		field("dead_bytes", stats.DeadBytes)
+		field("dead_ratio", strconv.FormatFloat(ratio, 'f', 4, 64))
+		field("index_keys", stats.Keys)
+		field("index_memory_bytes", stats.IndexMemory)
+	}
+	if section("cache") {
+		field("cache_hits", stats.Cache.Hits)
+		field("cache_misses", stats.Cache.Misses)
+		field("cache_evictions", stats.Cache.Evictions)
+		field("cache_entries", stats.Cache.Entries)
+		field("cache_bytes", stats.Cache.Bytes)
+		field("cache_capacity", stats.Cache.Capacity)
+		field("file_cache_hits", stats.FileCache.Hits)
+		field("file_cache_misses", stats.FileCache.Misses)
+		field("file_cache_open", stats.FileCache.Open)
+	}
+	if section("compaction") {
+		field("compaction_runs", stats.Compaction.Runs)
+		field("compaction_reclaimed_bytes", stats.Compaction.ReclaimedBytes)
+		for n, run := range stats.Compaction.History {
+			field(fmt.Sprintf("compaction_run%d", n), fmt.Sprintf(
+				"started=%d,duration_ms=%d,segments=%d,reclaimed_bytes=%d",
+				run.Started.Unix(), run.Duration.Milliseconds(), run.Segments, run.ReclaimedBytes,
+			))
+		}
+	}
+	if section("keyspace") && stats.Keys > 0 {
+		field("db0", fmt.Sprintf("keys=%d,expires=%d,avg_ttl=0", stats.Keys, stats.Expiring))
+	}
+	if extraSection("segments") {
+		for _, segment := range stats.Segments {
+			field(fmt.Sprintf("segment%d", segment.ID), fmt.Sprintf(
+				"file=%s,size=%d,live_bytes=%d,dead_bytes=%d",
+				segment.File, segment.Size, segment.LiveBytes, segment.DeadBytes,
+			))
+		}
+	}
+
+	c.writer.bulkString(b.String())
+}
+
+// config implements CONFIG GET pattern [pattern ...] and CONFIG SET name value
+// [name value ...]. Settings are named by their JSON path, such as
+// "cacheOptions.size", and matched case-insensitively. CONFIG SET goes through
+// Instance.Reconfigure, so it either applies every setting or none of them.
+func (s *Server) config(c *client, args [][]byte) {
+	switch sub := strings.ToLower(string(args[1])); {
+	case sub == "get" && len(args) >= 3:
+		opts := s.instance.Options()
+		values := opts.Values()
+		names := make([]string, 0, len(values))
+		for name := range values {
+			for _, pattern := range args[2:] {
+				if match(strings.ToLower(string(pattern)), strings.ToLower(name)) {
+					names = append(names, name)
+					break
+				}
+			}
+		}
+		slices.Sort(names)
+
+		c.writer.mapHeader(len(names))
+		for _, name := range names {
+			c.writer.bulkString(name)
+			c.writer.bulkString(fmt.Sprint(values[name]))
+		}
+
+	case sub == "set" && len(args) >= 4 && len(args)%2 == 0:
+		settings := make(map[string]string, (len(args)-2)/2)
+		for i := 2; i < len(args); i += 2 {
+			settings[string(args[i])] = string(args[i+1])
+		}
+
+		opt, err := options.Parse(settings)
+		if err == nil {
+			err = s.instance.Reconfigure(opt)
+		}
+		if err != nil {
+			c.writer.error("ERR CONFIG SET failed: " + describeConfigError(err))
+			return
+		}
+
+		s.log.Infow("Configuration changed by client", "client", c.conn.RemoteAddr().String(), "id", c.id, "settings", settings)
+		c.writer.simple("OK")
+
+	default:
+		c.writer.error(fmt.Sprintf("ERR unknown subcommand or wrong number of arguments for 'config|%s'", truncate(args[1])))
+	}
+}
+
+// get implements GET key.
+func (s *Server) get(c *client, args [][]byte) {
+	value, err := s.instance.Get(context.Background(), string(args[1]))
+	if err != nil {
+		if isNotFound(err) {
+			c.writer.null()
+			return
+		}
+		writeError(c, err)
+		return
+	}
+	c.writer.bulk(value)
+}
+
+// set implements SET key value [NX | XX] [EX seconds | PX milliseconds]. A condition
+// that does not hold is answered with a null reply.
+func (s *Server) set(c *client, args [][]byte) {
+	var opts ignite.SetOptions
+	for i := 3; i < len(args); i++ {
+		switch option := strings.ToUpper(string(args[i])); {
+		case option == "NX" && opts.Condition != ignite.SetIfExists:
+			opts.Condition = ignite.SetIfAbsent
+		case option == "XX" && opts.Condition != ignite.SetIfAbsent:
+			opts.Condition = ignite.SetIfExists
+		case (option == "EX" || option == "PX") && opts.TTL == 0 && i+1 < len(args):
+			n, err := strconv.ParseInt(string(args[i+1]), 10, 64)
+			if err != nil {
+				c.writer.error("ERR value is not an integer or out of range")
+				return
+			}
+			unit := time.Second
+			if option == "PX" {
+				unit = time.Millisecond
+			}
+			if n <= 0 || n > int64(math.MaxInt64/unit) {
+				c.writer.error("ERR invalid expire time in 'set' command")
+				return
+			}
+			opts.TTL = time.Duration(n) * unit
+			i++
+		default:
+			c.writer.error("ERR syntax error")
+			return
+		}
+	}
+
+	stored, err := s.instance.SetWith(context.Background(), string(args[1]), args[2], opts)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	if !stored {
+		c.writer.null()
+		return
+	}
+	c.writer.simple("OK")
+}
+
+// del implements DEL key [key ...] and replies with the number of keys removed.
+func (s *Server) del(c *client, args [][]byte) {
+	var removed int64
+	for _, key := range args[1:] {
+		ok, err := s.instance.Remove(context.Background(), string(key))
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+		if ok {
+			removed++
+		}
+	}
+	c.writer.integer(removed)
+}
+
+// exists implements EXISTS key [key ...] and replies with how many of the keys exist,
+// counting repeated keys every time.
+func (s *Server) exists(c *client, args [][]byte) {
+	var found int64
+	for _, key := range args[1:] {
+		ok, err := s.instance.Exists(context.Background(), string(key))
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+		if ok {
+			found++
+		}
+	}
+	c.writer.integer(found)
+}
+
+// ttl implements TTL key and PTTL key: -2 if the key does not exist, -1 if it never
+// expires, and otherwise its remaining time in seconds or milliseconds.
+func (s *Server) ttl(c *client, args [][]byte) {
+	ttl, err := s.instance.TTL(context.Background(), string(args[1]))
+	switch {
+	case isNotFound(err):
+		c.writer.integer(-2)
+	case err != nil:
+		writeError(c, err)
+	case ttl == 0:
+		c.writer.integer(-1)
+	case strings.EqualFold(string(args[0]), "pttl"):
+		c.writer.integer(int64((ttl + time.Millisecond/2) / time.Millisecond))
+	default:
+		c.writer.integer(int64((ttl + time.Second/2) / time.Second))
+	}
+}
+
+// expire implements EXPIRE key seconds and PEXPIRE key milliseconds. It replies 1 if
+// the expiry was set and 0 if the key does not exist. A time of zero or less deletes
+// the key.
+func (s *Server) expire(c *client, args [][]byte) {
+	n, err := strconv.ParseInt(string(args[2]), 10, 64)
+	if err != nil {
+		c.writer.error("ERR value is not an integer or out of range")
+		return
+	}
+
+	unit := time.Second
+	if strings.EqualFold(string(args[0]), "pexpire") {
+		unit = time.Millisecond
+	}
+	if n > int64(math.MaxInt64/unit) || n < int64(math.MinInt64/unit) {
+		c.writer.error(fmt.Sprintf("ERR invalid expire time in '%s' command", strings.ToLower(string(args[0]))))
+		return
+	}
+
+	err = s.instance.Expire(context.Background(), string(args[1]), time.Duration(n)*unit)
+	switch {
+	case isNotFound(err):
+		c.writer.integer(0)
+	case err != nil:
+		writeError(c, err)
+	default:
+		c.writer.integer(1)
+	}
+}
+
+// scan implements SCAN cursor [MATCH pattern] [COUNT count] [TYPE type]. Keys come
+// back in sorted order, COUNT of them at most per call, and every key that exists for
+// the whole iteration is returned exactly once.
+func (s *Server) scan(c *client, args [][]byte) {
+	cursor, err := strconv.ParseUint(string(args[1]), 10, 64)
+	if err != nil {
+		c.writer.error("ERR invalid cursor")
+		return
+	}
+
+	count := scanDefaultCount
+	pattern, typ := "", ""
+	for i := 2; i < len(args); i += 2 {
+		if i+1 >= len(args) {
+			c.writer.error("ERR syntax error")
+			return
+		}
+
+		value := string(args[i+1])
+		switch strings.ToUpper(string(args[i])) {
+		case "MATCH":
+			pattern = value
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				c.writer.error("ERR value is out of range, must be positive")
+				return
+			}
+			count = n
+		case "TYPE":
+			typ = strings.ToLower(value)
+		default:
+			c.writer.error("ERR syntax error")
+			return
+		}
+	}
+
+	after := ""
+	if cursor != 0 {
+		var ok bool
+		if after, ok = s.cursors.load(cursor); !ok {
+			c.writer.error("ERR invalid cursor")
+			return
+		}
+	}
+
+	// Every value is a string, so any other type matches nothing.
+	var keys []string
+	if typ == "" || typ == "string" {
+		var filter func(key string) bool
+		if pattern != "" && pattern != "*" {
+			filter = func(key string) bool { return match(pattern, key) }
+		}
+
+		keys, err = s.instance.Scan(context.Background(), after, count, filter)
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+	}
+
+	next := uint64(0)
+	if len(keys) == count {
+		next = s.cursors.save(keys[len(keys)-1])
+	}
+
+	c.writer.array(2)
+	c.writer.bulkString(strconv.FormatUint(next, 10))
+	c.writer.array(len(keys))
+	for _, key := range keys {
+		c.writer.bulkString(key)
+	}
+}
+
+// writeError replies with an error from the store, translating error codes into the
+// error prefixes Redis clients know.
+func writeError(c *client, err error) {
+	msg := strings.ReplaceAll(err.Error(), "\r\n", " ")
+
+	switch errors.GetErrorCode(err) {
+	case errors.ErrorCodeReadOnly:
+		c.writer.error("READONLY " + msg)
+	default:
+		c.writer.error("ERR " + msg)
+	}
+}
+
+// describeConfigError summarizes a failed reconfiguration on one line, naming the
+// field and the problem of every violation.
+func describeConfigError(err error) string {
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+
+	parts := make([]string, 0, len(errs))
+	for _, err := range errs {
+		ve, ok := errors.AsValidationError(err)
+		if !ok {
+			parts = append(parts, err.Error())
+			continue
+		}
+
+		part := ve.Field() + ": " + ve.Error()
+		if issue, ok := errors.GetErrorDetails(err)["validationIssue"]; ok {
+			part = fmt.Sprintf("%s: %v", ve.Field(), issue)
+		}
+		if provided := ve.Provided(); provided != nil {
+			part += fmt.Sprintf(" (got %v)", provided)
+		}
+		parts = append(parts, part)
+	}
+	return strings.ReplaceAll(strings.Join(parts, "; "), "\r\n", " ")
+}
+
+// isNotFound reports whether err means that a key does not exist.
+func isNotFound(err error) bool {
+	return err != nil && errors.GetErrorCode(err) == errors.ErrorCodeIndexKeyNotFound
+}
+
+// truncate shortens an argument echoed back in an error message.
+func truncate(arg []byte) string {
+	const limit = 128
+	if len(arg) > limit {
+		return string(arg[:limit]) + "..."
+	}
+	return string(arg)
+}
@@ -0,0 +1,51 @@
+package resp
+
+import "sync"
+
+// maxCursors bounds the number of SCAN iterations remembered at once. The oldest are
+// forgotten first; continuing one of them fails with an invalid cursor error.
+const maxCursors = 4096
+
+// cursorTable maps the numeric cursors SCAN hands out to the last key each iteration
+// returned. Cursors are shared by all connections, because clients with connection
+// pools may continue an iteration on a different connection than they started it on.
+type cursorTable struct {
+	next      uint64            // Last cursor handed out; cursor 0 always starts a new iteration.
+	positions map[uint64]string // Last key returned by each live cursor.
+	order     []uint64          // Live cursors, oldest first.
+	mu        sync.Mutex        // Guards every field.
+}
+
+// newCursorTable returns an empty cursor table.
+func newCursorTable() *cursorTable {
+	return &cursorTable{positions: make(map[uint64]string)}
+}
+
+// save returns a new cursor that continues after key.
+func (t *cursorTable) save(key string) uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.next++
+	if t.next == 0 {
+		t.next++
+	}
+	t.positions[t.next] = key
+	t.order = append(t.order, t.next)
+
+	if len(t.order) > maxCursors {
+		delete(t.positions, t.order[0])
+		t.order = t.order[1:]
+	}
+	return t.next
+}
+
+// load returns the key the iteration of cursor continues after. Cursors stay valid
+// after use, so a client may retry a SCAN call.
+func (t *cursorTable) load(cursor uint64) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key, ok := t.positions[cursor]
+	return key, ok
+}
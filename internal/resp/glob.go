@@ -0,0 +1,89 @@
+package resp
+
+// match reports whether s matches the glob-style pattern used by Redis commands such
+// as SCAN MATCH: '*' matches any run of bytes, '?' any single byte, '[...]' one byte
+// from a set or range (negated by a leading '^'), and '\' escapes the next byte.
+func match(pattern, s string) bool {
+	// star and resume remember the latest '*' and where its match last ended, so that
+	// a mismatch backtracks by letting the star swallow one more byte.
+	star, resume := -1, 0
+
+	p, i := 0, 0
+	for i < len(s) {
+		if p < len(pattern) {
+			switch pattern[p] {
+			case '*':
+				star, resume = p, i
+				p++
+				continue
+			case '?':
+				p++
+				i++
+				continue
+			case '[':
+				if end, ok := matchClass(pattern, p, s[i]); ok {
+					p = end
+					i++
+					continue
+				}
+			case '\\':
+				if p+1 < len(pattern) && pattern[p+1] == s[i] {
+					p += 2
+					i++
+					continue
+				}
+			default:
+				if pattern[p] == s[i] {
+					p++
+					i++
+					continue
+				}
+			}
+		}
+
+		if star < 0 {
+			return false
+		}
+		resume++
+		p, i = star+1, resume
+	}
+
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// matchClass matches b against the character class starting at pattern[start], which
+// is '['. It returns the position just past the class and whether b is in it. An
+// unterminated class runs to the end of the pattern.
+func matchClass(pattern string, start int, b byte) (int, bool) {
+	p := start + 1
+	negate := p < len(pattern) && pattern[p] == '^'
+	if negate {
+		p++
+	}
+
+	matched := false
+	for p < len(pattern) && pattern[p] != ']' {
+		switch {
+		case pattern[p] == '\\' && p+1 < len(pattern):
+			matched = matched || pattern[p+1] == b
+			p += 2
+		case p+2 < len(pattern) && pattern[p+1] == '-' && pattern[p+2] != ']':
+			lo, hi := pattern[p], pattern[p+2]
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			matched = matched || (b >= lo && b <= hi)
+			p += 3
+		default:
+			matched = matched || pattern[p] == b
+			p++
+		}
+	}
+	if p < len(pattern) {
+		p++ // Skip the closing ']'.
+	}
+	return p, matched != negate
+}
@@ -0,0 +1,56 @@
+package resp
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/iamNilotpal/ignite/pkg/ignite"
+	"go.uber.org/zap"
+)
+
+// Server serves an ignite.Instance to Redis clients over RESP2 and RESP3.
+type Server struct {
+	instance    *ignite.Instance          // Store the commands operate on.
+	log         *zap.SugaredLogger        // Structured logger for connection and shutdown events.
+	maxBulk     int64                     // Largest bulk string a request may carry.
+	started     time.Time                 // Time the server was created, reported as its uptime.
+	listeners   map[net.Listener]struct{} // Listeners Serve is accepting on.
+	clients     map[*client]struct{}      // Connections currently being served.
+	cursors     *cursorTable              // Positions of SCAN iterations in progress.
+	nextID      atomic.Uint64             // Source of client IDs.
+	connections atomic.Uint64             // Connections accepted since the server started.
+	commands    atomic.Uint64             // Commands processed since the server started.
+	closed      atomic.Bool               // Whether Shutdown has been called.
+	wg          sync.WaitGroup            // Tracks connection goroutines so Shutdown can wait for them.
+	mu          sync.Mutex                // Guards listeners and clients.
+}
+
+// Config holds the parameters of a new Server.
+type Config struct {
+	Instance *ignite.Instance   // Store to serve; required.
+	Logger   *zap.SugaredLogger // Logger for connection and shutdown events; required.
+
+	// MaxBulkSize is the largest bulk string, and so the largest value, a request may
+	// carry. Zero selects DefaultMaxBulkSize.
+	MaxBulkSize int64
+}
+
+// client is the state of a single connection.
+type client struct {
+	id     uint64   // Unique ID reported by CLIENT ID and HELLO.
+	conn   net.Conn // Underlying connection.
+	reader *reader  // Parses the requests of the connection.
+	writer *writer  // Buffers the replies of the connection.
+	name   string   // Name set with CLIENT SETNAME or HELLO SETNAME.
+	quit   bool     // Set by QUIT to close the connection once the reply is sent.
+}
+
+// command describes how a command is dispatched.
+type command struct {
+	// arity is the number of arguments, including the command name, that the command
+	// takes: exactly arity when positive, at least -arity when negative.
+	arity   int
+	handler func(s *Server, c *client, args [][]byte)
+}
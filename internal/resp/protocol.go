@@ -0,0 +1,226 @@
+package resp
+
+import (
+	"bufio"
+	"bytes"
+	stdErrors "errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+const (
+	// maxInlineSize bounds the length of an inline command and of the length lines of
+	// a multibulk request.
+	maxInlineSize = 64 * 1024
+
+	// maxArgs bounds the number of arguments of a single command.
+	maxArgs = 1024 * 1024
+)
+
+// errProtocol marks malformed requests. The connection cannot be resynchronized after
+// one, so it is answered with an error and closed.
+var errProtocol = stdErrors.New("protocol error")
+
+// reader parses client requests, either as RESP arrays of bulk strings or as inline
+// commands typed by hand, such as over telnet.
+type reader struct {
+	r       *bufio.Reader
+	maxBulk int64
+}
+
+// newReader returns a reader that accepts bulk strings of at most maxBulk bytes.
+func newReader(r io.Reader, maxBulk int64) *reader {
+	return &reader{r: bufio.NewReaderSize(r, 16*1024), maxBulk: maxBulk}
+}
+
+// buffered reports whether a pipelined request is already waiting to be read.
+func (r *reader) buffered() bool {
+	return r.r.Buffered() > 0
+}
+
+// readCommand reads the next request and returns its arguments. An empty request
+// returns no arguments and no error.
+func (r *reader) readCommand() ([][]byte, error) {
+	first, err := r.r.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+	if first[0] != '*' {
+		return r.readInline()
+	}
+
+	line, err := r.readLine()
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.ParseInt(string(line[1:]), 10, 64)
+	if err != nil || count > maxArgs {
+		return nil, fmt.Errorf("%w: invalid multibulk length", errProtocol)
+	}
+	if count <= 0 {
+		return nil, nil
+	}
+
+	args := make([][]byte, 0, count)
+	for range count {
+		line, err := r.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if len(line) == 0 || line[0] != '$' {
+			return nil, fmt.Errorf("%w: expected '$', got '%s'", errProtocol, firstByte(line))
+		}
+
+		size, err := strconv.ParseInt(string(line[1:]), 10, 64)
+		if err != nil || size < 0 || size > r.maxBulk {
+			return nil, fmt.Errorf("%w: invalid bulk length", errProtocol)
+		}
+
+		arg := make([]byte, size+2)
+		if _, err := io.ReadFull(r.r, arg); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		if arg[size] != '\r' || arg[size+1] != '\n' {
+			return nil, fmt.Errorf("%w: bulk string not terminated by CRLF", errProtocol)
+		}
+		args = append(args, arg[:size])
+	}
+	return args, nil
+}
+
+// readInline reads a command written as a single line of space-separated words.
+func (r *reader) readInline() ([][]byte, error) {
+	line, err := r.readLine()
+	if err != nil {
+		return nil, err
+	}
+
+	fields := bytes.Fields(line)
+	args := make([][]byte, len(fields))
+	for i, field := range fields {
+		args[i] = bytes.Clone(field)
+	}
+	return args, nil
+}
+
+// readLine reads a line terminated by LF, or CRLF, and returns it without the line
+// ending. The returned slice is only valid until the next read.
+func (r *reader) readLine() ([]byte, error) {
+	line, err := r.r.ReadSlice('\n')
+	if err == bufio.ErrBufferFull || len(line) > maxInlineSize {
+		return nil, fmt.Errorf("%w: too big request line", errProtocol)
+	}
+	if err != nil {
+		if len(line) > 0 {
+			return nil, unexpectedEOF(err)
+		}
+		return nil, err
+	}
+
+	line = line[:len(line)-1]
+	if n := len(line); n > 0 && line[n-1] == '\r' {
+		line = line[:n-1]
+	}
+	return line, nil
+}
+
+// unexpectedEOF turns an EOF in the middle of a request into io.ErrUnexpectedEOF.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// firstByte returns the first byte of line for error messages.
+func firstByte(line []byte) string {
+	if len(line) == 0 {
+		return ""
+	}
+	return string(line[:1])
+}
+
+// writer encodes replies in the protocol version the client negotiated with HELLO.
+// Replies are buffered and only sent on flush, so that the replies to pipelined
+// requests go out together.
+type writer struct {
+	w     *bufio.Writer
+	proto int
+	buf   []byte
+}
+
+// newWriter returns a writer that speaks RESP2 until HELLO switches it.
+func newWriter(w io.Writer) *writer {
+	return &writer{w: bufio.NewWriterSize(w, 16*1024), proto: 2}
+}
+
+// simple writes a simple string reply.
+func (w *writer) simple(s string) {
+	w.w.WriteByte('+')
+	w.w.WriteString(s)
+	w.w.WriteString("\r\n")
+}
+
+// error writes an error reply. msg must start with an error code such as ERR.
+func (w *writer) error(msg string) {
+	w.w.WriteByte('-')
+	w.w.WriteString(msg)
+	w.w.WriteString("\r\n")
+}
+
+// integer writes an integer reply.
+func (w *writer) integer(n int64) {
+	w.prefixed(':', n)
+}
+
+// bulk writes a bulk string reply.
+func (w *writer) bulk(b []byte) {
+	w.prefixed('$', int64(len(b)))
+	w.w.Write(b)
+	w.w.WriteString("\r\n")
+}
+
+// bulkString writes a bulk string reply holding s.
+func (w *writer) bulkString(s string) {
+	w.prefixed('$', int64(len(s)))
+	w.w.WriteString(s)
+	w.w.WriteString("\r\n")
+}
+
+// null writes a null reply: the RESP3 null, or a null bulk string in RESP2.
+func (w *writer) null() {
+	if w.proto >= 3 {
+		w.w.WriteString("_\r\n")
+		return
+	}
+	w.w.WriteString("$-1\r\n")
+}
+
+// array writes the header of an array reply of n elements.
+func (w *writer) array(n int) {
+	w.prefixed('*', int64(n))
+}
+
+// mapHeader writes the header of a map reply of n pairs, which RESP2 clients receive
+// as a flat array of keys and values.
+func (w *writer) mapHeader(n int) {
+	if w.proto >= 3 {
+		w.prefixed('%', int64(n))
+		return
+	}
+	w.prefixed('*', int64(2*n))
+}
+
+// prefixed writes a type byte followed by a number and CRLF.
+func (w *writer) prefixed(kind byte, n int64) {
+	w.buf = append(w.buf[:0], kind)
+	w.buf = strconv.AppendInt(w.buf, n, 10)
+	w.buf = append(w.buf, '\r', '\n')
+	w.w.Write(w.buf)
+}
+
+// flush sends the buffered replies.
+func (w *writer) flush() error {
+	return w.w.Flush()
+}
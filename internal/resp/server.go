@@ -0,0 +1,258 @@
+// Package resp serves an Ignite store over the Redis serialization protocol, so that
+// existing Redis clients and tools can use it unchanged.
+//
+// Both RESP2 and RESP3 are spoken; clients switch to RESP3 with HELLO 3. Requests
+// may be pipelined: every request already received is executed before the replies
+// are flushed together. Commands are mapped onto ignite.Instance, which keeps
+// conditional writes and expiry atomic no matter how many connections are open.
+package resp
+
+import (
+	"context"
+	stdErrors "errors"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/iamNilotpal/ignite/pkg/errors"
+)
+
+// DefaultMaxBulkSize is the largest bulk string a request may carry unless
+// Config.MaxBulkSize says otherwise. It matches the Redis default.
+const DefaultMaxBulkSize = 512 * 1024 * 1024
+
+// ErrServerClosed is returned by Serve and ListenAndServe once Shutdown is called.
+var ErrServerClosed = stdErrors.New("resp: server closed")
+
+// New creates a server for the instance in config. The server does not own the
+// instance: after Shutdown returns, the caller closes it.
+func New(config *Config) (*Server, error) {
+	if config == nil || config.Instance == nil {
+		return nil, errors.NewRequiredFieldError("instance")
+	}
+	if config.Logger == nil {
+		return nil, errors.NewRequiredFieldError("logger")
+	}
+	if config.MaxBulkSize < 0 {
+		return nil, errors.NewFieldRangeError("maxBulkSize", config.MaxBulkSize, 0, int64(DefaultMaxBulkSize))
+	}
+
+	maxBulk := config.MaxBulkSize
+	if maxBulk == 0 {
+		maxBulk = DefaultMaxBulkSize
+	}
+
+	return &Server{
+		instance:  config.Instance,
+		log:       config.Logger,
+		maxBulk:   maxBulk,
+		started:   time.Now(),
+		listeners: make(map[net.Listener]struct{}),
+		clients:   make(map[*client]struct{}),
+		cursors:   newCursorTable(),
+	}, nil
+}
+
+// ListenAndServe listens on the TCP address addr and serves connections on it.
+func (s *Server) ListenAndServe(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(listener)
+}
+
+// Serve accepts connections on listener and serves each of them in its own goroutine,
+// until Shutdown is called, after which it returns ErrServerClosed. Serve closes the
+// listener when it returns.
+func (s *Server) Serve(listener net.Listener) error {
+	if !s.trackListener(listener) {
+		listener.Close()
+		return ErrServerClosed
+	}
+	defer s.untrackListener(listener)
+
+	s.log.Infow("Serving RESP connections", "addr", listener.Addr().String())
+
+	var delay time.Duration
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if s.closed.Load() {
+				return ErrServerClosed
+			}
+
+			// Back off on temporary failures such as running out of file descriptors,
+			// instead of failing the whole server.
+			if isTemporary(err) {
+				delay = min(max(2*delay, 5*time.Millisecond), time.Second)
+				s.log.Warnw("Failed to accept connection, retrying", "delay", delay, "error", err)
+				time.Sleep(delay)
+				continue
+			}
+			return err
+		}
+		delay = 0
+
+		c := &client{
+			id:     s.nextID.Add(1),
+			conn:   conn,
+			reader: newReader(conn, s.maxBulk),
+			writer: newWriter(conn),
+		}
+		if !s.trackClient(c) {
+			conn.Close()
+			return ErrServerClosed
+		}
+		s.connections.Add(1)
+
+		go s.serveClient(c)
+	}
+}
+
+// Shutdown stops the server gracefully. It stops accepting connections, lets every
+// command that is executing finish and its reply be sent, and then closes the
+// connections. Requests that were pipelined behind them are dropped. If ctx ends
+// first, the remaining connections are closed at once and ctx's error is returned.
+func (s *Server) Shutdown(ctx context.Context) error {
+	if !s.closed.CompareAndSwap(false, true) {
+		return ErrServerClosed
+	}
+
+	s.mu.Lock()
+	for listener := range s.listeners {
+		listener.Close()
+	}
+	// An expired read deadline wakes connections waiting for their next request,
+	// without interrupting one that is executing.
+	for c := range s.clients {
+		c.conn.SetReadDeadline(time.Now())
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.log.Infow("RESP server shut down", "connections", s.connections.Load(), "commands", s.commands.Load())
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		for c := range s.clients {
+			c.conn.Close()
+		}
+		s.mu.Unlock()
+		<-done
+		s.log.Warnw("RESP server shutdown timed out, closed remaining connections", "error", ctx.Err())
+		return ctx.Err()
+	}
+}
+
+// serveClient executes the requests of a connection until it is closed.
+func (s *Server) serveClient(c *client) {
+	defer s.wg.Done()
+	defer s.untrackClient(c)
+	defer c.conn.Close()
+
+	for !s.closed.Load() {
+		args, err := c.reader.readCommand()
+		if err != nil {
+			if stdErrors.Is(err, errProtocol) {
+				c.writer.error("ERR Protocol error: " + strings.TrimPrefix(err.Error(), errProtocol.Error()+": "))
+				c.writer.flush()
+			} else if !isDisconnect(err) && !s.closed.Load() {
+				s.log.Warnw("Failed to read request", "client", c.id, "remote", c.conn.RemoteAddr().String(), "error", err)
+			}
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		s.execute(c, args)
+
+		// Replies are sent once every pipelined request already received has run.
+		if c.quit || !c.reader.buffered() {
+			if err := c.writer.flush(); err != nil || c.quit {
+				return
+			}
+		}
+	}
+
+	// Shutdown stopped the loop with pipelined requests still buffered. They are
+	// dropped, but the replies of those that already ran are owed to the client.
+	if err := c.writer.flush(); err != nil && !isDisconnect(err) {
+		s.log.Warnw("Failed to send replies on shutdown", "client", c.id, "remote", c.conn.RemoteAddr().String(), "error", err)
+	}
+}
+
+// trackListener registers a listener unless the server is shutting down.
+func (s *Server) trackListener(listener net.Listener) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed.Load() {
+		return false
+	}
+	s.listeners[listener] = struct{}{}
+	return true
+}
+
+// untrackListener closes a listener and forgets it.
+func (s *Server) untrackListener(listener net.Listener) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	listener.Close()
+	delete(s.listeners, listener)
+}
+
+// trackClient registers a connection unless the server is shutting down.
+func (s *Server) trackClient(c *client) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed.Load() {
+		return false
+	}
+	s.clients[c] = struct{}{}
+	s.wg.Add(1)
+	return true
+}
+
+// untrackClient forgets a connection.
+func (s *Server) untrackClient(c *client) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.clients, c)
+}
+
+// clientCount returns the number of open connections.
+func (s *Server) clientCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.clients)
+}
+
+// isDisconnect reports whether a read error only means that the connection went away
+// or was interrupted by Shutdown.
+func isDisconnect(err error) bool {
+	return err == io.EOF ||
+		stdErrors.Is(err, io.ErrUnexpectedEOF) ||
+		stdErrors.Is(err, net.ErrClosed) ||
+		stdErrors.Is(err, os.ErrDeadlineExceeded) ||
+		stdErrors.Is(err, syscall.ECONNRESET)
+}
+
+// isTemporary reports whether an accept error is worth retrying.
+func isTemporary(err error) bool {
+	var temporary interface{ Temporary() bool }
+	return stdErrors.As(err, &temporary) && temporary.Temporary()
+}
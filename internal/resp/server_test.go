@@ -0,0 +1,453 @@
+package resp
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iamNilotpal/ignite/pkg/ignite"
+	"github.com/iamNilotpal/ignite/pkg/options"
+	"go.uber.org/zap"
+)
+
+// respError is an error reply.
+type respError string
+
+// testConn is a client connection to a test server.
+type testConn struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// startServer serves a fresh store on a loopback port and returns a connection to
+// it. The server and the store are shut down when the test ends.
+func startServer(t *testing.T) *testConn {
+	t.Helper()
+	c, _ := startStore(t)
+	return c
+}
+
+// startStore is startServer that also returns the instance being served.
+func startStore(t *testing.T) (*testConn, *ignite.Instance) {
+	t.Helper()
+	_, instance, c := serve(t)
+	return c, instance
+}
+
+// serve is startServer that also returns the server and the instance being served.
+func serve(t *testing.T) (*Server, *ignite.Instance, *testConn) {
+	t.Helper()
+
+	instance, err := ignite.NewInstance(
+		context.Background(), "resp-test",
+		options.WithDataDir(t.TempDir()), options.WithLogLevel("error"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server, err := New(&Config{Instance: instance, Logger: zap.NewNop().Sugar()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(listener)
+
+	t.Cleanup(func() {
+		server.Shutdown(context.Background())
+		instance.Close(context.Background())
+	})
+	return server, instance, dial(t, listener.Addr().String())
+}
+
+// dial opens a connection to the server at addr.
+func dial(t *testing.T, addr string) *testConn {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	t.Cleanup(func() { conn.Close() })
+	return &testConn{t: t, conn: conn, r: bufio.NewReader(conn)}
+}
+
+// send writes a request without waiting for its reply.
+func (c *testConn) send(args ...string) {
+	c.t.Helper()
+	if _, err := c.conn.Write([]byte(request(args...))); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// request encodes a request as an array of bulk strings.
+func request(args ...string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	return b.String()
+}
+
+// do sends a request and returns its reply.
+func (c *testConn) do(args ...string) any {
+	c.t.Helper()
+	c.send(args...)
+	return c.read()
+}
+
+// read parses the next reply. Simple and bulk strings come back as strings, integers
+// as int64, nulls as nil, arrays as []any and maps as map[string]any.
+func (c *testConn) read() any {
+	c.t.Helper()
+
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+
+	switch line[0] {
+	case '+':
+		return line[1:]
+	case '-':
+		return respError(line[1:])
+	case ':':
+		n, err := strconv.ParseInt(line[1:], 10, 64)
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		return n
+	case '_':
+		return nil
+	case '$':
+		n, _ := strconv.Atoi(line[1:])
+		if n < 0 {
+			return nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			c.t.Fatal(err)
+		}
+		return string(buf[:n])
+	case '*':
+		n, _ := strconv.Atoi(line[1:])
+		if n < 0 {
+			return nil
+		}
+		items := make([]any, n)
+		for i := range items {
+			items[i] = c.read()
+		}
+		return items
+	case '%':
+		n, _ := strconv.Atoi(line[1:])
+		m := make(map[string]any, n)
+		for range n {
+			key := c.read()
+			m[fmt.Sprint(key)] = c.read()
+		}
+		return m
+	}
+	c.t.Fatalf("unexpected reply %q", line)
+	return nil
+}
+
+// expect sends a request and fails the test unless the reply equals want.
+func (c *testConn) expect(want any, args ...string) {
+	c.t.Helper()
+	if got := c.do(args...); fmt.Sprint(got) != fmt.Sprint(want) {
+		c.t.Fatalf("%s = %#v, want %#v", strings.Join(args, " "), got, want)
+	}
+}
+
+// expectError sends a request and fails the test unless it is answered with an error
+// starting with prefix.
+func (c *testConn) expectError(prefix string, args ...string) {
+	c.t.Helper()
+	got, ok := c.do(args...).(respError)
+	if !ok || !strings.HasPrefix(string(got), prefix) {
+		c.t.Fatalf("%s = %#v, want an error starting with %q", strings.Join(args, " "), got, prefix)
+	}
+}
+
+func TestSetConditions(t *testing.T) {
+	c := startServer(t)
+
+	c.expect("OK", "SET", "k", "v1", "NX")
+	c.expect(nil, "SET", "k", "v2", "NX")
+	c.expect("v1", "GET", "k")
+
+	c.expect("OK", "SET", "k", "v3", "XX")
+	c.expect("v3", "GET", "k")
+	c.expect(nil, "SET", "missing", "v", "XX")
+	c.expect(int64(0), "EXISTS", "missing")
+
+	// Options are case-insensitive, and NX and XX exclude each other.
+	c.expect("OK", "set", "other", "v", "nx")
+	c.expectError("ERR syntax error", "SET", "k", "v", "NX", "XX")
+	c.expectError("ERR syntax error", "SET", "k", "v", "KEEPTTL")
+	c.expectError("ERR wrong number of arguments", "SET", "k")
+}
+
+func TestSetExpiry(t *testing.T) {
+	c := startServer(t)
+
+	c.expect("OK", "SET", "ex", "v", "EX", "100")
+	c.expect(int64(100), "TTL", "ex")
+	c.expect(nil, "SET", "px", "v", "PX", "100000", "XX")
+	c.expect(nil, "GET", "px")
+	c.expect("OK", "SET", "px", "v", "PX", "100000")
+	if got := c.do("PTTL", "px").(int64); got <= 99000 || got > 100000 {
+		t.Fatalf("PTTL px = %d, want just under 100000", got)
+	}
+
+	// A plain SET clears the expiry time.
+	c.expect("OK", "SET", "ex", "v2")
+	c.expect(int64(-1), "TTL", "ex")
+	c.expect(int64(-2), "TTL", "missing")
+
+	c.expectError("ERR invalid expire time", "SET", "k", "v", "EX", "0")
+	c.expectError("ERR invalid expire time", "SET", "k", "v", "PX", "-5")
+	c.expectError("ERR value is not an integer", "SET", "k", "v", "EX", "soon")
+	c.expectError("ERR syntax error", "SET", "k", "v", "EX", "1", "PX", "1000")
+	c.expectError("ERR syntax error", "SET", "k", "v", "EX")
+
+	c.expect("OK", "SET", "short", "v", "PX", "20")
+	time.Sleep(50 * time.Millisecond)
+	c.expect(nil, "GET", "short")
+	c.expect(int64(-2), "TTL", "short")
+
+	c.expect(int64(1), "EXPIRE", "ex", "50")
+	c.expect(int64(50), "TTL", "ex")
+	c.expect("v2", "GET", "ex")
+	c.expect(int64(0), "EXPIRE", "missing", "50")
+	c.expect(int64(1), "EXPIRE", "ex", "0")
+	c.expect(int64(0), "EXISTS", "ex")
+}
+
+func TestScanCursors(t *testing.T) {
+	c := startServer(t)
+
+	var want []string
+	for i := range 25 {
+		key := fmt.Sprintf("user:%02d", i)
+		want = append(want, key)
+		c.expect("OK", "SET", key, "v")
+	}
+	c.expect("OK", "SET", "other", "v")
+
+	// Every key is returned exactly once, at most COUNT per call, and the iteration
+	// ends with cursor 0.
+	var got []string
+	cursor, calls := "0", 0
+	for {
+		reply := c.do("SCAN", cursor, "MATCH", "user:*", "COUNT", "10").([]any)
+		cursor = reply[0].(string)
+		keys := reply[1].([]any)
+		if len(keys) > 10 {
+			t.Fatalf("SCAN returned %d keys with COUNT 10", len(keys))
+		}
+		for _, key := range keys {
+			got = append(got, key.(string))
+		}
+		calls++
+		if cursor == "0" {
+			break
+		}
+		if calls > 10 {
+			t.Fatal("SCAN did not end")
+		}
+	}
+	slices.Sort(got)
+	if !slices.Equal(got, want) {
+		t.Fatalf("SCAN returned %v, want %v", got, want)
+	}
+
+	// A cursor stays valid after use and may be continued on another connection.
+	first := c.do("SCAN", "0", "COUNT", "5").([]any)
+	next := first[0].(string)
+	again := c.do("SCAN", next, "COUNT", "5").([]any)
+	other := dial(t, c.conn.RemoteAddr().String())
+	if got := other.do("SCAN", next, "COUNT", "5").([]any); fmt.Sprint(got[1]) != fmt.Sprint(again[1]) {
+		t.Fatalf("SCAN %s on another connection returned %v, want %v", next, got[1], again[1])
+	}
+
+	// Every value is a string, so other types match nothing.
+	c.expect([]any{"0", []any{}}, "SCAN", "0", "TYPE", "hash")
+	c.expectError("ERR invalid cursor", "SCAN", "12345")
+	c.expectError("ERR invalid cursor", "SCAN", "abc")
+	c.expectError("ERR value is out of range", "SCAN", "0", "COUNT", "0")
+	c.expectError("ERR syntax error", "SCAN", "0", "MATCH")
+}
+
+func TestPipelining(t *testing.T) {
+	c := startServer(t)
+
+	c.send("SET", "a", "1")
+	c.send("GET", "a")
+	c.send("DEL", "a", "b")
+	c.send("GET", "a")
+	c.send("PING")
+
+	for i, want := range []any{"OK", "1", int64(1), nil, "PONG"} {
+		if got := c.read(); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Fatalf("reply %d = %#v, want %#v", i, got, want)
+		}
+	}
+}
+
+func TestShutdownSendsPipelinedReplies(t *testing.T) {
+	server, instance, c := serve(t)
+
+	// A command that holds its connection until Shutdown has begun, so that the
+	// requests pipelined behind it are still buffered when the server stops.
+	executing := make(chan struct{})
+	commands["waitshutdown"] = command{arity: 1, handler: func(s *Server, c *client, args [][]byte) {
+		close(executing)
+		for !s.closed.Load() {
+			time.Sleep(time.Millisecond)
+		}
+		c.writer.simple("OK")
+	}}
+	t.Cleanup(func() { delete(commands, "waitshutdown") })
+
+	batch := request("SET", "a", "1") + request("WAITSHUTDOWN") + request("SET", "b", "2")
+	if _, err := c.conn.Write([]byte(batch)); err != nil {
+		t.Fatal(err)
+	}
+
+	<-executing
+	if err := server.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	// Replies to the requests that ran are delivered; the rest of the batch is dropped.
+	for i, want := range []any{"OK", "OK"} {
+		if got := c.read(); got != want {
+			t.Fatalf("reply %d = %#v, want %#v", i, got, want)
+		}
+	}
+	if _, err := c.r.ReadByte(); err != io.EOF {
+		t.Fatalf("read after the last reply error = %v, want EOF", err)
+	}
+
+	if _, err := instance.Get(context.Background(), "a"); err != nil {
+		t.Fatalf("Get(a) error = %v", err)
+	}
+	if _, err := instance.Get(context.Background(), "b"); err == nil {
+		t.Fatal("request pipelined behind the shutdown was executed")
+	}
+}
+
+func TestHelloSwitchesProtocol(t *testing.T) {
+	c := startServer(t)
+
+	hello, ok := c.do("HELLO", "3").(map[string]any)
+	if !ok || hello["proto"] != int64(3) || hello["server"] != "ignite" {
+		t.Fatalf("HELLO 3 = %#v", hello)
+	}
+	// RESP3 answers a missing key with a null instead of a null bulk string.
+	if _, err := c.conn.Write([]byte("*2\r\n$3\r\nGET\r\n$7\r\nmissing\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	if line, _ := c.r.ReadString('\n'); line != "_\r\n" {
+		t.Fatalf("GET missing over RESP3 = %q, want a null", line)
+	}
+	c.expectError("NOPROTO", "HELLO", "4")
+}
+
+// infoFields sends INFO with the given sections and returns its fields by name.
+func (c *testConn) infoFields(sections ...string) map[string]string {
+	c.t.Helper()
+
+	reply, ok := c.do(append([]string{"INFO"}, sections...)...).(string)
+	if !ok {
+		c.t.Fatalf("INFO %v did not answer with a bulk string", sections)
+	}
+	fields := make(map[string]string)
+	for _, line := range strings.Split(reply, "\r\n") {
+		if name, value, ok := strings.Cut(line, ":"); ok && !strings.HasPrefix(line, "#") {
+			fields[name] = value
+		}
+	}
+	return fields
+}
+
+func TestInfoReportsCompaction(t *testing.T) {
+	c, instance := startStore(t)
+
+	fields := c.infoFields("compaction")
+	if fields["compaction_runs"] != "0" || fields["compaction_reclaimed_bytes"] != "0" {
+		t.Fatalf("INFO compaction on a fresh store = %v", fields)
+	}
+	if _, ok := fields["compaction_run0"]; ok {
+		t.Fatalf("INFO compaction lists a run on a fresh store: %v", fields)
+	}
+
+	value := strings.Repeat("v", 100)
+	for i := range 200 {
+		c.expect("OK", "SET", fmt.Sprintf("key-%d", i%10), value)
+	}
+	// A backup seals the active segment, so that compaction can rewrite it.
+	if _, err := instance.BackupTo(context.Background(), t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	before := c.infoFields("storage")
+
+	run, err := instance.Compact(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.Segments == 0 || run.ReclaimedBytes == 0 {
+		t.Fatalf("Compact() = %+v, want a segment compacted", run)
+	}
+
+	fields = c.infoFields("compaction")
+	want := fmt.Sprintf("started=%d,duration_ms=%d,segments=%d,reclaimed_bytes=%d",
+		run.Started.Unix(), run.Duration.Milliseconds(), run.Segments, run.ReclaimedBytes)
+	if fields["compaction_runs"] != "1" || fields["compaction_reclaimed_bytes"] != strconv.FormatUint(run.ReclaimedBytes, 10) ||
+		fields["compaction_run0"] != want {
+		t.Fatalf("INFO compaction after a run = %v, want the run %s", fields, want)
+	}
+
+	// The storage section moves with it: the dead bytes are gone.
+	after := c.infoFields("storage")
+	deadBefore, _ := strconv.ParseInt(before["dead_bytes"], 10, 64)
+	deadAfter, _ := strconv.ParseInt(after["dead_bytes"], 10, 64)
+	if deadBefore == 0 || deadAfter >= deadBefore {
+		t.Fatalf("dead_bytes = %d before compaction and %d after", deadBefore, deadAfter)
+	}
+	if after["index_keys"] != "10" {
+		t.Fatalf("index_keys = %s after compaction, want 10", after["index_keys"])
+	}
+
+	// Segments are only listed when asked for.
+	for name := range c.infoFields() {
+		if strings.HasPrefix(name, "segment") && name != "segments" {
+			t.Fatalf("INFO without sections lists %s", name)
+		}
+	}
+	segments := c.infoFields("segments")
+	for _, segment := range instance.Stats().Segments {
+		if !strings.HasPrefix(segments[fmt.Sprintf("segment%d", segment.ID)], "file="+segment.File+",") {
+			t.Fatalf("INFO segments = %v, want segment %d", segments, segment.ID)
+		}
+	}
+	if len(segments) != len(instance.Stats().Segments) {
+		t.Fatalf("INFO segments = %v, want every live segment", segments)
+	}
+}
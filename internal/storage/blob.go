@@ -0,0 +1,641 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/iamNilotpal/ignite/pkg/errors"
+	"github.com/iamNilotpal/ignite/pkg/filesys"
+)
+
+// Values at or above the configured blob threshold are not stored in a segment.
+// Each one is written to its own file in the blob directory, and the segment entry
+// (flagged FlagBlob) holds an encoded BlobRef instead of the value. Blob files are
+// immutable: overwriting a key writes a new blob and the old file is removed once the
+// new reference is durable. Every blob file starts with a header:
+//
+//	+-------+---------+-------+--------------------------------+----------+
+//	| Magic | Version | Flags | KeyIDLen, KeyID, WrappedLen, … | Checksum |
+//	|   8   |    1    |   1   |   only when blobEncrypted set  |    4     |
+//	+-------+---------+-------+--------------------------------+----------+
+//
+// The key fields have the same layout as in an encrypted segment header. The value
+// follows the header: as-is for plaintext blobs, or split into blobChunkSize chunks
+// that are each sealed with AES-GCM under the blob's data key. A chunk's nonce is
+// its index, and its additional data marks whether it is the last chunk, so chunks
+// can be neither reordered nor dropped from the end without failing decryption.
+const (
+	// blobMagic identifies a blob file header.
+	blobMagic = "IGNITE\x00B"
+
+	// blobHeaderVersion is the current blob header format version.
+	blobHeaderVersion uint8 = 1
+
+	// blobEncrypted marks a blob whose value is stored in sealed chunks.
+	blobEncrypted uint8 = 1 << 0
+
+	// blobChunkSize is the amount of plaintext sealed into each chunk of an encrypted
+	// blob. It bounds the memory needed to stream a blob regardless of its size.
+	blobChunkSize = 64 * 1024
+
+	// blobTagSize is the size of the authentication tag appended to every sealed chunk.
+	blobTagSize = 16
+
+	// blobExt and blobTempExt name finished blob files and blobs still being written.
+	blobExt     = ".blob"
+	blobTempExt = ".tmp"
+
+	// BlobRefSize is the size of an encoded BlobRef:
+	// Version (1) + ID (16) + Size (8) + Checksum (4).
+	BlobRefSize = 29
+
+	// blobRefVersion is the current BlobRef encoding version.
+	blobRefVersion uint8 = 1
+)
+
+// BlobID uniquely identifies a blob file. IDs are random, so a blob written for a key
+// never collides with an older blob of the same key that is still being removed.
+type BlobID [16]byte
+
+// String returns the hexadecimal form of the ID, which is also the blob's file name
+// without extension.
+func (id BlobID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// ParseBlobID parses the hexadecimal form produced by BlobID.String.
+func ParseBlobID(s string) (BlobID, error) {
+	var id BlobID
+	raw, err := hex.DecodeString(s)
+	if err != nil || len(raw) != len(id) {
+		return id, fmt.Errorf("invalid blob ID %q", s)
+	}
+	copy(id[:], raw)
+	return id, nil
+}
+
+// BlobRef is the value stored in a segment entry whose actual value lives in a blob
+// file. It records enough to verify the blob when it is read back.
+type BlobRef struct {
+	ID       BlobID // Identifier and file name of the blob.
+	Size     uint64 // Length of the value in bytes.
+	Checksum uint32 // CRC32 (IEEE) of the value.
+}
+
+// Encode serializes the reference into the value of a FlagBlob entry.
+func (r *BlobRef) Encode() []byte {
+	buf := make([]byte, BlobRefSize)
+	buf[0] = blobRefVersion
+	copy(buf[1:17], r.ID[:])
+	binary.LittleEndian.PutUint64(buf[17:25], r.Size)
+	binary.LittleEndian.PutUint32(buf[25:29], r.Checksum)
+	return buf
+}
+
+// DecodeBlobRef parses the value of a FlagBlob entry.
+func DecodeBlobRef(buf []byte) (*BlobRef, error) {
+	if len(buf) != BlobRefSize || buf[0] != blobRefVersion {
+		return nil, fmt.Errorf("invalid blob reference of %d bytes", len(buf))
+	}
+
+	ref := &BlobRef{
+		Size:     binary.LittleEndian.Uint64(buf[17:25]),
+		Checksum: binary.LittleEndian.Uint32(buf[25:29]),
+	}
+	copy(ref.ID[:], buf[1:17])
+	return ref, nil
+}
+
+// WriteBlob streams r into a new blob file and returns a reference to it. The value
+// is written to a temporary file that is synced and renamed into place, so a blob
+// file that exists under its final name is always complete. Memory use is bounded by
+// the chunk size regardless of the value's length.
+func (s *Storage) WriteBlob(r io.Reader) (*BlobRef, error) {
+	if s.closed.Load() {
+		return nil, ErrSegmentClosed
+	}
+	if s.readOnly {
+		return nil, errors.NewReadOnlyError("blob_write")
+	}
+
+	dir := s.blobDir()
+	if err := filesys.CreateDir(dir, 0755, true); err != nil {
+		return nil, errors.ClassifyDirectoryCreationError(err, dir)
+	}
+
+	ref := &BlobRef{}
+	if _, err := rand.Read(ref.ID[:]); err != nil {
+		return nil, errors.NewStorageError(err, errors.ErrorCodeInternal, "Failed to generate blob ID").
+			WithDetail("operation", "blob_write")
+	}
+
+	path := s.blobPath(ref.ID)
+	tempPath := path + blobTempExt
+
+	file, err := os.OpenFile(tempPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, errors.ClassifyFileOpenError(err, tempPath, filepath.Base(tempPath))
+	}
+
+	fail := func(err error, operation string) (*BlobRef, error) {
+		file.Close()
+		os.Remove(tempPath)
+		if _, ok := errors.AsStorageError(err); ok {
+			return nil, err
+		}
+		return nil, errors.NewFileAccessError(tempPath, filepath.Base(tempPath), operation, err)
+	}
+
+	header, aead, err := s.newBlobHeader(ref.ID)
+	if err != nil {
+		return fail(err, "blob_header")
+	}
+
+	out := bufio.NewWriterSize(&countingWriter{w: file, n: &s.io.written}, blobChunkSize)
+	if _, err := out.Write(header); err != nil {
+		return fail(err, "blob_header")
+	}
+
+	hasher := crc32.NewIEEE()
+	in := io.TeeReader(r, hasher)
+
+	if aead == nil {
+		n, err := io.Copy(out, in)
+		if err != nil {
+			return fail(err, "blob_write")
+		}
+		ref.Size = uint64(n)
+	} else {
+		n, err := writeSealedChunks(out, bufio.NewReaderSize(in, blobChunkSize), aead)
+		if err != nil {
+			return fail(err, "blob_write")
+		}
+		ref.Size = n
+	}
+
+	if err := out.Flush(); err != nil {
+		return fail(err, "blob_write")
+	}
+	if err := s.syncFile(file); err != nil {
+		return fail(err, "blob_sync")
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tempPath)
+		return nil, errors.NewFileAccessError(tempPath, filepath.Base(tempPath), "blob_close", err)
+	}
+
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return nil, errors.NewFileAccessError(path, filepath.Base(path), "blob_rename", err)
+	}
+	if err := syncDir(dir); err != nil {
+		return nil, errors.NewFileAccessError(dir, filepath.Base(dir), "blob_dir_sync", err)
+	}
+
+	ref.Checksum = hasher.Sum32()
+	return ref, nil
+}
+
+// OpenBlob opens the blob a reference points at. The returned reader yields the
+// original value and verifies its length and checksum once the end is reached, so
+// callers must read it to io.EOF to be sure the value is intact.
+func (s *Storage) OpenBlob(ref *BlobRef) (*BlobReader, error) {
+	if s.closed.Load() {
+		return nil, ErrSegmentClosed
+	}
+
+	path := s.blobPath(ref.ID)
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.NewBlobCorruptionError(path, "missing blob file", err)
+		}
+		return nil, errors.ClassifyFileOpenError(err, path, filepath.Base(path))
+	}
+
+	reader := bufio.NewReaderSize(&countingReader{file: file, n: &s.io.read}, blobChunkSize+blobTagSize)
+	aead, err := s.readBlobHeader(ref.ID, path, reader)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &BlobReader{
+		file:   file,
+		reader: reader,
+		aead:   aead,
+		ref:    *ref,
+		path:   path,
+		hasher: crc32.NewIEEE(),
+	}, nil
+}
+
+// RemoveBlob deletes a blob file. A blob that is already gone is not an error.
+func (s *Storage) RemoveBlob(id BlobID) error {
+	if s.readOnly {
+		return errors.NewReadOnlyError("blob_remove")
+	}
+
+	path := s.blobPath(id)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return errors.NewFileAccessError(path, filepath.Base(path), "blob_remove", err)
+	}
+	return nil
+}
+
+// BlobIDs lists every complete blob file in the blob directory. Partially written
+// blobs are not included.
+func (s *Storage) BlobIDs() ([]BlobID, error) {
+	files, err := filesys.ReadDir(filepath.Join(s.blobDir(), "*"+blobExt))
+	if err != nil {
+		return nil, errors.NewFileAccessError(s.blobDir(), filepath.Base(s.blobDir()), "blob_list", err)
+	}
+
+	ids := make([]BlobID, 0, len(files))
+	for _, file := range files {
+		id, err := ParseBlobID(strings.TrimSuffix(filepath.Base(file), blobExt))
+		if err != nil {
+			s.log.Warnw("Ignoring unrecognized file in blob directory", "path", file)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// removeTempBlobs deletes blobs left behind by writes that were interrupted before
+// the blob was renamed into place, along with the staging files of streamed appends.
+func (s *Storage) removeTempBlobs() {
+	files, err := filesys.ReadDir(filepath.Join(s.blobDir(), "*"+blobExt+blobTempExt))
+	if err != nil {
+		return
+	}
+
+	for _, file := range files {
+		if err := os.Remove(file); err != nil {
+			s.log.Warnw("Failed to remove incomplete blob", "path", file, "error", err)
+			continue
+		}
+		s.log.Infow("Removed incomplete blob", "path", file)
+	}
+}
+
+// Sync flushes the active segment to stable storage. It is used to make a new blob
+// reference durable before the blob it replaces is deleted.
+func (s *Storage) Sync() error {
+	if s.closed.Load() {
+		return ErrSegmentClosed
+	}
+	if s.readOnly {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.syncLocked()
+}
+
+// SetSyncWrites sets whether every append syncs the active segment before it returns,
+// as the "always" sync policy requires. It takes effect from the next append.
+func (s *Storage) SetSyncWrites(on bool) {
+	s.syncWrites.Store(on)
+}
+
+// syncLocked flushes the active segment to stable storage. The caller must hold the
+// write lock.
+func (s *Storage) syncLocked() error {
+	if err := s.syncFile(s.activeSegment); err != nil {
+		return errors.ClassifySyncError(
+			err, filepath.Base(s.segments[s.activeSegmentId]), s.segments[s.activeSegmentId], int(s.size),
+		)
+	}
+	return nil
+}
+
+// syncWrite syncs the active segment after an append when SetSyncWrites is on, and
+// returns the position of the append, or the error if the sync failed. The caller
+// must hold the write lock.
+func (s *Storage) syncWrite(pos *Position, err error) (*Position, error) {
+	if err != nil || !s.syncWrites.Load() {
+		return pos, err
+	}
+	if err := s.syncLocked(); err != nil {
+		return nil, err
+	}
+	return pos, nil
+}
+
+// blobDir returns the directory holding blob files.
+func (s *Storage) blobDir() string {
+	return filepath.Join(s.options.DataDir, s.options.BlobOptions.Directory)
+}
+
+// blobPath returns the path of the blob file with the given ID.
+func (s *Storage) blobPath(id BlobID) string {
+	return filepath.Join(s.blobDir(), id.String()+blobExt)
+}
+
+// newBlobHeader builds the header of a new blob file. When a key provider is
+// configured it also generates the blob's data key and returns its cipher.
+func (s *Storage) newBlobHeader(id BlobID) ([]byte, cipher.AEAD, error) {
+	var header bytes.Buffer
+	header.WriteString(blobMagic)
+	header.WriteByte(blobHeaderVersion)
+
+	var aead cipher.AEAD
+	if s.options.KeyProvider == nil {
+		header.WriteByte(0)
+	} else {
+		var (
+			keyID   string
+			wrapped []byte
+			err     error
+		)
+		aead, keyID, wrapped, err = s.generateDataKey(blobAAD(id))
+		if err != nil {
+			return nil, nil, err
+		}
+		header.WriteByte(blobEncrypted)
+		writeKeyHeader(&header, keyID, wrapped)
+	}
+
+	binary.Write(&header, binary.LittleEndian, crc32.ChecksumIEEE(header.Bytes()))
+	return header.Bytes(), aead, nil
+}
+
+// readBlobHeader parses and verifies a blob header, returning the blob's data key
+// cipher for encrypted blobs and nil otherwise.
+func (s *Storage) readBlobHeader(id BlobID, path string, r io.Reader) (cipher.AEAD, error) {
+	keyID, wrapped, err := parseBlobHeader(path, r)
+	if err != nil || keyID == "" {
+		return nil, err
+	}
+
+	aead, err := s.unwrapDataKey(keyID, wrapped, blobAAD(id))
+	if err != nil {
+		if se, ok := errors.AsStorageError(err); ok {
+			se.WithPath(path)
+		}
+		return nil, err
+	}
+	return aead, nil
+}
+
+// parseBlobHeader parses and verifies a blob header, returning the ID of the master
+// key and the wrapped data key of an encrypted blob. Both are empty for a plaintext
+// blob.
+func parseBlobHeader(path string, r io.Reader) (string, []byte, error) {
+	header := make([]byte, len(blobMagic)+2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return "", nil, errors.NewBlobCorruptionError(path, "truncated header", err)
+	}
+	if string(header[:len(blobMagic)]) != blobMagic {
+		return "", nil, errors.NewBlobCorruptionError(path, "bad magic", nil)
+	}
+	if version := header[len(blobMagic)]; version != blobHeaderVersion {
+		return "", nil, errors.NewBlobCorruptionError(path, fmt.Sprintf("unsupported header version %d", version), nil)
+	}
+
+	flags := header[len(blobMagic)+1]
+
+	var keyID string
+	var wrapped []byte
+	if flags&blobEncrypted != 0 {
+		var err error
+		if keyID, wrapped, err = readKeyHeader(r, &header); err != nil {
+			return "", nil, errors.NewBlobCorruptionError(path, "truncated key header", err)
+		}
+	}
+
+	var checksum [4]byte
+	if _, err := io.ReadFull(r, checksum[:]); err != nil {
+		return "", nil, errors.NewBlobCorruptionError(path, "truncated header", err)
+	}
+	if crc32.ChecksumIEEE(header) != binary.LittleEndian.Uint32(checksum[:]) {
+		return "", nil, errors.NewBlobCorruptionError(path, "header checksum mismatch", nil)
+	}
+	return keyID, wrapped, nil
+}
+
+// BlobKeyID returns the ID of the master key that wraps the data key of a blob, or
+// an empty string for a plaintext blob.
+func (s *Storage) BlobKeyID(id BlobID) (string, error) {
+	path := s.blobPath(id)
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", errors.NewBlobCorruptionError(path, "missing blob file", err)
+		}
+		return "", errors.ClassifyFileOpenError(err, path, filepath.Base(path))
+	}
+	defer file.Close()
+
+	keyID, _, err := parseBlobHeader(path, bufio.NewReader(file))
+	return keyID, err
+}
+
+// writeSealedChunks splits r into chunks, seals each one and writes it to w. The last
+// chunk is always written, even if empty, so truncation at a chunk boundary is
+// detected. It returns the number of plaintext bytes consumed.
+func writeSealedChunks(w io.Writer, r *bufio.Reader, aead cipher.AEAD) (uint64, error) {
+	plain := make([]byte, blobChunkSize)
+	sealed := make([]byte, 0, blobChunkSize+blobTagSize)
+
+	var total uint64
+	for index := uint64(0); ; index++ {
+		n, final, err := readChunk(r, plain)
+		if err != nil {
+			return total, err
+		}
+		total += uint64(n)
+
+		if !final {
+			if _, err := r.Peek(1); err == io.EOF {
+				final = true
+			} else if err != nil {
+				return total, err
+			}
+		}
+
+		sealed = aead.Seal(sealed[:0], chunkNonce(index), plain[:n], chunkAAD(final))
+		if _, err := w.Write(sealed); err != nil {
+			return total, err
+		}
+		if final {
+			return total, nil
+		}
+	}
+}
+
+// readChunk fills buf from r, reporting whether r ended before buf was full. Unlike
+// io.ReadFull it passes on every error other than io.EOF, including an
+// io.ErrUnexpectedEOF from r itself, so a failed source is never mistaken for the end
+// of the value.
+func readChunk(r io.Reader, buf []byte) (int, bool, error) {
+	n := 0
+	for n < len(buf) {
+		m, err := r.Read(buf[n:])
+		n += m
+		if err == io.EOF {
+			return n, true, nil
+		}
+		if err != nil {
+			return n, false, err
+		}
+	}
+	return n, false, nil
+}
+
+// BlobReader streams the value of a blob file. It verifies the value's length and
+// checksum against its reference when the end is reached and reports a mismatch in
+// place of io.EOF.
+type BlobReader struct {
+	file    *os.File      // Open blob file.
+	reader  *bufio.Reader // Buffered reader positioned after the header.
+	aead    cipher.AEAD   // Data key cipher; nil for plaintext blobs.
+	ref     BlobRef       // Reference the blob is verified against.
+	path    string        // Path of the blob file, for errors.
+	hasher  hash.Hash32   // Running checksum of the value read so far.
+	read    uint64        // Number of value bytes returned so far.
+	pending []byte        // Decrypted bytes not yet returned.
+	sealed  []byte        // Buffer for the sealed chunk being read.
+	chunk   uint64        // Index of the next chunk to decrypt.
+	done    bool          // Whether the last chunk has been consumed.
+}
+
+// Size returns the length of the value in bytes.
+func (b *BlobReader) Size() uint64 {
+	return b.ref.Size
+}
+
+// Read implements io.Reader.
+func (b *BlobReader) Read(p []byte) (int, error) {
+	for len(b.pending) == 0 {
+		if b.done {
+			return 0, b.verify()
+		}
+		if err := b.fill(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, b.pending)
+	b.pending = b.pending[n:]
+	return n, nil
+}
+
+// fill loads the next run of value bytes into pending.
+func (b *BlobReader) fill() error {
+	if b.aead == nil {
+		if b.sealed == nil {
+			b.sealed = make([]byte, blobChunkSize)
+		}
+		n, err := b.reader.Read(b.sealed)
+		if err == io.EOF {
+			b.done = true
+		} else if err != nil {
+			return errors.NewFileAccessError(b.path, filepath.Base(b.path), "blob_read", err)
+		}
+		return b.accept(b.sealed[:n])
+	}
+
+	if b.sealed == nil {
+		b.sealed = make([]byte, blobChunkSize+blobTagSize)
+	}
+
+	n, err := io.ReadFull(b.reader, b.sealed)
+	switch {
+	case err == io.EOF:
+		return errors.NewBlobCorruptionError(b.path, "missing final chunk", nil)
+	case err == io.ErrUnexpectedEOF:
+		b.done = true
+	case err != nil:
+		return errors.NewFileAccessError(b.path, filepath.Base(b.path), "blob_read", err)
+	default:
+		if _, err := b.reader.Peek(1); err == io.EOF {
+			b.done = true
+		}
+	}
+
+	plain, err := b.aead.Open(b.sealed[:0], chunkNonce(b.chunk), b.sealed[:n], chunkAAD(b.done))
+	if err != nil {
+		return errors.NewBlobCorruptionError(b.path, "chunk authentication failed", err).
+			WithDetail("chunk", b.chunk)
+	}
+	b.chunk++
+	return b.accept(plain)
+}
+
+// accept records newly read value bytes as pending.
+func (b *BlobReader) accept(value []byte) error {
+	b.read += uint64(len(value))
+	if b.read > b.ref.Size {
+		return errors.NewBlobCorruptionError(b.path, "blob longer than its reference", nil).
+			WithDetail("expected_size", b.ref.Size)
+	}
+	b.hasher.Write(value)
+	b.pending = value
+	return nil
+}
+
+// verify checks the fully read value against the reference.
+func (b *BlobReader) verify() error {
+	if b.read != b.ref.Size {
+		return errors.NewBlobCorruptionError(b.path, "blob shorter than its reference", nil).
+			WithDetail("expected_size", b.ref.Size).
+			WithDetail("actual_size", b.read)
+	}
+	if b.hasher.Sum32() != b.ref.Checksum {
+		return errors.NewBlobCorruptionError(b.path, "checksum mismatch", nil).
+			WithDetail("stored_checksum", b.ref.Checksum)
+	}
+	return io.EOF
+}
+
+// Close releases the blob file.
+func (b *BlobReader) Close() error {
+	return b.file.Close()
+}
+
+// blobAAD returns the additional data that binds a wrapped blob data key to its blob.
+func blobAAD(id BlobID) []byte {
+	return append([]byte(blobMagic), id[:]...)
+}
+
+// chunkNonce returns the nonce of the chunk with the given index. Every blob has its
+// own data key, so a counter is a safe nonce.
+func chunkNonce(index uint64) []byte {
+	nonce := make([]byte, envelopeNonceSize)
+	binary.LittleEndian.PutUint64(nonce, index)
+	return nonce
+}
+
+// chunkAAD returns the additional data of a chunk, which marks the last one.
+func chunkAAD(final bool) []byte {
+	if final {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+// syncDir fsyncs a directory so that renames and file creations inside it are durable.
+func syncDir(dir string) error {
+	file, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return file.Sync()
+}
@@ -0,0 +1,320 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/iamNilotpal/ignite/pkg/errors"
+)
+
+// SegmentScan reports the outcome of reading a segment file with WalkSegment.
+type SegmentScan struct {
+	KeyID     string // ID of the master key wrapping the data key; empty for unencrypted segments.
+	DataStart int64  // Offset of the first entry, past the encryption header if any.
+	ValidSize int64  // Offset just past the last entry that was read intact.
+	FileSize  int64  // Size of the segment file.
+	Damage    error  // First damage found; nil when every byte up to FileSize is intact.
+}
+
+// Encrypted reports whether the segment starts with an encryption header.
+func (s *SegmentScan) Encrypted() bool {
+	return s.KeyID != ""
+}
+
+// WalkSegment reads a segment file directly, without opening a Storage, and calls fn
+// with every entry whose checksum holds, in file order. It is meant for offline tools
+// that inspect a data directory nobody is writing to.
+//
+// Checksums cover the bytes as stored, so segments can be verified without their
+// encryption keys; entries of encrypted segments are passed to fn sealed, with nil key
+// and value. Nothing past damage can be located reliably, so the walk stops at the
+// first damaged entry and records it in the returned SegmentScan rather than failing.
+// An error is only returned if the file cannot be read at all or fn fails.
+func WalkSegment(path string, segmentID uint64, fn func(pos *Position, entry *Entry) error) (*SegmentScan, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, errors.NewFileAccessError(path, filepath.Base(path), "segment_stat", err).
+			WithSegmentID(int(segmentID))
+	}
+
+	scan := &SegmentScan{FileSize: info.Size()}
+
+	keyID, _, dataStart, err := readSegmentHeader(segmentID, path)
+	if err != nil {
+		if !errors.IsStorageError(err) || errors.GetErrorCode(err) != errors.ErrorCodeSegmentCorrupted {
+			return nil, err
+		}
+		scan.Damage = err
+		return scan, nil
+	}
+	scan.KeyID = keyID
+	scan.DataStart = dataStart
+
+	var fnErr error
+	scan.ValidSize, err = walkEntries(path, segmentID, dataStart, func(pos *Position, entry *Entry) error {
+		if fnErr = fn(pos, entry); fnErr != nil {
+			return fnErr
+		}
+		return nil
+	})
+	if fnErr != nil {
+		return nil, fnErr
+	}
+	scan.Damage = err
+	return scan, nil
+}
+
+// LockDir takes the exclusive lock on a data directory, the same one a writable
+// Storage holds, so that offline tools never work on a directory a live process is
+// writing to. The returned function releases the lock.
+func LockDir(dataDir string) (func() error, error) {
+	lock, err := acquireDirLock(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	return lock.release, nil
+}
+
+// DamagedRegion is a stretch of a segment file that failed verification and was left
+// out by SalvageSegment.
+type DamagedRegion struct {
+	Offset int64 // Offset of the first damaged byte.
+	Length int64 // Number of damaged bytes.
+
+	// Entry is the damaged entry when the region starts with a header that still
+	// describes its extent, so that exactly one entry was lost; nil when nothing in the
+	// region could be identified. Its key is only set for unencrypted entries and may
+	// itself be damaged.
+	Entry *Entry
+}
+
+// Salvage reports the outcome of SalvageSegment.
+type Salvage struct {
+	Entries int             // Entries copied to the new segment file.
+	Size    int64           // Size of the new segment file.
+	Regions []DamagedRegion // Damaged regions that were skipped, in file order.
+}
+
+// LostBytes returns the total size of the damaged regions.
+func (s *Salvage) LostBytes() int64 {
+	var lost int64
+	for _, region := range s.Regions {
+		lost += region.Length
+	}
+	return lost
+}
+
+// SalvageSegment copies every entry of the segment file at src that passes its
+// checksum into a new segment file at dest, which must not exist, and syncs it. Where
+// WalkSegment stops at damage, SalvageSegment resynchronizes: it skips the damaged
+// entry when its header still gives its size and the next entry checks out, and
+// otherwise scans forward byte by byte for the next offset at which a complete entry
+// with a valid checksum starts. fn is called with every entry copied, with its
+// position in src.
+//
+// Entries are copied byte for byte, along with the encryption header of an encrypted
+// segment, so no keys are needed. If that header is itself damaged, the data key is
+// lost and none of the entries can be salvaged. The whole source file is read into
+// memory.
+func SalvageSegment(src, dest string, segmentID uint64, fn func(pos *Position, entry *Entry) error) (*Salvage, error) {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return nil, errors.NewFileAccessError(src, filepath.Base(src), "segment_read", err).
+			WithSegmentID(int(segmentID))
+	}
+
+	salvage := &Salvage{}
+	var out []byte
+
+	keyID, _, dataStart, err := readSegmentHeader(segmentID, src)
+	switch {
+	case err != nil && errors.GetErrorCode(err) == errors.ErrorCodeSegmentCorrupted:
+		salvage.Regions = append(salvage.Regions, DamagedRegion{Offset: 0, Length: int64(len(data))})
+		return salvage, writeSegmentFile(dest, nil)
+	case err != nil:
+		return nil, err
+	}
+	out = append(out, data[:dataStart]...)
+
+	err = resyncEntries(data, dataStart, segmentID, keyID != "", func(pos *Position, entry *Entry, region *DamagedRegion) error {
+		if region != nil {
+			salvage.Regions = append(salvage.Regions, *region)
+			return nil
+		}
+		if err := fn(pos, entry); err != nil {
+			return err
+		}
+		out = append(out, data[pos.Offset:pos.Offset+int64(pos.Size)]...)
+		salvage.Entries++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	salvage.Size = int64(len(out))
+	return salvage, writeSegmentFile(dest, out)
+}
+
+// InspectSegment reads every entry of a segment file, damaged or not, for debugging.
+// Like SalvageSegment it resynchronizes after damage, so that the entries following a
+// damaged one are still shown. fn is called in file order with either an entry that
+// passed its checksum and its position, or with a damaged region; the other arguments
+// are nil. Entries of encrypted segments are passed on sealed.
+//
+// The returned SegmentScan describes the segment the same way WalkSegment does. When
+// the encryption header is damaged, fn is called once with the whole file as a
+// damaged region.
+func InspectSegment(path string, segmentID uint64, fn func(pos *Position, entry *Entry, region *DamagedRegion) error) (*SegmentScan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.NewFileAccessError(path, filepath.Base(path), "segment_read", err).
+			WithSegmentID(int(segmentID))
+	}
+	scan := &SegmentScan{FileSize: int64(len(data))}
+
+	keyID, _, dataStart, err := readSegmentHeader(segmentID, path)
+	switch {
+	case err != nil && errors.GetErrorCode(err) == errors.ErrorCodeSegmentCorrupted:
+		scan.Damage = err
+		return scan, fn(nil, nil, &DamagedRegion{Offset: 0, Length: int64(len(data))})
+	case err != nil:
+		return nil, err
+	}
+	scan.KeyID = keyID
+	scan.DataStart = dataStart
+	scan.ValidSize = dataStart
+
+	err = resyncEntries(data, dataStart, segmentID, keyID != "", func(pos *Position, entry *Entry, region *DamagedRegion) error {
+		switch {
+		case region != nil && scan.Damage == nil:
+			scan.Damage = errors.NewSegmentCorruptionError(
+				int(segmentID), int(region.Offset), fmt.Errorf("%d damaged bytes", region.Length),
+			)
+		case region == nil && scan.Damage == nil:
+			scan.ValidSize = pos.Offset + int64(pos.Size)
+		}
+		return fn(pos, entry, region)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return scan, nil
+}
+
+// resyncEntries walks the entries held in data from dataStart on, calling fn with each
+// entry that passes its checksum or with each damaged region between them. After
+// damage it skips exactly one entry when that entry's header still gives its size and
+// the entry after it checks out, and otherwise scans forward byte by byte for the next
+// offset at which a complete entry with a valid checksum starts.
+func resyncEntries(
+	data []byte, dataStart int64, segmentID uint64, encrypted bool,
+	fn func(pos *Position, entry *Entry, region *DamagedRegion) error,
+) error {
+	// intactAt decodes the entry starting at offset if it passes its checksum and
+	// belongs in this segment.
+	intactAt := func(offset int64) (*Entry, int, bool) {
+		size, ok := entrySizeAt(data, offset)
+		if !ok {
+			return nil, 0, false
+		}
+		entry, err := DecodeEntry(data[offset:offset+int64(size)], segmentID, offset)
+		if err != nil || entry.IsEncrypted() != encrypted {
+			return nil, 0, false
+		}
+		return entry, size, true
+	}
+
+	offset := dataStart
+	end := int64(len(data))
+	for offset < end {
+		if entry, size, ok := intactAt(offset); ok {
+			if err := fn(&Position{SegmentID: segmentID, Offset: offset, Size: uint32(size)}, entry, nil); err != nil {
+				return err
+			}
+			offset += int64(size)
+			continue
+		}
+
+		region := DamagedRegion{Offset: offset, Length: -1}
+
+		// Damage inside a single entry leaves its header intact; skip exactly that entry
+		// if the next one checks out.
+		if size, ok := entrySizeAt(data, offset); ok {
+			next := offset + int64(size)
+			if _, _, intact := intactAt(next); intact || next == end {
+				region.Length = int64(size)
+				region.Entry = damagedEntry(data[offset:next])
+			}
+		}
+
+		if region.Length < 0 {
+			next := offset + 1
+			for ; next < end; next++ {
+				if _, _, ok := intactAt(next); ok {
+					break
+				}
+			}
+			region.Length = next - offset
+		}
+
+		if err := fn(nil, nil, &region); err != nil {
+			return err
+		}
+		offset += region.Length
+	}
+	return nil
+}
+
+// entrySizeAt returns the size of the entry whose header starts at offset, if the
+// header is plausible and the entry fits in data.
+func entrySizeAt(data []byte, offset int64) (int, bool) {
+	if offset+HeaderSize > int64(len(data)) {
+		return 0, false
+	}
+
+	h := decodeHeader(data[offset:])
+	if h.version < EntryVersion || h.version > EntryVersionExpiry {
+		return 0, false
+	}
+
+	size := h.size()
+	if size < HeaderSize || offset+int64(size) > int64(len(data)) {
+		return 0, false
+	}
+	return size, true
+}
+
+// damagedEntry describes an entry that failed its checksum from its header, with the
+// key as stored for unencrypted entries.
+func damagedEntry(buf []byte) *Entry {
+	h := decodeHeader(buf)
+	entry := &Entry{Timestamp: h.timestamp, Version: h.version, Flags: h.flags}
+	if !entry.IsEncrypted() {
+		key, expiresAt := splitKey(buf[HeaderSize:HeaderSize+int(h.keySize)], h.version, h.flags)
+		entry.Key, entry.ExpiresAt = append([]byte(nil), key...), expiresAt
+	}
+	return entry
+}
+
+// writeSegmentFile creates a segment file holding data and syncs it to stable storage.
+func writeSegmentFile(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return errors.ClassifyFileOpenError(err, path, filepath.Base(path))
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return errors.NewFileAccessError(path, filepath.Base(path), "segment_write", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return errors.ClassifySyncError(err, filepath.Base(path), path, len(data))
+	}
+	if err := file.Close(); err != nil {
+		return errors.NewFileAccessError(path, filepath.Base(path), "segment_close", err)
+	}
+	return syncDir(filepath.Dir(path))
+}
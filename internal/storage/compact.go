@@ -0,0 +1,154 @@
+package storage
+
+import (
+	"os"
+
+	"github.com/iamNilotpal/ignite/pkg/errors"
+)
+
+// CurrentKeyID returns the ID of the master key new segments and blobs are wrapped
+// with, or an empty string when encryption is disabled.
+func (s *Storage) CurrentKeyID() (string, error) {
+	if s.options.KeyProvider == nil {
+		return "", nil
+	}
+
+	keyID, _, err := s.options.KeyProvider.CurrentKey()
+	if err != nil {
+		return "", errors.NewEncryptionKeyError(keyID, 0, err).
+			WithDetail("operation", "key_current")
+	}
+	return keyID, nil
+}
+
+// SegmentKeyID returns the ID of the master key that wraps the data key of a segment,
+// or an empty string for a plaintext segment.
+func (s *Storage) SegmentKeyID(segmentID uint64) (string, error) {
+	s.mu.RLock()
+	path, ok := s.segments[segmentID]
+	s.mu.RUnlock()
+	if !ok {
+		return "", errors.NewStorageError(
+			os.ErrNotExist, errors.ErrorCodeIO, "Segment file not found for segment ID",
+		).WithSegmentID(int(segmentID)).
+			WithDetail("operation", "segment_lookup")
+	}
+
+	sc, err := s.cipherFor(segmentID, path)
+	if err != nil {
+		return "", err
+	}
+	return sc.keyID, nil
+}
+
+// RotateKey seals the active segment and starts a new one if the active segment is
+// not wrapped by the master key with the given ID, even when it holds no entries yet,
+// so that nothing more is written under an old key.
+func (s *Storage) RotateKey(keyID string) error {
+	if s.closed.Load() {
+		return ErrSegmentClosed
+	}
+	if s.readOnly {
+		return errors.NewReadOnlyError("segment_rotate")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.activeCipher.keyID == keyID {
+		return nil
+	}
+	return s.rotateSegment()
+}
+
+// ScanSegment walks the entries of a sealed segment in write order and invokes fn for
+// each one, decrypted. Unlike Scan it reads through its own file handle without
+// holding the storage lock, so appends and reads continue while a segment is being
+// compacted. A damaged entry stops the walk with a corruption error.
+func (s *Storage) ScanSegment(segmentID uint64, fn func(pos *Position, entry *Entry) error) error {
+	if s.closed.Load() {
+		return ErrSegmentClosed
+	}
+
+	s.mu.RLock()
+	path, ok := s.segments[segmentID]
+	active := segmentID == s.activeSegmentId && !s.readOnly
+	s.mu.RUnlock()
+
+	if !ok {
+		return errors.NewStorageError(
+			os.ErrNotExist, errors.ErrorCodeIO, "Segment file not found for segment ID",
+		).WithSegmentID(int(segmentID)).
+			WithDetail("operation", "segment_lookup")
+	}
+	if active {
+		return errors.NewStorageError(
+			nil, errors.ErrorCodeInvalidInput, "Cannot scan the active segment while it is written",
+		).WithSegmentID(int(segmentID)).
+			WithDetail("operation", "segment_scan")
+	}
+
+	sc, err := s.cipherFor(segmentID, path)
+	if err != nil {
+		return err
+	}
+
+	_, err = walkEntries(path, segmentID, sc.dataStart, func(pos *Position, entry *Entry) error {
+		if err := openEntry(sc, entry, segmentID, pos.Offset); err != nil {
+			return err
+		}
+		return fn(pos, entry)
+	})
+	return err
+}
+
+// RetireSegments removes sealed segments whose live entries compaction has copied
+// elsewhere. The manifest records them as obsolete in a single compaction record
+// first, so that a crash part way through never resurrects some of them: recovery
+// finishes deleting whatever files are left. Segments pinned by a snapshot are removed
+// once the last snapshot holding them is released.
+//
+// The entries that replaced them must be durable before this is called.
+func (s *Storage) RetireSegments(ids []uint64) error {
+	if s.closed.Load() {
+		return ErrSegmentClosed
+	}
+	if s.readOnly {
+		return errors.NewReadOnlyError("segment_retire")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range ids {
+		if id == s.activeSegmentId {
+			return errors.NewStorageError(
+				nil, errors.ErrorCodeInvalidInput, "Cannot retire the active segment",
+			).WithSegmentID(int(id)).
+				WithDetail("operation", "segment_retire")
+		}
+		if _, ok := s.segments[id]; !ok {
+			return errors.NewStorageError(
+				os.ErrNotExist, errors.ErrorCodeIO, "Segment file not found for segment ID",
+			).WithSegmentID(int(id)).
+				WithDetail("operation", "segment_retire")
+		}
+	}
+
+	if err := s.manifest.LogCompaction(ids, nil); err != nil {
+		return err
+	}
+
+	var firstErr error
+	for _, id := range ids {
+		if s.pins[id] > 0 {
+			s.doomed[id] = struct{}{}
+			s.log.Infow("Deferring removal of compacted segment pinned by a snapshot", "segmentID", id, "pins", s.pins[id])
+			continue
+		}
+		if err := s.removeSegmentLocked(id); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
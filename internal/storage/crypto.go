@@ -0,0 +1,376 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/iamNilotpal/ignite/pkg/errors"
+)
+
+// Encrypted segments start with a header that carries the segment's data key, wrapped
+// by a master key from the configured KeyProvider:
+//
+//	+-------+---------+----------+-------+------------+------------+----------+
+//	| Magic | Version | KeyIDLen | KeyID | WrappedLen | WrappedKey | Checksum |
+//	|   8   |    1    |    2     |   N   |     2      |     M      |    4     |
+//	+-------+---------+----------+-------+------------+------------+----------+
+//
+// The wrapped key is an AES-GCM envelope (nonce, ciphertext, tag) of the 32-byte data
+// key, authenticated together with the segment ID so it cannot be moved to another
+// segment. The checksum is a CRC32 (IEEE) over every preceding header byte. Entries
+// follow the header, so the first entry of an encrypted segment sits at the header size
+// rather than at offset zero. Unencrypted segments have no header at all, which keeps
+// segments written before encryption existed readable.
+const (
+	// segmentMagic identifies an encrypted segment header.
+	segmentMagic = "IGNITE\x00E"
+
+	// segmentHeaderVersion is the current segment header format version.
+	segmentHeaderVersion uint8 = 1
+
+	// dataKeySize is the size of per-segment data keys, selecting AES-256.
+	dataKeySize = 32
+
+	// maxSegmentHeaderSize bounds how much is read when looking for a segment header.
+	maxSegmentHeaderSize = len(segmentMagic) + 1 + 2 + 0xFFFF + 2 + 0xFFFF + 4
+)
+
+// segmentCipher describes how the entries of a single segment are protected.
+type segmentCipher struct {
+	aead      cipher.AEAD // Data key cipher; nil for unencrypted segments.
+	keyID     string      // ID of the master key that wraps the data key.
+	dataStart int64       // Offset of the first entry, i.e. the size of the header.
+}
+
+// plaintextCipher describes every unencrypted segment.
+var plaintextCipher = &segmentCipher{}
+
+// newSegmentCipher generates a data key for a new segment and returns its cipher along
+// with the header to write at the start of the segment file. Without a key provider it
+// returns plaintextCipher and no header.
+func (s *Storage) newSegmentCipher(segmentID uint64) (*segmentCipher, []byte, error) {
+	if s.options.KeyProvider == nil {
+		return plaintextCipher, nil, nil
+	}
+
+	aead, keyID, wrapped, err := s.generateDataKey(wrapAAD(segmentID))
+	if err != nil {
+		if se, ok := errors.AsStorageError(err); ok {
+			se.WithSegmentID(int(segmentID))
+		}
+		return nil, nil, err
+	}
+
+	var header bytes.Buffer
+	header.WriteString(segmentMagic)
+	header.WriteByte(segmentHeaderVersion)
+	writeKeyHeader(&header, keyID, wrapped)
+	binary.Write(&header, binary.LittleEndian, crc32.ChecksumIEEE(header.Bytes()))
+
+	return &segmentCipher{aead: aead, keyID: keyID, dataStart: int64(header.Len())}, header.Bytes(), nil
+}
+
+// generateDataKey creates a random data key and wraps it with the provider's current
+// master key, binding the wrapped key to aad. It returns the data key cipher, the
+// master key ID and the wrapped key.
+func (s *Storage) generateDataKey(aad []byte) (cipher.AEAD, string, []byte, error) {
+	keyID, masterKey, err := s.options.KeyProvider.CurrentKey()
+	if err != nil {
+		return nil, "", nil, errors.NewEncryptionKeyError(keyID, 0, err).
+			WithDetail("operation", "key_current")
+	}
+	if len(keyID) > 0xFFFF {
+		return nil, "", nil, errors.NewEncryptionKeyError(keyID[:64], 0, fmt.Errorf("key ID longer than %d bytes", 0xFFFF))
+	}
+
+	master, err := newAEAD(masterKey)
+	if err != nil {
+		return nil, "", nil, errors.NewEncryptionKeyError(keyID, 0, err).
+			WithDetail("operation", "key_current")
+	}
+
+	dataKey := make([]byte, dataKeySize)
+	nonce := make([]byte, master.NonceSize())
+	if _, err := rand.Read(dataKey); err == nil {
+		_, err = rand.Read(nonce)
+	}
+	if err != nil {
+		return nil, "", nil, errors.NewStorageError(err, errors.ErrorCodeInternal, "Failed to generate data key").
+			WithDetail("operation", "key_generate")
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, "", nil, errors.NewStorageError(err, errors.ErrorCodeInternal, "Failed to create data key cipher").
+			WithDetail("operation", "key_generate")
+	}
+
+	return aead, keyID, master.Seal(nonce, nonce, dataKey, aad), nil
+}
+
+// unwrapDataKey recovers a data key wrapped by generateDataKey and returns its cipher.
+func (s *Storage) unwrapDataKey(keyID string, wrapped, aad []byte) (cipher.AEAD, error) {
+	provider := s.options.KeyProvider
+	if provider == nil {
+		return nil, errors.NewEncryptionKeyError(keyID, 0, nil).
+			WithMessage("data is encrypted but no key provider is configured")
+	}
+
+	masterKey, err := provider.Key(keyID)
+	if err != nil {
+		return nil, errors.NewEncryptionKeyError(keyID, 0, err)
+	}
+
+	master, err := newAEAD(masterKey)
+	if err != nil {
+		return nil, errors.NewEncryptionKeyError(keyID, 0, err)
+	}
+
+	if len(wrapped) < master.NonceSize() {
+		return nil, errors.NewEncryptionKeyError(keyID, 0, fmt.Errorf("wrapped key too short")).
+			WithMessage("failed to unwrap data key")
+	}
+
+	dataKey, err := master.Open(nil, wrapped[:master.NonceSize()], wrapped[master.NonceSize():], aad)
+	if err != nil {
+		// Either the wrong master key is registered under this ID or the header was altered.
+		return nil, errors.NewEncryptionKeyError(keyID, 0, err).
+			WithMessage("failed to unwrap data key")
+	}
+
+	return newAEAD(dataKey)
+}
+
+// writeKeyHeader appends the key ID and wrapped data key, each prefixed with its
+// 16-bit length, to a file header.
+func writeKeyHeader(header *bytes.Buffer, keyID string, wrapped []byte) {
+	binary.Write(header, binary.LittleEndian, uint16(len(keyID)))
+	header.WriteString(keyID)
+	binary.Write(header, binary.LittleEndian, uint16(len(wrapped)))
+	header.Write(wrapped)
+}
+
+// readKeyHeader reads the fields written by writeKeyHeader, appending the raw bytes to
+// header so the caller can verify the header checksum.
+func readKeyHeader(r io.Reader, header *[]byte) (string, []byte, error) {
+	readField := func() ([]byte, error) {
+		var length [2]byte
+		if _, err := io.ReadFull(r, length[:]); err != nil {
+			return nil, err
+		}
+		field := make([]byte, binary.LittleEndian.Uint16(length[:]))
+		if _, err := io.ReadFull(r, field); err != nil {
+			return nil, err
+		}
+		*header = append(append(*header, length[:]...), field...)
+		return field, nil
+	}
+
+	keyID, err := readField()
+	if err != nil {
+		return "", nil, err
+	}
+	wrapped, err := readField()
+	if err != nil {
+		return "", nil, err
+	}
+	return string(keyID), wrapped, nil
+}
+
+// cipherFor returns the cipher of a segment, reading and unwrapping its header the first
+// time the segment is seen. It only touches the cipher cache, so it may be called with or
+// without the storage lock held.
+func (s *Storage) cipherFor(segmentID uint64, path string) (*segmentCipher, error) {
+	s.cipherMu.Lock()
+	defer s.cipherMu.Unlock()
+
+	if sc, ok := s.ciphers[segmentID]; ok {
+		return sc, nil
+	}
+
+	sc, err := s.loadSegmentCipher(segmentID, path)
+	if err != nil {
+		return nil, err
+	}
+
+	s.ciphers[segmentID] = sc
+	return sc, nil
+}
+
+// setCipher records the cipher of a segment this storage just created.
+func (s *Storage) setCipher(segmentID uint64, sc *segmentCipher) {
+	s.cipherMu.Lock()
+	defer s.cipherMu.Unlock()
+	s.ciphers[segmentID] = sc
+}
+
+// dropCipher forgets the cipher of a removed segment.
+func (s *Storage) dropCipher(segmentID uint64) {
+	s.cipherMu.Lock()
+	defer s.cipherMu.Unlock()
+	delete(s.ciphers, segmentID)
+}
+
+// loadSegmentCipher reads the header of an existing segment and unwraps its data key.
+func (s *Storage) loadSegmentCipher(segmentID uint64, path string) (*segmentCipher, error) {
+	keyID, wrapped, dataStart, err := readSegmentHeader(segmentID, path)
+	if err != nil {
+		return nil, err
+	}
+	if keyID == "" {
+		return plaintextCipher, nil
+	}
+
+	aead, err := s.unwrapDataKey(keyID, wrapped, wrapAAD(segmentID))
+	if err != nil {
+		if se, ok := errors.AsStorageError(err); ok {
+			se.WithSegmentID(int(segmentID)).WithPath(path)
+		}
+		return nil, err
+	}
+
+	return &segmentCipher{aead: aead, keyID: keyID, dataStart: dataStart}, nil
+}
+
+// readSegmentHeader reads and verifies the header of an encrypted segment, returning
+// the ID of the master key, the wrapped data key and the offset of the first entry.
+// Unencrypted segments have no header; for them the key ID is empty and entries start
+// at offset zero.
+func readSegmentHeader(segmentID uint64, path string) (string, []byte, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", nil, 0, errors.ClassifyFileOpenError(err, path, filepath.Base(path))
+	}
+	defer file.Close()
+
+	magic := make([]byte, len(segmentMagic))
+	if _, err := io.ReadFull(file, magic); err != nil || string(magic) != segmentMagic {
+		// Too short for a header, or no header at all: an unencrypted segment.
+		return "", nil, 0, nil
+	}
+
+	corrupt := func(cause error, issue string) error {
+		return errors.NewSegmentCorruptionError(int(segmentID), 0, cause).
+			WithPath(path).
+			WithFileName(filepath.Base(path)).
+			WithDetail("corruption_type", "segment_header").
+			WithDetail("issue", issue)
+	}
+
+	reader := io.NewSectionReader(file, 0, int64(maxSegmentHeaderSize))
+	header := make([]byte, len(segmentMagic)+1)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return "", nil, 0, corrupt(err, "truncated header")
+	}
+	if header[len(segmentMagic)] != segmentHeaderVersion {
+		return "", nil, 0, corrupt(nil, fmt.Sprintf("unsupported header version %d", header[len(segmentMagic)]))
+	}
+
+	keyID, wrapped, err := readKeyHeader(reader, &header)
+	if err != nil {
+		return "", nil, 0, corrupt(err, "truncated key header")
+	}
+
+	var checksum [4]byte
+	if _, err := io.ReadFull(reader, checksum[:]); err != nil {
+		return "", nil, 0, corrupt(err, "truncated header")
+	}
+	if crc32.ChecksumIEEE(header) != binary.LittleEndian.Uint32(checksum[:]) {
+		return "", nil, 0, corrupt(nil, "header checksum mismatch")
+	}
+
+	return keyID, wrapped, int64(len(header) + 4), nil
+}
+
+// openEntry decrypts a sealed entry read from the given segment.
+func openEntry(sc *segmentCipher, entry *Entry, segmentID uint64, offset int64) error {
+	if entry.sealed == nil {
+		return nil
+	}
+
+	if sc.aead == nil {
+		return errors.NewSegmentCorruptionError(int(segmentID), int(offset), nil).
+			WithDetail("corruption_type", "encrypted_entry_in_plaintext_segment")
+	}
+
+	if err := entry.open(sc.aead); err != nil {
+		return errors.NewSegmentCorruptionError(int(segmentID), int(offset), err).
+			WithDetail("corruption_type", "authentication_failed")
+	}
+	return nil
+}
+
+// decodeEntry decodes an entry read from a segment, decrypting it if it is sealed. The
+// caller must not hold the storage lock.
+func (s *Storage) decodeEntry(buf []byte, segmentID uint64, offset int64) (*Entry, error) {
+	entry, err := DecodeEntry(buf, segmentID, offset)
+	if err != nil || entry.sealed == nil {
+		return entry, err
+	}
+
+	s.mu.RLock()
+	path, ok := s.segments[segmentID]
+	s.mu.RUnlock()
+
+	if !ok {
+		return nil, errors.NewStorageError(
+			os.ErrNotExist, errors.ErrorCodeIO, "Segment file not found for segment ID",
+		).WithSegmentID(int(segmentID)).
+			WithOffset(int(offset)).
+			WithDetail("operation", "segment_lookup")
+	}
+
+	sc, err := s.cipherFor(segmentID, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := openEntry(sc, entry, segmentID, offset); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// encodeEntry serializes an entry for the active segment, sealing it when the active
+// segment is encrypted. The caller must hold the write lock.
+func (s *Storage) encodeEntry(entry *Entry) ([]byte, error) {
+	if s.activeCipher.aead == nil {
+		return entry.Encode(), nil
+	}
+	return entry.EncodeSealed(s.activeCipher.aead)
+}
+
+// encodedSize returns how many bytes entry will occupy in the active segment. The
+// caller must hold the write lock.
+func (s *Storage) encodedSize(entry *Entry) int64 {
+	size := int64(entry.Size())
+	if s.activeCipher.aead != nil {
+		size += EnvelopeOverhead
+	}
+	return size
+}
+
+// newAEAD creates an AES-GCM cipher for the given key.
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// wrapAAD returns the additional data that binds a wrapped data key to its segment.
+func wrapAAD(segmentID uint64) []byte {
+	aad := make([]byte, len(segmentMagic)+8)
+	copy(aad, segmentMagic)
+	binary.LittleEndian.PutUint64(aad[len(segmentMagic):], segmentID)
+	return aad
+}
@@ -0,0 +1,378 @@
+package storage
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"hash/crc32"
+	"time"
+
+	"github.com/iamNilotpal/ignite/pkg/errors"
+)
+
+const (
+	// EntryVersion is the current on-disk entry format version. It is written into
+	// every entry header so that future format changes can be detected and handled
+	// without rewriting existing segments.
+	EntryVersion uint8 = 1
+
+	// EntryVersionCodec is the format version of entries whose value was compressed.
+	// Such entries carry the codec ID in the FlagCodecMask bits of their flags. Entries
+	// stored uncompressed keep writing EntryVersion, so segments without compressed
+	// values stay readable by versions that predate compression.
+	EntryVersionCodec uint8 = 2
+
+	// EntryVersionEncrypted is the format version of entries whose key and value are
+	// sealed in an AES-GCM envelope (FlagEncrypted). KeySize and ValueSize still give
+	// the plaintext lengths, and the payload is EnvelopeOverhead bytes longer.
+	EntryVersionEncrypted uint8 = 3
+
+	// EntryVersionBlob is the format version of entries whose value lives in a separate
+	// blob file (FlagBlob). Their value is an encoded BlobRef rather than the user value.
+	EntryVersionBlob uint8 = 4
+
+	// EntryVersionExpiry is the format version of entries that expire (FlagExpiry). The
+	// expiry time follows the key as ExpirySize bytes holding Unix nanoseconds, and
+	// KeySize counts them, so that the value is laid out exactly as it is without one.
+	EntryVersionExpiry uint8 = 5
+
+	// HeaderSize is the fixed size of an entry header in bytes:
+	// Checksum (4) + Timestamp (8) + Version (1) + Flags (1) + KeySize (4) + ValueSize (4).
+	HeaderSize = 22
+
+	// FlagTombstone marks an entry as a deletion marker. Tombstones carry no value
+	// and instruct recovery to drop the key from the index.
+	FlagTombstone uint8 = 1 << 0
+
+	// FlagCodecMask selects the three flag bits that hold the ID of the codec the value
+	// was compressed with. They are only meaningful in EntryVersionCodec entries.
+	FlagCodecMask uint8 = 0x7 << codecShift
+
+	// codecShift is the position of the lowest codec bit within the flags.
+	codecShift = 1
+
+	// FlagEncrypted marks an entry whose payload is an AES-GCM envelope. It is only
+	// meaningful in EntryVersionEncrypted entries.
+	FlagEncrypted uint8 = 1 << 4
+
+	// FlagBlob marks an entry whose value is a reference to a blob file. It is only
+	// meaningful in EntryVersionBlob entries.
+	FlagBlob uint8 = 1 << 5
+
+	// FlagExpiry marks an entry whose key is followed by an expiry time. It is only
+	// meaningful in EntryVersionExpiry entries.
+	FlagExpiry uint8 = 1 << 6
+
+	// ExpirySize is the size of the expiry time stored after the key of FlagExpiry
+	// entries.
+	ExpirySize = 8
+
+	// envelopeNonceSize is the size of the random nonce that starts every envelope.
+	envelopeNonceSize = 12
+
+	// EnvelopeOverhead is the number of bytes an envelope adds to the payload: the
+	// nonce in front of the ciphertext and the authentication tag after it.
+	EnvelopeOverhead = envelopeNonceSize + 16
+)
+
+// Entry is the decoded, in-memory representation of a single record stored in a
+// segment file. The layout on disk follows the format described in the README:
+//
+//	+----------+-----------+---------+-------+---------+-----------+-----+-------+
+//	| Checksum | Timestamp | Version | Flags | KeySize | ValueSize | Key | Value |
+//	+----------+-----------+---------+-------+---------+-----------+-----+-------+
+//	|<------------------------- Header ------------------------->|<- Payload ->|
+//
+// All integers are encoded in little-endian byte order. The checksum is a CRC32
+// (IEEE) computed over every byte that follows it, so both the header and the
+// payload are protected against corruption.
+type Entry struct {
+	Timestamp int64  // Unix nanosecond timestamp of the write.
+	Version   uint8  // Format version the entry was written with.
+	Flags     uint8  // Bit flags describing the entry (e.g. FlagTombstone, codec ID).
+	Key       []byte // Raw key bytes.
+	Value     []byte // Value bytes as stored, compressed if Codec is non-zero (empty for tombstones).
+	ExpiresAt int64  // Unix nanosecond time the entry expires at; zero if it never expires.
+
+	// sealed holds the envelope of an encrypted entry until it is opened. Key and
+	// Value are nil while it is set.
+	sealed  []byte
+	aad     []byte // Header bytes authenticated together with the envelope.
+	keySize uint32 // Plaintext key length of a sealed entry.
+}
+
+// NewEntry creates an entry for the given key and value stamped with the current time.
+func NewEntry(key, value []byte, flags uint8) *Entry {
+	return &Entry{
+		Key:       key,
+		Value:     value,
+		Flags:     flags,
+		Version:   EntryVersion,
+		Timestamp: time.Now().UnixNano(),
+	}
+}
+
+// NewCompressedEntry creates an entry whose value was compressed by the codec with the
+// given ID. The codec ID is recorded in the flags so the value can be decoded later.
+func NewCompressedEntry(key, value []byte, codec uint8) *Entry {
+	entry := NewEntry(key, value, (codec<<codecShift)&FlagCodecMask)
+	entry.Version = EntryVersionCodec
+	return entry
+}
+
+// NewBlobEntry creates an entry whose value is an encoded BlobRef pointing at the blob
+// file that holds the actual value.
+func NewBlobEntry(key, ref []byte) *Entry {
+	entry := NewEntry(key, ref, FlagBlob)
+	entry.Version = EntryVersionBlob
+	return entry
+}
+
+// Relocated returns a copy of the entry for appending to another segment, as
+// compaction does when it moves a live entry. The timestamp, format version, flags,
+// key, value and expiry time carry over unchanged. The encryption flag is cleared,
+// since whether the copy is sealed depends on the segment it is appended to.
+func (e *Entry) Relocated() *Entry {
+	return &Entry{
+		Timestamp: e.Timestamp,
+		Version:   e.Version,
+		Flags:     e.Flags &^ FlagEncrypted,
+		Key:       e.Key,
+		Value:     e.Value,
+		ExpiresAt: e.ExpiresAt,
+	}
+}
+
+// Restamped returns a copy of the entry rewritten with a new expiry time, as setting
+// the TTL of an existing key does. It is stamped with the current time so that it
+// supersedes the entry; the format version, flags, key and value carry over, and the
+// encryption flag is cleared like it is by Relocated. An expiresAt of zero makes the
+// copy never expire.
+func (e *Entry) Restamped(expiresAt int64) *Entry {
+	entry := e.Relocated()
+	entry.Timestamp = time.Now().UnixNano()
+	entry.ExpiresAt = expiresAt
+	return entry
+}
+
+// Codec returns the ID of the codec the value was compressed with, or zero if the
+// value is stored as-is.
+func (e *Entry) Codec() uint8 {
+	if e.Version < EntryVersionCodec {
+		return 0
+	}
+	return (e.Flags & FlagCodecMask) >> codecShift
+}
+
+// IsTombstone reports whether the entry marks a deleted key.
+func (e *Entry) IsTombstone() bool {
+	return e.Flags&FlagTombstone != 0
+}
+
+// IsEncrypted reports whether the entry was stored in an AES-GCM envelope.
+func (e *Entry) IsEncrypted() bool {
+	return e.Version >= EntryVersionEncrypted && e.Flags&FlagEncrypted != 0
+}
+
+// IsBlob reports whether the entry's value is a reference to a blob file.
+func (e *Entry) IsBlob() bool {
+	return e.Version >= EntryVersionBlob && e.Flags&FlagBlob != 0
+}
+
+// Size returns the number of bytes the entry occupies on disk when stored unencrypted.
+func (e *Entry) Size() int {
+	return HeaderSize + e.storedKeySize() + len(e.Value)
+}
+
+// IsExpiring reports whether the entry carries an expiry time.
+func (e *Entry) IsExpiring() bool {
+	return e.Version >= EntryVersionExpiry && e.Flags&FlagExpiry != 0
+}
+
+// storedKeySize returns the size of the key as stored, including its expiry time.
+func (e *Entry) storedKeySize() int {
+	if e.ExpiresAt != 0 {
+		return len(e.Key) + ExpirySize
+	}
+	return len(e.Key)
+}
+
+// prepareExpiry sets the flag and format version the entry is written with according
+// to whether it has an expiry time.
+func (e *Entry) prepareExpiry() {
+	if e.ExpiresAt == 0 {
+		e.Flags &^= FlagExpiry
+		return
+	}
+	e.Flags |= FlagExpiry
+	if e.Version < EntryVersionExpiry {
+		e.Version = EntryVersionExpiry
+	}
+}
+
+// appendStoredKey appends the key as stored, followed by its expiry time if any.
+func (e *Entry) appendStoredKey(buf []byte) []byte {
+	buf = append(buf, e.Key...)
+	if e.ExpiresAt != 0 {
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(e.ExpiresAt))
+	}
+	return buf
+}
+
+// splitKey separates the expiry time from the key of an entry as stored.
+func splitKey(stored []byte, version, flags uint8) ([]byte, int64) {
+	if version < EntryVersionExpiry || flags&FlagExpiry == 0 || len(stored) < ExpirySize {
+		return stored, 0
+	}
+	end := len(stored) - ExpirySize
+	return stored[:end], int64(binary.LittleEndian.Uint64(stored[end:]))
+}
+
+// Encode serializes the entry into its on-disk representation, computing and
+// embedding the checksum as the first four bytes.
+func (e *Entry) Encode() []byte {
+	e.prepareExpiry()
+	buf := make([]byte, HeaderSize, e.Size())
+
+	binary.LittleEndian.PutUint64(buf[4:12], uint64(e.Timestamp))
+	buf[12] = e.Version
+	buf[13] = e.Flags
+	binary.LittleEndian.PutUint32(buf[14:18], uint32(e.storedKeySize()))
+	binary.LittleEndian.PutUint32(buf[18:22], uint32(len(e.Value)))
+
+	buf = append(e.appendStoredKey(buf), e.Value...)
+
+	binary.LittleEndian.PutUint32(buf[0:4], crc32.ChecksumIEEE(buf[4:]))
+	return buf
+}
+
+// EncodeSealed serializes the entry like Encode, but seals the key and value in an
+// AES-GCM envelope under the given segment data key. The header is authenticated as
+// additional data, so it cannot be altered without failing decryption.
+func (e *Entry) EncodeSealed(aead cipher.AEAD) ([]byte, error) {
+	e.prepareExpiry()
+	e.Flags |= FlagEncrypted
+	if e.Version < EntryVersionEncrypted {
+		e.Version = EntryVersionEncrypted
+	}
+
+	buf := make([]byte, e.Size()+EnvelopeOverhead)
+
+	binary.LittleEndian.PutUint64(buf[4:12], uint64(e.Timestamp))
+	buf[12] = e.Version
+	buf[13] = e.Flags
+	binary.LittleEndian.PutUint32(buf[14:18], uint32(e.storedKeySize()))
+	binary.LittleEndian.PutUint32(buf[18:22], uint32(len(e.Value)))
+
+	nonce := buf[HeaderSize : HeaderSize+envelopeNonceSize]
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, errors.NewStorageError(err, errors.ErrorCodeInternal, "Failed to generate entry nonce").
+			WithDetail("operation", "entry_seal")
+	}
+
+	plaintext := make([]byte, 0, e.storedKeySize()+len(e.Value))
+	plaintext = append(e.appendStoredKey(plaintext), e.Value...)
+	aead.Seal(buf[HeaderSize+envelopeNonceSize:HeaderSize+envelopeNonceSize], nonce, plaintext, buf[4:HeaderSize])
+
+	binary.LittleEndian.PutUint32(buf[0:4], crc32.ChecksumIEEE(buf[4:]))
+	return buf, nil
+}
+
+// open decrypts a sealed entry in place, setting Key and Value to freshly allocated
+// plaintext. It is a no-op for entries that are not sealed.
+func (e *Entry) open(aead cipher.AEAD) error {
+	if e.sealed == nil {
+		return nil
+	}
+
+	nonce, ciphertext := e.sealed[:envelopeNonceSize], e.sealed[envelopeNonceSize:]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, e.aad)
+	if err != nil {
+		return err
+	}
+
+	e.Key, e.ExpiresAt = splitKey(plaintext[:e.keySize], e.Version, e.Flags)
+	e.Value = plaintext[e.keySize:]
+	e.sealed, e.aad = nil, nil
+	return nil
+}
+
+// header holds the fixed-size portion of an entry once it has been parsed.
+type header struct {
+	checksum  uint32
+	timestamp int64
+	version   uint8
+	flags     uint8
+	keySize   uint32
+	valueSize uint32
+}
+
+// decodeHeader parses the fixed-size header at the start of buf. The caller must
+// ensure that buf holds at least HeaderSize bytes.
+func decodeHeader(buf []byte) header {
+	return header{
+		checksum:  binary.LittleEndian.Uint32(buf[0:4]),
+		timestamp: int64(binary.LittleEndian.Uint64(buf[4:12])),
+		version:   buf[12],
+		flags:     buf[13],
+		keySize:   binary.LittleEndian.Uint32(buf[14:18]),
+		valueSize: binary.LittleEndian.Uint32(buf[18:22]),
+	}
+}
+
+// size returns the total on-disk size of the entry the header belongs to.
+func (h header) size() int {
+	size := HeaderSize + int(h.keySize) + int(h.valueSize)
+	if h.version >= EntryVersionEncrypted && h.flags&FlagEncrypted != 0 {
+		size += EnvelopeOverhead
+	}
+	return size
+}
+
+// DecodeEntry parses a complete entry from buf and verifies its checksum. The
+// returned entry's key and value slices alias buf, so callers that retain them
+// beyond the lifetime of buf must copy them. Encrypted entries come back sealed,
+// with nil key and value, and must be opened with the segment's data key.
+func DecodeEntry(buf []byte, segmentID uint64, offset int64) (*Entry, error) {
+	if len(buf) < HeaderSize {
+		return nil, errors.NewHeaderReadError("", int(offset), nil).
+			WithSegmentID(int(segmentID)).
+			WithDetail("header_size_expected", HeaderSize).
+			WithDetail("bytes_available", len(buf))
+	}
+
+	h := decodeHeader(buf)
+	total := h.size()
+	if len(buf) < total {
+		return nil, errors.NewPayloadReadError("", int(segmentID), int(offset), total, nil).
+			WithDetail("bytes_available", len(buf))
+	}
+
+	if crc32.ChecksumIEEE(buf[4:total]) != h.checksum {
+		return nil, errors.NewSegmentCorruptionError(int(segmentID), int(offset), nil).
+			WithDetail("stored_checksum", h.checksum).
+			WithDetail("entry_size", total)
+	}
+
+	if h.version >= EntryVersionEncrypted && h.flags&FlagEncrypted != 0 {
+		return &Entry{
+			Timestamp: h.timestamp,
+			Version:   h.version,
+			Flags:     h.flags,
+			sealed:    buf[HeaderSize:total],
+			aad:       buf[4:HeaderSize],
+			keySize:   h.keySize,
+		}, nil
+	}
+
+	keyEnd := HeaderSize + int(h.keySize)
+	key, expiresAt := splitKey(buf[HeaderSize:keyEnd], h.version, h.flags)
+	return &Entry{
+		Timestamp: h.timestamp,
+		Version:   h.version,
+		Flags:     h.flags,
+		Key:       key,
+		Value:     buf[keyEnd:total],
+		ExpiresAt: expiresAt,
+	}, nil
+}
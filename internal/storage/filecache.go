@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"container/list"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/iamNilotpal/ignite/pkg/errors"
+)
+
+// FileCacheStats is a point-in-time snapshot of segment file handle cache activity.
+type FileCacheStats struct {
+	Hits      uint64 `json:"hits"`      // Reads that reused an already open handle.
+	Misses    uint64 `json:"misses"`    // Reads that had to open the segment file.
+	Evictions uint64 `json:"evictions"` // Handles closed to stay within the open file limit.
+	Open      uint64 `json:"open"`      // Handles currently held open by the cache.
+	MaxOpen   uint64 `json:"maxOpen"`   // Maximum number of handles the cache keeps open.
+}
+
+// segmentHandle is a reference-counted, read-only file handle for a sealed segment.
+type segmentHandle struct {
+	segmentID uint64        // Segment the handle belongs to.
+	file      *os.File      // Read-only file handle.
+	refs      int           // Readers currently using the handle.
+	detached  bool          // Set once the handle has left the cache and must close on last release.
+	element   *list.Element // Position in the LRU list while the handle is cached.
+}
+
+// fileCache keeps a bounded number of read-only segment handles open, closing the
+// least recently used one when the limit is reached. Since a RecordPointer can
+// address tens of thousands of segments, keeping every segment open is not an
+// option, while reopening a file on every read is needlessly expensive.
+//
+// Handles are reference counted so that a handle can be evicted, or its segment
+// deleted by compaction, while readers still use it: such handles are detached
+// from the cache and the file is closed when the last reader releases it.
+type fileCache struct {
+	mu        sync.Mutex                // Guards every field below.
+	maxOpen   int                       // Maximum number of cached handles.
+	handles   map[uint64]*segmentHandle // Cached handles by segment ID.
+	lru       *list.List                // Cached handles, most recently used at the front.
+	hits      uint64                    // Lookups served by a cached handle.
+	misses    uint64                    // Lookups that opened the file.
+	evictions uint64                    // Handles evicted to honour maxOpen.
+}
+
+// newFileCache creates a handle cache that keeps at most maxOpen files open.
+func newFileCache(maxOpen int) *fileCache {
+	return &fileCache{
+		maxOpen: max(maxOpen, 1),
+		handles: make(map[uint64]*segmentHandle),
+		lru:     list.New(),
+	}
+}
+
+// acquire returns a referenced handle for the segment stored at path, opening the
+// file if it is not cached. The caller must pass the handle to release when done.
+func (c *fileCache) acquire(segmentID uint64, path string) (*segmentHandle, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if h, ok := c.handles[segmentID]; ok {
+		c.hits++
+		h.refs++
+		c.lru.MoveToFront(h.element)
+		return h, nil
+	}
+
+	c.misses++
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.ClassifyFileOpenError(err, path, filepath.Base(path))
+	}
+
+	h := &segmentHandle{segmentID: segmentID, file: file, refs: 1}
+	h.element = c.lru.PushFront(h)
+	c.handles[segmentID] = h
+
+	for c.lru.Len() > c.maxOpen {
+		victim := c.lru.Back().Value.(*segmentHandle)
+		c.detach(victim)
+		c.evictions++
+	}
+
+	return h, nil
+}
+
+// release drops a reference taken by acquire, closing the file if the handle has
+// been detached from the cache and this was its last user.
+func (c *fileCache) release(h *segmentHandle) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	h.refs--
+	if h.detached && h.refs == 0 {
+		return h.file.Close()
+	}
+	return nil
+}
+
+// remove drops the handle of a segment from the cache, typically because the
+// segment is being deleted. The file is closed now or by its last reader.
+func (c *fileCache) remove(segmentID uint64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if h, ok := c.handles[segmentID]; ok {
+		return c.detach(h)
+	}
+	return nil
+}
+
+// close detaches every cached handle. Handles still in use are closed by their
+// last reader.
+func (c *fileCache) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var firstErr error
+	for _, h := range c.handles {
+		if err := c.detach(h); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// stats returns a snapshot of the cache counters.
+func (c *fileCache) stats() FileCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return FileCacheStats{
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+		Open:      uint64(c.lru.Len()),
+		MaxOpen:   uint64(c.maxOpen),
+	}
+}
+
+// detach removes a handle from the cache and closes it if nobody is using it.
+// The caller must hold the cache lock.
+func (c *fileCache) detach(h *segmentHandle) error {
+	c.lru.Remove(h.element)
+	delete(c.handles, h.segmentID)
+	h.detached = true
+
+	if h.refs == 0 {
+		return h.file.Close()
+	}
+	return nil
+}
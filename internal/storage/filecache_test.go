@@ -0,0 +1,168 @@
+package storage
+
+import (
+	stdErrors "errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// tempFiles creates n small files and returns their paths.
+func tempFiles(t *testing.T, n int) []string {
+	t.Helper()
+
+	dir := t.TempDir()
+	paths := make([]string, n)
+	for i := range paths {
+		paths[i] = filepath.Join(dir, fmt.Sprintf("%d.seg", i))
+		if err := os.WriteFile(paths[i], []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return paths
+}
+
+// acquireHandle acquires the handle of file i of paths from c.
+func acquireHandle(t *testing.T, c *fileCache, paths []string, i int) *segmentHandle {
+	t.Helper()
+
+	h, err := c.acquire(uint64(i), paths[i])
+	if err != nil {
+		t.Fatalf("acquire(%d) error = %v", i, err)
+	}
+	return h
+}
+
+// releaseHandle releases h, failing the test on error.
+func releaseHandle(t *testing.T, c *fileCache, h *segmentHandle) {
+	t.Helper()
+	if err := c.release(h); err != nil {
+		t.Fatalf("release(%d) error = %v", h.segmentID, err)
+	}
+}
+
+// isClosed reports whether f has been closed.
+func isClosed(f *os.File) bool {
+	_, err := f.Stat()
+	return stdErrors.Is(err, os.ErrClosed)
+}
+
+func TestFileCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	paths := tempFiles(t, 3)
+	c := newFileCache(2)
+
+	handles := make([]*segmentHandle, 3)
+	for i := range 2 {
+		handles[i] = acquireHandle(t, c, paths, i)
+		releaseHandle(t, c, handles[i])
+	}
+
+	// Using file 0 again makes file 1 the least recently used one.
+	releaseHandle(t, c, acquireHandle(t, c, paths, 0))
+	handles[2] = acquireHandle(t, c, paths, 2)
+	releaseHandle(t, c, handles[2])
+
+	if !isClosed(handles[1].file) {
+		t.Fatal("least recently used handle was not closed")
+	}
+	if isClosed(handles[0].file) || isClosed(handles[2].file) {
+		t.Fatal("recently used handle was closed")
+	}
+
+	want := FileCacheStats{Hits: 1, Misses: 3, Evictions: 1, Open: 2, MaxOpen: 2}
+	if got := c.stats(); got != want {
+		t.Fatalf("stats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestFileCacheKeepsHandlesInUse(t *testing.T) {
+	paths := tempFiles(t, 3)
+	c := newFileCache(1)
+
+	// The handle is evicted while a reader still uses it.
+	inUse := acquireHandle(t, c, paths, 0)
+	releaseHandle(t, c, acquireHandle(t, c, paths, 1))
+	if isClosed(inUse.file) {
+		t.Fatal("evicted handle closed while in use")
+	}
+	if _, err := inUse.file.ReadAt(make([]byte, 4), 0); err != nil {
+		t.Fatalf("ReadAt() through an evicted handle error = %v", err)
+	}
+
+	// The last reader closes it.
+	releaseHandle(t, c, inUse)
+	if !isClosed(inUse.file) {
+		t.Fatal("evicted handle not closed by its last reader")
+	}
+
+	// A fresh acquire opens the file again.
+	again := acquireHandle(t, c, paths, 0)
+	defer releaseHandle(t, c, again)
+	if again == inUse || isClosed(again.file) {
+		t.Fatal("acquire after eviction returned the closed handle")
+	}
+}
+
+func TestFileCacheRemove(t *testing.T) {
+	paths := tempFiles(t, 2)
+	c := newFileCache(4)
+
+	idle := acquireHandle(t, c, paths, 0)
+	releaseHandle(t, c, idle)
+	if err := c.remove(0); err != nil {
+		t.Fatalf("remove() error = %v", err)
+	}
+	if !isClosed(idle.file) {
+		t.Fatal("removed idle handle was not closed")
+	}
+
+	busy := acquireHandle(t, c, paths, 1)
+	if err := c.remove(1); err != nil {
+		t.Fatalf("remove() error = %v", err)
+	}
+	if isClosed(busy.file) {
+		t.Fatal("removed handle closed while in use")
+	}
+	releaseHandle(t, c, busy)
+	if !isClosed(busy.file) {
+		t.Fatal("removed handle not closed by its last reader")
+	}
+
+	if stats := c.stats(); stats.Open != 0 {
+		t.Fatalf("stats().Open = %d after removing every handle", stats.Open)
+	}
+}
+
+func TestStorageBoundsOpenFiles(t *testing.T) {
+	opts := testOptions(t.TempDir())
+	opts.ReadOptions.MaxOpenFiles = 2
+	s := openStorage(t, opts)
+
+	positions := fillSegments(t, s, 4)
+	for round := range 2 {
+		for i, pos := range positions {
+			expectEntry(t, s, pos, fmt.Sprintf("key-%d", i), entryValue(i))
+		}
+		if stats := s.FileCacheStats(); stats.Open > 2 || stats.Evictions == 0 {
+			t.Fatalf("round %d: FileCacheStats() = %+v, want at most 2 open and evictions", round, stats)
+		}
+	}
+
+	// Removing a segment closes its cached handle.
+	s.files.mu.Lock()
+	h, ok := s.files.handles[4]
+	s.files.mu.Unlock()
+	if !ok {
+		t.Fatal("the last sealed segment read has no cached handle")
+	}
+	if err := s.RemoveSegment(4); err != nil {
+		t.Fatalf("RemoveSegment() error = %v", err)
+	}
+	if !isClosed(h.file) {
+		t.Fatal("handle of a removed segment was not closed")
+	}
+	if stats := s.FileCacheStats(); stats.Open != 1 {
+		t.Fatalf("FileCacheStats().Open = %d after removal, want 1", stats.Open)
+	}
+}
@@ -0,0 +1,575 @@
+package storage
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	stdErrors "errors"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+
+	"github.com/iamNilotpal/ignite/pkg/errors"
+)
+
+// Hint files let a store rebuild its index without reading every value back. Each
+// sealed segment gets one next to it, named after the segment file with hintExt
+// appended, that lists the entries of the segment without their values:
+//
+//	+-------+---------+-------+-------------+-------+-------------+------+----------+
+//	| Magic | Version | Flags | SegmentSize | Count |   Records   | Keys | Checksum |
+//	|   8   |    1    |   1   |      8      |   4   | Count × 26  |  N   |    4     |
+//	+-------+---------+-------+-------------+-------+-------------+------+----------+
+//
+// Every record repeats the header of one entry minus its checksum: Offset (8),
+// Timestamp (8), Version (1), Flags (1), KeySize (4) and ValueSize (4). The keys
+// section then holds, in the same order, every key as stored, followed by its expiry
+// time when it has one, and the encoded BlobRef of blob entries. When the segment is
+// encrypted, hintFlagSealed is set and the keys section is sealed in an AES-GCM
+// envelope under the segment's data key, with every byte before it as additional
+// data, so that a hint reveals no more than the entry headers of its segment do. The
+// checksum is a CRC32 (IEEE) over every byte before it.
+//
+// A hint is only used when its records tile the segment exactly, from the first entry
+// up to the size the segment has on disk; anything else falls back to reading the
+// segment. Hints are written in the background once a segment is sealed, through a
+// temporary file renamed into place, and are removed together with their segment.
+const (
+	// hintExt is appended to the name of a segment file to name its hint file.
+	hintExt = ".hint"
+
+	// hintTempExt marks a hint file that is still being written.
+	hintTempExt = ".tmp"
+
+	// hintMagic identifies a hint file.
+	hintMagic = "IGNITE\x00H"
+
+	// hintVersion is the current hint file format version.
+	hintVersion uint8 = 1
+
+	// hintFlagSealed marks a hint whose keys section is encrypted.
+	hintFlagSealed uint8 = 1 << 0
+
+	// hintHeaderSize is the size of the fixed part before the records:
+	// Magic (8) + Version (1) + Flags (1) + SegmentSize (8) + Count (4).
+	hintHeaderSize = len(hintMagic) + 1 + 1 + 8 + 4
+
+	// hintRecordSize is the size of a single record:
+	// Offset (8) + Timestamp (8) + Version (1) + Flags (1) + KeySize (4) + ValueSize (4).
+	hintRecordSize = 26
+)
+
+// errHintAborted stops building a hint when the storage is closed part way through.
+var errHintAborted = stdErrors.New("hint build aborted: storage closed")
+
+// Hint is the decoded content of a hint file.
+type Hint struct {
+	SegmentSize int64        // Size of the segment file when the hint was written.
+	Sealed      bool         // Whether the keys are encrypted under the segment's data key.
+	Records     []HintRecord // One record per entry of the segment, in file order.
+
+	keys []byte // Keys section, kept until a sealed hint is opened.
+	aad  []byte // Bytes authenticated together with a sealed keys section.
+}
+
+// HintRecord describes a single entry as listed in a hint file.
+type HintRecord struct {
+	Offset    int64  // Byte offset of the entry within the segment.
+	Size      uint32 // Total size of the entry on disk.
+	Timestamp int64  // Unix nanosecond timestamp of the write.
+	Version   uint8  // Format version the entry was written with.
+	Flags     uint8  // Flags of the entry, as stored.
+	ValueSize uint32 // Length of the value as stored, before encryption.
+	Key       []byte // Key of the entry; nil while the hint is sealed.
+	ExpiresAt int64  // Unix nanosecond time the entry expires at; zero if it never expires.
+	BlobRef   []byte // Encoded BlobRef of a blob entry; nil for other entries or while sealed.
+
+	keySize uint32 // Length of the key as stored, including the expiry time.
+}
+
+// HintPath returns the path of the hint file of the segment file at segmentPath.
+func HintPath(segmentPath string) string {
+	return segmentPath + hintExt
+}
+
+// ReadHint reads the hint file at path and verifies its checksum and layout. The keys
+// of a sealed hint cannot be read without the segment's data key and are left nil.
+func ReadHint(path string) (*Hint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.NewFileAccessError(path, filepath.Base(path), "hint_read", err)
+	}
+	return decodeHint(path, data)
+}
+
+// decodeHint parses the content of the hint file at path.
+func decodeHint(path string, data []byte) (*Hint, error) {
+	if len(data) < hintHeaderSize+4 {
+		return nil, errors.NewHintFileError(path, "truncated header", nil)
+	}
+
+	end := len(data) - 4
+	if crc32.ChecksumIEEE(data[:end]) != binary.LittleEndian.Uint32(data[end:]) {
+		return nil, errors.NewHintFileError(path, "checksum mismatch", nil)
+	}
+	if string(data[:len(hintMagic)]) != hintMagic {
+		return nil, errors.NewHintFileError(path, "not a hint file", nil)
+	}
+
+	fixed := data[len(hintMagic):hintHeaderSize]
+	if fixed[0] != hintVersion {
+		return nil, errors.NewHintFileError(path, "unsupported version", nil).
+			WithDetail("version", fixed[0])
+	}
+
+	hint := &Hint{
+		Sealed:      fixed[1]&hintFlagSealed != 0,
+		SegmentSize: int64(binary.LittleEndian.Uint64(fixed[2:10])),
+	}
+
+	count := int(binary.LittleEndian.Uint32(fixed[10:14]))
+	keysStart := hintHeaderSize + count*hintRecordSize
+	if count < 0 || keysStart > end {
+		return nil, errors.NewHintFileError(path, "truncated records", nil).
+			WithDetail("records", count)
+	}
+
+	hint.Records = make([]HintRecord, count)
+	for i := range hint.Records {
+		buf := data[hintHeaderSize+i*hintRecordSize:]
+		r := &hint.Records[i]
+		r.Offset = int64(binary.LittleEndian.Uint64(buf[0:8]))
+		r.Timestamp = int64(binary.LittleEndian.Uint64(buf[8:16]))
+		r.Version = buf[16]
+		r.Flags = buf[17]
+		r.keySize = binary.LittleEndian.Uint32(buf[18:22])
+		r.ValueSize = binary.LittleEndian.Uint32(buf[22:26])
+
+		h := header{version: r.Version, flags: r.Flags, keySize: r.keySize, valueSize: r.ValueSize}
+		r.Size = uint32(h.size())
+	}
+
+	hint.keys = data[keysStart:end]
+	hint.aad = data[:keysStart]
+	if hint.Sealed {
+		return hint, nil
+	}
+
+	if err := hint.parseKeys(hint.keys); err != nil {
+		return nil, errors.NewHintFileError(path, err.Error(), nil)
+	}
+	return hint, nil
+}
+
+// open decrypts the keys of a sealed hint with the data key of its segment.
+func (h *Hint) open(aead cipher.AEAD) error {
+	if !h.Sealed || h.keys == nil {
+		return nil
+	}
+	if aead == nil || len(h.keys) < EnvelopeOverhead {
+		return stdErrors.New("sealed keys cannot be opened")
+	}
+
+	nonce, ciphertext := h.keys[:envelopeNonceSize], h.keys[envelopeNonceSize:]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, h.aad)
+	if err != nil {
+		return err
+	}
+	return h.parseKeys(plaintext)
+}
+
+// parseKeys fills in the keys and blob references of the records from the plaintext
+// keys section.
+func (h *Hint) parseKeys(keys []byte) error {
+	for i := range h.Records {
+		r := &h.Records[i]
+		if uint64(len(keys)) < uint64(r.keySize) {
+			return stdErrors.New("truncated keys")
+		}
+		r.Key, r.ExpiresAt = splitKey(keys[:r.keySize:r.keySize], r.Version, r.Flags)
+		keys = keys[r.keySize:]
+
+		if r.Entry().IsBlob() {
+			if len(keys) < BlobRefSize {
+				return stdErrors.New("truncated blob reference")
+			}
+			r.BlobRef = keys[:BlobRefSize:BlobRefSize]
+			keys = keys[BlobRefSize:]
+		}
+	}
+	if len(keys) != 0 {
+		return stdErrors.New("trailing bytes after keys")
+	}
+
+	h.keys, h.aad = nil, nil
+	return nil
+}
+
+// Covers reports whether the records cover a segment of the given size exactly, one
+// entry after another from dataStart on.
+func (h *Hint) Covers(dataStart, size int64) bool {
+	if h.SegmentSize != size {
+		return false
+	}
+
+	offset := dataStart
+	for _, r := range h.Records {
+		if r.Offset != offset {
+			return false
+		}
+		offset += int64(r.Size)
+	}
+	return offset == size
+}
+
+// Matches reports whether the record describes the entry read from a segment at pos.
+// Keys and blob references are only compared when both sides have them in plaintext.
+func (r *HintRecord) Matches(pos *Position, entry *Entry) bool {
+	if r.Offset != pos.Offset || r.Size != pos.Size || r.ValueSize != pos.ValueSize ||
+		r.Timestamp != entry.Timestamp || r.Version != entry.Version || r.Flags != entry.Flags {
+		return false
+	}
+	if r.Key == nil || entry.sealed != nil {
+		return true
+	}
+	if string(r.Key) != string(entry.Key) || r.ExpiresAt != entry.ExpiresAt {
+		return false
+	}
+	return !entry.IsBlob() || string(r.BlobRef) == string(entry.Value)
+}
+
+// Entry returns the entry the record stands for, as Scan passes it on: with its key,
+// expiry time and blob reference, but without the value of an inline entry.
+func (r *HintRecord) Entry() *Entry {
+	entry := &Entry{
+		Timestamp: r.Timestamp,
+		Version:   r.Version,
+		Flags:     r.Flags,
+		Key:       r.Key,
+		ExpiresAt: r.ExpiresAt,
+	}
+	if r.BlobRef != nil {
+		entry.Value = r.BlobRef
+	}
+	return entry
+}
+
+// hintBuilder accumulates the records and keys of a hint file while its segment is
+// walked.
+type hintBuilder struct {
+	records []byte // Encoded records.
+	keys    []byte // Plaintext keys section.
+	count   uint32 // Number of records.
+}
+
+// add appends the record of an opened entry read from the segment at pos.
+func (b *hintBuilder) add(pos *Position, entry *Entry) {
+	keySize := len(entry.Key)
+	if entry.IsExpiring() {
+		keySize += ExpirySize
+	}
+
+	b.records = binary.LittleEndian.AppendUint64(b.records, uint64(pos.Offset))
+	b.records = binary.LittleEndian.AppendUint64(b.records, uint64(entry.Timestamp))
+	b.records = append(b.records, entry.Version, entry.Flags)
+	b.records = binary.LittleEndian.AppendUint32(b.records, uint32(keySize))
+	b.records = binary.LittleEndian.AppendUint32(b.records, pos.ValueSize)
+
+	b.keys = append(b.keys, entry.Key...)
+	if entry.IsExpiring() {
+		b.keys = binary.LittleEndian.AppendUint64(b.keys, uint64(entry.ExpiresAt))
+	}
+	if entry.IsBlob() {
+		b.keys = append(b.keys, entry.Value...)
+	}
+	b.count++
+}
+
+// encode serializes the hint of a segment of the given size, sealing the keys with
+// aead when the segment is encrypted.
+func (b *hintBuilder) encode(segmentSize int64, aead cipher.AEAD) ([]byte, error) {
+	var flags uint8
+	if aead != nil {
+		flags |= hintFlagSealed
+	}
+
+	buf := make([]byte, 0, hintHeaderSize+len(b.records)+len(b.keys)+EnvelopeOverhead+4)
+	buf = append(buf, hintMagic...)
+	buf = append(buf, hintVersion, flags)
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(segmentSize))
+	buf = binary.LittleEndian.AppendUint32(buf, b.count)
+	buf = append(buf, b.records...)
+
+	if aead == nil {
+		buf = append(buf, b.keys...)
+	} else {
+		aad := append([]byte(nil), buf...)
+		nonce := make([]byte, envelopeNonceSize)
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, errors.NewStorageError(err, errors.ErrorCodeInternal, "Failed to generate hint nonce").
+				WithDetail("operation", "hint_seal")
+		}
+		buf = append(buf, nonce...)
+		buf = aead.Seal(buf, nonce, b.keys, aad)
+	}
+
+	return binary.LittleEndian.AppendUint32(buf, crc32.ChecksumIEEE(buf)), nil
+}
+
+// buildHint walks the segment file at path and returns the content of its hint file.
+// A hint must list every entry, so a damaged segment fails the build. abort is checked
+// between entries and stops the build with errHintAborted when it returns true.
+func buildHint(path string, segmentID uint64, sc *segmentCipher, abort func() bool) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, errors.NewFileAccessError(path, filepath.Base(path), "segment_stat", err).
+			WithSegmentID(int(segmentID))
+	}
+
+	var b hintBuilder
+	_, err = walkEntries(path, segmentID, sc.dataStart, func(pos *Position, entry *Entry) error {
+		if abort() {
+			return errHintAborted
+		}
+		if err := openEntry(sc, entry, segmentID, pos.Offset); err != nil {
+			return err
+		}
+		b.add(pos, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return b.encode(info.Size(), sc.aead)
+}
+
+// writeHintFile writes a hint file through a temporary file that is synced and
+// renamed into place, so that a hint under its final name is always complete.
+func writeHintFile(path string, data []byte) error {
+	tempPath := path + hintTempExt
+	os.Remove(tempPath)
+
+	if err := writeSegmentFile(tempPath, data); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return errors.NewFileAccessError(path, filepath.Base(path), "hint_rename", err)
+	}
+	return syncDir(filepath.Dir(path))
+}
+
+// WriteHint writes the hint file of the sealed, unencrypted segment file at path,
+// without opening a Storage, for offline tools that rewrite segments. It reports
+// whether a hint was written: the keys section of an encrypted segment's hint must be
+// sealed with its data key, so those are left for the store to write on its next open.
+func WriteHint(path string, segmentID uint64) (bool, error) {
+	keyID, _, _, err := readSegmentHeader(segmentID, path)
+	if err != nil {
+		return false, err
+	}
+	if keyID != "" {
+		return false, nil
+	}
+
+	data, err := buildHint(path, segmentID, plaintextCipher, func() bool { return false })
+	if err != nil {
+		return false, err
+	}
+	return true, writeHintFile(HintPath(path), data)
+}
+
+// RemoveHint deletes the hint file of the segment file at path, if it has one.
+func RemoveHint(path string) error {
+	return removeHint(path)
+}
+
+// loadHint reads the hint of a sealed segment for Scan and opens its keys. It returns
+// nil without an error when the segment has no hint, and an error when the hint exists
+// but cannot be trusted.
+func loadHint(path string, sc *segmentCipher) (*Hint, error) {
+	hintPath := HintPath(path)
+
+	data, err := os.ReadFile(hintPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.NewFileAccessError(hintPath, filepath.Base(hintPath), "hint_read", err)
+	}
+
+	hint, err := decodeHint(hintPath, data)
+	if err != nil {
+		return nil, err
+	}
+	if hint.Sealed != (sc.aead != nil) {
+		return nil, errors.NewHintFileError(hintPath, "encryption does not match the segment", nil)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, errors.NewFileAccessError(path, filepath.Base(path), "segment_stat", err)
+	}
+	if !hint.Covers(sc.dataStart, info.Size()) {
+		return nil, errors.NewHintFileError(hintPath, "records do not match the segment", nil).
+			WithDetail("segmentSize", info.Size()).
+			WithDetail("hintSegmentSize", hint.SegmentSize)
+	}
+
+	if err := hint.open(sc.aead); err != nil {
+		return nil, errors.NewHintFileError(hintPath, "sealed keys cannot be opened", err)
+	}
+	return hint, nil
+}
+
+// scanHint replays a sealed segment from its hint file through fn instead of reading
+// the segment. It reports whether the hint was used; when it was not, the caller must
+// read the segment. A hint that cannot be trusted is removed, so that it is rebuilt.
+// Only errors returned by fn are returned. The caller must hold the write lock.
+func (s *Storage) scanHint(segmentID uint64, sc *segmentCipher, fn func(pos *Position, entry *Entry) error) (bool, error) {
+	path := s.segments[segmentID]
+
+	hint, err := loadHint(path, sc)
+	if err != nil {
+		s.log.Warnw("Ignoring hint file that does not match its segment", "segmentID", segmentID, "error", err)
+		if err := os.Remove(HintPath(path)); err != nil && !os.IsNotExist(err) {
+			s.log.Warnw("Failed to remove stale hint file", "segmentID", segmentID, "error", err)
+		}
+		return false, nil
+	}
+	if hint == nil {
+		return false, nil
+	}
+
+	for i := range hint.Records {
+		r := &hint.Records[i]
+		pos := &Position{SegmentID: segmentID, Offset: r.Offset, Size: r.Size, ValueSize: r.ValueSize}
+		if err := fn(pos, r.Entry()); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+// startHintWriter starts the goroutine that writes the hint files of sealed segments
+// queued with queueHint. It runs until the storage is closed.
+func (s *Storage) startHintWriter() {
+	s.hintWake = make(chan struct{}, 1)
+	s.hintStop = make(chan struct{})
+	s.hintDone = make(chan struct{})
+	go s.hintWriter()
+}
+
+// queueHint schedules writing the hint file of a sealed segment.
+func (s *Storage) queueHint(segmentID uint64) {
+	if s.hintWake == nil {
+		return
+	}
+
+	s.hintMu.Lock()
+	s.hintQueue = append(s.hintQueue, segmentID)
+	s.hintMu.Unlock()
+
+	select {
+	case s.hintWake <- struct{}{}:
+	default:
+	}
+}
+
+// stopHintWriter stops the hint writer, abandoning any hint still being built, and
+// waits for it to exit.
+func (s *Storage) stopHintWriter() {
+	if s.hintStop == nil {
+		return
+	}
+	close(s.hintStop)
+	<-s.hintDone
+}
+
+// hintWriter writes queued hint files one at a time until stopHintWriter is called.
+func (s *Storage) hintWriter() {
+	defer close(s.hintDone)
+
+	for {
+		select {
+		case <-s.hintStop:
+			return
+		case <-s.hintWake:
+		}
+
+		for {
+			s.hintMu.Lock()
+			if len(s.hintQueue) == 0 {
+				s.hintMu.Unlock()
+				break
+			}
+			segmentID := s.hintQueue[0]
+			s.hintQueue = s.hintQueue[1:]
+			s.hintMu.Unlock()
+
+			if err := s.writeHint(segmentID); err != nil {
+				if stdErrors.Is(err, errHintAborted) {
+					return
+				}
+				s.log.Warnw("Failed to write hint file", "segmentID", segmentID, "error", err)
+			}
+		}
+	}
+}
+
+// writeHint builds and writes the hint file of a sealed segment. A segment removed in
+// the meantime loses the hint again, since its removal may have missed it.
+func (s *Storage) writeHint(segmentID uint64) error {
+	s.mu.RLock()
+	path, ok := s.segments[segmentID]
+	s.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	sc, err := s.cipherFor(segmentID, path)
+	if err != nil {
+		return err
+	}
+
+	stopped := func() bool {
+		select {
+		case <-s.hintStop:
+			return true
+		default:
+			return false
+		}
+	}
+
+	data, err := buildHint(path, segmentID, sc, stopped)
+	if err != nil {
+		return err
+	}
+
+	hintPath := HintPath(path)
+	if err := writeHintFile(hintPath, data); err != nil {
+		return err
+	}
+
+	s.mu.RLock()
+	_, ok = s.segments[segmentID]
+	s.mu.RUnlock()
+	if !ok {
+		os.Remove(hintPath)
+		return nil
+	}
+
+	s.log.Debugw("Wrote hint file", "segmentID", segmentID, "path", hintPath, "size", len(data))
+	return nil
+}
+
+// removeHint deletes the hint file of a segment, if it has one.
+func removeHint(segmentPath string) error {
+	path := HintPath(segmentPath)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return errors.NewFileAccessError(path, filepath.Base(path), "hint_remove", err)
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+//go:build !unix
+
+package storage
+
+import "os"
+
+// lockSupported reports whether this platform can lock the data directory.
+const lockSupported = false
+
+// lockFile is a no-op on platforms without flock. The directory is left unprotected
+// against concurrent openers, which New reports with a warning.
+func lockFile(file *os.File) error {
+	return nil
+}
+
+// unlockFile is a no-op since lockFile never takes a lock on this platform.
+func unlockFile(file *os.File) error {
+	return nil
+}
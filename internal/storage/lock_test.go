@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/iamNilotpal/ignite/pkg/errors"
+	"go.uber.org/zap"
+)
+
+func TestDirectoryLockExcludesSecondOpener(t *testing.T) {
+	if !lockSupported {
+		t.Skip("directory locking is not supported on this platform")
+	}
+
+	opts := testOptions(t.TempDir())
+	s := openStorage(t, opts)
+	appendEntry(t, s, "key", "value")
+
+	second, err := New(context.Background(), &Config{Options: opts, Logger: zap.NewNop().Sugar()})
+	if err == nil {
+		second.Close()
+		t.Fatal("New() succeeded on a directory that is already open")
+	}
+
+	le, ok := errors.AsLockError(err)
+	if !ok || le.Code() != errors.ErrorCodeDirectoryLocked {
+		t.Fatalf("New() error = %v, want %s", err, errors.ErrorCodeDirectoryLocked)
+	}
+	if le.HolderPID() != os.Getpid() {
+		t.Fatalf("HolderPID() = %d, want %d", le.HolderPID(), os.Getpid())
+	}
+	if le.Path() != filepath.Join(opts.DataDir, LockFileName) {
+		t.Fatalf("Path() = %q, want the lock file", le.Path())
+	}
+	if !strings.Contains(le.Error(), "pid "+strconv.Itoa(os.Getpid())) {
+		t.Fatalf("Error() = %q, want the holder PID", le.Error())
+	}
+
+	// The failed opener must not have disturbed the holder.
+	appendEntry(t, s, "other", "value")
+}
+
+func TestCloseReleasesDirectoryLock(t *testing.T) {
+	opts := testOptions(t.TempDir())
+
+	s := openStorage(t, opts)
+	pos := appendEntry(t, s, "key", "value")
+	closeStorage(t, s)
+
+	// The lock file stays behind, without the PID of a process that no longer holds it.
+	data, err := os.ReadFile(filepath.Join(opts.DataDir, LockFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 0 {
+		t.Fatalf("lock file holds %q after Close, want it empty", data)
+	}
+
+	s = openStorage(t, opts)
+	expectEntry(t, s, pos, "key", "value")
+}
@@ -0,0 +1,34 @@
+//go:build unix
+
+package storage
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockSupported reports whether this platform can lock the data directory.
+const lockSupported = true
+
+// lockFile takes an exclusive, non-blocking flock on file. It returns errLockHeld if
+// another open file description already holds the lock.
+func lockFile(file *os.File) error {
+	for {
+		err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		switch err {
+		case nil:
+			return nil
+		case syscall.EINTR:
+			continue
+		case syscall.EWOULDBLOCK:
+			return errLockHeld
+		default:
+			return err
+		}
+	}
+}
+
+// unlockFile releases a lock taken by lockFile.
+func unlockFile(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+}
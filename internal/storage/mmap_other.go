@@ -0,0 +1,19 @@
+//go:build !unix
+
+package storage
+
+import "os"
+
+// mmapSupported reports whether this platform can serve reads from memory mappings.
+const mmapSupported = false
+
+// mapFile reports that memory mapping is unavailable, which makes callers fall back
+// to positioned reads.
+func mapFile(file *os.File, size int64) ([]byte, error) {
+	return nil, errMmapUnsupported
+}
+
+// unmapFile is a no-op since mapFile never creates mappings on this platform.
+func unmapFile(data []byte) error {
+	return nil
+}
@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/iamNilotpal/ignite/pkg/options"
+)
+
+// openMapped opens a storage that reads sealed segments through memory mappings.
+func openMapped(t *testing.T) *Storage {
+	t.Helper()
+	if !mmapSupported {
+		t.Skip("memory-mapped reads are not supported on this platform")
+	}
+
+	opts := testOptions(t.TempDir())
+	opts.ReadOptions.Mode = options.ReadModeMmap
+	return openStorage(t, opts)
+}
+
+// mapping returns the mapping storage holds for a segment, if any.
+func mapping(s *Storage, segmentID uint64) *mappedSegment {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.mappings[segmentID]
+}
+
+func TestSealedSegmentsAreMapped(t *testing.T) {
+	s := openMapped(t)
+	positions := fillSegments(t, s, 2)
+
+	for i, pos := range positions {
+		expectEntry(t, s, pos, fmt.Sprintf("key-%d", i), entryValue(i))
+	}
+
+	// Sealed segments are mapped on first read, the growing one never is.
+	for _, id := range []uint64{1, 2} {
+		m := mapping(s, id)
+		if m == nil || len(m.data) == 0 {
+			t.Fatalf("sealed segment %d is not mapped", id)
+		}
+		// Storage's own reference remains once the reads are done.
+		if refs := m.refs.Load(); refs != 1 {
+			t.Fatalf("segment %d mapping has %d references after reads, want 1", id, refs)
+		}
+	}
+	if active := positions[len(positions)-1].SegmentID; mapping(s, active) != nil {
+		t.Fatalf("active segment %d is mapped", active)
+	}
+}
+
+func TestViewSurvivesSegmentRemoval(t *testing.T) {
+	s := openMapped(t)
+	pos := fillSegments(t, s, 1)[0]
+
+	var m *mappedSegment
+	err := s.View(pos.SegmentID, pos.Offset, pos.Size, func(entry *Entry) error {
+		m = mapping(s, pos.SegmentID)
+		if m == nil || m.refs.Load() != 2 {
+			t.Fatal("reader does not hold a reference to the mapping")
+		}
+
+		// Removing the segment while the entry is in use leaves the memory mapped.
+		if err := s.RemoveSegment(pos.SegmentID); err != nil {
+			t.Fatalf("RemoveSegment() error = %v", err)
+		}
+		if mapping(s, pos.SegmentID) != nil {
+			t.Fatal("removed segment is still registered")
+		}
+		if m.data == nil || m.refs.Load() != 1 {
+			t.Fatal("mapping released while a reader holds it")
+		}
+		if string(entry.Key) != "key-0" || string(entry.Value) != entryValue(0) {
+			t.Fatalf("View() entry = %q: %q after removal", entry.Key, entry.Value)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("View() error = %v", err)
+	}
+
+	// The reader dropped the last reference.
+	if m.data != nil || m.refs.Load() != 0 {
+		t.Fatalf("mapping still held after the last reader: %d references", m.refs.Load())
+	}
+	if m.acquire() {
+		t.Fatal("released mapping can be acquired again")
+	}
+}
+
+func TestRemoveSegmentUnmaps(t *testing.T) {
+	s := openMapped(t)
+	pos := fillSegments(t, s, 1)[0]
+	expectEntry(t, s, pos, "key-0", entryValue(0))
+
+	m := mapping(s, pos.SegmentID)
+	if m == nil {
+		t.Fatal("sealed segment is not mapped")
+	}
+	if err := s.RemoveSegment(pos.SegmentID); err != nil {
+		t.Fatalf("RemoveSegment() error = %v", err)
+	}
+	if m.data != nil || m.refs.Load() != 0 {
+		t.Fatalf("mapping of a removed segment kept with %d references", m.refs.Load())
+	}
+	if _, err := s.ReadEntry(pos.SegmentID, pos.Offset, pos.Size); err == nil {
+		t.Fatal("ReadEntry() of a removed segment succeeded")
+	}
+}
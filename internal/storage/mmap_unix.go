@@ -0,0 +1,21 @@
+//go:build unix
+
+package storage
+
+import (
+	"os"
+	"syscall"
+)
+
+// mmapSupported reports whether this platform can serve reads from memory mappings.
+const mmapSupported = true
+
+// mapFile maps size bytes of file into memory with read-only, shared access.
+func mapFile(file *os.File, size int64) ([]byte, error) {
+	return syscall.Mmap(int(file.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+}
+
+// unmapFile releases a mapping created by mapFile.
+func unmapFile(data []byte) error {
+	return syscall.Munmap(data)
+}
@@ -2,8 +2,11 @@ package storage
 
 import (
 	"os"
+	"sync"
 	"sync/atomic"
 
+	"github.com/iamNilotpal/ignite/internal/manifest"
+	"github.com/iamNilotpal/ignite/internal/metrics"
 	"github.com/iamNilotpal/ignite/pkg/options"
 	"go.uber.org/zap"
 )
@@ -16,12 +19,67 @@ import (
 // the current active file handle, configuration options that control behavior, a logger for
 // observability, and size tracking for determining when segment rotation is needed.
 type Storage struct {
-	size            int64              // Current size of the active segment file in bytes.
-	activeSegmentId uint64             // Unique identifier for the currently active segment file being written to.
-	closed          atomic.Bool        // Flag indicating whether the storage has been closed.
-	activeSegment   *os.File           // The currently active segment file where new data is written.
-	options         *options.Options   // Configuration parameters controlling storage behavior.
-	log             *zap.SugaredLogger // Structured logger for operational visibility and debugging.
+	size            int64                     // Current size of the active segment file in bytes.
+	activeSegmentId uint64                    // Unique identifier for the currently active segment file being written to.
+	closed          atomic.Bool               // Flag indicating whether the storage has been closed.
+	activeSegment   *os.File                  // The currently active segment file where new data is written.
+	segments        map[uint64]string         // Maps every known segment ID to the full path of its file.
+	mappings        map[uint64]*mappedSegment // Read-only memory mappings of sealed segments, keyed by segment ID.
+	useMmap         bool                      // Whether sealed segments are read through memory mappings.
+	files           *fileCache                // Bounded cache of read-only handles for sealed segments.
+	manifest        *manifest.Manifest        // Append-only log that is the source of truth for live segments.
+	lock            *dirLock                  // Exclusive lock on the data directory, held until Close.
+	readOnly        bool                      // Whether the data directory is opened for reading only.
+	unsealed        uint64                    // In read-only mode, the segment the writer may still append to.
+	scanned         map[uint64]int64          // In read-only mode, how many bytes of each segment were replayed.
+	activeCipher    *segmentCipher            // Encryption state of the active segment.
+	ciphers         map[uint64]*segmentCipher // Encryption state of every segment seen so far.
+	cipherMu        sync.Mutex                // Guards ciphers independently of mu, so lookups work under either lock.
+	pins            map[uint64]int            // Number of snapshots holding each segment, keyed by segment ID.
+	doomed          map[uint64]struct{}       // Pinned segments whose removal waits for the last pin to go.
+	hintQueue       []uint64                  // Sealed segments waiting for their hint file to be written.
+	hintMu          sync.Mutex                // Guards hintQueue.
+	hintWake        chan struct{}             // Signals the hint writer that hintQueue has work.
+	hintStop        chan struct{}             // Closed to stop the hint writer.
+	hintDone        chan struct{}             // Closed once the hint writer has exited.
+	mu              sync.RWMutex              // Guards the active segment, its size, the segments map and mappings.
+	io              ioStats                   // Byte counters and fsync latencies, read by IOStats.
+	syncWrites      atomic.Bool               // Whether every append syncs the active segment before returning.
+	options         *options.Options          // Configuration parameters controlling storage behavior.
+	log             *zap.SugaredLogger        // Structured logger for operational visibility and debugging.
+}
+
+// ioStats accumulates the I/O performed by a Storage. Its counters are atomic, so
+// they are updated without holding any lock.
+type ioStats struct {
+	written atomic.Uint64      // Bytes written to segment and blob files.
+	read    atomic.Uint64      // Bytes read from segment and blob files to serve reads.
+	fsync   *metrics.Histogram // Latency of every fsync of a segment or blob file.
+}
+
+// IOStats is a point-in-time snapshot of the I/O performed by a Storage since it
+// was opened.
+type IOStats struct {
+	BytesWritten uint64                    // Bytes written to segment and blob files.
+	BytesRead    uint64                    // Bytes read from segment and blob files, including memory-mapped reads.
+	Fsync        metrics.HistogramSnapshot // Latency of fsyncs of segment and blob files.
+}
+
+// Position describes where an entry was written within the segment files.
+// It carries exactly the information the index needs to build a RecordPointer.
+type Position struct {
+	SegmentID uint64 // Identifier of the segment the entry was appended to.
+	Offset    int64  // Byte offset of the entry within the segment.
+	Size      uint32 // Total number of bytes occupied by the entry on disk.
+	ValueSize uint32 // Length of the value as stored; only set for entries read back by a scan.
+}
+
+// SegmentFile describes a sealed segment file, as needed to copy it elsewhere.
+type SegmentFile struct {
+	ID   uint64 // Segment ID.
+	File string // Filename within the segment directory.
+	Path string // Full path of the file.
+	Size int64  // Size of the file in bytes.
 }
 
 // Config encapsulates all the configuration parameters required to initialize a Storage instance.
@@ -0,0 +1,224 @@
+package storage
+
+import (
+	"path/filepath"
+	"slices"
+
+	"github.com/iamNilotpal/ignite/internal/manifest"
+	"github.com/iamNilotpal/ignite/pkg/errors"
+	"github.com/iamNilotpal/ignite/pkg/seginfo"
+)
+
+// newReadOnly opens a data directory for reading only. No directory is created, no lock
+// is taken, no active segment is opened and no file is modified, which allows a read-only
+// instance to run next to the writer process that owns the directory.
+//
+// The most recent segment may still be appended to by that writer. It is read with
+// positioned reads and never memory mapped, and a partially written entry at its end is
+// treated as not yet visible rather than as corruption.
+func newReadOnly(config *Config) (*Storage, error) {
+	storage := newStorage(config)
+	storage.readOnly = true
+	storage.scanned = make(map[uint64]int64)
+
+	m, err := manifest.Open(&manifest.Config{
+		Dir:      config.Options.DataDir,
+		ReadOnly: true,
+		Logger:   config.Logger,
+	})
+	if err != nil {
+		return nil, err
+	}
+	storage.manifest = m
+
+	if _, err := storage.syncSegments(); err != nil {
+		storage.abort()
+		return nil, err
+	}
+
+	config.Logger.Infow(
+		"Storage opened read-only",
+		"dataDir", config.Options.DataDir,
+		"segments", len(storage.segments),
+		"unsealedSegmentID", storage.unsealed,
+	)
+
+	return storage, nil
+}
+
+// Refresh brings a read-only storage up to date with its writer. It reloads the manifest
+// and passes fn every entry that became visible since the last Scan or Refresh: the
+// remainder of the segment that was still growing, and every segment that appeared.
+//
+// If segments known before have disappeared, for example because the writer compacted
+// them, entries already handed out may point at removed data. Refresh then scans nothing
+// and returns the IDs of the removed segments; the caller must discard what it built and
+// start over with Scan. Refresh is a no-op for writable storage.
+func (s *Storage) Refresh(fn func(pos *Position, entry *Entry) error) ([]uint64, error) {
+	if s.closed.Load() {
+		return nil, ErrSegmentClosed
+	}
+	if !s.readOnly {
+		return nil, nil
+	}
+
+	if err := s.manifest.Reload(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previousUnsealed := s.unsealed
+
+	removed, err := s.syncSegments()
+	if err != nil {
+		return nil, err
+	}
+	if len(removed) > 0 {
+		clear(s.scanned)
+		return removed, nil
+	}
+
+	for _, segmentID := range s.sortedSegmentIDs() {
+		from, seen := s.scanned[segmentID]
+		if seen && segmentID != previousUnsealed {
+			continue
+		}
+
+		if err := s.scanReadOnly(segmentID, from, fn); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+// syncSegments replaces the registered segments with the ones currently visible on disk
+// and returns the IDs of segments that disappeared. Their mappings and cached handles are
+// dropped. The caller must hold the write lock or have exclusive access.
+func (s *Storage) syncSegments() ([]uint64, error) {
+	visible, err := s.visibleSegments()
+	if err != nil {
+		return nil, err
+	}
+
+	segmentDir := filepath.Join(s.options.DataDir, s.options.SegmentOptions.Directory)
+	current := make(map[uint64]string, len(visible))
+	for _, segment := range visible {
+		current[segment.ID] = filepath.Join(segmentDir, segment.File)
+	}
+
+	var removed []uint64
+	for segmentID := range s.segments {
+		if _, ok := current[segmentID]; ok {
+			continue
+		}
+
+		removed = append(removed, segmentID)
+		if err := s.unmapSegment(segmentID); err != nil {
+			s.log.Errorw("Failed to unmap removed segment", "segmentID", segmentID, "error", err)
+		}
+		if err := s.files.remove(segmentID); err != nil {
+			s.log.Errorw("Failed to close removed segment handle", "segmentID", segmentID, "error", err)
+		}
+		s.dropCipher(segmentID)
+	}
+	slices.Sort(removed)
+
+	s.segments = current
+	s.unsealed = 0
+	if n := len(visible); n > 0 && !visible[n-1].Sealed {
+		s.unsealed = visible[n-1].ID
+	}
+
+	return removed, nil
+}
+
+// visibleSegments returns the segments a read-only storage can see, ordered by ID. The
+// manifest is authoritative when it exists; otherwise the segment directory is listed
+// and every segment but the most recent one is assumed to be sealed.
+func (s *Storage) visibleSegments() ([]manifest.Segment, error) {
+	if !s.manifest.Created() {
+		return s.manifest.Segments(), nil
+	}
+
+	names, err := seginfo.ListSegmentNames(
+		s.options.DataDir, s.options.SegmentOptions.Directory, s.options.SegmentOptions.Prefix,
+	)
+	if err != nil {
+		return nil, errors.NewStorageError(
+			err, errors.ErrorCodeIO, "Failed to list existing segments",
+		).WithPath(filepath.Join(s.options.DataDir, s.options.SegmentOptions.Directory)).
+			WithDetail("operation", "segment_discovery")
+	}
+
+	segments := make([]manifest.Segment, 0, len(names))
+	for i, name := range names {
+		id, err := seginfo.ParseSegmentID(name, s.options.SegmentOptions.Prefix)
+		if err != nil {
+			return nil, errors.NewStorageError(
+				err, errors.ErrorCodeSegmentCorrupted, "Failed to parse segment ID from filename",
+			).WithPath(name).
+				WithFileName(filepath.Base(name)).
+				WithDetail("operation", "segment_discovery")
+		}
+
+		segments = append(segments, manifest.Segment{
+			ID:     id,
+			File:   filepath.Base(name),
+			Sealed: i < len(names)-1,
+		})
+	}
+
+	return segments, nil
+}
+
+// scanReadOnly replays a segment from the given offset and records how far it got. A
+// damaged entry at the end of the unsealed segment is most likely an append the writer
+// has not finished, so it is left for the next Refresh. The caller must hold the write lock.
+func (s *Storage) scanReadOnly(segmentID uint64, from int64, fn func(pos *Position, entry *Entry) error) error {
+	sc, err := s.cipherFor(segmentID, s.segments[segmentID])
+	if err != nil {
+		return err
+	}
+
+	validSize, err := s.scanSegment(segmentID, sc, max(from, sc.dataStart), fn)
+	s.scanned[segmentID] = validSize
+	if err == nil {
+		return nil
+	}
+
+	// Errors returned by the callback are not corruption and must be propagated.
+	if !errors.IsStorageError(err) {
+		return err
+	}
+
+	if segmentID == s.unsealed {
+		s.log.Debugw(
+			"Stopped at an incomplete entry at the end of the unsealed segment",
+			"segmentID", segmentID,
+			"validSize", validSize,
+			"error", err,
+		)
+		return nil
+	}
+
+	s.log.Errorw(
+		"Skipping remainder of corrupted segment",
+		"segmentID", segmentID,
+		"validSize", validSize,
+		"path", s.segments[segmentID],
+		"error", err,
+	)
+	return nil
+}
+
+// isGrowing reports whether a segment may still be appended to, either by this storage
+// or, in read-only mode, by the writer process. Growing segments are never memory mapped.
+func (s *Storage) isGrowing(segmentID uint64) bool {
+	if s.readOnly {
+		return segmentID == s.unsealed
+	}
+	return segmentID == s.activeSegmentId
+}
@@ -0,0 +1,211 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/iamNilotpal/ignite/internal/manifest"
+	"github.com/iamNilotpal/ignite/pkg/errors"
+	"github.com/iamNilotpal/ignite/pkg/filesys"
+	"github.com/iamNilotpal/ignite/pkg/seginfo"
+)
+
+// recoverSegments opens the manifest and reconciles the segment directory with it.
+// It finishes deleting compaction inputs that were left behind, removes segment files
+// the manifest does not reference (such as half-written compaction outputs), verifies
+// that every live segment still exists, and registers the live segments for reads.
+//
+// It returns the live segment with the highest ID, or nil when there is none.
+func (s *Storage) recoverSegments() (*manifest.Segment, error) {
+	m, err := manifest.Open(&manifest.Config{Dir: s.options.DataDir, Logger: s.log})
+	if err != nil {
+		return nil, err
+	}
+	s.manifest = m
+
+	// Data directories written before the manifest existed are adopted as-is.
+	if m.Created() {
+		if err := s.bootstrapManifest(); err != nil {
+			return nil, err
+		}
+	}
+
+	segmentDir := filepath.Join(s.options.DataDir, s.options.SegmentOptions.Directory)
+
+	// Complete deletions of compaction inputs interrupted by a crash.
+	for _, segment := range m.Obsolete() {
+		path := filepath.Join(segmentDir, segment.File)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, errors.NewFileAccessError(path, segment.File, "obsolete_segment_remove", err).
+				WithSegmentID(int(segment.ID))
+		}
+
+		if err := removeHint(path); err != nil {
+			return nil, err
+		}
+
+		if err := m.LogDelete(segment.ID); err != nil {
+			return nil, err
+		}
+
+		s.log.Infow("Removed obsolete compaction input", "segmentID", segment.ID, "path", path)
+	}
+
+	// Garbage-collect segment files the manifest knows nothing about.
+	files, err := filesys.ReadDir(filepath.Join(segmentDir, s.options.SegmentOptions.Prefix+"*.seg"))
+	if err != nil {
+		return nil, errors.NewStorageError(
+			err, errors.ErrorCodeIO, "Failed to list segment directory",
+		).WithPath(segmentDir).
+			WithDetail("operation", "segment_gc")
+	}
+
+	for _, path := range files {
+		if m.References(filepath.Base(path)) {
+			continue
+		}
+
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, errors.NewFileAccessError(path, filepath.Base(path), "unreferenced_segment_remove", err)
+		}
+
+		s.log.Warnw("Removed segment file not referenced by the manifest", "path", path)
+	}
+
+	// Hint files outlive their segment when the process dies between removing the
+	// two, and temporary ones are left by interrupted writes.
+	if err := s.removeStaleHints(segmentDir); err != nil {
+		return nil, err
+	}
+
+	// Register every live segment, refusing to start if one has gone missing.
+	segments := m.Segments()
+	for _, segment := range segments {
+		path := filepath.Join(segmentDir, segment.File)
+
+		exists, err := filesys.Exists(path)
+		if err != nil || !exists {
+			return nil, errors.NewStorageError(
+				err, errors.ErrorCodeRecoveryFailed, "Segment listed in the manifest is missing from disk",
+			).WithSegmentID(int(segment.ID)).
+				WithPath(path).
+				WithFileName(segment.File).
+				WithDetail("operation", "manifest_verify").
+				WithDetail("suggestion", "restore the segment file from a backup")
+		}
+
+		s.segments[segment.ID] = path
+	}
+
+	// Compact the manifest history now that it reflects the directory exactly.
+	if err := m.Rewrite(); err != nil {
+		return nil, err
+	}
+
+	if len(segments) == 0 {
+		return nil, nil
+	}
+
+	last := segments[len(segments)-1]
+	return &last, nil
+}
+
+// removeStaleHints deletes the hint files of segments the manifest does not reference
+// and temporary hint files.
+func (s *Storage) removeStaleHints(segmentDir string) error {
+	pattern := filepath.Join(segmentDir, s.options.SegmentOptions.Prefix+"*.seg"+hintExt)
+
+	hints, err := filesys.ReadDir(pattern)
+	if err != nil {
+		return errors.NewStorageError(
+			err, errors.ErrorCodeIO, "Failed to list hint files",
+		).WithPath(segmentDir).
+			WithDetail("operation", "hint_gc")
+	}
+	temps, err := filesys.ReadDir(pattern + hintTempExt)
+	if err != nil {
+		return errors.NewStorageError(
+			err, errors.ErrorCodeIO, "Failed to list hint files",
+		).WithPath(segmentDir).
+			WithDetail("operation", "hint_gc")
+	}
+
+	for _, path := range hints {
+		if s.manifest.References(strings.TrimSuffix(filepath.Base(path), hintExt)) {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return errors.NewFileAccessError(path, filepath.Base(path), "stale_hint_remove", err)
+		}
+		s.log.Infow("Removed hint file of a segment that no longer exists", "path", path)
+	}
+
+	for _, path := range temps {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return errors.NewFileAccessError(path, filepath.Base(path), "stale_hint_remove", err)
+		}
+	}
+	return nil
+}
+
+// bootstrapManifest seeds a freshly created manifest with the segment files already on
+// disk. Every segment except the most recent one is recorded as sealed.
+func (s *Storage) bootstrapManifest() error {
+	names, err := seginfo.ListSegmentNames(
+		s.options.DataDir, s.options.SegmentOptions.Directory, s.options.SegmentOptions.Prefix,
+	)
+	if err != nil {
+		return errors.NewStorageError(
+			err, errors.ErrorCodeIO, "Failed to list existing segments",
+		).WithPath(filepath.Join(s.options.DataDir, s.options.SegmentOptions.Directory)).
+			WithDetail("operation", "manifest_bootstrap")
+	}
+
+	for i, name := range names {
+		id, err := seginfo.ParseSegmentID(name, s.options.SegmentOptions.Prefix)
+		if err != nil {
+			return errors.NewStorageError(
+				err, errors.ErrorCodeSegmentCorrupted, "Failed to parse segment ID from filename",
+			).WithPath(name).
+				WithFileName(filepath.Base(name)).
+				WithDetail("operation", "manifest_bootstrap")
+		}
+
+		if err := s.manifest.LogCreate(id, filepath.Base(name)); err != nil {
+			return err
+		}
+
+		if i == len(names)-1 {
+			continue
+		}
+
+		info, err := seginfo.GetFileInfo(name)
+		if err != nil {
+			return errors.NewFileAccessError(name, filepath.Base(name), "manifest_bootstrap", err)
+		}
+
+		if err := s.manifest.LogSeal(id, info.Size()); err != nil {
+			return err
+		}
+	}
+
+	if len(names) > 0 {
+		s.log.Infow("Bootstrapped manifest from existing segment files", "segments", len(names))
+	}
+
+	return nil
+}
+
+// abort releases the manifest and the directory lock after a failed initialization.
+func (s *Storage) abort() {
+	if s.manifest != nil {
+		if err := s.manifest.Close(); err != nil {
+			s.log.Warnw("Failed to close manifest", "error", err)
+		}
+	}
+
+	if err := s.lock.release(); err != nil {
+		s.log.Warnw("Failed to release data directory lock", "error", err)
+	}
+}
@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/iamNilotpal/ignite/internal/manifest"
+	"github.com/iamNilotpal/ignite/pkg/errors"
+	"github.com/iamNilotpal/ignite/pkg/options"
+	"github.com/iamNilotpal/ignite/pkg/seginfo"
+	"go.uber.org/zap"
+)
+
+// newSegmentPath returns a path for a new segment file with the given ID.
+func newSegmentPath(opts *options.Options, id uint64) string {
+	return filepath.Join(
+		opts.DataDir, opts.SegmentOptions.Directory, seginfo.GenerateName(id, opts.SegmentOptions.Prefix),
+	)
+}
+
+// expectRemoved fails the test if any of paths still exists.
+func expectRemoved(t *testing.T, paths ...string) {
+	t.Helper()
+	for _, path := range paths {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Fatalf("%s was not removed: %v", filepath.Base(path), err)
+		}
+	}
+}
+
+// writeFile creates a file at path holding data.
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRecoveryRemovesUnreferencedFiles(t *testing.T) {
+	opts := testOptions(t.TempDir())
+	s := openStorage(t, opts)
+	positions := fillSegments(t, s, 2)
+	first := s.segments[1]
+	closeStorage(t, s)
+
+	// A compaction output whose manifest record never became durable, and the hint
+	// files of segments that no longer exist or were still being written.
+	orphan := newSegmentPath(opts, 9)
+	writeFile(t, orphan, []byte("half-written compaction output"))
+	writeFile(t, HintPath(orphan), []byte("stale hint"))
+	writeFile(t, HintPath(first)+hintTempExt, []byte("partial hint"))
+
+	s = openStorage(t, opts)
+	expectRemoved(t, orphan, HintPath(orphan), HintPath(first)+hintTempExt)
+	if _, err := os.Stat(first); err != nil {
+		t.Fatalf("live segment removed: %v", err)
+	}
+	for i, pos := range positions {
+		expectEntry(t, s, pos, fmt.Sprintf("key-%d", i), entryValue(i))
+	}
+}
+
+func TestRecoveryRemovesObsoleteSegments(t *testing.T) {
+	opts := testOptions(t.TempDir())
+	s := openStorage(t, opts)
+	positions := fillSegments(t, s, 2)
+	first := s.segments[1]
+	closeStorage(t, s)
+	if _, err := os.Stat(first); err != nil {
+		t.Fatal(err)
+	}
+
+	// A compaction that swapped segment 1 out but crashed before deleting it.
+	m, err := manifest.Open(&manifest.Config{Dir: opts.DataDir, Logger: zap.NewNop().Sugar()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.LogCompaction([]uint64{1}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s = openStorage(t, opts)
+	expectRemoved(t, first, HintPath(first))
+	if obsolete := s.manifest.Obsolete(); len(obsolete) != 0 {
+		t.Fatalf("manifest still lists obsolete segments %+v", obsolete)
+	}
+	for _, segment := range s.manifest.Segments() {
+		if segment.ID == 1 {
+			t.Fatal("manifest still lists segment 1 as live")
+		}
+	}
+	for i, pos := range positions {
+		if pos.SegmentID != 1 {
+			expectEntry(t, s, pos, fmt.Sprintf("key-%d", i), entryValue(i))
+		}
+	}
+}
+
+func TestRecoveryFailsOnMissingSegment(t *testing.T) {
+	opts := testOptions(t.TempDir())
+	s := openStorage(t, opts)
+	fillSegments(t, s, 2)
+	second := s.segments[2]
+	closeStorage(t, s)
+
+	if err := os.Remove(second); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := New(context.Background(), &Config{Options: opts, Logger: zap.NewNop().Sugar()})
+	if err == nil {
+		s.Close()
+		t.Fatal("New() succeeded with a live segment missing")
+	}
+	if se, ok := errors.AsStorageError(err); !ok || se.Code() != errors.ErrorCodeRecoveryFailed {
+		t.Fatalf("New() error = %v, want %s", err, errors.ErrorCodeRecoveryFailed)
+	}
+}
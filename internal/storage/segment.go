@@ -0,0 +1,514 @@
+package storage
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/iamNilotpal/ignite/pkg/errors"
+)
+
+// Append writes a single entry to the end of the active segment and returns the
+// position at which it was stored. If the entry would push the active segment past
+// its configured size limit, the segment is sealed and a new one is created first.
+// With SetSyncWrites on, the segment is synced before Append returns.
+func (s *Storage) Append(entry *Entry) (*Position, error) {
+	if s.closed.Load() {
+		return nil, ErrSegmentClosed
+	}
+	if s.readOnly {
+		return nil, errors.NewReadOnlyError("entry_append")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Rotate before writing so that an entry never straddles two segments. An empty
+	// segment always accepts the entry, even if it is larger than the size limit.
+	if s.size > s.activeCipher.dataStart && s.size+s.encodedSize(entry) > int64(s.options.SegmentOptions.Size) {
+		if err := s.rotateSegment(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Encode only now, since an encrypted entry is sealed under the data key of the
+	// segment it ends up in.
+	buf, err := s.encodeEntry(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.syncWrite(s.writeLocked(buf))
+}
+
+// ReadEntry reads and decodes the entry stored at the given position, verifying its
+// checksum before returning it. The returned entry owns its key and value buffers.
+func (s *Storage) ReadEntry(segmentID uint64, offset int64, size uint32) (*Entry, error) {
+	if s.closed.Load() {
+		return nil, ErrSegmentClosed
+	}
+
+	var entry *Entry
+	handled, err := s.viewMapped(segmentID, offset, size, func(mapped *Entry) error {
+		entry = &Entry{
+			Timestamp: mapped.Timestamp,
+			Version:   mapped.Version,
+			Flags:     mapped.Flags,
+			Key:       slices.Clone(mapped.Key),
+			Value:     slices.Clone(mapped.Value),
+			ExpiresAt: mapped.ExpiresAt,
+		}
+		return nil
+	})
+	if handled {
+		return entry, err
+	}
+
+	return s.readEntry(segmentID, offset, size)
+}
+
+// View decodes the entry stored at the given position and passes it to fn. When
+// sealed segments are memory-mapped, the entry's key and value slice directly into
+// the read-only mapping without any copy; they are valid only until fn returns and
+// must never be modified. The mapping is guaranteed to stay mapped while fn runs,
+// even if the segment is removed concurrently.
+func (s *Storage) View(segmentID uint64, offset int64, size uint32, fn func(entry *Entry) error) error {
+	if s.closed.Load() {
+		return ErrSegmentClosed
+	}
+
+	if handled, err := s.viewMapped(segmentID, offset, size, fn); handled {
+		return err
+	}
+
+	entry, err := s.readEntry(segmentID, offset, size)
+	if err != nil {
+		return err
+	}
+	return fn(entry)
+}
+
+// RemoveSegment deletes a sealed segment file, for example once compaction has
+// moved its live entries elsewhere. Any memory mapping or cached file handle of the
+// segment is released as soon as in-flight readers are done with it. Segments pinned
+// by a snapshot are removed when the last snapshot holding them unpins them.
+func (s *Storage) RemoveSegment(segmentID uint64) error {
+	if s.closed.Load() {
+		return ErrSegmentClosed
+	}
+	if s.readOnly {
+		return errors.NewReadOnlyError("segment_remove")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if segmentID == s.activeSegmentId {
+		return errors.NewStorageError(
+			nil, errors.ErrorCodeInvalidInput, "Cannot remove the active segment",
+		).WithSegmentID(int(segmentID)).
+			WithDetail("operation", "segment_remove")
+	}
+
+	if _, ok := s.segments[segmentID]; !ok {
+		return nil
+	}
+
+	// A snapshot may still read from the segment; it is removed once released.
+	if s.pins[segmentID] > 0 {
+		s.doomed[segmentID] = struct{}{}
+		s.log.Infow("Deferring removal of segment pinned by a snapshot", "segmentID", segmentID, "pins", s.pins[segmentID])
+		return nil
+	}
+
+	return s.removeSegmentLocked(segmentID)
+}
+
+// removeSegmentLocked deletes a sealed segment file. The caller must hold the write lock.
+func (s *Storage) removeSegmentLocked(segmentID uint64) error {
+	path := s.segments[segmentID]
+
+	// Log the deletion before removing the file. If the process dies in between, the
+	// file is no longer referenced and recovery garbage-collects it.
+	if err := s.manifest.LogDelete(segmentID); err != nil {
+		return err
+	}
+
+	if err := s.unmapSegment(segmentID); err != nil {
+		s.log.Errorw("Failed to unmap removed segment", "segmentID", segmentID, "error", err)
+	}
+	s.dropCipher(segmentID)
+	if err := s.files.remove(segmentID); err != nil {
+		s.log.Errorw("Failed to close handle of removed segment", "segmentID", segmentID, "error", err)
+	}
+	delete(s.segments, segmentID)
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return errors.NewFileAccessError(path, filepath.Base(path), "segment_remove", err).
+			WithSegmentID(int(segmentID))
+	}
+	if err := removeHint(path); err != nil {
+		s.log.Warnw("Failed to remove hint file of removed segment", "segmentID", segmentID, "error", err)
+	}
+
+	s.log.Infow("Removed segment", "segmentID", segmentID, "path", path)
+	return nil
+}
+
+// PinSegments pins every segment currently part of the store so that RemoveSegment
+// defers their removal, and returns their IDs. A snapshot pins the segments its keys
+// point into and passes the IDs to UnpinSegments once it is released.
+func (s *Storage) PinSegments() []uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := s.sortedSegmentIDs()
+	for _, id := range ids {
+		s.pins[id]++
+	}
+	return ids
+}
+
+// UnpinSegments drops pins taken by PinSegments, removing segments whose removal was
+// deferred once nothing pins them anymore.
+func (s *Storage) UnpinSegments(ids []uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var firstErr error
+	for _, id := range ids {
+		if s.pins[id]--; s.pins[id] > 0 {
+			continue
+		}
+		delete(s.pins, id)
+
+		if _, ok := s.doomed[id]; !ok {
+			continue
+		}
+		delete(s.doomed, id)
+
+		if s.closed.Load() {
+			continue
+		}
+		if err := s.removeSegmentLocked(id); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// readEntry reads an entry into a freshly allocated buffer using a positioned read.
+func (s *Storage) readEntry(segmentID uint64, offset int64, size uint32) (*Entry, error) {
+	buf := make([]byte, size)
+	if err := s.readAt(segmentID, offset, buf); err != nil {
+		return nil, err
+	}
+
+	return s.decodeEntry(buf, segmentID, offset)
+}
+
+// viewMapped serves an entry straight out of a sealed segment's memory mapping. It
+// reports whether the read was handled; when it was not (mmap disabled, or the
+// segment is still active) the caller must fall back to a positioned read.
+func (s *Storage) viewMapped(segmentID uint64, offset int64, size uint32, fn func(entry *Entry) error) (bool, error) {
+	if !s.useMmap {
+		return false, nil
+	}
+
+	m, err := s.mapSegment(segmentID)
+	if err != nil {
+		return true, err
+	}
+	if m == nil {
+		return false, nil
+	}
+
+	defer func() {
+		if err := m.release(); err != nil {
+			s.log.Errorw("Failed to unmap segment", "segmentID", segmentID, "error", err)
+		}
+	}()
+
+	end := offset + int64(size)
+	if offset < 0 || end > int64(len(m.data)) {
+		return true, errors.NewPayloadReadError(
+			"", int(segmentID), int(offset), int(size), io.ErrUnexpectedEOF,
+		).WithDetail("mappedSize", len(m.data))
+	}
+
+	s.io.read.Add(uint64(size))
+	entry, err := s.decodeEntry(m.data[offset:end:end], segmentID, offset)
+	if err != nil {
+		return true, err
+	}
+
+	return true, fn(entry)
+}
+
+// Scan walks every entry of every segment in write order and invokes fn for each one.
+// It is used during startup to rebuild the in-memory index from the segment files.
+//
+// A sealed segment with a hint file that matches it is replayed from the hint, which
+// holds no values: fn then gets entries without the value of inline entries, and must
+// take the value size from the Position. Sealed segments without a usable hint are
+// read in full and queued for a new hint.
+//
+// A damaged entry in a sealed segment stops the scan of that segment only, since
+// nothing after the damage can be located reliably. A damaged tail in the active
+// segment is the signature of a torn write, so the active segment is truncated back
+// to the last valid entry to keep future appends reachable.
+func (s *Storage) Scan(fn func(pos *Position, entry *Entry) error) error {
+	if s.closed.Load() {
+		return ErrSegmentClosed
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, segmentID := range s.sortedSegmentIDs() {
+		if s.readOnly {
+			if err := s.scanReadOnly(segmentID, 0, fn); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// A segment whose key cannot be obtained is not corrupt; recovery must stop
+		// rather than treat its entries as damaged and truncate them.
+		sc, err := s.cipherFor(segmentID, s.segments[segmentID])
+		if err != nil {
+			return err
+		}
+
+		// Sealed segments are replayed from their hint file when it can be trusted.
+		if segmentID != s.activeSegmentId {
+			used, err := s.scanHint(segmentID, sc, fn)
+			if err != nil {
+				return err
+			}
+			if used {
+				continue
+			}
+		}
+
+		validSize, err := s.scanSegment(segmentID, sc, sc.dataStart, fn)
+		if err == nil {
+			if segmentID != s.activeSegmentId {
+				s.queueHint(segmentID)
+			}
+			continue
+		}
+
+		// Errors returned by the callback are not corruption and must be propagated.
+		if !errors.IsStorageError(err) {
+			return err
+		}
+
+		if segmentID != s.activeSegmentId {
+			s.log.Errorw(
+				"Skipping remainder of corrupted segment",
+				"segmentID", segmentID,
+				"validSize", validSize,
+				"path", s.segments[segmentID],
+				"error", err,
+			)
+			continue
+		}
+
+		s.log.Warnw(
+			"Truncating torn write at the end of the active segment",
+			"segmentID", segmentID,
+			"validSize", validSize,
+			"previousSize", s.size,
+			"error", err,
+		)
+
+		if err := s.activeSegment.Truncate(validSize); err != nil {
+			return errors.NewStorageError(
+				err, errors.ErrorCodeRecoveryFailed, "Failed to truncate damaged tail of active segment",
+			).WithSegmentID(int(segmentID)).
+				WithPath(s.segments[segmentID]).
+				WithOffset(int(validSize)).
+				WithDetail("operation", "segment_truncate")
+		}
+		s.size = validSize
+	}
+
+	return nil
+}
+
+// scanSegment streams the entries of a single segment, starting at byte offset from,
+// through fn, decrypting them with the segment's cipher. It returns the offset just past the last entry that was successfully
+// decoded, which marks the boundary of valid data when the segment turns out to be damaged.
+func (s *Storage) scanSegment(
+	segmentID uint64, sc *segmentCipher, from int64, fn func(pos *Position, entry *Entry) error,
+) (int64, error) {
+	return walkEntries(s.segments[segmentID], segmentID, from, func(pos *Position, entry *Entry) error {
+		if err := openEntry(sc, entry, segmentID, pos.Offset); err != nil {
+			return err
+		}
+		return fn(pos, entry)
+	})
+}
+
+// walkEntries streams the entries of a segment file, starting at byte offset from,
+// through fn, verifying each entry's checksum. Encrypted entries are passed on sealed.
+// It returns the offset just past the last entry that was successfully decoded.
+func walkEntries(path string, segmentID uint64, from int64, fn func(pos *Position, entry *Entry) error) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return from, errors.ClassifyFileOpenError(err, path, filepath.Base(path))
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(from, io.SeekStart); err != nil {
+		return from, errors.NewFileAccessError(path, filepath.Base(path), "segment_seek", err).
+			WithSegmentID(int(segmentID)).
+			WithOffset(int(from))
+	}
+
+	reader := bufio.NewReaderSize(file, 64*1024)
+	headerBuf := make([]byte, HeaderSize)
+
+	offset := from
+	for {
+		if _, err := io.ReadFull(reader, headerBuf); err != nil {
+			if err == io.EOF {
+				return offset, nil
+			}
+			return offset, errors.NewHeaderReadError(filepath.Base(path), int(offset), err).
+				WithSegmentID(int(segmentID)).
+				WithPath(path).
+				WithDetail("header_size_expected", HeaderSize)
+		}
+
+		h := decodeHeader(headerBuf)
+		buf := make([]byte, h.size())
+		copy(buf, headerBuf)
+
+		if _, err := io.ReadFull(reader, buf[HeaderSize:]); err != nil {
+			return offset, errors.NewPayloadReadError(
+				filepath.Base(path), int(segmentID), int(offset), len(buf), err,
+			).WithPath(path)
+		}
+
+		entry, err := DecodeEntry(buf, segmentID, offset)
+		if err != nil {
+			return offset, err
+		}
+
+		pos := &Position{SegmentID: segmentID, Offset: offset, Size: uint32(len(buf)), ValueSize: h.valueSize}
+		if err := fn(pos, entry); err != nil {
+			return offset, err
+		}
+
+		offset += int64(len(buf))
+	}
+}
+
+// readAt fills buf with the bytes stored at offset in the given segment. Reads from
+// the active segment reuse its open handle, while sealed segments are read through
+// the bounded file handle cache.
+func (s *Storage) readAt(segmentID uint64, offset int64, buf []byte) error {
+	s.mu.RLock()
+	if segmentID == s.activeSegmentId {
+		// Hold the read lock for the duration of the read so the handle cannot be
+		// closed by a concurrent rotation.
+		n, err := s.activeSegment.ReadAt(buf, offset)
+		path := s.segments[segmentID]
+		s.mu.RUnlock()
+		s.io.read.Add(uint64(n))
+		if err != nil {
+			return errors.NewPayloadReadError(filepath.Base(path), int(segmentID), int(offset), len(buf), err).
+				WithPath(path)
+		}
+		return nil
+	}
+
+	path, ok := s.segments[segmentID]
+	s.mu.RUnlock()
+
+	if !ok {
+		return errors.NewStorageError(
+			os.ErrNotExist, errors.ErrorCodeIO, "Segment file not found for segment ID",
+		).WithSegmentID(int(segmentID)).
+			WithOffset(int(offset)).
+			WithDetail("operation", "segment_lookup")
+	}
+
+	handle, err := s.files.acquire(segmentID, path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := s.files.release(handle); err != nil {
+			s.log.Errorw("Failed to close evicted segment handle", "segmentID", segmentID, "error", err)
+		}
+	}()
+
+	n, err := handle.file.ReadAt(buf, offset)
+	s.io.read.Add(uint64(n))
+	if err != nil {
+		return errors.NewPayloadReadError(filepath.Base(path), int(segmentID), int(offset), len(buf), err).
+			WithPath(path)
+	}
+
+	return nil
+}
+
+// rotateSegment seals the active segment and opens a fresh one with the next ID.
+// The new file is opened before the old handle is closed, so that a failed rotation
+// leaves the active segment in place and writable. The caller must hold the write
+// lock.
+func (s *Storage) rotateSegment() error {
+	previousID := s.activeSegmentId
+	previousPath := s.segments[previousID]
+	previous := s.activeSegment
+	sealedSize := s.size
+
+	if err := s.syncFile(previous); err != nil {
+		return errors.ClassifySyncError(err, filepath.Base(previousPath), previousPath, int(sealedSize))
+	}
+
+	file, err := s.openSegmentFile(previousID+1, true)
+	if err != nil {
+		return err
+	}
+
+	s.activeSegment = file
+	s.activeSegmentId = previousID + 1
+
+	if err := previous.Close(); err != nil {
+		s.log.Errorw("Failed to close sealed segment file handle", "segmentID", previousID, "path", previousPath, "error", err)
+	}
+
+	if err := s.manifest.LogSeal(previousID, sealedSize); err != nil {
+		return err
+	}
+	s.queueHint(previousID)
+
+	s.log.Infow(
+		"Rotated active segment",
+		"sealedSegmentID", previousID,
+		"activeSegmentID", s.activeSegmentId,
+	)
+
+	return nil
+}
+
+// sortedSegmentIDs returns every known segment ID in ascending order.
+func (s *Storage) sortedSegmentIDs() []uint64 {
+	ids := make([]uint64, 0, len(s.segments))
+	for id := range s.segments {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// FileCacheStats returns a snapshot of the segment file handle cache counters.
+func (s *Storage) FileCacheStats() FileCacheStats {
+	return s.files.stats()
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/iamNilotpal/ignite/internal/manifest"
+)
+
+func TestAppendRotatesSegments(t *testing.T) {
+	opts := testOptions(t.TempDir())
+	s := openStorage(t, opts)
+
+	positions := fillSegments(t, s, 3)
+	for i, pos := range positions {
+		if pos.Offset+int64(pos.Size) > int64(opts.SegmentOptions.Size) {
+			t.Fatalf("entry %d ends at %d, past the segment size", i, pos.Offset+int64(pos.Size))
+		}
+	}
+
+	segments := s.manifest.Segments()
+	if len(segments) != 4 {
+		t.Fatalf("manifest lists %d segments, want 4", len(segments))
+	}
+	for _, segment := range segments[:3] {
+		if !segment.Sealed || segment.Size == 0 {
+			t.Fatalf("rotated segment %+v is not sealed with its size", segment)
+		}
+	}
+	closeStorage(t, s)
+
+	s = openStorage(t, opts)
+	for i, pos := range positions {
+		expectEntry(t, s, pos, fmt.Sprintf("key-%d", i), entryValue(i))
+	}
+}
+
+func TestFailedRotationKeepsActiveSegment(t *testing.T) {
+	opts := testOptions(t.TempDir())
+	s := openStorage(t, opts)
+
+	positions := fillSegments(t, s, 1)
+	last := positions[len(positions)-1]
+
+	// With the manifest unavailable, the new segment cannot be recorded.
+	if err := s.manifest.Close(); err != nil {
+		t.Fatal(err)
+	}
+	big := strings.Repeat("x", int(opts.SegmentOptions.Size))
+	if _, err := s.Append(NewEntry([]byte("big"), []byte(big), 0)); err == nil {
+		t.Fatal("Append() succeeded without a manifest to record the new segment")
+	}
+
+	// The active segment is still open for reads and, once the manifest is back, for
+	// the rotation that failed.
+	expectEntry(t, s, last, fmt.Sprintf("key-%d", len(positions)-1), entryValue(len(positions)-1))
+
+	m, err := manifest.Open(&manifest.Config{Dir: opts.DataDir, Logger: s.log})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.manifest = m
+
+	pos := appendEntry(t, s, "big", big)
+	if pos.SegmentID != last.SegmentID+1 {
+		t.Fatalf("Append() after recovery went to segment %d, want %d", pos.SegmentID, last.SegmentID+1)
+	}
+	closeStorage(t, s)
+
+	s = openStorage(t, opts)
+	for i, pos := range positions {
+		expectEntry(t, s, pos, fmt.Sprintf("key-%d", i), entryValue(i))
+	}
+	expectEntry(t, s, pos, "big", big)
+}
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// IOStats returns a snapshot of the bytes written and read and of the fsync
+// latencies since the storage was opened. Replaying segments on open is not counted.
+func (s *Storage) IOStats() IOStats {
+	return IOStats{
+		BytesWritten: s.io.written.Load(),
+		BytesRead:    s.io.read.Load(),
+		Fsync:        s.io.fsync.Snapshot(),
+	}
+}
+
+// ActiveSegment returns the ID and size in bytes of the segment new entries are
+// appended to. In read-only mode it is the segment the writer may still be appending
+// to, as of the last Refresh, and its size is measured on disk.
+func (s *Storage) ActiveSegment() (uint64, int64) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.readOnly {
+		return s.activeSegmentId, s.size
+	}
+
+	var size int64
+	if info, err := os.Stat(s.segments[s.unsealed]); err == nil {
+		size = info.Size()
+	}
+	return s.unsealed, size
+}
+
+// Segments returns every live segment, ordered by ascending ID, with its current
+// size. Sealed segments report the size recorded when they were sealed, and are
+// measured on disk only if it was not recorded; a segment that cannot be measured
+// reports a size of zero.
+func (s *Storage) Segments() []SegmentFile {
+	if s.closed.Load() {
+		return nil
+	}
+
+	activeID, activeSize := s.ActiveSegment()
+	segmentDir := filepath.Join(s.options.DataDir, s.options.SegmentOptions.Directory)
+
+	live := s.manifest.Segments()
+	files := make([]SegmentFile, 0, len(live))
+	for _, segment := range live {
+		file := SegmentFile{
+			ID:   segment.ID,
+			File: segment.File,
+			Path: filepath.Join(segmentDir, segment.File),
+			Size: segment.Size,
+		}
+
+		switch {
+		case segment.ID == activeID:
+			file.Size = activeSize
+		case file.Size == 0:
+			if info, err := os.Stat(file.Path); err == nil {
+				file.Size = info.Size()
+			}
+		}
+
+		files = append(files, file)
+	}
+	return files
+}
+
+// syncFile flushes a segment or blob file to stable storage, recording how long the
+// fsync took.
+func (s *Storage) syncFile(file *os.File) error {
+	defer s.io.fsync.Since(time.Now())
+	return file.Sync()
+}
+
+// countingReader reads from a file, adding the number of bytes read to a counter.
+type countingReader struct {
+	file *os.File
+	n    *atomic.Uint64
+}
+
+// Read implements io.Reader.
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.file.Read(p)
+	r.n.Add(uint64(n))
+	return n, err
+}
+
+// ReadAt implements io.ReaderAt.
+func (r *countingReader) ReadAt(p []byte, off int64) (int, error) {
+	n, err := r.file.ReadAt(p, off)
+	r.n.Add(uint64(n))
+	return n, err
+}
+
+// countingWriter writes to w, adding the number of bytes written to a counter.
+type countingWriter struct {
+	w io.Writer
+	n *atomic.Uint64
+}
+
+// Write implements io.Writer.
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n.Add(uint64(n))
+	return n, err
+}
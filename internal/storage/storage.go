@@ -38,8 +38,10 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/iamNilotpal/ignite/internal/metrics"
 	"github.com/iamNilotpal/ignite/pkg/errors"
 	"github.com/iamNilotpal/ignite/pkg/filesys"
+	"github.com/iamNilotpal/ignite/pkg/options"
 	"github.com/iamNilotpal/ignite/pkg/seginfo"
 )
 
@@ -66,6 +68,10 @@ func New(ctx context.Context, config *Config) (*Storage, error) {
 		"segmentPrefix", config.Options.SegmentOptions.Prefix,
 	)
 
+	if config.Options.ReadOnly {
+		return newReadOnly(config)
+	}
+
 	// Construct the full directory path where segment files will be stored.
 	segmentDirPath := filepath.Join(config.Options.DataDir, config.Options.SegmentOptions.Directory)
 
@@ -77,15 +83,28 @@ func New(ctx context.Context, config *Config) (*Storage, error) {
 
 	config.Logger.Infow("Segment directory created successfully", "path", segmentDirPath)
 
-	// Initialize the Storage instance with configuration.
-	storage := &Storage{
-		log:     config.Logger,
-		options: config.Options,
+	// Take the exclusive directory lock before touching any segment. A second process
+	// appending to the same active segment would interleave writes and corrupt it.
+	lock, err := acquireDirLock(config.Options.DataDir)
+	if err != nil {
+		return nil, err
 	}
 
+	if !lockSupported {
+		config.Logger.Warnw(
+			"Data directory locking is not supported on this platform, concurrent openers are not detected",
+			"dataDir", config.Options.DataDir,
+		)
+	}
+
+	// Initialize the Storage instance with configuration.
+	storage := newStorage(config)
+	storage.lock = lock
+
 	// Discover existing segments to understand the current state of the storage system
 	// This is a critical step that determines whether we continue with an existing segment
-	// or need to create a new one
+	// or need to create a new one. The manifest is the source of truth for which segment
+	// files exist; anything in the segment directory it does not reference is removed.
 	config.Logger.Infow(
 		"Discovering existing segments",
 		"dataDir", config.Options.DataDir,
@@ -93,24 +112,20 @@ func New(ctx context.Context, config *Config) (*Storage, error) {
 		"prefix", config.Options.SegmentOptions.Prefix,
 	)
 
-	lastSegmentID, lastSegmentInfo, err := seginfo.GetLastSegmentInfo(
-		config.Options.DataDir,
-		config.Options.SegmentOptions.Directory,
-		config.Options.SegmentOptions.Prefix,
-	)
+	lastSegment, err := storage.recoverSegments()
 	if err != nil {
-		return nil, errors.NewStorageError(
-			err, errors.ErrorCodeIO,
-			"Failed to discover existing segments during initialization",
-		).WithPath(segmentDirPath).
-			WithDetail("operation", "segment_discovery")
+		storage.abort()
+		return nil, err
 	}
 
+	// Blobs whose write was interrupted were never referenced by any entry.
+	storage.removeTempBlobs()
+
 	// Determine the appropriate segment to use based on discovery results.
 	var targetSegmentID uint64
 	var shouldCreateNewSegment bool
 
-	if lastSegmentInfo == nil {
+	if lastSegment == nil {
 		// Bootstrap case: no existing segments found, start with ID 1
 		storage.size = 0
 		targetSegmentID = 1
@@ -118,27 +133,54 @@ func New(ctx context.Context, config *Config) (*Storage, error) {
 		config.Logger.Infow("No existing segments found, starting fresh", "newSegmentID", targetSegmentID)
 	} else {
 		// Existing segments found, check if we need to rotate to a new segment.
+		lastSegmentInfo, err := seginfo.GetFileInfo(storage.segments[lastSegment.ID])
+		if err != nil {
+			storage.abort()
+			return nil, errors.NewStorageError(
+				err, errors.ErrorCodeIO,
+				"Failed to discover existing segments during initialization",
+			).WithPath(segmentDirPath).
+				WithDetail("operation", "segment_discovery")
+		}
+
 		currentSize := lastSegmentInfo.Size()
 		maxSize := int64(config.Options.SegmentOptions.Size)
 
-		if currentSize >= maxSize {
-			// Current segment is full, create a new one.
+		// Appending must not mix encrypted and plaintext entries within a segment, so
+		// a segment written with encryption switched the other way is sealed as well.
+		lastCipher, err := storage.cipherFor(lastSegment.ID, storage.segments[lastSegment.ID])
+		if err != nil {
+			storage.abort()
+			return nil, err
+		}
+		encryptionChanged := (lastCipher.aead != nil) != (config.Options.KeyProvider != nil)
+
+		if lastSegment.Sealed || currentSize >= maxSize || encryptionChanged {
+			// Current segment is full or already sealed, create a new one.
+			if !lastSegment.Sealed {
+				if err := storage.manifest.LogSeal(lastSegment.ID, currentSize); err != nil {
+					storage.abort()
+					return nil, err
+				}
+			}
+
 			storage.size = 0
 			shouldCreateNewSegment = true
-			targetSegmentID = lastSegmentID + 1
+			targetSegmentID = lastSegment.ID + 1
 
 			config.Logger.Infow(
 				"Current segment is full, creating new segment",
-				"currentSegmentID", lastSegmentID,
+				"currentSegmentID", lastSegment.ID,
 				"currentSize", currentSize,
 				"maxSize", maxSize,
+				"encryptionChanged", encryptionChanged,
 				"newSegmentID", targetSegmentID,
 			)
 		} else {
 			// Current segment has space, continue using it.
 			storage.size = currentSize
 			shouldCreateNewSegment = false
-			targetSegmentID = lastSegmentID
+			targetSegmentID = lastSegment.ID
 
 			config.Logger.Infow(
 				"Continuing with existing segment",
@@ -153,12 +195,14 @@ func New(ctx context.Context, config *Config) (*Storage, error) {
 	// Open the target segment file for writing.
 	segmentFile, err := storage.openSegmentFile(targetSegmentID, shouldCreateNewSegment)
 	if err != nil {
+		storage.abort()
 		return nil, err
 	}
 
 	// Store the file handle and complete initialization.
 	storage.activeSegment = segmentFile
 	storage.activeSegmentId = targetSegmentID
+	storage.startHintWriter()
 
 	config.Logger.Infow(
 		"Storage system initialized successfully",
@@ -170,6 +214,29 @@ func New(ctx context.Context, config *Config) (*Storage, error) {
 	return storage, nil
 }
 
+// newStorage creates a Storage with its read path set up but no segments registered.
+func newStorage(config *Config) *Storage {
+	storage := &Storage{
+		log:      config.Logger,
+		options:  config.Options,
+		segments: make(map[uint64]string),
+		mappings: make(map[uint64]*mappedSegment),
+		ciphers:  make(map[uint64]*segmentCipher),
+		pins:     make(map[uint64]int),
+		doomed:   make(map[uint64]struct{}),
+		files:    newFileCache(int(config.Options.ReadOptions.MaxOpenFiles)),
+		useMmap:  config.Options.ReadOptions.Mode == options.ReadModeMmap,
+	}
+	storage.io.fsync = metrics.NewHistogram(metrics.LatencyBuckets)
+
+	if storage.useMmap && !mmapSupported {
+		storage.useMmap = false
+		config.Logger.Warnw("Memory-mapped reads are not supported on this platform, using standard reads")
+	}
+
+	return storage
+}
+
 // Close gracefully shuts down the storage system, ensuring all buffered data is written
 // to disk and all resources are properly released.
 func (s *Storage) Close() error {
@@ -177,8 +244,47 @@ func (s *Storage) Close() error {
 		return ErrSegmentClosed
 	}
 
+	// A hint being built is abandoned; the next open queues its segment again.
+	s.stopHintWriter()
+
+	// Wait for in-flight appends and reads of the active segment to finish.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Release the directory lock last, even if closing fails part way through, so that a
+	// restarted process is not locked out by a storage instance that no longer writes.
+	defer func() {
+		if err := s.lock.release(); err != nil {
+			s.log.Errorw("Failed to release data directory lock", "error", err)
+		}
+	}()
+
 	s.log.Infow("Closing storage system", "currentSize", s.size)
 
+	// Close cached read handles. Handles still in use by in-flight readers are
+	// closed when those readers finish.
+	if err := s.files.close(); err != nil {
+		s.log.Errorw("Failed to close cached segment handles", "error", err)
+	}
+
+	// Drop storage's references to every mapping. Mappings still used by
+	// in-flight readers are unmapped when those readers finish.
+	for segmentID := range s.mappings {
+		if err := s.unmapSegment(segmentID); err != nil {
+			s.log.Errorw("Failed to unmap segment", "segmentID", segmentID, "error", err)
+		}
+	}
+
+	// A read-only storage has no active segment to sync.
+	if s.readOnly {
+		if err := s.manifest.Close(); err != nil {
+			return err
+		}
+
+		s.log.Infow("Read-only storage closed successfully")
+		return nil
+	}
+
 	var currentFileName string
 	var currentFilePath string
 	if stat, err := s.activeSegment.Stat(); err == nil {
@@ -189,7 +295,7 @@ func (s *Storage) Close() error {
 	// First, ensure all buffered data is written to disk.
 	// This is critical for data durability - without sync, recently written data
 	// might still be in OS buffers and could be lost if the process crashes.
-	if err := s.activeSegment.Sync(); err != nil {
+	if err := s.syncFile(s.activeSegment); err != nil {
 		s.log.Errorw(
 			"Failed to sync file before closing",
 			"error", err,
@@ -230,6 +336,10 @@ func (s *Storage) Close() error {
 	// Clear the file reference to prevent accidental use after close.
 	s.activeSegment = nil
 
+	if err := s.manifest.Close(); err != nil {
+		return err
+	}
+
 	s.log.Infow(
 		"Storage system closed successfully",
 		"finalSize", s.size,
@@ -248,9 +358,15 @@ func (s *Storage) Close() error {
 // continued writing, ensuring that the file is always in the correct state for
 // append operations.
 func (s *Storage) openSegmentFile(segmentID uint64, isNewSegment bool) (*os.File, error) {
-	// Generate the filename using the seginfo package's naming convention.
+	// Generate the filename using the seginfo package's naming convention. Existing
+	// segments must keep their original filename, since the embedded timestamp would
+	// otherwise produce a brand new file with the same segment ID.
 	filename := seginfo.GenerateName(segmentID, s.options.SegmentOptions.Prefix)
 	filePath := filepath.Join(s.options.DataDir, s.options.SegmentOptions.Directory, filename)
+	if existingPath, ok := s.segments[segmentID]; ok && !isNewSegment {
+		filePath = existingPath
+		filename = filepath.Base(existingPath)
+	}
 
 	s.log.Infow(
 		"Opening segment file",
@@ -296,6 +412,48 @@ func (s *Storage) openSegmentFile(segmentID uint64, isNewSegment bool) (*os.File
 			WithDetail("suggestion", "file may be corrupted or filesystem may have issues")
 	}
 
+	// Work out how entries of this segment are protected. New segments get a fresh data
+	// key when encryption is enabled, written and synced as the segment header before
+	// the segment is recorded anywhere.
+	var sc *segmentCipher
+	if isNewSegment {
+		var header []byte
+		sc, header, err = s.newSegmentCipher(segmentID)
+		if err == nil && header != nil {
+			var n int
+			n, err = file.Write(header)
+			s.io.written.Add(uint64(n))
+			if err == nil {
+				err = s.syncFile(file)
+			}
+			if err != nil {
+				err = errors.NewFileAccessError(filePath, filename, "segment_header_write", err).
+					WithSegmentID(int(segmentID))
+			}
+			offset += int64(len(header))
+		}
+	} else {
+		sc, err = s.cipherFor(segmentID, filePath)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	// Record the new segment in the manifest only once its file exists, so that a crash
+	// in between leaves an unreferenced file that recovery removes.
+	if isNewSegment {
+		if err := s.manifest.LogCreate(segmentID, filename); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+
+	s.setCipher(segmentID, sc)
+	s.activeCipher = sc
+	s.size = offset
+	s.segments[segmentID] = filePath
+
 	s.log.Infow(
 		"Segment file opened successfully",
 		"path", filePath,
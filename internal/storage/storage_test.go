@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/iamNilotpal/ignite/pkg/options"
+	"go.uber.org/zap"
+)
+
+// testOptions returns options for a store in dir with segments small enough that a
+// few dozen test entries span several of them.
+func testOptions(dir string) *options.Options {
+	opts := options.NewDefaultOptions()
+	opts.DataDir = dir
+	opts.SegmentOptions.Size = 1024
+	return &opts
+}
+
+// openStorage opens a storage with opts and closes it when the test ends.
+func openStorage(t *testing.T, opts *options.Options) *Storage {
+	t.Helper()
+
+	s, err := New(context.Background(), &Config{Options: opts, Logger: zap.NewNop().Sugar()})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// closeStorage closes s, failing the test on error.
+func closeStorage(t *testing.T, s *Storage) {
+	t.Helper()
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+}
+
+// appendEntry appends an entry for key holding value and returns its position.
+func appendEntry(t *testing.T, s *Storage, key, value string) *Position {
+	t.Helper()
+
+	pos, err := s.Append(NewEntry([]byte(key), []byte(value), 0))
+	if err != nil {
+		t.Fatalf("Append(%q) error = %v", key, err)
+	}
+	return pos
+}
+
+// fillSegments appends entries of about 100 bytes until n segments are sealed, and
+// returns the position of every entry in write order. Entry i has key "key-i" and
+// value entryValue(i).
+func fillSegments(t *testing.T, s *Storage, n int) []*Position {
+	t.Helper()
+
+	var positions []*Position
+	for i := 0; ; i++ {
+		pos := appendEntry(t, s, fmt.Sprintf("key-%d", i), entryValue(i))
+		if pos.SegmentID > uint64(n) {
+			return append(positions, pos)
+		}
+		positions = append(positions, pos)
+	}
+}
+
+// entryValue returns the value fillSegments stores in entry i.
+func entryValue(i int) string {
+	return fmt.Sprintf("%080d", i)
+}
+
+// expectEntry fails the test unless the entry at pos holds key and value.
+func expectEntry(t *testing.T, s *Storage, pos *Position, key, value string) {
+	t.Helper()
+
+	entry, err := s.ReadEntry(pos.SegmentID, pos.Offset, pos.Size)
+	if err != nil {
+		t.Fatalf("ReadEntry(%d, %d) error = %v", pos.SegmentID, pos.Offset, err)
+	}
+	if string(entry.Key) != key || string(entry.Value) != value {
+		t.Fatalf("ReadEntry(%d, %d) = %q: %q, want %q: %q", pos.SegmentID, pos.Offset, entry.Key, entry.Value, key, value)
+	}
+}
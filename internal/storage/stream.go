@@ -0,0 +1,402 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/iamNilotpal/ignite/pkg/errors"
+	"github.com/iamNilotpal/ignite/pkg/filesys"
+)
+
+// streamBufferSize is the size of the pooled buffers values are copied through when
+// they are streamed into or out of a segment.
+const streamBufferSize = 32 * 1024
+
+// streamBuffers recycles copy buffers across streaming reads and writes.
+var streamBuffers = sync.Pool{
+	New: func() any {
+		buf := make([]byte, streamBufferSize)
+		return &buf
+	},
+}
+
+// CopyBuffered copies r to w through a pooled buffer, returning the number of bytes
+// written. It behaves like io.Copy without allocating a buffer per call.
+func CopyBuffered(w io.Writer, r io.Reader) (int64, error) {
+	buf := streamBuffers.Get().(*[]byte)
+	defer streamBuffers.Put(buf)
+	return io.CopyBuffer(w, r, *buf)
+}
+
+// StagedEntry is an entry whose value has already been read from its source, so
+// that appending it never waits on a slow reader while the storage lock is held.
+// Small values, and values bound for an encrypted segment, which are sealed as a
+// whole, are held in memory. Larger values are copied into a staging file in the blob
+// directory along with the finished entry header, checksum included. A staged entry
+// must be closed once it has been appended or abandoned.
+type StagedEntry struct {
+	entry  *Entry   // Key and metadata; carries the value when staged in memory.
+	file   *os.File // Staging file holding the value, or nil.
+	header []byte   // Encoded header and key of a value in a staging file.
+	size   uint32   // Length of the value in bytes.
+}
+
+// Timestamp returns the timestamp the entry was stamped with when it was staged.
+func (e *StagedEntry) Timestamp() int64 {
+	return e.entry.Timestamp
+}
+
+// Close removes the staging file, if any.
+func (e *StagedEntry) Close() error {
+	if e.file == nil {
+		return nil
+	}
+
+	err := e.file.Close()
+	if rerr := os.Remove(e.file.Name()); rerr != nil && err == nil {
+		err = rerr
+	}
+	e.file = nil
+	return err
+}
+
+// StageEntry reads exactly size bytes of value for key from r ahead of an AppendStaged
+// call. The value is copied into a staging file through a pooled buffer rather than
+// being collected in memory, and the entry checksum is computed on the way, so the
+// append itself only copies between two local files. No storage lock is held while r
+// is read. The entry expires at expiresAt, or never when it is zero. If r fails or
+// ends early, nothing is staged.
+func (s *Storage) StageEntry(key []byte, r io.Reader, size uint32, expiresAt int64) (*StagedEntry, error) {
+	if s.closed.Load() {
+		return nil, ErrSegmentClosed
+	}
+	if s.readOnly {
+		return nil, errors.NewReadOnlyError("entry_stage")
+	}
+
+	readError := func(err error) error {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if _, ok := errors.AsStorageError(err); ok {
+			return err
+		}
+		return errors.NewStorageError(err, errors.ErrorCodeIO, "Failed to read value to append").
+			WithDetail("operation", "entry_stage").
+			WithDetail("expected_size", size)
+	}
+
+	entry := NewEntry(key, nil, 0)
+	entry.ExpiresAt = expiresAt
+	if size <= streamBufferSize || s.options.KeyProvider != nil {
+		value := make([]byte, size)
+		if _, err := io.ReadFull(r, value); err != nil {
+			return nil, readError(err)
+		}
+		entry.Value = value
+		return &StagedEntry{entry: entry, size: size}, nil
+	}
+
+	dir := s.blobDir()
+	if err := filesys.CreateDir(dir, 0755, true); err != nil {
+		return nil, errors.ClassifyDirectoryCreationError(err, dir)
+	}
+
+	// Staging files carry the temporary blob suffix, so that any left behind by a
+	// crash are removed on the next open.
+	file, err := os.CreateTemp(dir, "stage-*"+blobExt+blobTempExt)
+	if err != nil {
+		return nil, errors.NewFileAccessError(dir, filepath.Base(dir), "entry_stage", err)
+	}
+	staged := &StagedEntry{entry: entry, file: file, size: size}
+
+	fail := func(err error) (*StagedEntry, error) {
+		staged.Close()
+		return nil, err
+	}
+
+	entry.prepareExpiry()
+	header := make([]byte, HeaderSize, HeaderSize+entry.storedKeySize())
+	binary.LittleEndian.PutUint64(header[4:12], uint64(entry.Timestamp))
+	header[12] = entry.Version
+	header[13] = entry.Flags
+	binary.LittleEndian.PutUint32(header[14:18], uint32(entry.storedKeySize()))
+	binary.LittleEndian.PutUint32(header[18:22], size)
+	header = entry.appendStoredKey(header)
+	checksum := crc32.ChecksumIEEE(header[4:])
+
+	buf := streamBuffers.Get().(*[]byte)
+	defer streamBuffers.Put(buf)
+
+	remaining := int64(size)
+	for remaining > 0 {
+		chunk := (*buf)[:min(remaining, int64(len(*buf)))]
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			return fail(readError(err))
+		}
+
+		checksum = crc32.Update(checksum, crc32.IEEETable, chunk)
+		if _, err := file.Write(chunk); err != nil {
+			return fail(errors.NewFileAccessError(file.Name(), filepath.Base(file.Name()), "entry_stage", err))
+		}
+		remaining -= int64(len(chunk))
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return fail(errors.NewFileAccessError(file.Name(), filepath.Base(file.Name()), "entry_stage", err))
+	}
+
+	binary.LittleEndian.PutUint32(header[0:4], checksum)
+	staged.header = header
+	return staged, nil
+}
+
+// AppendStaged writes a staged entry to the end of the active segment. The header is
+// complete before the first byte is written, so the entry never sits in the segment
+// with a checksum that does not match it. If copying the staged value fails, the
+// segment is truncated back to where the entry began.
+func (s *Storage) AppendStaged(staged *StagedEntry) (*Position, error) {
+	if staged.file == nil {
+		return s.Append(staged.entry)
+	}
+	if s.closed.Load() {
+		return nil, ErrSegmentClosed
+	}
+	if s.readOnly {
+		return nil, errors.NewReadOnlyError("entry_append")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	encodedSize := int64(len(staged.header)) + int64(staged.size)
+	if s.size > s.activeCipher.dataStart && s.size+encodedSize > int64(s.options.SegmentOptions.Size) {
+		if err := s.rotateSegment(); err != nil {
+			return nil, err
+		}
+	}
+
+	offset := s.size
+	path := s.segments[s.activeSegmentId]
+
+	// abort undoes the partial entry so that the next append starts at its offset.
+	abort := func(err error) (*Position, error) {
+		if terr := s.activeSegment.Truncate(offset); terr != nil {
+			s.log.Errorw("Failed to truncate partial entry", "segmentID", s.activeSegmentId, "offset", offset, "error", terr)
+		} else {
+			s.size = offset
+		}
+
+		return nil, errors.NewStorageError(err, errors.ErrorCodeIO, "Failed to append staged entry to segment file").
+			WithSegmentID(int(s.activeSegmentId)).
+			WithPath(path).
+			WithOffset(int(offset)).
+			WithDetail("operation", "entry_append_staged").
+			WithDetail("expected_size", staged.size)
+	}
+
+	n, err := s.activeSegment.Write(staged.header)
+	s.size += int64(n)
+	s.io.written.Add(uint64(n))
+	if err != nil {
+		return abort(err)
+	}
+
+	buf := streamBuffers.Get().(*[]byte)
+	defer streamBuffers.Put(buf)
+
+	copied, err := io.CopyBuffer(
+		&countingWriter{w: s.activeSegment, n: &s.io.written},
+		io.LimitReader(staged.file, int64(staged.size)),
+		*buf,
+	)
+	s.size += copied
+	if err == nil && copied != int64(staged.size) {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return abort(err)
+	}
+
+	return s.syncWrite(&Position{
+		SegmentID: s.activeSegmentId,
+		Offset:    offset,
+		Size:      uint32(s.size - offset),
+	}, nil)
+}
+
+// StreamEntry reads the entry stored at the given position and passes fn the entry's
+// metadata along with a reader over its value. The entry passed to fn carries the
+// key but no value. The value reader verifies the entry checksum as it goes and
+// returns a corruption error in place of io.EOF if it does not match, so fn must read
+// it to the end before trusting what it read. Both are valid only until fn returns.
+//
+// Plaintext entries are streamed from the segment file in pooled chunks. Entries in
+// encrypted segments, and entries served from a memory mapping, are verified in
+// memory first and handed to fn through a bytes.Reader.
+func (s *Storage) StreamEntry(segmentID uint64, offset int64, size uint32, fn func(entry *Entry, value io.Reader) error) error {
+	if s.closed.Load() {
+		return ErrSegmentClosed
+	}
+
+	whole := func(entry *Entry) error {
+		value := entry.Value
+		entry.Value = nil
+		return fn(entry, bytes.NewReader(value))
+	}
+
+	handled, err := s.viewMapped(segmentID, offset, size, whole)
+	if handled {
+		return err
+	}
+
+	sc, err := s.segmentCipher(segmentID)
+	if err != nil {
+		return err
+	}
+	if sc.aead != nil {
+		entry, err := s.readEntry(segmentID, offset, size)
+		if err != nil {
+			return err
+		}
+		return whole(entry)
+	}
+
+	s.mu.RLock()
+	path, ok := s.segments[segmentID]
+	s.mu.RUnlock()
+	if !ok {
+		return errors.NewStorageError(
+			os.ErrNotExist, errors.ErrorCodeIO, "Segment file not found for segment ID",
+		).WithSegmentID(int(segmentID)).
+			WithOffset(int(offset)).
+			WithDetail("operation", "segment_lookup")
+	}
+
+	// The handle cache is reference counted, so the handle stays readable even if the
+	// segment is sealed or removed while the value is being streamed.
+	handle, err := s.files.acquire(segmentID, path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := s.files.release(handle); err != nil {
+			s.log.Errorw("Failed to close evicted segment handle", "segmentID", segmentID, "error", err)
+		}
+	}()
+
+	section := io.NewSectionReader(&countingReader{file: handle.file, n: &s.io.read}, offset, int64(size))
+
+	buf := make([]byte, HeaderSize)
+	if _, err := io.ReadFull(section, buf); err != nil {
+		return errors.NewHeaderReadError(filepath.Base(path), int(offset), err).
+			WithSegmentID(int(segmentID)).
+			WithPath(path)
+	}
+
+	h := decodeHeader(buf)
+	if h.size() != int(size) {
+		return errors.NewSegmentCorruptionError(int(segmentID), int(offset), nil).
+			WithPath(path).
+			WithDetail("corruption_type", "entry_size_mismatch").
+			WithDetail("indexed_size", size).
+			WithDetail("header_size", h.size())
+	}
+
+	key := make([]byte, h.keySize)
+	if _, err := io.ReadFull(section, key); err != nil {
+		return errors.NewPayloadReadError(filepath.Base(path), int(segmentID), int(offset), int(size), err).
+			WithPath(path)
+	}
+
+	value := &checksumReader{
+		r:         io.LimitReader(section, int64(h.valueSize)),
+		remaining: int64(h.valueSize),
+		checksum:  crc32.Update(crc32.ChecksumIEEE(buf[4:]), crc32.IEEETable, key),
+		expected:  h.checksum,
+		segmentID: segmentID,
+		offset:    offset,
+		path:      path,
+	}
+
+	entry := &Entry{Timestamp: h.timestamp, Version: h.version, Flags: h.flags}
+	entry.Key, entry.ExpiresAt = splitKey(key, h.version, h.flags)
+	return fn(entry, value)
+}
+
+// segmentCipher returns the encryption state of a segment.
+func (s *Storage) segmentCipher(segmentID uint64) (*segmentCipher, error) {
+	s.mu.RLock()
+	path, ok := s.segments[segmentID]
+	s.mu.RUnlock()
+	if !ok {
+		return plaintextCipher, nil
+	}
+	return s.cipherFor(segmentID, path)
+}
+
+// writeLocked appends an encoded entry to the active segment. The caller must hold
+// the write lock.
+func (s *Storage) writeLocked(buf []byte) (*Position, error) {
+	offset := s.size
+	n, err := s.activeSegment.Write(buf)
+	s.size += int64(n)
+	s.io.written.Add(uint64(n))
+	if err != nil {
+		return nil, errors.NewStorageError(
+			err, errors.ErrorCodeIO, "Failed to append entry to segment file",
+		).WithSegmentID(int(s.activeSegmentId)).
+			WithPath(s.segments[s.activeSegmentId]).
+			WithOffset(int(offset)).
+			WithDetail("operation", "entry_append").
+			WithDetail("entrySize", len(buf)).
+			WithDetail("bytesWritten", n)
+	}
+
+	return &Position{SegmentID: s.activeSegmentId, Offset: offset, Size: uint32(len(buf))}, nil
+}
+
+// checksumReader streams an entry's value and verifies the entry checksum once the
+// last byte has been read.
+type checksumReader struct {
+	r         io.Reader // Reader limited to the value bytes.
+	remaining int64     // Value bytes not yet read.
+	checksum  uint32    // Running CRC32 over the header, key and value read so far.
+	expected  uint32    // Checksum stored in the entry header.
+	segmentID uint64    // Segment the entry belongs to, for errors.
+	offset    int64     // Offset of the entry, for errors.
+	path      string    // Path of the segment file, for errors.
+}
+
+// Read implements io.Reader.
+func (c *checksumReader) Read(p []byte) (int, error) {
+	if c.remaining == 0 {
+		if c.checksum != c.expected {
+			return 0, errors.NewSegmentCorruptionError(int(c.segmentID), int(c.offset), nil).
+				WithPath(c.path).
+				WithDetail("stored_checksum", c.expected)
+		}
+		return 0, io.EOF
+	}
+
+	n, err := c.r.Read(p)
+	c.checksum = crc32.Update(c.checksum, crc32.IEEETable, p[:n])
+	c.remaining -= int64(n)
+
+	if err == io.EOF && c.remaining > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	if err == io.EOF {
+		err = nil
+	}
+	if err != nil {
+		return n, errors.NewPayloadReadError(filepath.Base(c.path), int(c.segmentID), int(c.offset), 0, err).
+			WithPath(c.path)
+	}
+	return n, nil
+}
@@ -65,6 +65,28 @@ const (
 	// ErrorCodeFilesystemReadonly indicates that the filesystem is mounted read-only.
 	// This requires administrative intervention to remount the filesystem with write permissions.
 	ErrorCodeFilesystemReadonly ErrorCode = "FILESYSTEM_READONLY"
+
+	// ErrorCodeDirectoryLocked indicates that another process already holds the exclusive
+	// lock on the data directory. Two writers appending to the same active segment would
+	// corrupt it, so the second opener is refused until the holder shuts down.
+	ErrorCodeDirectoryLocked ErrorCode = "DIRECTORY_LOCKED"
+
+	// ErrorCodeReadOnly indicates that a write was attempted on an instance opened in
+	// read-only mode. The write must be sent to the process that owns the data directory.
+	ErrorCodeReadOnly ErrorCode = "READ_ONLY"
+
+	// ErrorCodeEncryptionKeyUnavailable indicates that the master key needed to unwrap a
+	// segment's data key could not be obtained from the key provider, or that encrypted
+	// data was found while no key provider is configured.
+	ErrorCodeEncryptionKeyUnavailable ErrorCode = "ENCRYPTION_KEY_UNAVAILABLE"
+
+	// ErrorCodeBlobCorrupted indicates that a blob file holding a large value is missing,
+	// truncated, or does not match the size and checksum recorded in its reference.
+	ErrorCodeBlobCorrupted ErrorCode = "BLOB_CORRUPTED"
+
+	// ErrorCodeBackupInvalid indicates that a backup archive or directory is malformed,
+	// incomplete, or contains files whose checksums do not match its metadata.
+	ErrorCodeBackupInvalid ErrorCode = "BACKUP_INVALID"
 )
 
 // Index-specific error codes extend the base error code system to handle
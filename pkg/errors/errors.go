@@ -133,6 +133,20 @@ func IsIndexError(err error) bool {
 	return stdErrors.As(err, &ie)
 }
 
+// IsLockError reports whether an error was caused by another process holding the
+// data directory lock. Lock errors are not transient in the usual sense: retrying is
+// only useful once the holder has shut down.
+//
+// Example usage:
+//
+//	if lockErr, ok := errors.AsLockError(err); ok {
+//	    log.Fatalf("data directory in use by pid %d", lockErr.HolderPID())
+//	}
+func IsLockError(err error) bool {
+	var le *LockError
+	return stdErrors.As(err, &le)
+}
+
 // AsValidationError safely extracts a ValidationError from an error chain, providing access
 // to validation-specific context such as which field failed, what rule was violated, and
 // what values were provided versus expected. This extraction is essential for building
@@ -219,6 +233,16 @@ func AsIndexError(err error) (*IndexError, bool) {
 	return nil, false
 }
 
+// AsLockError extracts LockError context from an error chain, providing access to the
+// contended lock file path and the PID of the process holding it.
+func AsLockError(err error) (*LockError, bool) {
+	var le *LockError
+	if stdErrors.As(err, &le) {
+		return le, true
+	}
+	return nil, false
+}
+
 // GetErrorCode extracts the error code from any error that supports it, or returns
 // ErrorCodeInternal for errors that don't have specific codes. This function provides
 // a consistent way to categorize errors for monitoring and handling purposes.
@@ -250,6 +274,11 @@ func GetErrorCode(err error) ErrorCode {
 		return ie.Code()
 	}
 
+	// Try LockError.
+	if le, ok := AsLockError(err); ok {
+		return le.Code()
+	}
+
 	// For any other error, return a generic internal error code.
 	return ErrorCodeInternal
 }
@@ -291,6 +320,13 @@ func GetErrorDetails(err error) map[string]any {
 		}
 	}
 
+	// Try LockError.
+	if le, ok := AsLockError(err); ok {
+		if details := le.Details(); details != nil {
+			return details
+		}
+	}
+
 	// Return empty map for errors without details.
 	return make(map[string]any)
 }
@@ -0,0 +1,84 @@
+package errors
+
+import "fmt"
+
+// LockError is a specialized error type for data directory locking failures.
+// It embeds baseError for the standard error functionality and adds the context an
+// operator needs to resolve the conflict: which lock file was contended and which
+// process currently holds it.
+type LockError struct {
+	// Embed the base error to inherit all standard error functionality
+	// including error chaining, structured details, and error codes.
+	*baseError
+
+	// path contains the full filesystem path of the lock file.
+	path string
+
+	// holderPID is the process ID recorded in the lock file by the current holder.
+	// It is zero when the holder's PID could not be read, for example because the
+	// holder had not yet written it.
+	holderPID int
+}
+
+// NewLockError creates a new lock-specific error with the provided context.
+func NewLockError(err error, code ErrorCode, msg string) *LockError {
+	return &LockError{baseError: NewBaseError(err, code, msg)}
+}
+
+// Override base error methods to return *LockError instead of *baseError.
+
+// WithMessage updates the error message while maintaining the LockError type.
+func (le *LockError) WithMessage(msg string) *LockError {
+	le.baseError.WithMessage(msg)
+	return le
+}
+
+// WithCode sets the error code while preserving the LockError type.
+func (le *LockError) WithCode(code ErrorCode) *LockError {
+	le.baseError.WithCode(code)
+	return le
+}
+
+// WithDetail adds contextual information while maintaining the LockError type.
+func (le *LockError) WithDetail(key string, value any) *LockError {
+	le.baseError.WithDetail(key, value)
+	return le
+}
+
+// WithPath records the path of the contended lock file.
+func (le *LockError) WithPath(path string) *LockError {
+	le.path = path
+	return le
+}
+
+// WithHolderPID records the process ID of the current lock holder.
+func (le *LockError) WithHolderPID(pid int) *LockError {
+	le.holderPID = pid
+	return le
+}
+
+// Path returns the full filesystem path of the contended lock file.
+func (le *LockError) Path() string {
+	return le.path
+}
+
+// HolderPID returns the process ID of the lock holder, or zero if it is unknown.
+func (le *LockError) HolderPID() int {
+	return le.holderPID
+}
+
+// NewDirectoryLockedError creates the error returned when a data directory is already
+// locked by another process. The holder's PID is part of the message when it is known.
+func NewDirectoryLockedError(path string, holderPID int, cause error) *LockError {
+	msg := "data directory is locked by another process"
+	if holderPID > 0 {
+		msg = fmt.Sprintf("data directory is locked by another process (pid %d)", holderPID)
+	}
+
+	return NewLockError(cause, ErrorCodeDirectoryLocked, msg).
+		WithPath(path).
+		WithHolderPID(holderPID).
+		WithDetail("holder_pid", holderPID).
+		WithDetail("operation", "directory_lock").
+		WithDetail("suggestion", "stop the other process or use a different data directory")
+}
@@ -0,0 +1,46 @@
+package errors
+
+import (
+	stdErrors "errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestNewDirectoryLockedError(t *testing.T) {
+	cause := stdErrors.New("lock is held by another process")
+
+	err := NewDirectoryLockedError("/srv/ignite/LOCK", 4242, cause)
+	if err.Code() != ErrorCodeDirectoryLocked || err.Path() != "/srv/ignite/LOCK" || err.HolderPID() != 4242 {
+		t.Fatalf("NewDirectoryLockedError() = code %s, path %q, holder %d", err.Code(), err.Path(), err.HolderPID())
+	}
+	if !strings.Contains(err.Error(), "pid 4242") {
+		t.Fatalf("Error() = %q, want the holder PID", err.Error())
+	}
+	if !stdErrors.Is(err, cause) {
+		t.Fatal("NewDirectoryLockedError() does not wrap its cause")
+	}
+
+	// A holder that has not written its PID yet is reported without one.
+	if err := NewDirectoryLockedError("/srv/ignite/LOCK", 0, cause); strings.Contains(err.Error(), "pid") {
+		t.Fatalf("Error() = %q, want no PID", err.Error())
+	}
+}
+
+func TestAsLockError(t *testing.T) {
+	wrapped := fmt.Errorf("open storage: %w", NewDirectoryLockedError("/srv/ignite/LOCK", 7, nil))
+
+	if !IsLockError(wrapped) {
+		t.Fatal("IsLockError() = false for a wrapped lock error")
+	}
+	if le, ok := AsLockError(wrapped); !ok || le.HolderPID() != 7 {
+		t.Fatalf("AsLockError() = %v, %t", le, ok)
+	}
+	if GetErrorCode(wrapped) != ErrorCodeDirectoryLocked {
+		t.Fatalf("GetErrorCode() = %s, want %s", GetErrorCode(wrapped), ErrorCodeDirectoryLocked)
+	}
+
+	if IsLockError(NewStorageError(nil, ErrorCodeIO, "io")) {
+		t.Fatal("IsLockError() = true for a storage error")
+	}
+}
@@ -1,5 +1,7 @@
 package errors
 
+import "path/filepath"
+
 // StorageError is a specialized error type for storage-related operations.
 // It embeds baseError to inherit all the standard error functionality, then adds
 // storage-specific fields that help pinpoint exactly where problems occurred.
@@ -158,3 +160,47 @@ func NewFileAccessError(path string, fileName string, operation string, cause er
 		WithFileName(fileName).
 		WithDetail("operation", operation)
 }
+
+// NewReadOnlyError creates an error for writes attempted on a read-only instance.
+func NewReadOnlyError(operation string) *StorageError {
+	return NewStorageError(nil, ErrorCodeReadOnly, "cannot write to a store opened in read-only mode").
+		WithDetail("operation", operation).
+		WithDetail("suggestion", "send writes to the process that owns the data directory")
+}
+
+// NewEncryptionKeyError creates an error for a master key that cannot be obtained.
+func NewEncryptionKeyError(keyID string, segmentId int, cause error) *StorageError {
+	return NewStorageError(cause, ErrorCodeEncryptionKeyUnavailable, "encryption key unavailable").
+		WithSegmentID(segmentId).
+		WithDetail("key_id", keyID).
+		WithDetail("operation", "key_unwrap").
+		WithDetail("suggestion", "configure a key provider that still holds this master key")
+}
+
+// NewBlobCorruptionError creates an error for a blob file that cannot be read back
+// intact.
+func NewBlobCorruptionError(path string, issue string, cause error) *StorageError {
+	return NewStorageError(cause, ErrorCodeBlobCorrupted, "blob file corrupted").
+		WithPath(path).
+		WithFileName(filepath.Base(path)).
+		WithDetail("issue", issue).
+		WithDetail("operation", "blob_read")
+}
+
+// NewHintFileError creates an error for a hint file that does not match its segment
+// or fails its checksum.
+func NewHintFileError(path string, issue string, cause error) *StorageError {
+	return NewStorageError(cause, ErrorCodeIndexHintFileCorrupted, "hint file corrupted").
+		WithPath(path).
+		WithFileName(filepath.Base(path)).
+		WithDetail("issue", issue).
+		WithDetail("operation", "hint_read")
+}
+
+// NewBackupError creates an error for a backup that cannot be restored.
+func NewBackupError(fileName string, issue string, cause error) *StorageError {
+	return NewStorageError(cause, ErrorCodeBackupInvalid, "backup is invalid").
+		WithFileName(fileName).
+		WithDetail("issue", issue).
+		WithDetail("operation", "restore")
+}
@@ -11,6 +11,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"syscall"
 )
 
 var (
@@ -169,6 +170,71 @@ func CopyFile(sourcePath, destPath string) error {
 	return os.WriteFile(destPath, input, 0644)
 }
 
+// LinkFile makes `destPath` a hard link to `sourcePath`, so both names share the same
+// data without copying it. When the two paths are on different file systems, where
+// hard links are impossible, the file is copied instead, streaming its contents rather
+// than reading them into memory. The destination must not exist.
+func LinkFile(sourcePath, destPath string) error {
+	err := os.Link(sourcePath, destPath)
+	if err == nil || !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	// Open the source file for reading.
+	srcFile, err := os.Open(sourcePath)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	// Create the destination file, refusing to overwrite an existing one.
+	destFile, err := os.OpenFile(destPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(destFile, srcFile); err != nil {
+		destFile.Close()
+		return err
+	}
+	if err := destFile.Sync(); err != nil {
+		destFile.Close()
+		return err
+	}
+	return destFile.Close()
+}
+
+// WriteFileSync writes `contents` to a new file at `filePath` with the given
+// `permission` and flushes it to stable storage before returning. Unlike WriteFile, it
+// fails if the file already exists.
+func WriteFileSync(filePath string, permission os.FileMode, contents []byte) error {
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, permission)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(contents); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// SyncDir flushes a directory to stable storage, making the creation, removal and
+// renaming of the files inside it durable.
+func SyncDir(dirPath string) error {
+	dir, err := os.Open(dirPath)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
+
 // ReadFile reads the entire content of the file at `filePath` into a byte slice.
 // It returns the file content and any error encountered.
 func ReadFile(filePath string) ([]byte, error) {
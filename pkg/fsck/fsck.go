@@ -0,0 +1,470 @@
+// Package fsck verifies an Ignite data directory offline.
+//
+// Check walks every segment listed in the MANIFEST and verifies the checksum of each
+// entry, the way a read would, but across the whole directory at once. It also
+// cross-checks the MANIFEST against the segment files on disk and the blob references
+// of live keys against the blob directory. The result is a Report with the health of
+// every segment, which encodes to JSON for tooling.
+//
+// Check takes the data directory lock, so it refuses to run while a process has the
+// store open for writing. Checksums cover the bytes as stored, so encrypted segments
+// are verified without their keys, although their keys and values cannot be examined.
+//
+// Every sealed segment should have a hint file, which lists its entries without their
+// values so that opening the store does not read every value back. Check compares the
+// hint of each sealed segment with the entries read from the segment itself. A hint
+// that is missing or does not match is only a warning: the store ignores it and writes
+// a new one on the next open.
+package fsck
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/iamNilotpal/ignite/internal/manifest"
+	"github.com/iamNilotpal/ignite/internal/storage"
+	"github.com/iamNilotpal/ignite/pkg/errors"
+	"github.com/iamNilotpal/ignite/pkg/options"
+	"github.com/iamNilotpal/ignite/pkg/seginfo"
+	"go.uber.org/zap"
+)
+
+// keyState is the latest intact entry seen for a key.
+type keyState struct {
+	deleted bool            // Whether the entry is a tombstone.
+	blob    *storage.BlobID // Blob holding the value, if the entry is a blob reference.
+}
+
+// checker carries the state of a single Check.
+type checker struct {
+	options *options.Options    // Options describing the directory layout.
+	report  *Report             // Report being built.
+	keys    map[string]keyState // Latest intact entry of every key seen so far.
+}
+
+// Check verifies the data directory dataDir and returns a report of its health. The
+// segment and blob directory options are honored like they are when opening a store;
+// other options are ignored. An error is only returned when the check itself cannot
+// run, for example because the directory is missing or locked by a running process;
+// problems with the data are reported in the Report.
+func Check(ctx context.Context, dataDir string, opts ...options.OptionFunc) (*Report, error) {
+	checkOpts := options.NewDefaultOptions()
+	for _, opt := range opts {
+		opt(&checkOpts)
+	}
+	checkOpts.DataDir = dataDir
+
+	info, err := os.Stat(dataDir)
+	if err != nil {
+		return nil, errors.NewFileAccessError(dataDir, filepath.Base(dataDir), "fsck_stat", err)
+	}
+	if !info.IsDir() {
+		return nil, errors.NewValidationError(
+			nil, errors.ErrorCodeInvalidInput, "data directory is not a directory",
+		).WithField("dataDir").WithRule("directory").WithProvided(dataDir)
+	}
+
+	unlock, err := storage.LockDir(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	c := &checker{
+		options: &checkOpts,
+		report:  &Report{DataDir: dataDir, Checked: time.Now().UTC(), Segments: []SegmentReport{}},
+		keys:    make(map[string]keyState),
+	}
+
+	segments := c.checkManifest()
+	if err := c.checkSegments(ctx, segments); err != nil {
+		return nil, err
+	}
+	c.checkBlobs()
+	c.summarize()
+
+	return c.report, nil
+}
+
+// checkManifest reads the MANIFEST and returns the live segments it lists. Without a
+// MANIFEST, the segments are taken from the segment directory the way the store
+// bootstraps its manifest on the next open.
+func (c *checker) checkManifest() []manifest.Segment {
+	report := &c.report.Manifest
+	path := filepath.Join(c.options.DataDir, manifest.FileName)
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		report.Issues = append(report.Issues, Issue{
+			Code:     errors.ErrorCodeIndexValidationFailed,
+			Severity: SeverityWarning,
+			Message:  "no MANIFEST; it is rebuilt from the segment files on the next open",
+			File:     manifest.FileName,
+		})
+		return c.bootstrapSegments()
+	}
+	if err != nil {
+		report.Issues = append(report.Issues, Issue{
+			Code:     errors.ErrorCodeIO,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("MANIFEST cannot be read: %v", err),
+			File:     manifest.FileName,
+		})
+		return nil
+	}
+
+	report.Present = true
+	segments, err := manifest.Decode(data)
+	if err != nil {
+		// A torn last record is cut off on the next open; read the manifest the same
+		// lenient way to see which segments remain.
+		m, openErr := manifest.Open(&manifest.Config{Dir: c.options.DataDir, ReadOnly: true, Logger: zap.NewNop().Sugar()})
+		if openErr != nil {
+			report.Issues = append(report.Issues, Issue{
+				Code:     errors.ErrorCodeIndexValidationFailed,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("MANIFEST cannot be decoded: %v", openErr),
+				File:     manifest.FileName,
+			})
+			return nil
+		}
+		defer m.Close()
+
+		report.Issues = append(report.Issues, Issue{
+			Code:     errors.ErrorCodeIndexValidationFailed,
+			Severity: SeverityWarning,
+			Message:  "MANIFEST ends with a torn record, which is removed on the next open",
+			File:     manifest.FileName,
+		})
+		segments = m.Segments()
+	}
+
+	report.Segments = len(segments)
+	return segments
+}
+
+// bootstrapSegments lists the segment files on disk, treating every one but the most
+// recent as sealed.
+func (c *checker) bootstrapSegments() []manifest.Segment {
+	prefix := c.options.SegmentOptions.Prefix
+	names, err := seginfo.ListSegmentNames(c.options.DataDir, c.options.SegmentOptions.Directory, prefix)
+	if err != nil {
+		c.report.Manifest.Issues = append(c.report.Manifest.Issues, Issue{
+			Code:     errors.ErrorCodeIO,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("segment directory cannot be listed: %v", err),
+		})
+		return nil
+	}
+
+	segments := make([]manifest.Segment, 0, len(names))
+	for i, name := range names {
+		id, err := seginfo.ParseSegmentID(name, prefix)
+		if err != nil {
+			continue
+		}
+		segments = append(segments, manifest.Segment{ID: id, File: filepath.Base(name), Sealed: i < len(names)-1})
+	}
+	return segments
+}
+
+// checkSegments verifies every listed segment in ID order, then reports segment
+// files on disk that the manifest does not list.
+func (c *checker) checkSegments(ctx context.Context, segments []manifest.Segment) error {
+	segmentDir := filepath.Join(c.options.DataDir, c.options.SegmentOptions.Directory)
+
+	listed := make(map[string]struct{}, len(segments))
+	for i, segment := range segments {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		listed[segment.File] = struct{}{}
+		active := i == len(segments)-1 && !segment.Sealed
+
+		report, err := c.checkSegment(filepath.Join(segmentDir, segment.File), segment, active)
+		if err != nil {
+			return err
+		}
+		c.report.Segments = append(c.report.Segments, *report)
+	}
+
+	names, err := seginfo.ListSegmentNames(c.options.DataDir, c.options.SegmentOptions.Directory, c.options.SegmentOptions.Prefix)
+	if err != nil {
+		return nil
+	}
+	for _, name := range names {
+		file := filepath.Base(name)
+		if _, ok := listed[file]; ok {
+			continue
+		}
+
+		id, _ := seginfo.ParseSegmentID(name, c.options.SegmentOptions.Prefix)
+		report := SegmentReport{ID: id, File: file, Status: StatusUnlisted}
+		if info, err := os.Stat(name); err == nil {
+			report.Size = info.Size()
+		}
+		report.Issues = append(report.Issues, Issue{
+			Code:     errors.ErrorCodeIndexValidationFailed,
+			Severity: SeverityWarning,
+			Message:  "segment file is not listed in the MANIFEST and is removed on the next open",
+			File:     file,
+		})
+		c.report.Segments = append(c.report.Segments, report)
+	}
+	return nil
+}
+
+// checkSegment verifies a single segment and records the latest entry of every key it
+// holds.
+func (c *checker) checkSegment(path string, segment manifest.Segment, active bool) (*SegmentReport, error) {
+	report := &SegmentReport{ID: segment.ID, File: segment.File, Status: StatusOK, Sealed: segment.Sealed}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		report.Status = StatusMissing
+		report.Issues = append(report.Issues, Issue{
+			Code:     errors.ErrorCodeIndexValidationFailed,
+			Severity: SeverityError,
+			Message:  "segment listed in the MANIFEST does not exist; its entries are lost",
+			File:     segment.File,
+		})
+		return report, nil
+	}
+
+	var hint *storage.Hint
+	var hintErr error
+	if segment.Sealed {
+		hint, hintErr = c.readHint(path)
+	}
+
+	scan, err := storage.WalkSegment(path, segment.ID, func(pos *storage.Position, entry *storage.Entry) error {
+		if hint != nil && hintErr == nil {
+			if report.Entries >= len(hint.Records) || !hint.Records[report.Entries].Matches(pos, entry) {
+				hintErr = fmt.Errorf("entry at offset %d does not match its record", pos.Offset)
+			}
+		}
+
+		report.Entries++
+		if entry.IsTombstone() {
+			report.Tombstones++
+		}
+		if entry.IsBlob() {
+			report.BlobRefs++
+		}
+
+		if entry.IsEncrypted() {
+			c.report.Keys.Sealed++
+			return nil
+		}
+
+		state := keyState{deleted: entry.IsTombstone()}
+		if entry.IsBlob() {
+			ref, err := storage.DecodeBlobRef(entry.Value)
+			if err != nil {
+				report.Issues = append(report.Issues, Issue{
+					Code:     errors.ErrorCodeBlobCorrupted,
+					Severity: SeverityError,
+					Message:  fmt.Sprintf("blob reference of key %q cannot be decoded: %v", entry.Key, err),
+					File:     segment.File,
+					Offset:   pos.Offset,
+				})
+			} else {
+				state.blob = &ref.ID
+			}
+		}
+		c.keys[string(entry.Key)] = state
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report.Encrypted = scan.Encrypted()
+	report.KeyID = scan.KeyID
+	report.Size = scan.FileSize
+	report.ValidSize = scan.ValidSize
+
+	if scan.Damage != nil {
+		if active {
+			report.Status = StatusTorn
+			report.Issues = append(report.Issues, Issue{
+				Code:     errors.ErrorCodeSegmentCorrupted,
+				Severity: SeverityWarning,
+				Message: fmt.Sprintf(
+					"active segment ends with an incomplete write; %d bytes are cut off on the next open",
+					scan.FileSize-scan.ValidSize,
+				),
+				File:   segment.File,
+				Offset: scan.ValidSize,
+			})
+		} else {
+			report.Status = StatusCorrupted
+			report.Issues = append(report.Issues, Issue{
+				Code:     errors.ErrorCodeSegmentCorrupted,
+				Severity: SeverityError,
+				Message: fmt.Sprintf(
+					"%v; the %d bytes after it cannot be read", scan.Damage, scan.FileSize-scan.ValidSize,
+				),
+				File:   segment.File,
+				Offset: scan.ValidSize,
+			})
+		}
+	}
+
+	if segment.Sealed {
+		c.checkHint(report, hint, hintErr, scan)
+	}
+
+	if segment.Sealed && segment.Size != 0 && segment.Size != scan.FileSize {
+		report.Status = StatusCorrupted
+		report.Issues = append(report.Issues, Issue{
+			Code:     errors.ErrorCodeIndexValidationFailed,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("segment is %d bytes but the MANIFEST recorded %d when it was sealed", scan.FileSize, segment.Size),
+			File:     segment.File,
+		})
+	}
+
+	return report, nil
+}
+
+// readHint reads the hint file of the segment at path. It returns nil without an error
+// when the segment has no hint file.
+func (c *checker) readHint(path string) (*storage.Hint, error) {
+	hintPath := storage.HintPath(path)
+	if _, err := os.Stat(hintPath); os.IsNotExist(err) {
+		return nil, nil
+	}
+	return storage.ReadHint(hintPath)
+}
+
+// checkHint records the state of the hint file of a sealed segment, given the hint
+// read before the segment was walked and the first mismatch found during the walk.
+func (c *checker) checkHint(report *SegmentReport, hint *storage.Hint, hintErr error, scan *storage.SegmentScan) {
+	if hint == nil && hintErr == nil {
+		report.Hint = HintMissing
+		return
+	}
+
+	// The damage is reported already, and repair replaces the hint with the segment.
+	if scan.Damage != nil {
+		report.Hint = HintStale
+		return
+	}
+
+	if hintErr == nil {
+		switch {
+		case hint.Sealed != scan.Encrypted():
+			hintErr = fmt.Errorf("hint encryption does not match the segment")
+		case hint.SegmentSize != scan.FileSize:
+			hintErr = fmt.Errorf("hint was written for %d bytes, the segment has %d", hint.SegmentSize, scan.FileSize)
+		case len(hint.Records) != report.Entries:
+			hintErr = fmt.Errorf("hint lists %d entries, %d were read intact", len(hint.Records), report.Entries)
+		}
+	}
+
+	if hintErr == nil {
+		report.Hint = HintOK
+		return
+	}
+
+	report.Hint = HintStale
+	report.Issues = append(report.Issues, Issue{
+		Code:     errors.ErrorCodeIndexHintFileCorrupted,
+		Severity: SeverityWarning,
+		Message:  fmt.Sprintf("%v; the hint file is ignored and rewritten on the next open", hintErr),
+		File:     filepath.Base(storage.HintPath(report.File)),
+	})
+}
+
+// checkBlobs cross-checks the blob references of live keys against the blob files on
+// disk.
+func (c *checker) checkBlobs() {
+	report := &c.report.Blobs
+	blobDir := filepath.Join(c.options.DataDir, c.options.BlobOptions.Directory)
+
+	entries, err := os.ReadDir(blobDir)
+	if err != nil && !os.IsNotExist(err) {
+		report.Issues = append(report.Issues, Issue{
+			Code:     errors.ErrorCodeIO,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("blob directory cannot be listed: %v", err),
+		})
+		return
+	}
+
+	files := make(map[storage.BlobID]struct{}, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasSuffix(name, ".tmp") {
+			report.Issues = append(report.Issues, Issue{
+				Code:     errors.ErrorCodeBlobCorrupted,
+				Severity: SeverityWarning,
+				Message:  "incomplete blob left by an interrupted write; it is removed on the next open",
+				File:     name,
+			})
+			continue
+		}
+
+		id, err := storage.ParseBlobID(strings.TrimSuffix(name, ".blob"))
+		if err != nil || !strings.HasSuffix(name, ".blob") {
+			continue
+		}
+		files[id] = struct{}{}
+	}
+	report.Files = len(files)
+
+	referenced := make(map[storage.BlobID]struct{})
+	for key, state := range c.keys {
+		if state.deleted || state.blob == nil {
+			continue
+		}
+		if _, ok := files[*state.blob]; !ok {
+			report.Missing++
+			report.Issues = append(report.Issues, Issue{
+				Code:     errors.ErrorCodeBlobCorrupted,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("value of key %q lives in a blob file that does not exist", key),
+				File:     state.blob.String() + ".blob",
+			})
+			continue
+		}
+		referenced[*state.blob] = struct{}{}
+	}
+	report.Referenced = len(referenced)
+
+	// Blob references inside encrypted entries cannot be read, so files they refer to
+	// would wrongly count as unreferenced.
+	if c.report.Keys.Sealed == 0 {
+		report.Unreferenced = len(files) - len(referenced)
+	}
+}
+
+// summarize counts the keys and decides whether the directory is healthy.
+func (c *checker) summarize() {
+	for _, state := range c.keys {
+		if state.deleted {
+			c.report.Keys.Deleted++
+		} else {
+			c.report.Keys.Live++
+		}
+	}
+
+	issues := append([]Issue(nil), c.report.Manifest.Issues...)
+	issues = append(issues, c.report.Blobs.Issues...)
+	for _, segment := range c.report.Segments {
+		issues = append(issues, segment.Issues...)
+	}
+
+	c.report.Healthy = true
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			c.report.Healthy = false
+			return
+		}
+	}
+}
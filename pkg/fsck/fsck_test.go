@@ -0,0 +1,183 @@
+package fsck
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iamNilotpal/ignite/internal/engine"
+	"github.com/iamNilotpal/ignite/internal/storage"
+	"github.com/iamNilotpal/ignite/pkg/options"
+	"go.uber.org/zap"
+)
+
+// writeStore creates a store in a temporary directory spanning several segments, with
+// 200 writes spread over the given number of keys, a blob and a deleted key, and waits
+// for the hint files of its sealed segments. It returns the options and the segment
+// files in ID order.
+func writeStore(t *testing.T, keys int) (*options.Options, []string) {
+	t.Helper()
+
+	opts := options.NewDefaultOptions()
+	opts.DataDir = t.TempDir()
+	opts.SegmentOptions.Size = 4096
+	opts.BlobOptions.Threshold = 1024
+	opts.CompactInterval = 0
+
+	e, err := engine.New(context.Background(), &engine.Config{Options: &opts, Logger: zap.NewNop().Sugar()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 200 {
+		v := bytes.Repeat([]byte{byte('a' + i%26)}, 100)
+		if err := e.Set(context.Background(), fmt.Sprintf("key-%03d", i%keys), v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := e.Set(context.Background(), "blob", bytes.Repeat([]byte("b"), 4096)); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Set(context.Background(), "gone", []byte("v")); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Delete(context.Background(), "gone"); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(opts.DataDir, opts.SegmentOptions.Directory, "*.seg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for _, file := range files[:len(files)-1] {
+		for {
+			if _, err := os.Stat(storage.HintPath(file)); err == nil {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("no hint file written for %s", filepath.Base(file))
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &opts, files
+}
+
+// check runs Check on the store at opts.
+func check(t *testing.T, opts *options.Options) *Report {
+	t.Helper()
+
+	report, err := Check(context.Background(), opts.DataDir)
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	return report
+}
+
+// flipByte inverts the byte at offset in the file at path.
+func flipByte(t *testing.T, path string, offset int64) {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[offset] ^= 0xff
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckHealthyStore(t *testing.T) {
+	opts, files := writeStore(t, 50)
+	report := check(t, opts)
+
+	if !report.Healthy {
+		t.Fatalf("Check() reported an unhealthy store: %+v", report)
+	}
+	if len(report.Segments) != len(files) {
+		t.Fatalf("Check() reported %d segments, want %d", len(report.Segments), len(files))
+	}
+	for _, segment := range report.Segments {
+		if segment.Status != StatusOK || len(segment.Issues) != 0 {
+			t.Errorf("segment %d: status %q, issues %v", segment.ID, segment.Status, segment.Issues)
+		}
+		if segment.Sealed && segment.Hint != HintOK {
+			t.Errorf("segment %d: hint %q, want %q", segment.ID, segment.Hint, HintOK)
+		}
+	}
+	if report.Keys.Live != 51 || report.Keys.Deleted != 1 {
+		t.Fatalf("Check() keys = %+v, want 51 live and 1 deleted", report.Keys)
+	}
+	if report.Blobs.Files != 1 || report.Blobs.Referenced != 1 {
+		t.Fatalf("Check() blobs = %+v, want one referenced blob", report.Blobs)
+	}
+}
+
+func TestCheckReportsStaleAndMissingHints(t *testing.T) {
+	opts, files := writeStore(t, 50)
+
+	flipByte(t, storage.HintPath(files[0]), 30)
+	if err := os.Remove(storage.HintPath(files[1])); err != nil {
+		t.Fatal(err)
+	}
+
+	report := check(t, opts)
+	if !report.Healthy {
+		t.Fatal("Check() reported a store with bad hint files as unhealthy")
+	}
+	if got := report.Segments[0]; got.Hint != HintStale || len(got.Issues) != 1 || got.Issues[0].Severity != SeverityWarning {
+		t.Fatalf("segment with damaged hint: hint %q, issues %v", got.Hint, got.Issues)
+	}
+	if got := report.Segments[1]; got.Hint != HintMissing || len(got.Issues) != 0 {
+		t.Fatalf("segment without hint: hint %q, issues %v", got.Hint, got.Issues)
+	}
+}
+
+func TestCheckReportsDamage(t *testing.T) {
+	opts, files := writeStore(t, 50)
+
+	// Damage the middle of a sealed segment, and tear the end of the active one.
+	info, err := os.Stat(files[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	flipByte(t, files[1], info.Size()/2)
+
+	active, err := os.OpenFile(files[len(files)-1], os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	active.Write([]byte{1, 2, 3})
+	active.Close()
+
+	report := check(t, opts)
+	if report.Healthy {
+		t.Fatal("Check() reported a damaged store as healthy")
+	}
+
+	damaged := report.Segments[1]
+	if damaged.Status != StatusCorrupted || damaged.ValidSize >= damaged.Size {
+		t.Fatalf("damaged segment: status %q, valid %d of %d bytes", damaged.Status, damaged.ValidSize, damaged.Size)
+	}
+	if damaged.Hint != HintStale {
+		t.Fatalf("hint of damaged segment: %q, want %q", damaged.Hint, HintStale)
+	}
+
+	torn := report.Segments[len(report.Segments)-1]
+	if torn.Status != StatusTorn || torn.Size-torn.ValidSize != 3 {
+		t.Fatalf("torn segment: status %q, valid %d of %d bytes", torn.Status, torn.ValidSize, torn.Size)
+	}
+	if torn.Issues[0].Severity != SeverityWarning {
+		t.Fatalf("torn write reported as %q", torn.Issues[0].Severity)
+	}
+}
@@ -0,0 +1,159 @@
+package fsck
+
+import (
+	"time"
+
+	"github.com/iamNilotpal/ignite/pkg/errors"
+)
+
+// Severity grades an issue found by Check.
+type Severity string
+
+const (
+	// SeverityError marks damage or inconsistencies that lose data or keep the store
+	// from being opened as it was written.
+	SeverityError Severity = "error"
+
+	// SeverityWarning marks conditions the next open repairs or ignores on its own,
+	// such as a torn write at the end of the active segment.
+	SeverityWarning Severity = "warning"
+)
+
+// Status summarizes the health of a single segment.
+type Status string
+
+const (
+	// StatusOK means every entry of the segment passed its checksum.
+	StatusOK Status = "ok"
+
+	// StatusCorrupted means a sealed segment holds damage; entries past it are lost.
+	StatusCorrupted Status = "corrupted"
+
+	// StatusTorn means the active segment ends with an incomplete write, which is cut
+	// off the next time the store is opened.
+	StatusTorn Status = "torn"
+
+	// StatusMissing means the manifest lists a segment whose file does not exist.
+	StatusMissing Status = "missing"
+
+	// StatusUnlisted means a segment file exists that the manifest does not list. The
+	// store ignores it and removes it the next time it is opened.
+	StatusUnlisted Status = "unlisted"
+)
+
+// HintStatus summarizes the hint file of a sealed segment.
+type HintStatus string
+
+const (
+	// HintOK means the hint file lists exactly the entries of the segment.
+	HintOK HintStatus = "ok"
+
+	// HintMissing means the segment has no hint file. The store reads the segment in
+	// full on the next open and writes one.
+	HintMissing HintStatus = "missing"
+
+	// HintStale means the hint file is damaged or does not match the segment. The
+	// store ignores it on the next open and writes a new one.
+	HintStale HintStatus = "stale"
+)
+
+// Issue describes a single problem found by Check.
+type Issue struct {
+	Code     errors.ErrorCode `json:"code"`             // Error code the problem maps to.
+	Severity Severity         `json:"severity"`         // How serious the problem is.
+	Message  string           `json:"message"`          // Human-readable description.
+	File     string           `json:"file,omitempty"`   // File the problem was found in, if any.
+	Offset   int64            `json:"offset,omitempty"` // Byte offset of the problem within File, if known.
+}
+
+// Report is the result of checking a data directory.
+type Report struct {
+	DataDir  string          `json:"dataDir"`  // Data directory that was checked.
+	Checked  time.Time       `json:"checked"`  // Time the check started.
+	Healthy  bool            `json:"healthy"`  // Whether no issue of SeverityError was found.
+	Manifest ManifestReport  `json:"manifest"` // Health of the MANIFEST.
+	Segments []SegmentReport `json:"segments"` // Health of every segment, ordered by ID.
+	Blobs    BlobReport      `json:"blobs"`    // Health of the blob files.
+	Keys     KeyReport       `json:"keys"`     // Keys found across the intact entries.
+}
+
+// ManifestReport describes the MANIFEST of a data directory.
+type ManifestReport struct {
+	Present  bool    `json:"present"`          // Whether a MANIFEST exists.
+	Segments int     `json:"segments"`         // Number of live segments it lists.
+	Issues   []Issue `json:"issues,omitempty"` // Problems found in the MANIFEST.
+}
+
+// SegmentReport describes the health of a single segment file.
+type SegmentReport struct {
+	ID         uint64     `json:"id"`               // Segment ID.
+	File       string     `json:"file"`             // File name within the segment directory.
+	Status     Status     `json:"status"`           // Overall health of the segment.
+	Sealed     bool       `json:"sealed"`           // Whether the manifest records the segment as sealed.
+	Encrypted  bool       `json:"encrypted"`        // Whether the segment starts with an encryption header.
+	KeyID      string     `json:"keyId,omitempty"`  // Master key protecting an encrypted segment.
+	Size       int64      `json:"size"`             // Size of the file in bytes.
+	ValidSize  int64      `json:"validSize"`        // Bytes up to the end of the last intact entry.
+	Entries    int        `json:"entries"`          // Intact entries.
+	Tombstones int        `json:"tombstones"`       // Intact entries that delete a key.
+	BlobRefs   int        `json:"blobRefs"`         // Intact entries whose value lives in a blob file.
+	Hint       HintStatus `json:"hint,omitempty"`   // State of the hint file of a sealed segment.
+	Issues     []Issue    `json:"issues,omitempty"` // Problems found in the segment.
+}
+
+// BlobReport describes the blob files of a data directory.
+type BlobReport struct {
+	Files        int     `json:"files"`            // Blob files on disk.
+	Referenced   int     `json:"referenced"`       // Blob files referenced by a live key.
+	Unreferenced int     `json:"unreferenced"`     // Blob files no live key refers to; removed on the next open.
+	Missing      int     `json:"missing"`          // Blob files referenced by a live key but not on disk.
+	Issues       []Issue `json:"issues,omitempty"` // Problems found with blob files.
+}
+
+// KeyReport summarizes the keys found in the intact entries. Entries of encrypted
+// segments cannot be read without their keys and are only counted.
+type KeyReport struct {
+	Live    int `json:"live"`    // Keys whose latest intact entry is a value.
+	Deleted int `json:"deleted"` // Keys whose latest intact entry is a tombstone.
+	Sealed  int `json:"sealed"`  // Entries that are encrypted and were not examined.
+}
+
+// RepairReport is the result of repairing a data directory.
+type RepairReport struct {
+	DataDir  string          `json:"dataDir"`  // Data directory that was repaired.
+	Repaired time.Time       `json:"repaired"` // Time the repair started.
+	Segments []SegmentRepair `json:"segments"` // Segments that were rewritten, ordered by ID.
+
+	// KeysLost counts the keys whose latest write was destroyed, so that they now read
+	// as an older value or not at all. LostKeys lists them.
+	KeysLost int      `json:"keysLost"`
+	LostKeys []string `json:"lostKeys,omitempty"`
+
+	// UnidentifiedEntries counts lost entries whose key could not be read because they
+	// were encrypted, and UnidentifiedBytes the damaged bytes in which no entry could be
+	// delimited at all. When both are zero, KeysLost is exact; otherwise more keys may
+	// have been lost than it reports.
+	UnidentifiedEntries int   `json:"unidentifiedEntries"`
+	UnidentifiedBytes   int64 `json:"unidentifiedBytes"`
+}
+
+// SegmentRepair describes how a single damaged segment was repaired.
+type SegmentRepair struct {
+	ID          uint64       `json:"id"`          // Segment ID, kept by the replacement.
+	File        string       `json:"file"`        // Damaged segment file.
+	Replacement string       `json:"replacement"` // New segment file holding the salvaged entries.
+	Quarantined string       `json:"quarantined"` // Path the damaged file was moved to, relative to the data directory.
+	Salvaged    int          `json:"salvaged"`    // Entries copied to the replacement.
+	LostEntries int          `json:"lostEntries"` // Damaged entries whose extent could be determined.
+	LostBytes   int64        `json:"lostBytes"`   // Total size of the damaged regions.
+	Regions     []LostRegion `json:"regions"`     // Damaged regions that were skipped.
+	Hint        bool         `json:"hint"`        // Whether a hint file was written for the replacement.
+}
+
+// LostRegion is a damaged stretch of a segment file that could not be salvaged.
+type LostRegion struct {
+	Offset int64  `json:"offset"`        // Offset of the first damaged byte.
+	Length int64  `json:"length"`        // Number of damaged bytes.
+	Entry  bool   `json:"entry"`         // Whether the region is exactly one damaged entry.
+	Key    string `json:"key,omitempty"` // Key of that entry, if it was unencrypted.
+}
@@ -0,0 +1,236 @@
+package fsck
+
+import (
+	"cmp"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/iamNilotpal/ignite/internal/manifest"
+	"github.com/iamNilotpal/ignite/internal/storage"
+	"github.com/iamNilotpal/ignite/pkg/errors"
+	"github.com/iamNilotpal/ignite/pkg/filesys"
+	"github.com/iamNilotpal/ignite/pkg/options"
+	"github.com/iamNilotpal/ignite/pkg/seginfo"
+	"go.uber.org/zap"
+)
+
+// QuarantineDir is the directory within the data directory that Repair moves damaged
+// segment files to.
+const QuarantineDir = "quarantine"
+
+// order places an entry in replay order: by segment, then by offset.
+type order struct {
+	segmentID uint64
+	offset    int64
+}
+
+// compare orders two replay positions.
+func (o order) compare(other order) int {
+	if c := cmp.Compare(o.segmentID, other.segmentID); c != 0 {
+		return c
+	}
+	return cmp.Compare(o.offset, other.offset)
+}
+
+// lostEntry is a damaged entry whose key could be read.
+type lostEntry struct {
+	key string
+	at  order
+}
+
+// Repair salvages the data of every damaged segment in the offline data directory
+// dataDir. Each damaged segment is rewritten into a fresh file under the same segment
+// ID, holding every entry that still passes its checksum: after damage, the scan
+// resynchronizes on the next offset at which a complete, checksum-verified entry
+// starts. The MANIFEST is switched to the new file atomically, and the damaged file is
+// moved to the quarantine directory rather than deleted.
+//
+// The report counts the keys whose latest write was lost. Segments without damage are
+// left untouched, so repairing a healthy directory changes nothing. Like Check, Repair
+// takes the data directory lock, and it requires a MANIFEST; opening the store once
+// creates one for directories written before manifests existed.
+//
+// The hint file of a damaged segment describes entries that are gone, so it is removed
+// with the segment. A replacement for a sealed, unencrypted segment gets a new hint
+// right away; hints of encrypted segments can only be written with their keys, so the
+// store writes those on its next open.
+func Repair(ctx context.Context, dataDir string, opts ...options.OptionFunc) (*RepairReport, error) {
+	repairOpts := options.NewDefaultOptions()
+	for _, opt := range opts {
+		opt(&repairOpts)
+	}
+	repairOpts.DataDir = dataDir
+
+	if _, err := os.Stat(filepath.Join(dataDir, manifest.FileName)); err != nil {
+		return nil, errors.NewFileAccessError(
+			filepath.Join(dataDir, manifest.FileName), manifest.FileName, "repair_manifest_stat", err,
+		)
+	}
+
+	unlock, err := storage.LockDir(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	m, err := manifest.Open(&manifest.Config{Dir: dataDir, Logger: zap.NewNop().Sugar()})
+	if err != nil {
+		return nil, err
+	}
+	defer m.Close()
+
+	report := &RepairReport{DataDir: dataDir, Repaired: time.Now().UTC(), Segments: []SegmentRepair{}}
+	segmentDir := filepath.Join(dataDir, repairOpts.SegmentOptions.Directory)
+
+	latest := make(map[string]order)
+	record := func(pos *storage.Position, entry *storage.Entry) error {
+		if !entry.IsEncrypted() {
+			latest[string(entry.Key)] = order{pos.SegmentID, pos.Offset}
+		}
+		return nil
+	}
+
+	var lost []lostEntry
+	for _, segment := range m.Segments() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		path := filepath.Join(segmentDir, segment.File)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			// Nothing is left to salvage; fsck reports the missing file.
+			continue
+		}
+
+		scan, err := storage.WalkSegment(path, segment.ID, record)
+		if err != nil {
+			return nil, err
+		}
+		if scan.Damage == nil {
+			continue
+		}
+
+		repair, entries, err := repairSegment(m, segmentDir, dataDir, repairOpts.SegmentOptions.Prefix, segment, record)
+		if err != nil {
+			return nil, err
+		}
+		report.Segments = append(report.Segments, *repair)
+
+		for _, region := range repair.Regions {
+			switch {
+			case !region.Entry:
+				report.UnidentifiedBytes += region.Length
+			case region.Key == "":
+				report.UnidentifiedEntries++
+			}
+		}
+		lost = append(lost, entries...)
+	}
+
+	// A lost write only loses its key if no later write of the same key survived.
+	keys := make(map[string]struct{})
+	for _, entry := range lost {
+		if at, ok := latest[entry.key]; ok && at.compare(entry.at) > 0 {
+			continue
+		}
+		keys[entry.key] = struct{}{}
+	}
+	for key := range keys {
+		report.LostKeys = append(report.LostKeys, key)
+	}
+	slices.Sort(report.LostKeys)
+	report.KeysLost = len(report.LostKeys)
+
+	return report, nil
+}
+
+// repairSegment rewrites a damaged segment, swaps it in through the manifest and
+// quarantines the damaged file. It returns the repair along with the damaged entries
+// whose keys could be read.
+func repairSegment(
+	m *manifest.Manifest,
+	segmentDir, dataDir, prefix string,
+	segment manifest.Segment,
+	record func(pos *storage.Position, entry *storage.Entry) error,
+) (*SegmentRepair, []lostEntry, error) {
+	path := filepath.Join(segmentDir, segment.File)
+
+	replacement := seginfo.GenerateName(segment.ID, prefix)
+	for replacement == segment.File {
+		replacement = seginfo.GenerateName(segment.ID, prefix)
+	}
+	replacementPath := filepath.Join(segmentDir, replacement)
+
+	// Salvaged entries are recorded at their offsets in the damaged file, which keeps
+	// them comparable with the lost ones.
+	salvage, err := storage.SalvageSegment(path, replacementPath, segment.ID, record)
+	if err != nil {
+		os.Remove(replacementPath)
+		return nil, nil, err
+	}
+
+	repair := &SegmentRepair{
+		ID:          segment.ID,
+		File:        segment.File,
+		Replacement: replacement,
+		Quarantined: filepath.Join(QuarantineDir, segment.File),
+		Salvaged:    salvage.Entries,
+		LostBytes:   salvage.LostBytes(),
+		Regions:     make([]LostRegion, 0, len(salvage.Regions)),
+	}
+
+	var lost []lostEntry
+	for _, region := range salvage.Regions {
+		lr := LostRegion{Offset: region.Offset, Length: region.Length, Entry: region.Entry != nil}
+		if region.Entry != nil {
+			repair.LostEntries++
+			if region.Entry.Key != nil {
+				lr.Key = string(region.Entry.Key)
+				lost = append(lost, lostEntry{key: lr.Key, at: order{segment.ID, region.Offset}})
+			}
+		}
+		repair.Regions = append(repair.Regions, lr)
+	}
+
+	// Link the damaged file into quarantine before the manifest stops referencing it,
+	// so that a crash at any point leaves it either live or quarantined.
+	quarantine := filepath.Join(dataDir, QuarantineDir)
+	if err := os.MkdirAll(quarantine, 0755); err != nil {
+		return nil, nil, errors.ClassifyDirectoryCreationError(err, quarantine)
+	}
+	if err := filesys.LinkFile(path, filepath.Join(dataDir, repair.Quarantined)); err != nil {
+		return nil, nil, errors.NewFileAccessError(path, segment.File, "repair_quarantine", err)
+	}
+	if err := filesys.SyncDir(quarantine); err != nil {
+		return nil, nil, errors.NewFileAccessError(quarantine, QuarantineDir, "repair_dir_sync", err)
+	}
+
+	replaced := manifest.Segment{ID: segment.ID, File: replacement, Sealed: segment.Sealed}
+	if segment.Sealed {
+		replaced.Size = salvage.Size
+	}
+	if err := m.Replace(replaced); err != nil {
+		return nil, nil, err
+	}
+
+	if err := os.Remove(path); err != nil {
+		return nil, nil, errors.NewFileAccessError(path, segment.File, "repair_remove", err)
+	}
+	if err := storage.RemoveHint(path); err != nil {
+		return nil, nil, err
+	}
+
+	if segment.Sealed {
+		if repair.Hint, err = storage.WriteHint(replacementPath, segment.ID); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := filesys.SyncDir(segmentDir); err != nil {
+		return nil, nil, errors.NewFileAccessError(segmentDir, filepath.Base(segmentDir), "repair_dir_sync", err)
+	}
+
+	return repair, lost, nil
+}
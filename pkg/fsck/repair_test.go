@@ -0,0 +1,168 @@
+package fsck
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/iamNilotpal/ignite/internal/engine"
+	"github.com/iamNilotpal/ignite/internal/storage"
+	igniteErrors "github.com/iamNilotpal/ignite/pkg/errors"
+	"github.com/iamNilotpal/ignite/pkg/options"
+	"go.uber.org/zap"
+)
+
+// damageEntry flips the last value byte of the n-th entry of the segment file at path,
+// and returns the key of that entry.
+func damageEntry(t *testing.T, path string, n int) string {
+	t.Helper()
+
+	var positions []storage.Position
+	var keys []string
+	_, err := storage.WalkSegment(path, 0, func(pos *storage.Position, entry *storage.Entry) error {
+		positions = append(positions, *pos)
+		keys = append(keys, string(entry.Key))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n < 0 {
+		n += len(positions)
+	}
+
+	flipByte(t, path, positions[n].Offset+int64(positions[n].Size)-1)
+	return keys[n]
+}
+
+// repair runs Repair on the store at opts.
+func repair(t *testing.T, opts *options.Options) *RepairReport {
+	t.Helper()
+
+	report, err := Repair(context.Background(), opts.DataDir)
+	if err != nil {
+		t.Fatalf("Repair() error = %v", err)
+	}
+	return report
+}
+
+// expectKeys opens the store at opts and checks that every key written by writeStore
+// with 200 keys reads back, except lost.
+func expectKeys(t *testing.T, opts *options.Options, lost string) {
+	t.Helper()
+
+	e, err := engine.New(context.Background(), &engine.Config{Options: opts, Logger: zap.NewNop().Sugar()})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer e.Close()
+
+	for i := range 200 {
+		key := fmt.Sprintf("key-%03d", i)
+		got, err := e.Get(context.Background(), key)
+		if key == lost {
+			if igniteErrors.GetErrorCode(err) != igniteErrors.ErrorCodeIndexKeyNotFound {
+				t.Fatalf("Get(%q) error = %v, want key not found", key, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Get(%q) error = %v", key, err)
+		}
+		if want := bytes.Repeat([]byte{byte('a' + i%26)}, 100); !bytes.Equal(got, want) {
+			t.Fatalf("Get(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestRepairSalvagesAroundDamage(t *testing.T) {
+	opts, files := writeStore(t, 200)
+	lostKey := damageEntry(t, files[1], 3)
+
+	report := repair(t, opts)
+	if len(report.Segments) != 1 {
+		t.Fatalf("Repair() rewrote %d segments, want 1", len(report.Segments))
+	}
+
+	segment := report.Segments[0]
+	if segment.Salvaged == 0 || segment.LostEntries != 1 || len(segment.Regions) != 1 {
+		t.Fatalf("Repair() segment = %+v, want one lost entry and the rest salvaged", segment)
+	}
+	if region := segment.Regions[0]; !region.Entry || region.Key != lostKey {
+		t.Fatalf("Repair() region = %+v, want the entry of %q", region, lostKey)
+	}
+	if report.KeysLost != 1 || !slices.Equal(report.LostKeys, []string{lostKey}) {
+		t.Fatalf("Repair() lost keys = %v, want [%s]", report.LostKeys, lostKey)
+	}
+	if !segment.Hint {
+		t.Fatal("Repair() wrote no hint file for the replacement")
+	}
+	if _, err := os.Stat(filepath.Join(opts.DataDir, segment.Quarantined)); err != nil {
+		t.Fatalf("damaged segment not quarantined: %v", err)
+	}
+	if _, err := os.Stat(storage.HintPath(files[1])); !os.IsNotExist(err) {
+		t.Fatal("hint file of the damaged segment was kept")
+	}
+
+	checked := check(t, opts)
+	if !checked.Healthy {
+		t.Fatalf("Check() after Repair() is unhealthy: %+v", checked.Segments)
+	}
+	if got := checked.Segments[1]; got.File != segment.Replacement || got.Hint != HintOK {
+		t.Fatalf("Check() after Repair() segment = %+v, want the replacement with a matching hint", got)
+	}
+
+	expectKeys(t, opts, lostKey)
+}
+
+func TestRepairSalvagesCorruptTail(t *testing.T) {
+	opts, files := writeStore(t, 200)
+	lostKey := damageEntry(t, files[0], -1)
+
+	before, err := os.Stat(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := repair(t, opts)
+	if len(report.Segments) != 1 || report.KeysLost != 1 {
+		t.Fatalf("Repair() = %+v, want one segment and one key lost", report)
+	}
+
+	segment := report.Segments[0]
+	region := segment.Regions[0]
+	if region.Offset+region.Length != before.Size() {
+		t.Fatalf("Repair() region = %+v, want it to end the %d byte segment", region, before.Size())
+	}
+
+	after, err := os.Stat(filepath.Join(filepath.Dir(files[0]), segment.Replacement))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Size() != before.Size()-segment.LostBytes {
+		t.Fatalf("replacement is %d bytes, want %d", after.Size(), before.Size()-segment.LostBytes)
+	}
+
+	expectKeys(t, opts, lostKey)
+}
+
+func TestRepairLeavesHealthyStoreAlone(t *testing.T) {
+	opts, files := writeStore(t, 50)
+
+	report := repair(t, opts)
+	if len(report.Segments) != 0 || report.KeysLost != 0 {
+		t.Fatalf("Repair() of a healthy store = %+v", report)
+	}
+
+	after, err := filepath.Glob(filepath.Join(filepath.Dir(files[0]), "*.seg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(after, files) {
+		t.Fatalf("Repair() changed the segment files: %v, was %v", after, files)
+	}
+}
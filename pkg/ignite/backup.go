@@ -0,0 +1,208 @@
+package ignite
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"time"
+
+	"github.com/iamNilotpal/ignite/internal/backup"
+	"github.com/iamNilotpal/ignite/internal/engine"
+	"github.com/iamNilotpal/ignite/pkg/errors"
+	"github.com/iamNilotpal/ignite/pkg/logger"
+	"github.com/iamNilotpal/ignite/pkg/options"
+)
+
+// BackupInfo describes a completed backup and its place in a chain of incremental
+// backups. Keep it, or read it back with ReadBackupInfo, to take the next
+// incremental backup with BackupSince.
+type BackupInfo struct {
+	ID          string    `json:"id"`               // Random identifier of the backup.
+	Parent      string    `json:"parent,omitempty"` // ID of the backup this one builds on; empty for a full backup.
+	Created     time.Time `json:"created"`          // Time the backup was started.
+	LastSegment uint64    `json:"lastSegment"`      // Highest segment ID covered by the chain up to this backup.
+	Blobs       []string  `json:"blobs,omitempty"`  // Blob files covered by the chain up to this backup.
+	Files       int       `json:"files"`            // Number of files in this backup, excluding its metadata.
+	Bytes       int64     `json:"bytes"`            // Total size of those files.
+}
+
+// BackupSource is a backup to restore or read information from.
+type BackupSource struct {
+	source backup.Source
+}
+
+// BackupArchive returns a BackupSource reading a tar archive written by
+// Instance.Backup or Instance.BackupSince from r.
+func BackupArchive(r io.Reader) BackupSource {
+	return BackupSource{source: backup.TarSource(r)}
+}
+
+// BackupDir returns a BackupSource reading a directory written by Instance.BackupTo
+// or Instance.BackupSinceTo.
+func BackupDir(dir string) BackupSource {
+	return BackupSource{source: backup.DirSource(dir)}
+}
+
+// Backup streams a consistent copy of the store to w as a tar archive. The active
+// segment is sealed, and the sealed segments, the blob files they refer to and a
+// matching MANIFEST are then copied while writes to the instance continue. Writes
+// made after Backup starts are not part of the backup. The archive ends with a
+// BACKUP.json file listing every other file with its size and SHA-256 checksum; an
+// archive without it is incomplete and Restore rejects it.
+//
+// Files are copied as they are on disk, so a backup of an encrypted store can only be
+// restored and read with the same master keys.
+// Instances opened read-only reject it with an ErrorCodeReadOnly error.
+func (i *Instance) Backup(context context.Context, w io.Writer) (*BackupInfo, error) {
+	return i.backup(context, backup.NewTarWriter(w), nil)
+}
+
+// BackupTo writes a backup like Backup does, but as a plain directory tree rather
+// than a tar archive. The directory must not exist or be empty. Every file is synced
+// to stable storage, and BACKUP.json is written last.
+func (i *Instance) BackupTo(context context.Context, dir string) (*BackupInfo, error) {
+	w, err := backup.NewDirWriter(dir)
+	if err != nil {
+		return nil, err
+	}
+	return i.backup(context, w, nil)
+}
+
+// BackupSince streams an incremental backup on top of parent to w as a tar archive.
+// Sealed segments never change, so only segments sealed since the parent and blob
+// files the parent chain does not hold are copied, along with a MANIFEST of the whole
+// store. Deletes are recorded as tombstones in those segments and are carried along.
+// Restoring it requires every backup of the chain, starting from the full backup; see
+// RestoreChain.
+func (i *Instance) BackupSince(context context.Context, w io.Writer, parent *BackupInfo) (*BackupInfo, error) {
+	if parent == nil {
+		return nil, errors.NewRequiredFieldError("parent")
+	}
+	return i.backup(context, backup.NewTarWriter(w), parent)
+}
+
+// BackupSinceTo writes an incremental backup like BackupSince does, but as a plain
+// directory tree like BackupTo.
+func (i *Instance) BackupSinceTo(context context.Context, dir string, parent *BackupInfo) (*BackupInfo, error) {
+	if parent == nil {
+		return nil, errors.NewRequiredFieldError("parent")
+	}
+
+	w, err := backup.NewDirWriter(dir)
+	if err != nil {
+		return nil, err
+	}
+	return i.backup(context, w, parent)
+}
+
+// backup runs a backup into w, incremental if parent is set.
+func (i *Instance) backup(context context.Context, w backup.Writer, parent *BackupInfo) (*BackupInfo, error) {
+	var chain *backup.Chain
+	if parent != nil {
+		chain = &backup.Chain{ID: parent.ID, Parent: parent.Parent, LastSegment: parent.LastSegment, Blobs: parent.Blobs}
+	}
+
+	metadata, err := i.engine.Backup(context, w, chain)
+	if err != nil {
+		return nil, err
+	}
+	return newBackupInfo(metadata), nil
+}
+
+// ReadBackupInfo returns the information recorded in a backup, so that a chain of
+// incremental backups can be continued from a backup taken by an earlier process.
+// The files of the backup are not verified; Restore does that.
+func ReadBackupInfo(context context.Context, source BackupSource) (*BackupInfo, error) {
+	metadata, err := backup.ReadMetadata(context, source.source)
+	if err != nil {
+		return nil, err
+	}
+	return newBackupInfo(metadata), nil
+}
+
+// Restore recreates a store from a tar archive written by Instance.Backup. The data
+// directory named by opts must not exist or be empty, and the segment and blob
+// directory options decide where files are placed, just like when opening an
+// instance. The archive is unpacked into a staging directory next to the data
+// directory and checked against its checksums before anything is moved into place.
+// The restored store is then opened once to rebuild and validate its index, and
+// closed again.
+func Restore(context context.Context, service string, r io.Reader, opts ...options.OptionFunc) error {
+	return RestoreChain(context, service, []BackupSource{BackupArchive(r)}, opts...)
+}
+
+// RestoreDir recreates a store from a directory written by Instance.BackupTo, like
+// Restore does for archives.
+func RestoreDir(context context.Context, service, dir string, opts ...options.OptionFunc) error {
+	return RestoreChain(context, service, []BackupSource{BackupDir(dir)}, opts...)
+}
+
+// RestoreChain recreates a store from a chain of backups: a full backup followed by
+// zero or more incremental backups, each taken with BackupSince on top of the one
+// before it. Every backup is verified, the chain is checked for gaps, and the store is
+// rebuilt as of the last backup, otherwise like Restore.
+func RestoreChain(context context.Context, service string, chain []BackupSource, opts ...options.OptionFunc) error {
+	log := logger.New(service)
+
+	restoreOpts := options.NewDefaultOptions()
+	for _, opt := range opts {
+		opt(&restoreOpts)
+	}
+	if err := restoreOpts.Validate(); err != nil {
+		return err
+	}
+
+	sources := make([]backup.Source, len(chain))
+	for i, source := range chain {
+		if source.source == nil {
+			return errors.NewRequiredFieldError("backup")
+		}
+		sources[i] = source.source
+	}
+
+	layout := backup.Layout{
+		DataDir:    restoreOpts.DataDir,
+		SegmentDir: filepath.Join(restoreOpts.DataDir, restoreOpts.SegmentOptions.Directory),
+		BlobDir:    filepath.Join(restoreOpts.DataDir, restoreOpts.BlobOptions.Directory),
+	}
+	metadata, err := backup.Restore(context, sources, layout)
+	if err != nil {
+		return err
+	}
+
+	eng, err := engine.New(context, &engine.Config{Logger: log, Options: &restoreOpts})
+	if err != nil {
+		return err
+	}
+
+	log.Infow("Restore completed", "dataDir", restoreOpts.DataDir, "backups", len(chain), "backup", metadata.ID)
+	return eng.Close()
+}
+
+// newBackupInfo converts backup metadata into a BackupInfo.
+func newBackupInfo(metadata *backup.Metadata) *BackupInfo {
+	info := &BackupInfo{
+		ID:          metadata.ID,
+		Parent:      metadata.Parent,
+		Created:     metadata.Created,
+		LastSegment: metadata.LastSegment,
+		Blobs:       metadata.Blobs,
+		Files:       len(metadata.Files),
+	}
+	for _, file := range metadata.Files {
+		info.Bytes += file.Size
+	}
+	return info
+}
+
+// Checkpoint makes dir a consistent, openable copy of the data directory as of the
+// call. The active segment is sealed and every live segment and blob file is
+// hard-linked into dir, so a checkpoint takes next to no time or space regardless of
+// the size of the store. Removing a file from either directory leaves the other's
+// copy intact. On a different file system than the data directory the files are copied
+// instead. dir must not exist or be empty, and is opened with the same segment and
+// blob directory options as this instance.
+// Instances opened read-only reject it with an ErrorCodeReadOnly error.
+func (i *Instance) Checkpoint(context context.Context, dir string) error {
+	return i.engine.Checkpoint(context, dir)
+}
@@ -9,11 +9,17 @@ package ignite
 
 import (
 	"context"
+	"io"
+	"math"
+	"sync"
 	"time"
 
 	"github.com/iamNilotpal/ignite/internal/engine"
+	"github.com/iamNilotpal/ignite/pkg/errors"
 	"github.com/iamNilotpal/ignite/pkg/logger"
 	"github.com/iamNilotpal/ignite/pkg/options"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 // Represents an instance of the Ignite key/value data store.
@@ -23,15 +29,58 @@ import (
 // Instance is the primary entry point for interacting with the Ignite store,
 // providing methods for setting, getting, and deleting key-value pairs.
 type Instance struct {
-	engine  *engine.Engine   // The underlying database engine handling read/write operations.
-	options *options.Options // Configuration options applied to this DB instance.
+	engine  *engine.Engine     // The underlying database engine handling read/write operations.
+	options *options.Options   // Configuration options applied to this DB instance.
+	log     *zap.SugaredLogger // Logger shared with the engine, used for the audit log of Reconfigure.
+	level   zap.AtomicLevel    // Minimum level of log, changed by Reconfigure.
+	metrics *instanceMetrics   // Call counts, latencies and errors, written by Collector.
+	mu      sync.Mutex         // Guards options against concurrent Reconfigure calls.
+}
+
+// CacheStats reports the activity of the hot value cache.
+type CacheStats struct {
+	Hits       uint64 `json:"hits"`       // Reads served from the cache.
+	Misses     uint64 `json:"misses"`     // Reads that had to go to a segment file.
+	Evictions  uint64 `json:"evictions"`  // Values evicted to make room for others.
+	Rejections uint64 `json:"rejections"` // Values refused by the admission filter.
+	Entries    uint64 `json:"entries"`    // Number of values currently cached.
+	Bytes      uint64 `json:"bytes"`      // Bytes currently held by the cache.
+	Capacity   uint64 `json:"capacity"`   // Maximum bytes the cache may hold.
+}
+
+// SetCondition restricts a write made with SetWith to keys that do or do not exist.
+type SetCondition uint8
+
+const (
+	// SetAlways writes the key whether it exists or not.
+	SetAlways SetCondition = iota
+
+	// SetIfAbsent only writes the key if it does not exist, like Redis SET NX.
+	SetIfAbsent
+
+	// SetIfExists only writes the key if it already exists, like Redis SET XX.
+	SetIfExists
+)
+
+// SetOptions controls a write made with SetWith.
+type SetOptions struct {
+	TTL       time.Duration // Time after which the key expires; zero means never.
+	Condition SetCondition  // Condition under which the key is written.
+}
+
+// FileCacheStats reports the activity of the sealed segment file handle cache.
+type FileCacheStats struct {
+	Hits      uint64 `json:"hits"`      // Reads that reused an open handle.
+	Misses    uint64 `json:"misses"`    // Reads that had to open the segment file.
+	Evictions uint64 `json:"evictions"` // Handles closed to stay within the limit.
+	Open      uint64 `json:"open"`      // Handles currently open.
+	MaxOpen   uint64 `json:"maxOpen"`   // Maximum number of handles kept open.
 }
 
 // Creates and initializes a new Ignite DB instance.
+// Options are validated first: if any is invalid, NewInstance returns every
+// violation, joined, as described by options.Options.Validate.
 func NewInstance(context context.Context, service string, opts ...options.OptionFunc) (*Instance, error) {
-	// Initialize a logger for the given service.
-	log := logger.New(service)
-
 	// Initialize default options.
 	defaultOpts := options.NewDefaultOptions()
 
@@ -42,20 +91,69 @@ func NewInstance(context context.Context, service string, opts ...options.Option
 		}
 	}
 
+	// Reject invalid options before anything is created on disk.
+	if err := defaultOpts.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Initialize a logger for the given service, at a level Reconfigure can change.
+	// Validate has already checked that the level parses.
+	logLevel, _ := zapcore.ParseLevel(defaultOpts.LogLevel)
+	level := zap.NewAtomicLevelAt(logLevel)
+	log := logger.NewWithLevel(service, level)
+
 	// Create a new internal engine with the initialized logger.
 	eng, err := engine.New(context, &engine.Config{Logger: log, Options: &defaultOpts})
 	if err != nil {
 		return nil, err
 	}
 
-	return &Instance{engine: eng, options: &defaultOpts}, nil
+	// The instance keeps its own copy of the options, which Reconfigure replaces,
+	// while the engine holds on to the options it was opened with.
+	current := defaultOpts.Clone()
+	return &Instance{engine: eng, options: &current, log: log, level: level, metrics: newInstanceMetrics()}, nil
 }
 
 // Set stores a key-value pair in the database.
 // If the key already exists, its value will be updated.
 // The operation is durable and will be written to the append-only log.
-func (i *Instance) Set(context context.Context, key string, value []byte) error {
-	return nil
+// Instances opened read-only reject it with an ErrorCodeReadOnly error.
+func (i *Instance) Set(context context.Context, key string, value []byte) (err error) {
+	defer i.metrics.observe(opSet, time.Now(), &err)
+
+	if key == "" {
+		return errors.NewRequiredFieldError("key")
+	}
+	return i.engine.Set(context, key, value)
+}
+
+// SetReader stores the value read from r under key, reading r until io.EOF. Values
+// at or above the blob threshold (see options.WithBlobThreshold) are streamed into a
+// blob file without ever being held in memory as a whole; shorter values are stored
+// like Set stores them.
+// Instances opened read-only reject it with an ErrorCodeReadOnly error.
+func (i *Instance) SetReader(context context.Context, key string, r io.Reader) (err error) {
+	defer i.metrics.observe(opSetReader, time.Now(), &err)
+
+	if key == "" {
+		return errors.NewRequiredFieldError("key")
+	}
+	return i.engine.SetReader(context, key, r)
+}
+
+// SetFrom stores exactly size bytes read from r under key. Unlike SetReader, the
+// length is known up front, so values below the blob threshold are streamed into a
+// staging file and copied into the segment file from there, without being held in
+// memory and without holding up other writers while r is read. If r fails or ends
+// early, nothing is stored.
+// Instances opened read-only reject it with an ErrorCodeReadOnly error.
+func (i *Instance) SetFrom(context context.Context, key string, r io.Reader, size int64) (err error) {
+	defer i.metrics.observe(opSetFrom, time.Now(), &err)
+
+	if key == "" {
+		return errors.NewRequiredFieldError("key")
+	}
+	return i.engine.SetFrom(context, key, r, size)
 }
 
 // SetX stores a key-value pair with an expiration time.
@@ -63,28 +161,237 @@ func (i *Instance) Set(context context.Context, key string, value []byte) error
 // after the specified duration from the time of setting.
 // If the key already exists, its value and expiry will be updated.
 func (i *Instance) SetX(context context.Context, key string, value []byte, expiry time.Duration) error {
-	return nil
+	if expiry <= 0 {
+		return errors.NewFieldRangeError("expiry", expiry, 1, time.Duration(math.MaxInt64))
+	}
+	_, err := i.SetWith(context, key, value, SetOptions{TTL: expiry})
+	return err
+}
+
+// SetWith stores a key-value pair if opts.Condition holds, checking the condition
+// atomically with the write, and makes it expire after opts.TTL unless the TTL is
+// zero. It reports whether the value was stored. Expired keys count as absent.
+func (i *Instance) SetWith(context context.Context, key string, value []byte, opts SetOptions) (_ bool, err error) {
+	defer i.metrics.observe(opSetWith, time.Now(), &err)
+
+	if key == "" {
+		return false, errors.NewRequiredFieldError("key")
+	}
+
+	cond, err := opts.Condition.engineCondition()
+	if err != nil {
+		return false, err
+	}
+	return i.engine.SetWith(context, key, value, opts.TTL, cond)
+}
+
+// SetFromWith stores exactly size bytes read from r under key like SetFrom, if
+// opts.Condition holds, and makes it expire after opts.TTL unless the TTL is zero. r
+// is read in full before the condition is checked, atomically with the write. It
+// reports whether the value was stored. Expired keys count as absent.
+func (i *Instance) SetFromWith(context context.Context, key string, r io.Reader, size int64, opts SetOptions) (_ bool, err error) {
+	defer i.metrics.observe(opSetFromWith, time.Now(), &err)
+
+	if key == "" {
+		return false, errors.NewRequiredFieldError("key")
+	}
+
+	cond, err := opts.Condition.engineCondition()
+	if err != nil {
+		return false, err
+	}
+	return i.engine.SetFromWith(context, key, r, size, opts.TTL, cond)
+}
+
+// engineCondition maps a SetCondition onto the engine's condition.
+func (c SetCondition) engineCondition() (engine.Condition, error) {
+	switch c {
+	case SetAlways:
+		return engine.Always, nil
+	case SetIfAbsent:
+		return engine.IfAbsent, nil
+	case SetIfExists:
+		return engine.IfExists, nil
+	}
+	return 0, errors.NewValidationError(
+		nil, errors.ErrorCodeInvalidInput, "unknown set condition",
+	).WithField("condition").WithRule("enum").WithProvided(c)
+}
+
+// Expire makes an existing key expire after ttl, replacing any expiration time it
+// had. A ttl of zero or less deletes the key right away. It returns a key-not-found
+// error if the key does not exist.
+func (i *Instance) Expire(context context.Context, key string, ttl time.Duration) (err error) {
+	defer i.metrics.observe(opExpire, time.Now(), &err)
+
+	if key == "" {
+		return errors.NewRequiredFieldError("key")
+	}
+	return i.engine.Expire(context, key, ttl)
+}
+
+// TTL returns how long a key has left before it expires, or zero if it never
+// expires. It returns a key-not-found error if the key does not exist.
+func (i *Instance) TTL(context context.Context, key string) (_ time.Duration, err error) {
+	defer i.metrics.observe(opTTL, time.Now(), &err)
+
+	if key == "" {
+		return 0, errors.NewRequiredFieldError("key")
+	}
+	return i.engine.TTL(context, key)
+}
+
+// Exists reports whether a key exists, without reading its value.
+func (i *Instance) Exists(context context.Context, key string) (_ bool, err error) {
+	defer i.metrics.observe(opExists, time.Now(), &err)
+
+	if key == "" {
+		return false, errors.NewRequiredFieldError("key")
+	}
+	return i.engine.Exists(context, key)
+}
+
+// Scan returns, in sorted order, up to limit keys that sort after the key after and
+// for which match returns true; a nil match selects every key. Start with an empty
+// after, and pass the last key of each page to get the next one until a page comes
+// back short. Keys that exist throughout are returned exactly once, however the store
+// changes in between. Every page takes a pass over the in-memory index.
+func (i *Instance) Scan(context context.Context, after string, limit int, match func(key string) bool) (_ []string, err error) {
+	defer i.metrics.observe(opScan, time.Now(), &err)
+
+	return i.engine.Scan(context, after, limit, match)
 }
 
 // Get retrieves the value associated with the given key.
-func (i *Instance) Get(context context.Context, key string) ([]byte, error) {
-	return nil, nil
+func (i *Instance) Get(context context.Context, key string) (_ []byte, err error) {
+	defer i.metrics.observe(opGet, time.Now(), &err)
+
+	if key == "" {
+		return nil, errors.NewRequiredFieldError("key")
+	}
+	return i.engine.Get(context, key)
+}
+
+// GetTo writes the value associated with the given key to w and returns the number
+// of bytes written. The value is streamed from disk through a pooled buffer, with its
+// checksum verified along the way, rather than being returned as one slice. Damage is
+// only detected once the end of the value is reached, after the bytes before it have
+// been written, so the output of a failed call must be discarded.
+func (i *Instance) GetTo(context context.Context, key string, w io.Writer) (_ int64, err error) {
+	defer i.metrics.observe(opGetTo, time.Now(), &err)
+
+	if key == "" {
+		return 0, errors.NewRequiredFieldError("key")
+	}
+	return i.engine.GetTo(context, key, w)
+}
+
+// GetWriter writes the value associated with the given key to w and returns the
+// number of bytes written. It is the counterpart of SetReader and behaves exactly
+// like GetTo.
+func (i *Instance) GetWriter(context context.Context, key string, w io.Writer) (int64, error) {
+	return i.GetTo(context, key, w)
+}
+
+// GetView calls fn with the value associated with the given key, avoiding a copy
+// when the instance is opened with options.ReadModeMmap and the value lives in a
+// sealed segment. The slice passed to fn must not be modified or retained after
+// fn returns; copy it if it is needed later. Errors returned by fn are passed back
+// to the caller unchanged.
+func (i *Instance) GetView(context context.Context, key string, fn func(value []byte) error) (err error) {
+	defer i.metrics.observe(opGetView, time.Now(), &err)
+
+	if key == "" {
+		return errors.NewRequiredFieldError("key")
+	}
+	return i.engine.GetView(context, key, fn)
+}
+
+// Remove deletes a key-value pair like Delete and reports whether the key existed.
+func (i *Instance) Remove(context context.Context, key string) (_ bool, err error) {
+	defer i.metrics.observe(opRemove, time.Now(), &err)
+
+	if key == "" {
+		return false, errors.NewRequiredFieldError("key")
+	}
+	return i.engine.Remove(context, key)
 }
 
 // Delete removes a key-value pair from the database.
 // The operation marks the key as deleted and will eventually be
 // removed during compaction.
-func (i *Instance) Delete(context context.Context, key string) error {
-	return nil
+// Instances opened read-only reject it with an ErrorCodeReadOnly error.
+func (i *Instance) Delete(context context.Context, key string) (err error) {
+	defer i.metrics.observe(opDelete, time.Now(), &err)
+
+	if key == "" {
+		return errors.NewRequiredFieldError("key")
+	}
+	return i.engine.Delete(context, key)
+}
+
+// Refresh picks up data written by the process that owns the data directory since
+// this instance was opened with options.WithReadOnly, or since the previous Refresh.
+// Segments sealed or created by the writer become readable, and keys it has updated
+// or deleted are reflected in subsequent reads. On a writable instance Refresh does
+// nothing.
+func (i *Instance) Refresh(context context.Context) error {
+	return i.engine.Refresh(context)
+}
+
+// Snapshot returns a read-only, point-in-time view of the store. Reads through the
+// snapshot see every key as it was when Snapshot was called, while writes to the
+// instance continue unaffected, so several keys can be read consistently with each
+// other. The snapshot must be released with Snapshot.Release; until then, data it may
+// still read is kept on disk even after it is overwritten or compacted away.
+func (i *Instance) Snapshot(context context.Context) (*Snapshot, error) {
+	snapshot, err := i.engine.Snapshot(context)
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{snapshot: snapshot}, nil
+}
+
+// Compact runs compaction now instead of waiting for the compaction interval, and
+// returns what the run did. It rewrites the sealed segments that are mostly dead
+// space and, when encryption is enabled, every segment and blob file not yet wrapped
+// by the key provider's current master key, which completes a key rotation. Reads and
+// writes continue while it runs. If a run is already in progress, Compact waits for
+// it to finish and then runs again.
+// Instances opened read-only reject it with an ErrorCodeReadOnly error.
+func (i *Instance) Compact(context context.Context) (CompactionRun, error) {
+	run, err := i.engine.Compact(context)
+	return CompactionRun{
+		Started:        run.Started,
+		Duration:       run.Duration,
+		Segments:       run.Segments,
+		ReclaimedBytes: run.ReclaimedBytes,
+	}, err
+}
+
+// CacheStats returns a snapshot of the hot value cache's hit, miss and
+// eviction counters. All values are zero when the cache is disabled.
+func (i *Instance) CacheStats() CacheStats {
+	return newCacheStats(i.engine.CacheStats())
+}
+
+// FileCacheStats returns a snapshot of the sealed segment file handle cache's
+// hit, miss and eviction counters.
+func (i *Instance) FileCacheStats() FileCacheStats {
+	stats := i.engine.FileCacheStats()
+	return FileCacheStats{
+		Hits:      stats.Hits,
+		Misses:    stats.Misses,
+		Evictions: stats.Evictions,
+		Open:      stats.Open,
+		MaxOpen:   stats.MaxOpen,
+	}
 }
 
 // Close gracefully shuts down the Ignite DB instance, releasing all
 // associated resources, flushing any pending writes, and ensuring data
-// durability.
+// durability. A compaction run in progress is cancelled; the segments it was
+// rewriting stay in place until the next run.
 func (i *Instance) Close(context context.Context) error {
-	// TODO: Implement actual shutdown logic here.
-	// - Flushing in-memory buffers to disk.
-	// - Closing open file handles in the engine.
-	// - Waiting for any background goroutines (like compaction) to finish or be cancelled.
 	return i.engine.Close()
 }
@@ -0,0 +1,66 @@
+package ignite
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/iamNilotpal/ignite/pkg/errors"
+	"github.com/iamNilotpal/ignite/pkg/options"
+)
+
+// openInstance opens an instance on dataDir with quiet logging and opts.
+func openInstance(t *testing.T, dataDir string, opts ...options.OptionFunc) (*Instance, error) {
+	t.Helper()
+	return NewInstance(context.Background(), "ignite-test",
+		append([]options.OptionFunc{options.WithDataDir(dataDir), options.WithLogLevel("error")}, opts...)...)
+}
+
+func TestNewInstanceRejectsInvalidOptions(t *testing.T) {
+	dataDir := filepath.Join(t.TempDir(), "data")
+	_, err := openInstance(t, dataDir, options.WithSegmentSize(1), options.WithCacheShards(0))
+	if !errors.IsValidationError(err) {
+		t.Fatalf("NewInstance() error = %v, want a validation error", err)
+	}
+	var fields []string
+	for _, err := range err.(interface{ Unwrap() []error }).Unwrap() {
+		if ve, ok := errors.AsValidationError(err); ok {
+			fields = append(fields, ve.Field())
+		}
+	}
+	if want := []string{"segmentOptions.maxSegmentSize", "cacheOptions.shards"}; !slices.Equal(fields, want) {
+		t.Fatalf("NewInstance() violations = %v, want %v", fields, want)
+	}
+	if _, err := os.Stat(dataDir); !os.IsNotExist(err) {
+		t.Fatalf("NewInstance() created the data directory for invalid options: %v", err)
+	}
+}
+
+func TestEncryptedStoreWithoutKeyProvider(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "keys")
+	if err := os.WriteFile(keyFile, []byte("k1:"+strings.Repeat("ab", 32)+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	dataDir := t.TempDir()
+
+	instance, err := openInstance(t, dataDir, options.WithKeyProvider(options.NewFileKeyProvider(keyFile)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := instance.Set(context.Background(), "k", []byte("secret")); err != nil {
+		t.Fatal(err)
+	}
+	if err := instance.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// A nil provider is a valid option, but the segments written under the key
+	// cannot be read without it.
+	_, err = openInstance(t, dataDir, options.WithKeyProvider(nil))
+	if code := errors.GetErrorCode(err); code != errors.ErrorCodeEncryptionKeyUnavailable {
+		t.Fatalf("NewInstance() without a key provider error = %v (%s), want %s", err, code, errors.ErrorCodeEncryptionKeyUnavailable)
+	}
+}
@@ -0,0 +1,183 @@
+package ignite
+
+import (
+	"bytes"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/iamNilotpal/ignite/internal/metrics"
+	"github.com/iamNilotpal/ignite/pkg/errors"
+)
+
+// operation identifies an Instance method whose calls are counted and timed.
+type operation int
+
+const (
+	opGet operation = iota
+	opGetTo
+	opGetView
+	opSet
+	opSetWith
+	opSetReader
+	opSetFrom
+	opSetFromWith
+	opDelete
+	opRemove
+	opExists
+	opExpire
+	opTTL
+	opScan
+	numOperations
+)
+
+// operationNames are the values of the operation label, indexed by operation.
+var operationNames = [numOperations]string{
+	opGet:         "get",
+	opGetTo:       "get_to",
+	opGetView:     "get_view",
+	opSet:         "set",
+	opSetWith:     "set_with",
+	opSetReader:   "set_reader",
+	opSetFrom:     "set_from",
+	opSetFromWith: "set_from_with",
+	opDelete:      "delete",
+	opRemove:      "remove",
+	opExists:      "exists",
+	opExpire:      "expire",
+	opTTL:         "ttl",
+	opScan:        "scan",
+}
+
+// instanceMetrics holds the instruments an Instance updates on every call.
+type instanceMetrics struct {
+	latency [numOperations]*metrics.Histogram // Duration of every call, by operation.
+	errors  map[errors.ErrorCode]uint64       // Number of failed calls, by error code.
+	mu      sync.Mutex                        // Guards errors.
+}
+
+// Collector writes the metrics of an Instance in the Prometheus text exposition
+// format, without depending on the Prometheus client library. It implements
+// http.Handler, so embedding applications can serve it on a /metrics route of their
+// own; the ignite daemon serves it on its HTTP API.
+//
+// The metrics cover calls and their latency by operation, failed calls by error
+// code, bytes written and read, fsync latency, the active segment, live segments and
+// the share of their bytes that compaction could reclaim, the index, the value cache
+// and compaction. Gauges are sampled when the metrics are written.
+type Collector struct {
+	instance *Instance
+}
+
+// newInstanceMetrics creates the instruments of an Instance.
+func newInstanceMetrics() *instanceMetrics {
+	m := &instanceMetrics{errors: make(map[errors.ErrorCode]uint64)}
+	for op := range m.latency {
+		m.latency[op] = metrics.NewHistogram(metrics.LatencyBuckets)
+	}
+	return m
+}
+
+// observe records a call to op that started at start and failed if *err is not nil.
+// It is meant to be deferred with a named error result:
+//
+//	defer i.metrics.observe(opGet, time.Now(), &err)
+func (m *instanceMetrics) observe(op operation, start time.Time, err *error) {
+	m.latency[op].Since(start)
+	if *err == nil {
+		return
+	}
+
+	code := errors.GetErrorCode(*err)
+	m.mu.Lock()
+	m.errors[code]++
+	m.mu.Unlock()
+}
+
+// NewCollector creates a Collector for the metrics of instance.
+func NewCollector(instance *Instance) *Collector {
+	return &Collector{instance: instance}
+}
+
+// WriteTo writes every metric to w in the Prometheus text exposition format.
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	m := c.instance.metrics
+	stats := c.instance.engine.Stats()
+	out := metrics.NewWriter(w)
+
+	latencies := make([]metrics.HistogramSnapshot, numOperations)
+	for op := range latencies {
+		latencies[op] = m.latency[op].Snapshot()
+	}
+
+	out.Family("ignite_operations_total", "Calls to the store, by operation, including failed calls.", metrics.TypeCounter)
+	for op, latency := range latencies {
+		out.Sample("ignite_operations_total", float64(latency.Count), metrics.Label{Name: "operation", Value: operationNames[op]})
+	}
+
+	out.Family("ignite_operation_duration_seconds", "Duration of calls to the store, by operation.", metrics.TypeHistogram)
+	for op, latency := range latencies {
+		out.Histogram("ignite_operation_duration_seconds", latency, metrics.Label{Name: "operation", Value: operationNames[op]})
+	}
+
+	m.mu.Lock()
+	failures := maps.Clone(m.errors)
+	m.mu.Unlock()
+
+	out.Family("ignite_errors_total", "Failed calls to the store, by error code. Reads of missing keys count as INDEX_KEY_NOT_FOUND.", metrics.TypeCounter)
+	for _, code := range slices.Sorted(maps.Keys(failures)) {
+		out.Sample("ignite_errors_total", float64(failures[code]), metrics.Label{Name: "code", Value: string(code)})
+	}
+
+	out.Counter("ignite_written_bytes_total", "Bytes written to segment and blob files.", stats.IO.BytesWritten)
+	out.Counter("ignite_read_bytes_total", "Bytes read from segment and blob files, including memory-mapped reads.", stats.IO.BytesRead)
+
+	out.Family("ignite_fsync_duration_seconds", "Duration of fsyncs of segment and blob files.", metrics.TypeHistogram)
+	out.Histogram("ignite_fsync_duration_seconds", stats.IO.Fsync)
+
+	var size, dead int64
+	for _, segment := range stats.Segments {
+		size += segment.Size
+		dead += segment.DeadBytes()
+	}
+	var deadRatio float64
+	if size > 0 {
+		deadRatio = float64(dead) / float64(size)
+	}
+
+	out.Gauge("ignite_active_segment_id", "ID of the segment new entries are appended to.", float64(stats.ActiveSegmentID))
+	out.Gauge("ignite_active_segment_size_bytes", "Size of the active segment.", float64(stats.ActiveSegmentSize))
+	out.Gauge("ignite_segments", "Number of live segment files.", float64(len(stats.Segments)))
+	out.Gauge("ignite_segments_size_bytes", "Total size of the live segment files.", float64(size))
+	out.Gauge("ignite_segments_dead_bytes", "Bytes of the live segment files not referenced by the index.", float64(dead))
+	out.Gauge("ignite_segments_dead_ratio", "Share of the bytes of the live segment files not referenced by the index, from 0 to 1.", deadRatio)
+
+	out.Gauge("ignite_index_keys", "Number of keys in the index, including expired keys not yet dropped.", float64(stats.Keys))
+	out.Gauge("ignite_index_memory_bytes", "Estimated memory held by the index.", float64(stats.IndexMemory))
+
+	out.Counter("ignite_compaction_runs_total", "Completed compaction runs.", stats.Compaction.Runs)
+	out.Counter("ignite_compaction_reclaimed_bytes_total", "Bytes of disk space freed by compaction.", stats.Compaction.ReclaimedBytes)
+
+	out.Counter("ignite_cache_hits_total", "Reads served from the value cache.", stats.Cache.Hits)
+	out.Counter("ignite_cache_misses_total", "Reads that missed the value cache.", stats.Cache.Misses)
+	out.Counter("ignite_cache_evictions_total", "Values evicted from the value cache.", stats.Cache.Evictions)
+	out.Gauge("ignite_cache_size_bytes", "Bytes held by the value cache.", float64(stats.Cache.Bytes))
+	out.Gauge("ignite_cache_capacity_bytes", "Maximum bytes the value cache may hold.", float64(stats.Cache.Capacity))
+
+	return out.Flush()
+}
+
+// ServeHTTP implements http.Handler, answering every request with the metrics.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	if _, err := c.WriteTo(&buf); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", metrics.ContentType)
+	w.Write(buf.Bytes())
+}
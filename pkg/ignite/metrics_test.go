@@ -0,0 +1,180 @@
+package ignite
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/iamNilotpal/ignite/internal/metrics"
+)
+
+// scrape returns the samples the collector of instance writes, keyed by metric name
+// and labels as they appear in the exposition, along with the type of every family.
+func scrape(t *testing.T, instance *Instance) (map[string]float64, map[string]string) {
+	t.Helper()
+
+	var b strings.Builder
+	if _, err := NewCollector(instance).WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	samples, families := make(map[string]float64), make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+		if typ, ok := strings.CutPrefix(line, "# TYPE "); ok {
+			name, kind, _ := strings.Cut(typ, " ")
+			families[name] = kind
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		i := strings.LastIndexByte(line, ' ')
+		value, err := strconv.ParseFloat(line[i+1:], 64)
+		if err != nil {
+			t.Fatalf("malformed sample %q", line)
+		}
+		samples[line[:i]] = value
+	}
+	return samples, families
+}
+
+// sealActive seals the active segment of instance by taking a backup, so that
+// compaction can pick it.
+func sealActive(t *testing.T, instance *Instance) {
+	t.Helper()
+	if _, err := instance.BackupTo(context.Background(), t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCollectorReportsActivity(t *testing.T) {
+	instance, err := openInstance(t, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer instance.Close(context.Background())
+
+	ctx := context.Background()
+	for i := range 200 {
+		if err := instance.Set(ctx, fmt.Sprintf("key-%d", i%10), []byte(strings.Repeat("v", 100))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	instance.Get(ctx, "key-1")
+	instance.Get(ctx, "missing")
+	instance.Get(ctx, "missing")
+
+	sealActive(t, instance)
+	run, err := instance.Compact(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.ReclaimedBytes == 0 {
+		t.Fatalf("Compact() = %+v, want bytes reclaimed", run)
+	}
+
+	samples, _ := scrape(t, instance)
+	for name, want := range map[string]float64{
+		`ignite_operations_total{operation="set"}`:                 200,
+		`ignite_operations_total{operation="get"}`:                 3,
+		`ignite_operation_duration_seconds_count{operation="set"}`: 200,
+		`ignite_errors_total{code="INDEX_KEY_NOT_FOUND"}`:          2,
+		`ignite_index_keys`:                       10,
+		`ignite_compaction_runs_total`:            1,
+		`ignite_compaction_reclaimed_bytes_total`: float64(run.ReclaimedBytes),
+	} {
+		if got, ok := samples[name]; !ok || got != want {
+			t.Errorf("%s = %v (present %t), want %v", name, got, ok, want)
+		}
+	}
+	if samples["ignite_written_bytes_total"] < 200*100 {
+		t.Errorf("ignite_written_bytes_total = %v, want at least the values written", samples["ignite_written_bytes_total"])
+	}
+	if samples["ignite_fsync_duration_seconds_count"] == 0 {
+		t.Error("no fsync recorded, although sealing and compaction sync")
+	}
+	if ratio := samples["ignite_segments_dead_ratio"]; ratio < 0 || ratio > 1 {
+		t.Errorf("ignite_segments_dead_ratio = %v, want a share from 0 to 1", ratio)
+	}
+}
+
+func TestCollectorServesHTTP(t *testing.T) {
+	instance, err := openInstance(t, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer instance.Close(context.Background())
+
+	rec := httptest.NewRecorder()
+	NewCollector(instance).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if rec.Code != 200 || rec.Header().Get("Content-Type") != metrics.ContentType {
+		t.Fatalf("GET /metrics = %d with content type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(rec.Body.String(), "# TYPE ignite_operations_total counter") {
+		t.Fatalf("GET /metrics body lacks the operation counter:\n%s", rec.Body)
+	}
+}
+
+// TestReadmeListsMetrics keeps the metrics table of the README in step with what the
+// collector writes.
+func TestReadmeListsMetrics(t *testing.T) {
+	readme, err := os.ReadFile("../../README.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, section, _ := strings.Cut(string(readme), "\n## Metrics\n")
+	section, _, _ = strings.Cut(section, "\n### ")
+
+	// Every metric named in the table, with its labels dropped; a trailing * is a
+	// prefix.
+	documented := make(map[string]string)
+	for _, row := range regexp.MustCompile("(?m)^\\| (`ignite.*?) \\| (.*?) \\|").FindAllStringSubmatch(section, -1) {
+		for _, name := range regexp.MustCompile("`(ignite_[a-z_*]+)").FindAllStringSubmatch(row[1], -1) {
+			documented[name[1]] = row[2]
+		}
+	}
+	if len(documented) == 0 {
+		t.Fatal("no metrics found in the README")
+	}
+
+	instance, err := openInstance(t, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer instance.Close(context.Background())
+	_, families := scrape(t, instance)
+
+	listed := func(family string) (string, bool) {
+		if typ, ok := documented[family]; ok {
+			return typ, true
+		}
+		for name, typ := range documented {
+			if prefix, ok := strings.CutSuffix(name, "*"); ok && strings.HasPrefix(family, prefix) {
+				return typ, true
+			}
+		}
+		return "", false
+	}
+	for family, kind := range families {
+		typ, ok := listed(family)
+		if !ok {
+			t.Errorf("%s is not in the README", family)
+		} else if !strings.Contains(typ, kind) {
+			t.Errorf("%s is a %s, but the README lists it as %s", family, kind, typ)
+		}
+	}
+	for name := range documented {
+		if strings.HasSuffix(name, "*") {
+			continue
+		}
+		if _, ok := families[name]; !ok {
+			t.Errorf("the README lists %s, which is not written", name)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package ignite
+
+import (
+	stdErrors "errors"
+	"reflect"
+
+	"github.com/iamNilotpal/ignite/pkg/errors"
+	"github.com/iamNilotpal/ignite/pkg/options"
+	"go.uber.org/zap/zapcore"
+)
+
+// runtimeSettings lists the settings Reconfigure may change, by JSON path. The rest
+// shape files on disk or the structures built when the store is opened, and only
+// take effect when it is opened again.
+var runtimeSettings = map[string]bool{
+	"compactInterval":      true,
+	"compactRateLimit":     true,
+	"cacheOptions.size":    true,
+	"syncOptions.policy":   true,
+	"syncOptions.interval": true,
+	"logLevel":             true,
+}
+
+// Reconfigure changes settings of the running instance. The options are applied to a
+// copy of the current ones, which must pass options.Options.Validate. Only these
+// settings may differ from the current values:
+//
+//   - compactInterval: the next automatic compaction run is scheduled a full new
+//     interval from now.
+//   - compactRateLimit: applies at once, including to a compaction run in progress.
+//   - cacheOptions.size: the value cache is replaced by an empty one of the new size,
+//     or disabled when the size is zero.
+//   - syncOptions.policy and syncOptions.interval: apply from the next write; under
+//     the interval policy the next background sync is a full interval from now.
+//   - logLevel: applies at once to every message logged by the instance.
+//
+// Changing any other setting, or the key provider, fails with a ValidationError for
+// each such field, and nothing is changed: a reconfiguration applies completely or
+// not at all. Every change that is applied is logged with its old and new value, and
+// every rejected attempt is logged with its errors.
+func (i *Instance) Reconfigure(opts ...options.OptionFunc) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	next := i.options.Clone()
+	for _, opt := range opts {
+		opt(&next)
+	}
+
+	if err := next.Validate(); err != nil {
+		i.log.Warnw("Configuration change rejected", "fields", rejectedFields(err), "error", err)
+		return err
+	}
+
+	changes := options.Diff(i.options, &next)
+
+	var errs []error
+	for _, change := range changes {
+		if !runtimeSettings[change.Field] {
+			errs = append(errs, errors.NewConfigurationValidationError(change.Field, "cannot be changed while the store is open").
+				WithRule("immutable").WithProvided(change.New).WithExpected(change.Old))
+		}
+	}
+	if !sameProvider(i.options.KeyProvider, next.KeyProvider) {
+		errs = append(errs, errors.NewConfigurationValidationError("keyProvider", "cannot be changed while the store is open").
+			WithRule("immutable"))
+	}
+	if err := stdErrors.Join(errs...); err != nil {
+		i.log.Warnw("Configuration change rejected", "fields", rejectedFields(err), "error", err)
+		return err
+	}
+
+	// Validate has already checked that the level parses. The more verbose of the
+	// old and new levels is in effect while the changes are logged, so that lowering
+	// the verbosity does not hide its own audit entry.
+	level, _ := zapcore.ParseLevel(next.LogLevel)
+	if level < i.level.Level() {
+		i.level.SetLevel(level)
+	}
+
+	syncChanged := false
+	for _, change := range changes {
+		switch change.Field {
+		case "compactInterval":
+			i.engine.SetCompactInterval(next.CompactInterval)
+		case "compactRateLimit":
+			i.engine.SetCompactRateLimit(next.CompactRateLimit)
+		case "cacheOptions.size":
+			i.engine.SetCacheSize(next.CacheOptions.Size)
+		case "syncOptions.policy", "syncOptions.interval":
+			syncChanged = true
+		}
+	}
+	if syncChanged {
+		i.engine.SetSyncPolicy(next.SyncOptions.Policy, next.SyncOptions.Interval)
+	}
+	i.options = &next
+
+	for _, change := range changes {
+		i.log.Infow("Configuration changed", "field", change.Field, "old", change.Old, "new", change.New)
+	}
+	i.level.SetLevel(level)
+	return nil
+}
+
+// Options returns a copy of the options the instance is running with, including the
+// changes made by Reconfigure.
+func (i *Instance) Options() options.Options {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.options.Clone()
+}
+
+// rejectedFields lists the fields named by the validation errors joined in err, for
+// the log entry of a rejected reconfiguration.
+func rejectedFields(err error) []string {
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+
+	var fields []string
+	for _, err := range errs {
+		if ve, ok := errors.AsValidationError(err); ok {
+			fields = append(fields, ve.Field())
+		}
+	}
+	return fields
+}
+
+// sameProvider reports whether two key providers are the same one. Providers whose
+// type cannot be compared, such as functions, are the same only when they share a
+// type and an underlying pointer.
+func sameProvider(a, b options.KeyProvider) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	typ := reflect.TypeOf(a)
+	if typ != reflect.TypeOf(b) {
+		return false
+	}
+	if typ.Comparable() {
+		return a == b
+	}
+
+	switch va, vb := reflect.ValueOf(a), reflect.ValueOf(b); va.Kind() {
+	case reflect.Func, reflect.Map, reflect.Slice:
+		return va.Pointer() == vb.Pointer()
+	default:
+		return false
+	}
+}
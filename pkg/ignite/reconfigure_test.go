@@ -0,0 +1,77 @@
+package ignite
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/iamNilotpal/ignite/pkg/errors"
+	"github.com/iamNilotpal/ignite/pkg/options"
+)
+
+func TestReconfigureAppliesRuntimeSettings(t *testing.T) {
+	instance, err := openInstance(t, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer instance.Close(context.Background())
+
+	err = instance.Reconfigure(
+		options.WithCompactInterval(2*time.Hour), options.WithCompactRateLimit(1<<20),
+		options.WithCacheSize(1<<20), options.WithSyncPolicy(options.SyncAlways),
+		options.WithLogLevel("warn"),
+	)
+	if err != nil {
+		t.Fatalf("Reconfigure() error = %v", err)
+	}
+
+	o := instance.Options()
+	if o.CompactInterval != 2*time.Hour || o.CompactRateLimit != 1<<20 || o.CacheOptions.Size != 1<<20 ||
+		o.SyncOptions.Policy != options.SyncAlways || o.LogLevel != "warn" {
+		t.Fatalf("Options() after Reconfigure = %+v", o)
+	}
+
+	// The sync policy reaches the storage: every write is now synced.
+	before := instance.engine.Stats().IO.Fsync.Count
+	for i := range 5 {
+		if err := instance.Set(context.Background(), fmt.Sprintf("key-%d", i), []byte("v")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := instance.engine.Stats().IO.Fsync.Count - before; got < 5 {
+		t.Fatalf("%d fsyncs for 5 writes after switching to the always policy", got)
+	}
+
+	// And the cache was resized.
+	instance.Get(context.Background(), "key-0")
+	instance.Get(context.Background(), "key-0")
+	if hits := instance.Stats().Cache.Hits; hits == 0 {
+		t.Fatal("the cache enabled by Reconfigure served no hits")
+	}
+}
+
+func TestReconfigureRejectsImmutableSettings(t *testing.T) {
+	instance, err := openInstance(t, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer instance.Close(context.Background())
+
+	err = instance.Reconfigure(options.WithCacheSize(1<<20), options.WithSegmentPrefix("other"))
+	ve, ok := errors.AsValidationError(err)
+	if !ok || ve.Field() != "segmentOptions.prefix" || ve.Rule() != "immutable" {
+		t.Fatalf("Reconfigure() error = %v, want segmentOptions.prefix rejected as immutable", err)
+	}
+	if size := instance.Options().CacheOptions.Size; size != options.DefaultCacheSize {
+		t.Fatalf("a rejected Reconfigure changed the cache size to %d", size)
+	}
+
+	err = instance.Reconfigure(options.WithSyncPolicy(options.SyncInterval), options.WithSyncInterval(0))
+	if ve, ok := errors.AsValidationError(err); !ok || ve.Field() != "syncOptions.interval" {
+		t.Fatalf("Reconfigure() with a zero sync interval error = %v", err)
+	}
+	if policy := instance.Options().SyncOptions.Policy; policy != options.DefaultSyncPolicy {
+		t.Fatalf("a rejected Reconfigure changed the sync policy to %q", policy)
+	}
+}
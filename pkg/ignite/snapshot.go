@@ -0,0 +1,51 @@
+package ignite
+
+import (
+	"context"
+	"io"
+
+	"github.com/iamNilotpal/ignite/internal/engine"
+	"github.com/iamNilotpal/ignite/pkg/errors"
+)
+
+// Snapshot is a read-only, point-in-time view of an Ignite DB instance, created by
+// Instance.Snapshot. It is safe for concurrent use. Every snapshot must be released
+// once it is no longer needed.
+type Snapshot struct {
+	snapshot *engine.Snapshot // The underlying engine snapshot.
+}
+
+// Get retrieves the value the given key had when the snapshot was taken.
+func (s *Snapshot) Get(context context.Context, key string) ([]byte, error) {
+	if key == "" {
+		return nil, errors.NewRequiredFieldError("key")
+	}
+	return s.snapshot.Get(context, key)
+}
+
+// GetTo writes the value the given key had when the snapshot was taken to w and
+// returns the number of bytes written. It streams the value like Instance.GetTo.
+func (s *Snapshot) GetTo(context context.Context, key string, w io.Writer) (int64, error) {
+	if key == "" {
+		return 0, errors.NewRequiredFieldError("key")
+	}
+	return s.snapshot.GetTo(context, key, w)
+}
+
+// Keys returns every key that existed when the snapshot was taken, in sorted order.
+func (s *Snapshot) Keys(context context.Context) ([]string, error) {
+	return s.snapshot.Keys(context)
+}
+
+// Iterate calls fn with every key and value that existed when the snapshot was
+// taken, in key order. The value passed to fn is owned by fn. Iteration stops at the
+// first error, which is returned, or when the context is done.
+func (s *Snapshot) Iterate(context context.Context, fn func(key string, value []byte) error) error {
+	return s.snapshot.Iterate(context, fn)
+}
+
+// Release ends the snapshot and lets data kept only for it be removed. Reads through
+// a released snapshot fail. Calling Release more than once is harmless.
+func (s *Snapshot) Release() error {
+	return s.snapshot.Release()
+}
@@ -0,0 +1,114 @@
+package ignite
+
+import (
+	"time"
+
+	"github.com/iamNilotpal/ignite/internal/cache"
+)
+
+// Stats is a point-in-time snapshot of the internal state of an Instance, returned by
+// Instance.Stats. Its parts are gathered one after another, so under concurrent
+// writes they may be a few operations apart.
+type Stats struct {
+	Keys                int             `json:"keys"`                // Keys in the index, including expired keys not yet dropped.
+	Expiring            int             `json:"expiring"`            // Keys among them that have an expiry time.
+	IndexMemory         int64           `json:"indexMemory"`         // Estimated bytes of memory held by the index.
+	ActiveSegmentID     uint64          `json:"activeSegmentId"`     // Segment new entries are appended to.
+	ActiveSegmentOffset int64           `json:"activeSegmentOffset"` // Offset the next entry is appended at.
+	LiveBytes           int64           `json:"liveBytes"`           // Bytes of the entries the index points at, over all segments.
+	DeadBytes           int64           `json:"deadBytes"`           // Bytes of all segments that compaction could reclaim.
+	Segments            []SegmentStats  `json:"segments"`            // Every live segment, ordered by ascending ID.
+	Cache               CacheStats      `json:"cache"`               // Hot value cache counters.
+	FileCache           FileCacheStats  `json:"fileCache"`           // Segment file handle cache counters.
+	Compaction          CompactionStats `json:"compaction"`          // Compaction counters and recent runs.
+	Uptime              time.Duration   `json:"uptime"`              // Time since the instance was opened.
+	RecoveryDuration    time.Duration   `json:"recoveryDuration"`    // How long the last rebuild of the index took.
+}
+
+// SegmentStats describes one live segment file and how much of it is still used.
+type SegmentStats struct {
+	ID        uint64 `json:"id"`        // Segment file ID.
+	File      string `json:"file"`      // Filename within the segment directory.
+	Size      int64  `json:"size"`      // Size of the file in bytes.
+	LiveBytes int64  `json:"liveBytes"` // Bytes of the entries the index points at.
+	DeadBytes int64  `json:"deadBytes"` // Bytes of overwritten values, tombstones and expired keys.
+}
+
+// CompactionStats reports the work compaction has done since the instance was opened.
+type CompactionStats struct {
+	Runs           uint64          `json:"runs"`           // Number of completed runs.
+	ReclaimedBytes uint64          `json:"reclaimedBytes"` // Bytes of disk space freed by those runs.
+	History        []CompactionRun `json:"history"`        // Most recent runs, oldest first.
+}
+
+// CompactionRun describes one completed compaction run.
+type CompactionRun struct {
+	Started        time.Time     `json:"started"`        // Time the run started.
+	Duration       time.Duration `json:"duration"`       // How long the run took.
+	Segments       int           `json:"segments"`       // Number of segments compacted.
+	ReclaimedBytes uint64        `json:"reclaimedBytes"` // Bytes of disk space freed.
+}
+
+// Stats returns a snapshot of the internal state of the instance: the index, every
+// live segment with the bytes still in use and the bytes compaction could reclaim,
+// the active segment, the caches, compaction, the uptime and how long recovery took.
+//
+// Stats reads in-memory state only, apart from measuring the segment files whose size
+// is not known in memory, so it is cheap enough to call periodically.
+func (i *Instance) Stats() Stats {
+	stats := i.engine.Stats()
+
+	result := Stats{
+		Keys:                stats.Keys,
+		Expiring:            stats.Expiring,
+		IndexMemory:         stats.IndexMemory,
+		ActiveSegmentID:     stats.ActiveSegmentID,
+		ActiveSegmentOffset: stats.ActiveSegmentSize,
+		Segments:            make([]SegmentStats, len(stats.Segments)),
+		Cache:               newCacheStats(stats.Cache),
+		FileCache:           i.FileCacheStats(),
+		Compaction: CompactionStats{
+			Runs:           stats.Compaction.Runs,
+			ReclaimedBytes: stats.Compaction.ReclaimedBytes,
+			History:        make([]CompactionRun, len(stats.Compaction.History)),
+		},
+		Uptime:           stats.Uptime,
+		RecoveryDuration: stats.RecoveryDuration,
+	}
+
+	for n, segment := range stats.Segments {
+		result.Segments[n] = SegmentStats{
+			ID:        segment.ID,
+			File:      segment.File,
+			Size:      segment.Size,
+			LiveBytes: segment.LiveBytes,
+			DeadBytes: segment.DeadBytes(),
+		}
+		result.LiveBytes += segment.LiveBytes
+		result.DeadBytes += segment.DeadBytes()
+	}
+
+	for n, run := range stats.Compaction.History {
+		result.Compaction.History[n] = CompactionRun{
+			Started:        run.Started,
+			Duration:       run.Duration,
+			Segments:       run.Segments,
+			ReclaimedBytes: run.ReclaimedBytes,
+		}
+	}
+
+	return result
+}
+
+// newCacheStats converts the value cache counters of the engine.
+func newCacheStats(stats cache.Stats) CacheStats {
+	return CacheStats{
+		Hits:       stats.Hits,
+		Misses:     stats.Misses,
+		Evictions:  stats.Evictions,
+		Rejections: stats.Rejections,
+		Entries:    stats.Entries,
+		Bytes:      stats.Bytes,
+		Capacity:   stats.Capacity,
+	}
+}
@@ -0,0 +1,75 @@
+package ignite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatsFollowCompaction(t *testing.T) {
+	instance, err := openInstance(t, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer instance.Close(context.Background())
+
+	ctx := context.Background()
+	if stats := instance.Stats().Compaction; stats.Runs != 0 || stats.ReclaimedBytes != 0 || len(stats.History) != 0 {
+		t.Fatalf("Stats().Compaction on a fresh store = %+v", stats)
+	}
+
+	for i := range 200 {
+		if err := instance.Set(ctx, fmt.Sprintf("key-%d", i%10), []byte(strings.Repeat("v", 100))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := instance.SetX(ctx, "expiring", []byte("v"), time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	sealActive(t, instance)
+
+	before := instance.Stats()
+	if before.Keys != 11 || before.Expiring != 1 || before.DeadBytes == 0 {
+		t.Fatalf("Stats() before compaction = %d keys, %d expiring, %d dead bytes", before.Keys, before.Expiring, before.DeadBytes)
+	}
+
+	var runs []CompactionRun
+	for range 2 {
+		run, err := instance.Compact(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		runs = append(runs, run)
+	}
+	if runs[0].Segments == 0 || runs[0].ReclaimedBytes == 0 {
+		t.Fatalf("first Compact() = %+v, want a segment compacted", runs[0])
+	}
+
+	after := instance.Stats()
+	stats := after.Compaction
+	if stats.Runs != 2 || stats.ReclaimedBytes != runs[0].ReclaimedBytes+runs[1].ReclaimedBytes {
+		t.Fatalf("Stats().Compaction = %+v, want the totals of %+v", stats, runs)
+	}
+	if len(stats.History) != 2 || stats.History[0] != runs[0] || stats.History[1] != runs[1] {
+		t.Fatalf("Stats().Compaction.History = %+v, want %+v", stats.History, runs)
+	}
+
+	// The per-segment figures add up and follow the run.
+	if after.DeadBytes >= before.DeadBytes || after.Keys != before.Keys {
+		t.Fatalf("Stats() after compaction = %d keys, %d dead bytes; before %d keys, %d dead bytes",
+			after.Keys, after.DeadBytes, before.Keys, before.DeadBytes)
+	}
+	var live, dead int64
+	for _, segment := range after.Segments {
+		if segment.LiveBytes+segment.DeadBytes != segment.Size {
+			t.Errorf("segment %d: %d live + %d dead bytes, size %d", segment.ID, segment.LiveBytes, segment.DeadBytes, segment.Size)
+		}
+		live += segment.LiveBytes
+		dead += segment.DeadBytes
+	}
+	if live != after.LiveBytes || dead != after.DeadBytes {
+		t.Fatalf("segments add up to %d live and %d dead bytes, totals are %d and %d", live, dead, after.LiveBytes, after.DeadBytes)
+	}
+}
@@ -0,0 +1,342 @@
+// Package inspect shows what an Ignite data directory holds on disk, for debugging.
+//
+// Segment lists the entries of a single segment file with their offsets, headers and
+// checksum status, continuing past damage so that the entries behind it are still
+// shown. Key searches every live segment of a data directory for one key, and reports
+// each version of it still on disk along with the record pointer the index would
+// build for it.
+//
+// Hint lists the records of a hint file and compares them with the segment they
+// describe, the way the store decides whether to replay a segment from its hint.
+//
+// All three only read files and take no lock, so they can be pointed at the directory
+// of a running process; the end of its active segment may then show as damaged while
+// a write is in flight. Checksums cover the bytes as stored, so encrypted entries are
+// verified, but their keys and values cannot be read.
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/iamNilotpal/ignite/internal/compression"
+	"github.com/iamNilotpal/ignite/internal/manifest"
+	"github.com/iamNilotpal/ignite/internal/storage"
+	"github.com/iamNilotpal/ignite/pkg/errors"
+	"github.com/iamNilotpal/ignite/pkg/options"
+	"github.com/iamNilotpal/ignite/pkg/seginfo"
+	"go.uber.org/zap"
+)
+
+// Segment lists the entries of the segment file at path that match filter. The
+// segment prefix option is used to take the segment ID from the file name; other
+// options are ignored. Damage does not make Segment fail: damaged entries are listed
+// with their checksum status, and an error is only returned if the file cannot be
+// read.
+func Segment(ctx context.Context, path string, filter Filter, opts ...options.OptionFunc) (*SegmentDump, error) {
+	inspectOpts := options.NewDefaultOptions()
+	for _, opt := range opts {
+		opt(&inspectOpts)
+	}
+
+	// The ID only labels errors, so a file that was renamed can still be inspected.
+	id, _ := seginfo.ParseSegmentID(path, inspectOpts.SegmentOptions.Prefix)
+	dump := &SegmentDump{File: path, ID: id, Entries: []EntryInfo{}}
+
+	scan, err := storage.InspectSegment(path, id, func(pos *storage.Position, entry *storage.Entry, region *storage.DamagedRegion) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		info := describe(pos, entry, region)
+		if filter.matches(&info, region) {
+			dump.Entries = append(dump.Entries, info)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dump.Size = scan.FileSize
+	dump.Encrypted = scan.Encrypted()
+	dump.KeyID = scan.KeyID
+	dump.DataStart = scan.DataStart
+	dump.Damaged = scan.Damage != nil
+	return dump, nil
+}
+
+// Key searches the live segments of the data directory dataDir for key. The segment
+// directory options are honored like they are when opening a store; other options
+// are ignored.
+func Key(ctx context.Context, dataDir, key string, opts ...options.OptionFunc) (*KeyHistory, error) {
+	inspectOpts := options.NewDefaultOptions()
+	for _, opt := range opts {
+		opt(&inspectOpts)
+	}
+
+	if _, err := os.Stat(dataDir); err != nil {
+		return nil, errors.NewFileAccessError(dataDir, filepath.Base(dataDir), "inspect_stat", err)
+	}
+
+	segments, err := liveSegments(dataDir, &inspectOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &KeyHistory{DataDir: dataDir, Key: key, Versions: []Version{}}
+	segmentDir := filepath.Join(dataDir, inspectOpts.SegmentOptions.Directory)
+	live := -1
+
+	for _, segment := range segments {
+		path := filepath.Join(segmentDir, segment.File)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+
+		damaged := false
+		first := len(history.Versions)
+		var intact []bool
+		scan, err := storage.InspectSegment(path, segment.ID, func(pos *storage.Position, entry *storage.Entry, region *storage.DamagedRegion) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			if region != nil {
+				damaged = true
+				if region.Entry == nil || string(region.Entry.Key) != key || region.Entry.IsEncrypted() {
+					return nil
+				}
+			} else if entry.IsEncrypted() || string(entry.Key) != key {
+				return nil
+			}
+
+			history.Versions = append(history.Versions, Version{
+				SegmentID: segment.ID,
+				File:      segment.File,
+				EntryInfo: describe(pos, entry, region),
+			})
+
+			intact = append(intact, !damaged)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// Replay at open stops at the first damage of a segment, unless it replays the
+		// segment from a hint, and the last entry it reads for a key wins.
+		hinted := segment.Sealed && replaysHint(path, scan)
+		for i, ok := range intact {
+			if ok || hinted {
+				live = first + i
+			}
+		}
+		if scan.Encrypted() {
+			history.Sealed = append(history.Sealed, segment.ID)
+		}
+	}
+
+	if live >= 0 && !history.Versions[live].Tombstone {
+		version := &history.Versions[live]
+		version.Live = true
+		history.Pointer = &Pointer{
+			SegmentID: version.SegmentID,
+			Offset:    version.Offset,
+			EntrySize: uint32(version.Size),
+			ValueSize: uint32(version.ValueSize),
+			Timestamp: version.Timestamp,
+		}
+	}
+
+	return history, nil
+}
+
+// Hint lists the records of the hint file at path, or of the hint file of the segment
+// file at path, and compares them with the segment. The segment prefix option is used
+// to take the segment ID from the file name; other options are ignored. A hint that
+// does not match its segment is not an error: the reason is reported in Stale.
+func Hint(ctx context.Context, path string, opts ...options.OptionFunc) (*HintDump, error) {
+	inspectOpts := options.NewDefaultOptions()
+	for _, opt := range opts {
+		opt(&inspectOpts)
+	}
+
+	if !strings.HasSuffix(path, ".hint") {
+		path = storage.HintPath(path)
+	}
+	segmentPath := strings.TrimSuffix(path, ".hint")
+
+	hint, err := storage.ReadHint(path)
+	if err != nil {
+		return nil, err
+	}
+
+	id, _ := seginfo.ParseSegmentID(segmentPath, inspectOpts.SegmentOptions.Prefix)
+	dump := &HintDump{
+		File:        path,
+		Segment:     segmentPath,
+		ID:          id,
+		SegmentSize: hint.SegmentSize,
+		Sealed:      hint.Sealed,
+		Records:     make([]EntryInfo, 0, len(hint.Records)),
+	}
+
+	for i := range hint.Records {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		r := &hint.Records[i]
+		info := describeEntry(r.Entry(), r.Offset, int64(r.Size))
+		info.ValueSize = int(r.ValueSize)
+		dump.Records = append(dump.Records, info)
+	}
+
+	if dump.Stale, err = compareHint(ctx, segmentPath, id, hint); err != nil {
+		return nil, err
+	}
+	return dump, nil
+}
+
+// compareHint walks the segment a hint describes and returns why the hint does not
+// match it, or an empty string when it does.
+func compareHint(ctx context.Context, segmentPath string, id uint64, hint *storage.Hint) (string, error) {
+	if _, err := os.Stat(segmentPath); os.IsNotExist(err) {
+		return "the segment file does not exist", nil
+	}
+
+	read, matched := 0, true
+	scan, err := storage.WalkSegment(segmentPath, id, func(pos *storage.Position, entry *storage.Entry) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if read >= len(hint.Records) || !hint.Records[read].Matches(pos, entry) {
+			matched = false
+		}
+		read++
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case scan.Damage != nil:
+		return fmt.Sprintf("the segment is damaged: %v", scan.Damage), nil
+	case hint.Sealed != scan.Encrypted():
+		return "hint encryption does not match the segment", nil
+	case hint.SegmentSize != scan.FileSize:
+		return fmt.Sprintf("hint was written for %d bytes, the segment has %d", hint.SegmentSize, scan.FileSize), nil
+	case !hint.Covers(scan.DataStart, scan.FileSize):
+		return "hint records do not cover the segment", nil
+	case !matched || read != len(hint.Records):
+		return fmt.Sprintf("hint lists %d entries that do not match the %d in the segment", len(hint.Records), read), nil
+	}
+	return "", nil
+}
+
+// replaysHint reports whether opening the store would replay the segment at path from
+// its hint file rather than read it, which the store does whenever the hint covers the
+// segment.
+func replaysHint(path string, scan *storage.SegmentScan) bool {
+	hint, err := storage.ReadHint(storage.HintPath(path))
+	if err != nil {
+		return false
+	}
+	return hint.Sealed == scan.Encrypted() && hint.Covers(scan.DataStart, scan.FileSize)
+}
+
+// liveSegments returns the live segments of a data directory in ID order, as listed
+// by its MANIFEST or, without one, as found in the segment directory.
+func liveSegments(dataDir string, opts *options.Options) ([]manifest.Segment, error) {
+	if _, err := os.Stat(filepath.Join(dataDir, manifest.FileName)); err == nil {
+		m, err := manifest.Open(&manifest.Config{Dir: dataDir, ReadOnly: true, Logger: zap.NewNop().Sugar()})
+		if err != nil {
+			return nil, err
+		}
+		defer m.Close()
+		return m.Segments(), nil
+	}
+
+	prefix := opts.SegmentOptions.Prefix
+	names, err := seginfo.ListSegmentNames(dataDir, opts.SegmentOptions.Directory, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	segments := make([]manifest.Segment, 0, len(names))
+	for _, name := range names {
+		id, err := seginfo.ParseSegmentID(name, prefix)
+		if err != nil {
+			continue
+		}
+		segments = append(segments, manifest.Segment{ID: id, File: filepath.Base(name)})
+	}
+	return segments, nil
+}
+
+// describe turns an intact entry or a damaged region into an EntryInfo.
+func describe(pos *storage.Position, entry *storage.Entry, region *storage.DamagedRegion) EntryInfo {
+	if region != nil {
+		info := EntryInfo{Offset: region.Offset, Size: region.Length, Checksum: ChecksumUnreadable}
+		if region.Entry != nil {
+			info = describeEntry(region.Entry, region.Offset, region.Length)
+			info.Checksum = ChecksumMismatch
+			if !info.Encrypted {
+				info.ValueSize = int(region.Length) - storage.HeaderSize - len(info.Key)
+			}
+		}
+		return info
+	}
+
+	info := describeEntry(entry, pos.Offset, int64(pos.Size))
+	info.Checksum = ChecksumOK
+	return info
+}
+
+// describeEntry fills an EntryInfo from the fields of an entry.
+func describeEntry(entry *storage.Entry, offset, size int64) EntryInfo {
+	info := EntryInfo{
+		Offset:    offset,
+		Size:      size,
+		Timestamp: time.Unix(0, entry.Timestamp).UTC(),
+		Version:   entry.Version,
+		Key:       string(entry.Key),
+		ValueSize: len(entry.Value),
+		Tombstone: entry.IsTombstone(),
+		Blob:      entry.IsBlob(),
+		Encrypted: entry.IsEncrypted(),
+	}
+
+	if entry.ExpiresAt != 0 {
+		info.ExpiresAt = time.Unix(0, entry.ExpiresAt).UTC()
+	}
+
+	if id := entry.Codec(); id != 0 {
+		if codec, ok := compression.Lookup(id); ok {
+			info.Codec = codec.Name()
+		} else {
+			info.Codec = "unknown"
+		}
+	}
+	return info
+}
+
+// matches reports whether filter selects an entry. Damaged regions without a
+// readable key only match when no key pattern is set.
+func (f *Filter) matches(info *EntryInfo, region *storage.DamagedRegion) bool {
+	if info.Offset < f.From || (f.To > 0 && info.Offset >= f.To) {
+		return false
+	}
+	if f.Key == nil {
+		return true
+	}
+	if info.Encrypted || (region != nil && region.Entry == nil) {
+		return false
+	}
+	return f.Key.MatchString(info.Key)
+}
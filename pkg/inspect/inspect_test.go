@@ -0,0 +1,248 @@
+package inspect
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/iamNilotpal/ignite/internal/engine"
+	"github.com/iamNilotpal/ignite/internal/storage"
+	"github.com/iamNilotpal/ignite/pkg/options"
+	"go.uber.org/zap"
+)
+
+// writeStore creates a store in a temporary directory with 200 writes spread over 50
+// keys, across several segments, and waits for the hint files of its sealed segments.
+// It returns the data directory and the segment files in ID order.
+func writeStore(t *testing.T) (string, []string) {
+	t.Helper()
+
+	opts := options.NewDefaultOptions()
+	opts.DataDir = t.TempDir()
+	opts.SegmentOptions.Size = 4096
+	opts.CompactInterval = 0
+
+	e, err := engine.New(context.Background(), &engine.Config{Options: &opts, Logger: zap.NewNop().Sugar()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 200 {
+		v := bytes.Repeat([]byte{byte('a' + i%26)}, 100)
+		if err := e.Set(context.Background(), fmt.Sprintf("key-%03d", i%50), v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := filepath.Glob(filepath.Join(opts.DataDir, opts.SegmentOptions.Directory, "*.seg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for _, file := range files[:len(files)-1] {
+		for {
+			if _, err := os.Stat(storage.HintPath(file)); err == nil {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("no hint file written for %s", filepath.Base(file))
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return opts.DataDir, files
+}
+
+// dumpSegment runs Segment on the file at path without a filter.
+func dumpSegment(t *testing.T, path string) *SegmentDump {
+	t.Helper()
+
+	dump, err := Segment(context.Background(), path, Filter{})
+	if err != nil {
+		t.Fatalf("Segment() error = %v", err)
+	}
+	return dump
+}
+
+// flipByte inverts the byte at offset in the file at path.
+func flipByte(t *testing.T, path string, offset int64) {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[offset] ^= 0xff
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSegmentListsEntries(t *testing.T) {
+	_, files := writeStore(t)
+
+	dump := dumpSegment(t, files[0])
+	if dump.ID != 1 || dump.Damaged || len(dump.Entries) == 0 {
+		t.Fatalf("Segment() = id %d, damaged %t, %d entries", dump.ID, dump.Damaged, len(dump.Entries))
+	}
+
+	offset := dump.DataStart
+	for _, entry := range dump.Entries {
+		if entry.Offset != offset || entry.Checksum != ChecksumOK || entry.ValueSize != 100 {
+			t.Fatalf("entry %+v, want one at offset %d with a 100 byte value and a valid checksum", entry, offset)
+		}
+		offset += entry.Size
+	}
+	if offset != dump.Size {
+		t.Fatalf("entries end at %d, the segment has %d bytes", offset, dump.Size)
+	}
+
+	filtered, err := Segment(context.Background(), files[0], Filter{Key: regexp.MustCompile(`^key-00[0-4]$`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filtered.Entries) == 0 || len(filtered.Entries) >= len(dump.Entries) {
+		t.Fatalf("filtered Segment() listed %d of %d entries", len(filtered.Entries), len(dump.Entries))
+	}
+}
+
+func TestSegmentContinuesPastDamage(t *testing.T) {
+	_, files := writeStore(t)
+
+	before := dumpSegment(t, files[1])
+	second := before.Entries[1]
+	flipByte(t, files[1], second.Offset+second.Size-1)
+
+	after := dumpSegment(t, files[1])
+	if !after.Damaged || len(after.Entries) != len(before.Entries) {
+		t.Fatalf("Segment() of a damaged file: damaged %t, %d entries, want %d", after.Damaged, len(after.Entries), len(before.Entries))
+	}
+	if got := after.Entries[1]; got.Checksum != ChecksumMismatch || got.Key != second.Key {
+		t.Fatalf("damaged entry = %+v, want a checksum mismatch for %q", got, second.Key)
+	}
+	if got := after.Entries[2]; got.Checksum != ChecksumOK {
+		t.Fatalf("entry behind the damage = %+v, want it intact", got)
+	}
+}
+
+func TestKeyHistory(t *testing.T) {
+	dataDir, _ := writeStore(t)
+
+	history, err := Key(context.Background(), dataDir, "key-007")
+	if err != nil {
+		t.Fatalf("Key() error = %v", err)
+	}
+	if len(history.Versions) != 4 || history.Pointer == nil {
+		t.Fatalf("Key() = %d versions, pointer %v; want 4 versions and a pointer", len(history.Versions), history.Pointer)
+	}
+
+	last := history.Versions[3]
+	if !last.Live || history.Pointer.SegmentID != last.SegmentID || history.Pointer.Offset != last.Offset {
+		t.Fatalf("Key() pointer = %+v, want the latest version %+v", history.Pointer, last)
+	}
+	for _, version := range history.Versions[:3] {
+		if version.Live {
+			t.Fatalf("older version %+v reported live", version)
+		}
+	}
+
+	missing, err := Key(context.Background(), dataDir, "nope")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if missing.Pointer != nil || len(missing.Versions) != 0 {
+		t.Fatalf("Key() of a missing key = %+v", missing)
+	}
+}
+
+func TestKeyFollowsHintPastDamage(t *testing.T) {
+	dataDir, files := writeStore(t)
+
+	// Damage the first entry of the last sealed segment and look up a key whose latest
+	// version comes later in it: the store replays the segment from its hint.
+	sealed := files[len(files)-2]
+	dump := dumpSegment(t, sealed)
+	first, latest := dump.Entries[0], dump.Entries[len(dump.Entries)-1]
+	flipByte(t, sealed, first.Offset+first.Size-1)
+
+	history, err := Key(context.Background(), dataDir, latest.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := history.Pointer; p == nil || p.Offset != latest.Offset {
+		t.Fatalf("Key() pointer = %+v with a hint, want offset %d", p, latest.Offset)
+	}
+
+	if err := os.Remove(storage.HintPath(sealed)); err != nil {
+		t.Fatal(err)
+	}
+	history, err = Key(context.Background(), dataDir, latest.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := history.Pointer; p != nil && p.SegmentID == dump.ID && p.Offset == latest.Offset {
+		t.Fatalf("Key() pointer = %+v without a hint, want an entry before the damage", p)
+	}
+}
+
+func TestHintMatchesSegment(t *testing.T) {
+	_, files := writeStore(t)
+	segment := dumpSegment(t, files[0])
+
+	hint, err := Hint(context.Background(), storage.HintPath(files[0]))
+	if err != nil {
+		t.Fatalf("Hint() error = %v", err)
+	}
+	if hint.Stale != "" || hint.ID != segment.ID || hint.SegmentSize != segment.Size {
+		t.Fatalf("Hint() = stale %q, id %d, %d bytes; want a current hint of segment %d", hint.Stale, hint.ID, hint.SegmentSize, segment.ID)
+	}
+	if len(hint.Records) != len(segment.Entries) {
+		t.Fatalf("Hint() listed %d records, the segment has %d entries", len(hint.Records), len(segment.Entries))
+	}
+	for i, record := range hint.Records {
+		want := segment.Entries[i]
+		want.Checksum = ""
+		if record != want {
+			t.Fatalf("record %d = %+v, want %+v", i, record, want)
+		}
+	}
+
+	// The segment file can be given in place of its hint.
+	bySegment, err := Hint(context.Background(), files[0])
+	if err != nil || bySegment.File != hint.File {
+		t.Fatalf("Hint() of the segment file = %v, %v", bySegment, err)
+	}
+}
+
+func TestHintReportsStale(t *testing.T) {
+	_, files := writeStore(t)
+
+	other, err := os.ReadFile(storage.HintPath(files[1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(storage.HintPath(files[0]), other, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	hint, err := Hint(context.Background(), files[0])
+	if err != nil {
+		t.Fatalf("Hint() error = %v", err)
+	}
+	if hint.Stale == "" {
+		t.Fatal("Hint() reported a hint of another segment as current")
+	}
+
+	if _, err := Hint(context.Background(), files[len(files)-1]); err == nil {
+		t.Fatal("Hint() of the active segment, which has no hint, succeeded")
+	}
+}
@@ -0,0 +1,117 @@
+package inspect
+
+import (
+	"regexp"
+	"time"
+)
+
+// Checksum is the verification status of an entry.
+type Checksum string
+
+const (
+	// ChecksumOK means the entry passed its checksum.
+	ChecksumOK Checksum = "ok"
+
+	// ChecksumMismatch means the entry's header still gives its extent but the stored
+	// bytes fail the checksum, so any of its fields may be damaged.
+	ChecksumMismatch Checksum = "mismatch"
+
+	// ChecksumUnreadable marks damaged bytes in which no entry could be delimited.
+	ChecksumUnreadable Checksum = "unreadable"
+)
+
+// Filter selects the entries Segment reports. The zero value selects every entry.
+type Filter struct {
+	Key  *regexp.Regexp // Only entries whose key matches; nil matches every key.
+	From int64          // Skip entries that start before this offset.
+	To   int64          // Skip entries that start at or after this offset; 0 means no limit.
+}
+
+// SegmentDump lists the entries of a segment file.
+type SegmentDump struct {
+	File      string      `json:"file"`            // Path of the segment file.
+	ID        uint64      `json:"id"`              // Segment ID taken from the file name; 0 if it has none.
+	Size      int64       `json:"size"`            // Size of the file in bytes.
+	Encrypted bool        `json:"encrypted"`       // Whether the segment starts with an encryption header.
+	KeyID     string      `json:"keyId,omitempty"` // Master key the segment's data key is wrapped with.
+	DataStart int64       `json:"dataStart"`       // Offset of the first entry.
+	Damaged   bool        `json:"damaged"`         // Whether any part of the file failed verification.
+	Entries   []EntryInfo `json:"entries"`         // Entries selected by the filter, in file order.
+}
+
+// EntryInfo describes a single entry as stored in a segment file. Keys and values of
+// encrypted entries cannot be read without the store's keys, so they are left empty.
+type EntryInfo struct {
+	Offset    int64     `json:"offset"`             // Offset of the entry within the file.
+	Size      int64     `json:"size"`               // Bytes the entry occupies, header included.
+	Timestamp time.Time `json:"timestamp"`          // Time the entry was written.
+	Version   uint8     `json:"version"`            // Entry format version.
+	Key       string    `json:"key"`                // Key of the entry.
+	ValueSize int       `json:"valueSize"`          // Size of the value as stored, compressed if Codec is set.
+	Codec     string    `json:"codec,omitempty"`    // Compression codec of the value, if any.
+	Tombstone bool      `json:"tombstone"`          // Whether the entry deletes its key.
+	Blob      bool      `json:"blob"`               // Whether the value is a reference to a blob file.
+	Encrypted bool      `json:"encrypted"`          // Whether the entry is sealed.
+	Checksum  Checksum  `json:"checksum,omitempty"` // Verification status of the entry; empty in hint dumps.
+
+	// ExpiresAt is the time the entry expires at; zero if it never does. Like the key,
+	// it cannot be read from encrypted entries.
+	ExpiresAt time.Time `json:"expiresAt,omitzero"`
+}
+
+// HintDump lists the records of a hint file. Records reuse EntryInfo without a
+// checksum status, since hints hold no entry checksums; the keys and expiry times of a
+// sealed hint cannot be read without the store's keys, so they are left empty.
+type HintDump struct {
+	File        string      `json:"file"`        // Path of the hint file.
+	Segment     string      `json:"segment"`     // Path of the segment file the hint describes.
+	ID          uint64      `json:"id"`          // Segment ID taken from the file name; 0 if it has none.
+	SegmentSize int64       `json:"segmentSize"` // Size of the segment when the hint was written.
+	Sealed      bool        `json:"sealed"`      // Whether the keys section is encrypted.
+	Records     []EntryInfo `json:"records"`     // Records in segment order.
+
+	// Stale gives the reason the store would not trust the hint for its segment, and is
+	// empty when the hint matches the segment entry for entry.
+	Stale string `json:"stale,omitempty"`
+}
+
+// KeyHistory lists what a data directory holds for a single key.
+type KeyHistory struct {
+	DataDir string `json:"dataDir"` // Data directory that was searched.
+	Key     string `json:"key"`     // Key that was looked up.
+
+	// Pointer is where the index points the key when the store is opened; nil when the
+	// key does not exist or was deleted.
+	Pointer *Pointer `json:"pointer"`
+
+	// Versions lists every entry of the key still on disk, oldest first, including
+	// tombstones and damaged entries whose key could be read.
+	Versions []Version `json:"versions"`
+
+	// Sealed lists the encrypted segments, whose entries cannot be matched against the
+	// key without the store's keys and were therefore not searched.
+	Sealed []uint64 `json:"sealed,omitempty"`
+}
+
+// Pointer mirrors the index.RecordPointer the store builds for a key, with the
+// segment's ID in place of the index slot a running process assigns it.
+type Pointer struct {
+	SegmentID uint64    `json:"segmentId"` // Segment holding the entry.
+	Offset    int64     `json:"offset"`    // Offset of the entry within the segment.
+	EntrySize uint32    `json:"entrySize"` // Bytes the entry occupies, header included.
+	ValueSize uint32    `json:"valueSize"` // Size of the value as stored.
+	Timestamp time.Time `json:"timestamp"` // Time the entry was written.
+}
+
+// Version is a single entry of a key found on disk.
+type Version struct {
+	SegmentID uint64 `json:"segmentId"` // Segment holding the entry.
+	File      string `json:"file"`      // Segment file name.
+	EntryInfo
+
+	// Live reports whether the index points at this entry. Entries that follow damage
+	// in their segment are never live, since opening the store stops reading a
+	// segment at its first damaged entry, unless a hint file covering the segment is
+	// replayed in its place.
+	Live bool `json:"live"`
+}
@@ -24,6 +24,13 @@ import (
 // should be directed (e.g., "stderr", "stdout", or file paths like "logs/app.log").
 // If no paths are provided, logs will default to "stderr".
 func New(service string, outputPaths ...string) *zap.SugaredLogger {
+	return NewWithLevel(service, zap.NewAtomicLevelAt(zap.InfoLevel), outputPaths...)
+}
+
+// Creates a logger like New whose minimum level is controlled by level.
+// Changing level with SetLevel takes effect immediately for every logger built
+// from it, which is how the log level of a running process is changed.
+func NewWithLevel(service string, level zap.AtomicLevel, outputPaths ...string) *zap.SugaredLogger {
 	// Create a new production encoder configuration. This provides a good default
 	// for structured logging in production environments, typically outputting JSON.
 	encoderCfg := zap.NewProductionEncoderConfig()
@@ -38,9 +45,9 @@ func New(service string, outputPaths ...string) *zap.SugaredLogger {
 	// Initialize the Zap configuration. This struct holds all the settings
 	// for building the logger.
 	config := zap.Config{
-		// Set the logging level to Info. Messages at or above this level will be logged.
-		// Other common levels include Debug, Warn, Error, DPanic, Panic, Fatal.
-		Level: zap.NewAtomicLevelAt(zap.InfoLevel),
+		// Set the logging level. Messages at or above this level will be logged.
+		// Common levels include Debug, Info, Warn, Error, DPanic, Panic, Fatal.
+		Level: level,
 		// Development mode (true) is better for local development as it provides
 		// human-readable output and adds caller information. Setting it to false
 		// for production environments optimizes for performance and structured output.
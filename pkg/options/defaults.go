@@ -11,6 +11,10 @@ const (
 	// By default, compaction will run every 5 hours.
 	DefaultCompactInterval = time.Hour * 5
 
+	// Represents the shortest allowed time between compaction operations. Shorter
+	// intervals would keep the store compacting almost continuously.
+	MinCompactInterval = time.Minute
+
 	// Represents the minimum allowed size for a segment file in bytes (512MB).
 	MinSegmentSize uint64 = 512 * 1024 * 1024
 
@@ -27,19 +31,85 @@ const (
 	// Defines the default prefix for segment file names.
 	// For example, a segment file might be named "segment-00001.db".
 	DefaultSegmentPrefix = "segment"
+
+	// Specifies the default size of the hot value cache in bytes.
+	// The cache is disabled by default and must be enabled explicitly.
+	DefaultCacheSize uint64 = 0
+
+	// Specifies the default number of shards the hot value cache is split into.
+	DefaultCacheShards uint = 16
+
+	// Specifies the default mode used to read sealed segment files.
+	DefaultReadMode = ReadModeStandard
+
+	// Specifies the default number of sealed segment files kept open for reading.
+	DefaultMaxOpenFiles uint = 256
+
+	// Specifies the default codec used to compress values. Compression is off by default.
+	DefaultCompression = CompressionNone
+
+	// Specifies the default smallest value size, in bytes, that is compressed.
+	DefaultCompressionMinSize uint32 = 256
+
+	// Specifies the default value size, in bytes, from which values are stored in
+	// separate blob files (1MB).
+	DefaultBlobThreshold uint64 = 1 * 1024 * 1024
+
+	// Specifies the default subdirectory within the main data directory
+	// where blob files will be stored.
+	DefaultBlobDirectory = "/blobs"
+
+	// Specifies the default policy for syncing writes to stable storage.
+	DefaultSyncPolicy = SyncNone
+
+	// Specifies the default time between background syncs under SyncInterval.
+	DefaultSyncInterval = time.Second
+
+	// Specifies the default compaction rate limit in bytes per second. Zero means
+	// compaction is not throttled.
+	DefaultCompactRateLimit uint64 = 0
+
+	// Specifies the default minimum level of log messages.
+	DefaultLogLevel = "info"
 )
 
 // Holds the default configuration settings for an IgniteDB instance.
 var defaultOptions = Options{
-	DataDir:         DefaultDataDir,
-	CompactInterval: DefaultCompactInterval,
+	DataDir:          DefaultDataDir,
+	CompactInterval:  DefaultCompactInterval,
+	CompactRateLimit: DefaultCompactRateLimit,
 	SegmentOptions: &segmentOptions{
 		Size:      DefaultSegmentSize,
 		Prefix:    DefaultSegmentPrefix,
 		Directory: DefaultSegmentDirectory,
 	},
+	CacheOptions: &cacheOptions{
+		Size:   DefaultCacheSize,
+		Shards: DefaultCacheShards,
+	},
+	ReadOptions: &readOptions{
+		Mode:         DefaultReadMode,
+		MaxOpenFiles: DefaultMaxOpenFiles,
+	},
+	CompressionOptions: &compressionOptions{
+		Codec:   DefaultCompression,
+		MinSize: DefaultCompressionMinSize,
+	},
+	BlobOptions: &blobOptions{
+		Threshold: DefaultBlobThreshold,
+		Directory: DefaultBlobDirectory,
+	},
+	SyncOptions: &syncOptions{
+		Policy:   DefaultSyncPolicy,
+		Interval: DefaultSyncInterval,
+	},
+	LogLevel: DefaultLogLevel,
 }
 
+// NewDefaultOptions returns the default configuration. Every call returns options with
+// their own sections, so changing one instance's options never affects another's.
 func NewDefaultOptions() Options {
-	return defaultOptions
+	opts := defaultOptions
+	cloneSections(&opts)
+	return opts
 }
@@ -0,0 +1,116 @@
+package options
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// KeyProvider supplies the master keys used for encryption at rest.
+//
+// Every segment is encrypted with its own randomly generated data key. The data key is
+// wrapped (encrypted) with the provider's current master key and stored in the segment
+// header together with the master key's ID. Master keys must be 16, 24 or 32 bytes long,
+// selecting AES-128, AES-192 or AES-256.
+//
+// Rotating the master key only requires the provider to return a new current key: new
+// segments are wrapped with it, and rewriting old segments (as compaction does) moves
+// their data under it. Retired keys must remain available through Key for as long as
+// any segment wrapped with them exists.
+type KeyProvider interface {
+	// CurrentKey returns the ID and bytes of the master key that wraps new data keys.
+	CurrentKey() (id string, key []byte, err error)
+
+	// Key returns the master key with the given ID.
+	Key(id string) ([]byte, error)
+}
+
+// FileKeyProvider reads master keys from a text file with one key per line, written as
+// the key ID and the hex-encoded key separated by a colon:
+//
+//	# comments and blank lines are ignored
+//	2024-01:8f1c...e2
+//	2024-07:0b7a...9d
+//
+// The last key in the file is the current key. The file is read on every call, so a
+// key is rotated by appending a new line, without restarting the process.
+type FileKeyProvider struct {
+	path string
+}
+
+// NewFileKeyProvider creates a key provider backed by the key file at path.
+func NewFileKeyProvider(path string) *FileKeyProvider {
+	return &FileKeyProvider{path: path}
+}
+
+// CurrentKey returns the last key listed in the key file.
+func (p *FileKeyProvider) CurrentKey() (string, []byte, error) {
+	ids, keys, err := p.load()
+	if err != nil {
+		return "", nil, err
+	}
+	if len(ids) == 0 {
+		return "", nil, fmt.Errorf("key file %s contains no keys", p.path)
+	}
+
+	id := ids[len(ids)-1]
+	return id, keys[id], nil
+}
+
+// Key returns the key listed under id in the key file.
+func (p *FileKeyProvider) Key(id string) ([]byte, error) {
+	_, keys, err := p.load()
+	if err != nil {
+		return nil, err
+	}
+
+	key, ok := keys[id]
+	if !ok {
+		return nil, fmt.Errorf("key %q not found in key file %s", id, p.path)
+	}
+	return key, nil
+}
+
+// load parses the key file, returning key IDs in file order and the keys by ID.
+func (p *FileKeyProvider) load() ([]string, map[string][]byte, error) {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	var ids []string
+	keys := make(map[string][]byte)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		id, encoded, ok := strings.Cut(text, ":")
+		id, encoded = strings.TrimSpace(id), strings.TrimSpace(encoded)
+		if !ok || id == "" {
+			return nil, nil, fmt.Errorf("key file %s line %d: expected id:hexkey", p.path, line)
+		}
+
+		key, err := hex.DecodeString(encoded)
+		if err != nil {
+			return nil, nil, fmt.Errorf("key file %s line %d: invalid hex key: %w", p.path, line, err)
+		}
+		if n := len(key); n != 16 && n != 24 && n != 32 {
+			return nil, nil, fmt.Errorf("key file %s line %d: key must be 16, 24 or 32 bytes, got %d", p.path, line, n)
+		}
+		if _, dup := keys[id]; dup {
+			return nil, nil, fmt.Errorf("key file %s line %d: duplicate key id %q", p.path, line, id)
+		}
+
+		ids = append(ids, id)
+		keys[id] = key
+	}
+
+	return ids, keys, scanner.Err()
+}
@@ -0,0 +1,298 @@
+package options
+
+import (
+	"bytes"
+	"encoding/json"
+	stdErrors "errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/iamNilotpal/ignite/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// settingKind describes how the text of an environment variable is converted into the
+// value of a setting.
+type settingKind int
+
+const (
+	kindString settingKind = iota
+	kindUint
+	kindBool
+	kindDuration
+)
+
+// setting describes an option that can be configured from a file or the environment.
+type setting struct {
+	path string      // JSON path of the field, as spelled in configuration files.
+	env  string      // Name of the environment variable, without its prefix.
+	kind settingKind // Type of the value.
+}
+
+// settings lists every option that LoadFile and FromEnv understand. Environment
+// variable names follow the flags of the ignite command.
+var settings = []setting{
+	{path: "dataDir", env: "DATA_DIR", kind: kindString},
+	{path: "compactInterval", env: "COMPACT_INTERVAL", kind: kindDuration},
+	{path: "compactRateLimit", env: "COMPACT_RATE_LIMIT", kind: kindUint},
+	{path: "segmentOptions.maxSegmentSize", env: "SEGMENT_SIZE", kind: kindUint},
+	{path: "segmentOptions.directory", env: "SEGMENT_DIR", kind: kindString},
+	{path: "segmentOptions.prefix", env: "SEGMENT_PREFIX", kind: kindString},
+	{path: "cacheOptions.size", env: "CACHE_SIZE", kind: kindUint},
+	{path: "cacheOptions.shards", env: "CACHE_SHARDS", kind: kindUint},
+	{path: "readOptions.mode", env: "READ_MODE", kind: kindString},
+	{path: "readOptions.maxOpenFiles", env: "MAX_OPEN_FILES", kind: kindUint},
+	{path: "compressionOptions.codec", env: "COMPRESSION", kind: kindString},
+	{path: "compressionOptions.minSize", env: "COMPRESSION_MIN_SIZE", kind: kindUint},
+	{path: "blobOptions.threshold", env: "BLOB_THRESHOLD", kind: kindUint},
+	{path: "blobOptions.directory", env: "BLOB_DIR", kind: kindString},
+	{path: "syncOptions.policy", env: "SYNC_POLICY", kind: kindString},
+	{path: "syncOptions.interval", env: "SYNC_INTERVAL", kind: kindDuration},
+	{path: "readOnly", env: "READ_ONLY", kind: kindBool},
+	{path: "logLevel", env: "LOG_LEVEL", kind: kindString},
+}
+
+// LoadFile reads options from a configuration file and returns them as an OptionFunc.
+// The format follows the file extension: .json, .yaml or .yml, or .toml. Keys are the
+// JSON names of the Options fields, with each section as a nested object, for example:
+//
+//	dataDir: /srv/ignite
+//	compactInterval: 2h
+//	segmentOptions:
+//	  maxSegmentSize: 1073741824
+//
+// Only the settings present in the file are changed. Durations may be written as
+// strings such as "90m" or as nanoseconds. Unknown keys and values of the wrong type
+// are reported as validation errors instead of being ignored, and so are values out
+// of range, all of them at once.
+//
+// Options are applied in the order they are passed to ignite.NewInstance, later ones
+// taking precedence. The intended order is the defaults, then LoadFile, then FromEnv,
+// then options set explicitly in code or on the command line.
+func LoadFile(path string) (OptionFunc, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	switch ext {
+	case ".json", ".yaml", ".yml", ".toml":
+	default:
+		return nil, errors.NewConfigurationValidationError("config", "unsupported configuration file format").
+			WithProvided(ext).WithExpected([]string{".json", ".yaml", ".yml", ".toml"})
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.NewFileAccessError(path, filepath.Base(path), "read", err)
+	}
+
+	doc := make(map[string]any)
+	switch ext {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		err = decoder.Decode(&doc)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &doc)
+	case ".toml":
+		err = toml.Unmarshal(data, &doc)
+	}
+	if err != nil {
+		return nil, errors.NewValidationError(err, errors.ErrorCodeInvalidInput, "Configuration file could not be parsed").
+			WithField("config").WithRule("format").WithProvided(path)
+	}
+
+	// Durations are easier to write as text, so they are converted into the
+	// nanoseconds time.Duration decodes from.
+	var errs []error
+	for _, s := range settings {
+		if s.kind != kindDuration {
+			continue
+		}
+		if text, ok := lookup(doc, s.path).(string); ok {
+			duration, err := time.ParseDuration(text)
+			if err != nil {
+				errs = append(errs, errors.NewFieldFormatError(s.path, text, "duration such as 90m"))
+				continue
+			}
+			store(doc, s.path, int64(duration))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, stdErrors.Join(errs...)
+	}
+
+	return overlay(doc)
+}
+
+// FromEnv reads options from environment variables named prefix followed by the name
+// of a setting, and returns them as an OptionFunc. With the prefix "IGNITE_" they are:
+//
+//	IGNITE_DATA_DIR, IGNITE_COMPACT_INTERVAL, IGNITE_COMPACT_RATE_LIMIT,
+//	IGNITE_SEGMENT_SIZE, IGNITE_SEGMENT_DIR, IGNITE_SEGMENT_PREFIX, IGNITE_CACHE_SIZE,
+//	IGNITE_CACHE_SHARDS, IGNITE_READ_MODE, IGNITE_MAX_OPEN_FILES, IGNITE_COMPRESSION,
+//	IGNITE_COMPRESSION_MIN_SIZE, IGNITE_BLOB_THRESHOLD, IGNITE_BLOB_DIR,
+//	IGNITE_SYNC_POLICY, IGNITE_SYNC_INTERVAL, IGNITE_READ_ONLY, IGNITE_LOG_LEVEL
+//
+// Only the variables that are set change their setting. Sizes are whole numbers of
+// bytes, durations are written like "90m", and booleans like "true" or "0". Values
+// that do not parse or are out of range are reported as validation errors. See
+// LoadFile for how FromEnv combines with other options.
+func FromEnv(prefix string) (OptionFunc, error) {
+	doc := make(map[string]any)
+	var errs []error
+
+	for _, s := range settings {
+		name := prefix + s.env
+		text, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+
+		value, err := parseValue(s, name, text)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		store(doc, s.path, value)
+	}
+	if len(errs) > 0 {
+		return nil, stdErrors.Join(errs...)
+	}
+
+	return overlay(doc)
+}
+
+// Parse reads options from settings named by their JSON path, such as
+// "cacheOptions.size", with values written as for FromEnv, and returns them as an
+// OptionFunc. Names are matched case-insensitively. Unknown names, values that do not
+// parse and values out of range are reported as validation errors.
+func Parse(values map[string]string) (OptionFunc, error) {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	doc := make(map[string]any)
+	var errs []error
+	for _, name := range names {
+		i := slices.IndexFunc(settings, func(s setting) bool { return strings.EqualFold(s.path, name) })
+		if i < 0 {
+			errs = append(errs, errors.NewConfigurationValidationError(name, "unknown setting"))
+			continue
+		}
+
+		value, err := parseValue(settings[i], settings[i].path, values[name])
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		store(doc, settings[i].path, value)
+	}
+	if len(errs) > 0 {
+		return nil, stdErrors.Join(errs...)
+	}
+
+	return overlay(doc)
+}
+
+// parseValue converts the text of a setting into the value its JSON field decodes
+// from. name identifies the setting in errors.
+func parseValue(s setting, name, text string) (any, error) {
+	switch s.kind {
+	case kindUint:
+		n, err := strconv.ParseUint(strings.TrimSpace(text), 10, 64)
+		if err != nil {
+			return nil, errors.NewFieldFormatError(name, text, "whole number")
+		}
+		return n, nil
+	case kindBool:
+		b, err := strconv.ParseBool(strings.TrimSpace(text))
+		if err != nil {
+			return nil, errors.NewFieldFormatError(name, text, "boolean")
+		}
+		return b, nil
+	case kindDuration:
+		duration, err := time.ParseDuration(strings.TrimSpace(text))
+		if err != nil {
+			return nil, errors.NewFieldFormatError(name, text, "duration such as 90m")
+		}
+		return int64(duration), nil
+	default:
+		return text, nil
+	}
+}
+
+// overlay checks that doc decodes into valid Options and returns an OptionFunc that
+// applies the settings doc contains.
+func overlay(doc map[string]any) (OptionFunc, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, errors.NewValidationError(err, errors.ErrorCodeInvalidInput, "Configuration could not be converted").
+			WithField("config").WithRule("format")
+	}
+
+	// Decode on top of the defaults first to find unknown keys, mistyped values and
+	// values out of range before any instance is affected.
+	probe := NewDefaultOptions()
+	if err := decode(data, &probe); err != nil {
+		return nil, err
+	}
+	if err := probe.Validate(); err != nil {
+		return nil, err
+	}
+
+	return func(o *Options) {
+		// The sections of o may be shared with other Options, so they are copied
+		// before the settings are decoded into them.
+		cloneSections(o)
+		decode(data, o)
+	}, nil
+}
+
+// decode decodes JSON settings into o, rejecting keys Options does not have.
+func decode(data []byte, o *Options) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(o)
+	if err == nil {
+		return nil
+	}
+
+	var typ *json.UnmarshalTypeError
+	if stdErrors.As(err, &typ) {
+		return errors.NewFieldFormatError(typ.Field, typ.Value, typ.Type.String())
+	}
+	return errors.NewConfigurationValidationError("config", err.Error())
+}
+
+// lookup returns the value at a dotted path of doc, or nil if there is none.
+func lookup(doc map[string]any, path string) any {
+	var value any = doc
+	for _, name := range strings.Split(path, ".") {
+		section, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = section[name]
+	}
+	return value
+}
+
+// store sets the value at a dotted path of doc, creating the sections on the way.
+func store(doc map[string]any, path string, value any) {
+	names := strings.Split(path, ".")
+	for _, name := range names[:len(names)-1] {
+		section, ok := doc[name].(map[string]any)
+		if !ok {
+			section = make(map[string]any)
+			doc[name] = section
+		}
+		doc = section
+	}
+	doc[names[len(names)-1]] = value
+}
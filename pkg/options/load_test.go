@@ -0,0 +1,261 @@
+package options
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/iamNilotpal/ignite/pkg/errors"
+)
+
+// writeConfig writes a configuration file named name into a temporary directory and
+// returns its path.
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// apply returns the default options with opt applied.
+func apply(opt OptionFunc) Options {
+	o := NewDefaultOptions()
+	opt(&o)
+	return o
+}
+
+// violations returns the fields of the validation errors joined in err.
+func violations(t *testing.T, err error) []string {
+	t.Helper()
+
+	if err == nil {
+		t.Fatal("got no error, want validation errors")
+	}
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+
+	fields := make([]string, 0, len(errs))
+	for _, err := range errs {
+		ve, ok := errors.AsValidationError(err)
+		if !ok {
+			t.Fatalf("%v is not a validation error", err)
+		}
+		fields = append(fields, ve.Field())
+	}
+	slices.Sort(fields)
+	return fields
+}
+
+func TestLoadFileFormats(t *testing.T) {
+	files := map[string]string{
+		"ignite.json": `{
+			"dataDir": "/srv/ignite",
+			"compactInterval": "90m",
+			"segmentOptions": {"maxSegmentSize": 1073741824, "prefix": "seg"},
+			"cacheOptions": {"size": 4096},
+			"readOnly": true
+		}`,
+		"ignite.yaml": `
+dataDir: /srv/ignite
+compactInterval: 90m
+segmentOptions:
+  maxSegmentSize: 1073741824
+  prefix: seg
+cacheOptions:
+  size: 4096
+readOnly: true
+`,
+		"ignite.toml": `
+dataDir = "/srv/ignite"
+compactInterval = "90m"
+readOnly = true
+
+[segmentOptions]
+maxSegmentSize = 1073741824
+prefix = "seg"
+
+[cacheOptions]
+size = 4096
+`,
+	}
+
+	for name, content := range files {
+		t.Run(filepath.Ext(name), func(t *testing.T) {
+			opt, err := LoadFile(writeConfig(t, name, content))
+			if err != nil {
+				t.Fatalf("LoadFile() error = %v", err)
+			}
+
+			o := apply(opt)
+			if o.DataDir != "/srv/ignite" || o.CompactInterval != 90*time.Minute || !o.ReadOnly {
+				t.Fatalf("LoadFile() = dataDir %q, compactInterval %v, readOnly %t", o.DataDir, o.CompactInterval, o.ReadOnly)
+			}
+			if o.SegmentOptions.Size != 1<<30 || o.SegmentOptions.Prefix != "seg" || o.CacheOptions.Size != 4096 {
+				t.Fatalf("LoadFile() sections = %+v, %+v", o.SegmentOptions, o.CacheOptions)
+			}
+			// Settings the file leaves out keep their value.
+			if o.SegmentOptions.Directory != DefaultSegmentDirectory || o.CacheOptions.Shards != DefaultCacheShards {
+				t.Fatalf("LoadFile() changed settings missing from the file: %+v, %+v", o.SegmentOptions, o.CacheOptions)
+			}
+		})
+	}
+}
+
+func TestLoadFileDurationAsNanoseconds(t *testing.T) {
+	opt, err := LoadFile(writeConfig(t, "ignite.json", `{"compactInterval": 7200000000000}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o := apply(opt); o.CompactInterval != 2*time.Hour {
+		t.Fatalf("compactInterval = %v, want 2h", o.CompactInterval)
+	}
+}
+
+func TestLoadFileReportsEveryViolation(t *testing.T) {
+	path := writeConfig(t, "ignite.yaml", `
+compactInterval: 10s
+segmentOptions:
+  maxSegmentSize: 1024
+cacheOptions:
+  shards: 0
+readOptions:
+  mode: fast
+`)
+	_, err := LoadFile(path)
+	want := []string{"cacheOptions.shards", "compactInterval", "readOptions.mode", "segmentOptions.maxSegmentSize"}
+	if got := violations(t, err); !slices.Equal(got, want) {
+		t.Fatalf("LoadFile() violations = %v, want %v", got, want)
+	}
+}
+
+func TestLoadFileRejectsBadInput(t *testing.T) {
+	tests := map[string]struct{ name, content string }{
+		"unknown key":        {"ignite.json", `{"dataDirectory": "/srv"}`},
+		"unknown nested key": {"ignite.yaml", "segmentOptions:\n  size: 1\n"},
+		"wrong type":         {"ignite.json", `{"cacheOptions": {"size": "big"}}`},
+		"bad duration":       {"ignite.toml", `compactInterval = "often"`},
+		"syntax":             {"ignite.json", `{"dataDir": `},
+		"extension":          {"ignite.ini", `dataDir=/srv`},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadFile(writeConfig(t, test.name, test.content))
+			if !errors.IsValidationError(err) {
+				t.Fatalf("LoadFile() error = %v, want a validation error", err)
+			}
+		})
+	}
+
+	if _, err := LoadFile(filepath.Join(t.TempDir(), "missing.json")); err == nil || errors.IsValidationError(err) {
+		t.Fatalf("LoadFile() of a missing file error = %v, want a file access error", err)
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("TEST_DATA_DIR", "/srv/env")
+	t.Setenv("TEST_COMPACT_INTERVAL", "3h")
+	t.Setenv("TEST_CACHE_SIZE", " 1024 ")
+	t.Setenv("TEST_READ_ONLY", "1")
+	t.Setenv("TEST_READ_MODE", "mmap")
+	t.Setenv("TEST_SYNC_POLICY", "interval")
+	t.Setenv("TEST_SYNC_INTERVAL", "250ms")
+	t.Setenv("TEST_COMPACT_RATE_LIMIT", "1048576")
+	t.Setenv("OTHER_LOG_LEVEL", "debug")
+
+	opt, err := FromEnv("TEST_")
+	if err != nil {
+		t.Fatalf("FromEnv() error = %v", err)
+	}
+
+	o := apply(opt)
+	if o.DataDir != "/srv/env" || o.CompactInterval != 3*time.Hour || o.CacheOptions.Size != 1024 ||
+		!o.ReadOnly || o.ReadOptions.Mode != ReadModeMmap {
+		t.Fatalf("FromEnv() = %+v", o)
+	}
+	if o.SyncOptions.Policy != SyncInterval || o.SyncOptions.Interval != 250*time.Millisecond || o.CompactRateLimit != 1<<20 {
+		t.Fatalf("FromEnv() = sync %+v, compaction rate limit %d", o.SyncOptions, o.CompactRateLimit)
+	}
+	if o.LogLevel != DefaultLogLevel {
+		t.Fatalf("FromEnv() read a variable with another prefix: logLevel %q", o.LogLevel)
+	}
+}
+
+func TestFromEnvReportsEveryViolation(t *testing.T) {
+	t.Setenv("TEST_CACHE_SIZE", "-1")
+	t.Setenv("TEST_READ_ONLY", "maybe")
+	t.Setenv("TEST_COMPACT_INTERVAL", "soon")
+
+	_, err := FromEnv("TEST_")
+	want := []string{"TEST_CACHE_SIZE", "TEST_COMPACT_INTERVAL", "TEST_READ_ONLY"}
+	if got := violations(t, err); !slices.Equal(got, want) {
+		t.Fatalf("FromEnv() violations = %v, want %v", got, want)
+	}
+}
+
+func TestPrecedence(t *testing.T) {
+	file, err := LoadFile(writeConfig(t, "ignite.json", `{"dataDir": "/srv/file", "logLevel": "warn", "cacheOptions": {"size": 1}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("IGNITE_LOG_LEVEL", "debug")
+	t.Setenv("IGNITE_CACHE_SIZE", "2")
+	env, err := FromEnv("IGNITE_")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Defaults, then the file, then the environment, then code.
+	o := NewDefaultOptions()
+	for _, opt := range []OptionFunc{file, env, WithCacheSize(3)} {
+		opt(&o)
+	}
+	if o.DataDir != "/srv/file" || o.LogLevel != "debug" || o.CacheOptions.Size != 3 {
+		t.Fatalf("options = dataDir %q, logLevel %q, cache size %d", o.DataDir, o.LogLevel, o.CacheOptions.Size)
+	}
+}
+
+func TestParse(t *testing.T) {
+	opt, err := Parse(map[string]string{"CACHEOPTIONS.SIZE": "64", "compactInterval": "2h"})
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if o := apply(opt); o.CacheOptions.Size != 64 || o.CompactInterval != 2*time.Hour {
+		t.Fatalf("Parse() = cache size %d, compactInterval %v", o.CacheOptions.Size, o.CompactInterval)
+	}
+
+	_, err = Parse(map[string]string{"nope": "1", "cacheOptions.shards": "many"})
+	if got := violations(t, err); !slices.Equal(got, []string{"cacheOptions.shards", "nope"}) {
+		t.Fatalf("Parse() violations = %v", got)
+	}
+
+	// Values that parse are checked against their range as well.
+	_, err = Parse(map[string]string{"cacheOptions.shards": "0"})
+	if got := violations(t, err); !slices.Equal(got, []string{"cacheOptions.shards"}) {
+		t.Fatalf("Parse() violations = %v", got)
+	}
+}
+
+func TestOverlayDoesNotShareSections(t *testing.T) {
+	opt, err := Parse(map[string]string{"cacheOptions.size": "64"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base := NewDefaultOptions()
+	other := base // Shares its sections with base.
+	opt(&other)
+
+	if base.CacheOptions.Size != DefaultCacheSize {
+		t.Fatalf("applying settings to a copy changed the original: cache size %d", base.CacheOptions.Size)
+	}
+	if fresh := NewDefaultOptions(); fresh.CacheOptions.Size != DefaultCacheSize {
+		t.Fatalf("applying settings changed the defaults: cache size %d", fresh.CacheOptions.Size)
+	}
+}
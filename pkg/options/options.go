@@ -35,6 +35,130 @@ type segmentOptions struct {
 	Prefix string `json:"prefix"`
 }
 
+// Defines configurable parameters for the hot value cache placed in front of segment reads.
+// The cache is disabled unless a non-zero size is configured.
+type cacheOptions struct {
+	// Defines the maximum number of bytes (keys plus values) the cache may hold.
+	// A size of zero disables the cache entirely.
+	//
+	// Default: 0 (disabled)
+	Size uint64 `json:"size"`
+
+	// Defines how many independently locked shards the cache is split into.
+	// More shards reduce lock contention under concurrent reads. The value is
+	// rounded up to the next power of two.
+	//
+	// Default: 16
+	Shards uint `json:"shards"`
+}
+
+// ReadMode selects how values are read from sealed segment files.
+type ReadMode string
+
+const (
+	// ReadModeStandard reads every value from disk with a positioned read into a
+	// freshly allocated buffer.
+	ReadModeStandard ReadMode = "standard"
+
+	// ReadModeMmap maps sealed segment files into memory read-only and serves
+	// values by slicing directly out of the mapping. The active segment is still
+	// read with positioned reads because it keeps growing. Platforms without mmap
+	// support fall back to standard reads.
+	ReadModeMmap ReadMode = "mmap"
+)
+
+// Defines configurable parameters for the segment read path.
+type readOptions struct {
+	// Selects how sealed segments are read.
+	//
+	// Default: "standard"
+	Mode ReadMode `json:"mode"`
+
+	// Defines how many read-only sealed segment file handles are kept open at
+	// once. The least recently used handle is closed when the limit is reached.
+	//
+	// Default: 256
+	MaxOpenFiles uint `json:"maxOpenFiles"`
+}
+
+// Compression names the codec used to compress values before they are written.
+type Compression string
+
+const (
+	// CompressionNone stores every value as-is.
+	CompressionNone Compression = "none"
+
+	// CompressionFlate compresses values with DEFLATE from the standard library.
+	// It suits text-like values such as JSON documents.
+	CompressionFlate Compression = "flate"
+)
+
+// Defines configurable parameters for value compression.
+type compressionOptions struct {
+	// Selects the codec used to compress newly written values. Values already on
+	// disk are always decoded with the codec recorded in their entry header, so the
+	// codec can be changed at any time.
+	//
+	// Default: "none"
+	Codec Compression `json:"codec"`
+
+	// Defines the smallest value size, in bytes, that is compressed. Smaller values
+	// are stored as-is since compression rarely pays off for them. Values that do
+	// not shrink when compressed are stored as-is as well.
+	//
+	// Default: 256
+	MinSize uint32 `json:"minSize"`
+}
+
+// Defines configurable parameters for large value storage.
+type blobOptions struct {
+	// Defines the value size, in bytes, from which values are stored in their own blob
+	// file instead of inline in a segment. The segment then only holds a small
+	// reference to the blob. Zero stores every value inline.
+	//
+	// Default: 1MB
+	Threshold uint64 `json:"threshold"`
+
+	// Specifies the subdirectory within the data directory where blob files are stored.
+	//
+	// Default: "/blobs"
+	Directory string `json:"directory"`
+}
+
+// SyncPolicy selects when writes are flushed from the operating system's page cache to
+// stable storage.
+type SyncPolicy string
+
+const (
+	// SyncNone leaves flushing to the operating system. Segments are still synced
+	// when they are sealed and when the store is closed, so only the writes of the
+	// last moments before a machine crash can be lost.
+	SyncNone SyncPolicy = "none"
+
+	// SyncAlways syncs the active segment after every write, before the write
+	// returns. No acknowledged write is lost, at the cost of an fsync per write.
+	SyncAlways SyncPolicy = "always"
+
+	// SyncInterval syncs the active segment in the background every
+	// syncOptions.interval, bounding how much a machine crash can lose.
+	SyncInterval SyncPolicy = "interval"
+)
+
+// Defines configurable parameters for flushing writes to stable storage.
+type syncOptions struct {
+	// Selects when writes are synced. It can be changed on a running instance with
+	// Instance.Reconfigure.
+	//
+	// Default: "none"
+	Policy SyncPolicy `json:"policy"`
+
+	// Defines the time between background syncs under the "interval" policy. Other
+	// policies ignore it.
+	//
+	// Default: 1s
+	Interval time.Duration `json:"interval"`
+}
+
 // Defines the configuration parameters for Ignite DB.
 // It provides control over storage, performance and maintenance aspects.
 type Options struct {
@@ -50,8 +174,52 @@ type Options struct {
 	// Default: 5h
 	CompactInterval time.Duration `json:"compactInterval"`
 
+	// Limits how many bytes of segment data a compaction run walks per second, so
+	// that compaction does not starve foreground reads and writes of disk bandwidth.
+	// Zero leaves compaction unthrottled.
+	//
+	// Default: 0 (unlimited)
+	CompactRateLimit uint64 `json:"compactRateLimit"`
+
 	// Configures segment management including size limits and naming convention.
 	SegmentOptions *segmentOptions `json:"segmentOptions"`
+
+	// Configures the hot value cache that serves repeated reads from memory.
+	CacheOptions *cacheOptions `json:"cacheOptions"`
+
+	// Configures how values are read back from segment files.
+	ReadOptions *readOptions `json:"readOptions"`
+
+	// Configures compression of values before they are written.
+	CompressionOptions *compressionOptions `json:"compressionOptions"`
+
+	// Configures separate storage of large values in blob files.
+	BlobOptions *blobOptions `json:"blobOptions"`
+
+	// Configures when writes are flushed to stable storage.
+	SyncOptions *syncOptions `json:"syncOptions"`
+
+	// Enables encryption at rest when set. Every new segment is encrypted with its own
+	// data key, wrapped by the provider's current master key. Existing unencrypted
+	// segments remain readable.
+	//
+	// Default: nil (encryption disabled)
+	KeyProvider KeyProvider `json:"-"`
+
+	// Opens the data directory without the ability to write to it. A read-only
+	// instance creates no active segment, takes no directory lock and never modifies
+	// any file, so it can share a data directory with a live writer process. Set and
+	// Delete are rejected; Refresh picks up data the writer has added since open.
+	//
+	// Default: false
+	ReadOnly bool `json:"readOnly"`
+
+	// Sets the minimum level of log messages: "debug", "info", "warn", "error",
+	// "dpanic", "panic" or "fatal". It can be changed on a running instance with
+	// Instance.Reconfigure.
+	//
+	// Default: "info"
+	LogLevel string `json:"logLevel"`
 }
 
 // OptionFunc is a function type that modifies the Ignite system's configuration.
@@ -62,55 +230,167 @@ func WithDefaultOptions() OptionFunc {
 	return func(o *Options) {
 		opts := NewDefaultOptions()
 		o.DataDir = opts.DataDir
+		o.CacheOptions = opts.CacheOptions
+		o.ReadOptions = opts.ReadOptions
+		o.CompressionOptions = opts.CompressionOptions
+		o.BlobOptions = opts.BlobOptions
+		o.SyncOptions = opts.SyncOptions
 		o.SegmentOptions = opts.SegmentOptions
 		o.CompactInterval = opts.CompactInterval
+		o.CompactRateLimit = opts.CompactRateLimit
+		o.ReadOnly = opts.ReadOnly
+		o.KeyProvider = opts.KeyProvider
+		o.LogLevel = opts.LogLevel
 	}
 }
 
 // Sets the primary data directory for Ignite.
+// Options.Validate rejects an empty directory.
 func WithDataDir(directory string) OptionFunc {
 	return func(o *Options) {
-		directory = strings.TrimSpace(directory)
-		if directory != "" {
-			o.DataDir = directory
-		}
+		o.DataDir = strings.TrimSpace(directory)
 	}
 }
 
 // Sets the interval at which Ignite performs compaction operations.
+// Options.Validate rejects intervals shorter than MinCompactInterval.
 func WithCompactInterval(interval time.Duration) OptionFunc {
 	return func(o *Options) {
-		if interval > DefaultCompactInterval {
-			o.CompactInterval = interval
-		}
+		o.CompactInterval = interval
 	}
 }
 
 // Sets the directory specifically for storing segment files.
+// Options.Validate rejects an empty directory.
 func WithSegmentDir(directory string) OptionFunc {
 	return func(o *Options) {
-		directory = strings.TrimSpace(directory)
-		if directory != "" {
-			o.SegmentOptions.Directory = directory
-		}
+		o.SegmentOptions.Directory = strings.TrimSpace(directory)
 	}
 }
 
 // Sets the file name prefix for segment files.
+// Options.Validate rejects an empty prefix.
 func WithSegmentPrefix(prefix string) OptionFunc {
 	return func(o *Options) {
-		prefix = strings.TrimSpace(prefix)
-		if prefix != "" {
-			o.SegmentOptions.Prefix = prefix
-		}
+		o.SegmentOptions.Prefix = strings.TrimSpace(prefix)
 	}
 }
 
 // Sets the maximum size of individual segment files.
+// Options.Validate rejects sizes outside MinSegmentSize to MaxSegmentSize.
 func WithSegmentSize(size uint64) OptionFunc {
 	return func(o *Options) {
-		if size > MinSegmentSize && size < MaxSegmentSize {
-			o.SegmentOptions.Size = size
-		}
+		o.SegmentOptions.Size = size
+	}
+}
+
+// Sets the maximum number of bytes the hot value cache may hold.
+// A size of zero disables the cache.
+func WithCacheSize(size uint64) OptionFunc {
+	return func(o *Options) {
+		o.CacheOptions.Size = size
+	}
+}
+
+// Sets the number of shards the hot value cache is split into.
+// Options.Validate rejects zero shards.
+func WithCacheShards(shards uint) OptionFunc {
+	return func(o *Options) {
+		o.CacheOptions.Shards = shards
+	}
+}
+
+// Sets how values are read from sealed segment files.
+// Options.Validate rejects modes other than ReadModeStandard and ReadModeMmap.
+func WithReadMode(mode ReadMode) OptionFunc {
+	return func(o *Options) {
+		o.ReadOptions.Mode = mode
+	}
+}
+
+// Sets the maximum number of sealed segment files kept open for reading.
+// Options.Validate rejects a limit of zero.
+func WithMaxOpenFiles(limit uint) OptionFunc {
+	return func(o *Options) {
+		o.ReadOptions.MaxOpenFiles = limit
+	}
+}
+
+// Sets the codec used to compress newly written values. An empty name disables
+// compression, like CompressionNone; opening a store with an unknown codec fails.
+func WithCompression(codec Compression) OptionFunc {
+	return func(o *Options) {
+		o.CompressionOptions.Codec = Compression(strings.TrimSpace(string(codec)))
+	}
+}
+
+// Sets the smallest value size, in bytes, that is compressed.
+func WithCompressionMinSize(size uint32) OptionFunc {
+	return func(o *Options) {
+		o.CompressionOptions.MinSize = size
+	}
+}
+
+// Sets the value size, in bytes, from which values are stored in separate blob files.
+// Zero stores every value inline.
+func WithBlobThreshold(threshold uint64) OptionFunc {
+	return func(o *Options) {
+		o.BlobOptions.Threshold = threshold
+	}
+}
+
+// Sets the directory for storing blob files.
+// Options.Validate rejects an empty directory.
+func WithBlobDir(directory string) OptionFunc {
+	return func(o *Options) {
+		o.BlobOptions.Directory = strings.TrimSpace(directory)
+	}
+}
+
+// Sets when writes are flushed to stable storage.
+// Options.Validate rejects policies other than SyncNone, SyncAlways and SyncInterval.
+func WithSyncPolicy(policy SyncPolicy) OptionFunc {
+	return func(o *Options) {
+		o.SyncOptions.Policy = policy
+	}
+}
+
+// Sets the time between background syncs under the SyncInterval policy.
+// Options.Validate rejects intervals of zero or less when that policy is selected.
+func WithSyncInterval(interval time.Duration) OptionFunc {
+	return func(o *Options) {
+		o.SyncOptions.Interval = interval
+	}
+}
+
+// Sets how many bytes of segment data compaction may walk per second. Zero removes the
+// limit.
+func WithCompactRateLimit(bytesPerSecond uint64) OptionFunc {
+	return func(o *Options) {
+		o.CompactRateLimit = bytesPerSecond
+	}
+}
+
+// Enables encryption at rest with master keys from the given provider. A nil provider
+// disables encryption for new segments; a store that already holds encrypted
+// segments then fails to open with ErrorCodeEncryptionKeyUnavailable.
+func WithKeyProvider(provider KeyProvider) OptionFunc {
+	return func(o *Options) {
+		o.KeyProvider = provider
+	}
+}
+
+// Opens the data directory in read-only mode.
+func WithReadOnly() OptionFunc {
+	return func(o *Options) {
+		o.ReadOnly = true
+	}
+}
+
+// Sets the minimum level of log messages.
+// Options.Validate rejects names zap does not know.
+func WithLogLevel(level string) OptionFunc {
+	return func(o *Options) {
+		o.LogLevel = strings.ToLower(strings.TrimSpace(level))
 	}
 }
@@ -0,0 +1,116 @@
+package options
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// staticKeys is a KeyProvider with a single fixed key.
+type staticKeys struct{}
+
+func (staticKeys) CurrentKey() (string, []byte, error) { return "k1", make([]byte, 32), nil }
+func (staticKeys) Key(string) ([]byte, error)          { return make([]byte, 32), nil }
+
+func TestWithKeyProviderNil(t *testing.T) {
+	o := NewDefaultOptions()
+	WithKeyProvider(staticKeys{})(&o)
+	if o.KeyProvider == nil {
+		t.Fatal("WithKeyProvider() did not set the provider")
+	}
+
+	// nil turns encryption off again, and is valid.
+	WithKeyProvider(nil)(&o)
+	if o.KeyProvider != nil {
+		t.Fatalf("WithKeyProvider(nil) kept %v", o.KeyProvider)
+	}
+	if err := o.Validate(); err != nil {
+		t.Fatalf("Validate() with no key provider error = %v", err)
+	}
+}
+
+func TestValidateReportsEveryViolation(t *testing.T) {
+	o := NewDefaultOptions()
+	for _, opt := range []OptionFunc{
+		WithDataDir(" "), WithSegmentSize(MaxSegmentSize + 1), WithSegmentPrefix(""),
+		WithCacheShards(0), WithReadMode("fast"), WithMaxOpenFiles(0), WithLogLevel("loud"),
+		WithCompression("zstd"),
+	} {
+		opt(&o)
+	}
+
+	want := []string{
+		"cacheOptions.shards", "compressionOptions.codec", "dataDir", "logLevel", "readOptions.maxOpenFiles",
+		"readOptions.mode", "segmentOptions.maxSegmentSize", "segmentOptions.prefix",
+	}
+	if got := violations(t, o.Validate()); !slices.Equal(got, want) {
+		t.Fatalf("Validate() violations = %v, want %v", got, want)
+	}
+
+	o.CacheOptions = nil
+	if got := violations(t, o.Validate()); !slices.Contains(got, "cacheOptions") {
+		t.Fatalf("Validate() without a cache section = %v", got)
+	}
+}
+
+func TestDefaultOptionsDoNotShareSections(t *testing.T) {
+	a, b := NewDefaultOptions(), NewDefaultOptions()
+	WithCacheShards(1)(&a)
+	WithSegmentPrefix("other")(&a)
+
+	if b.CacheOptions.Shards != DefaultCacheShards || b.SegmentOptions.Prefix != DefaultSegmentPrefix {
+		t.Fatalf("changing one Options changed another: %+v, %+v", b.CacheOptions, b.SegmentOptions)
+	}
+	if err := b.Validate(); err != nil {
+		t.Fatalf("default options are invalid: %v", err)
+	}
+}
+
+func TestValidateSyncOptions(t *testing.T) {
+	tests := map[string]struct {
+		policy   SyncPolicy
+		interval time.Duration
+		want     []string
+	}{
+		"none":                {SyncNone, 0, nil},
+		"always":              {SyncAlways, 0, nil},
+		"interval":            {SyncInterval, time.Second, nil},
+		"interval of zero":    {SyncInterval, 0, []string{"syncOptions.interval"}},
+		"unknown policy":      {"sometimes", time.Second, []string{"syncOptions.policy"}},
+		"negative and unused": {SyncNone, -time.Second, nil},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			o := NewDefaultOptions()
+			WithSyncPolicy(test.policy)(&o)
+			WithSyncInterval(test.interval)(&o)
+
+			err := o.Validate()
+			if test.want == nil {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			if got := violations(t, err); !slices.Equal(got, test.want) {
+				t.Fatalf("Validate() violations = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestValidateCompressionCodec(t *testing.T) {
+	for _, codec := range []Compression{"", CompressionNone, CompressionFlate, " flate "} {
+		o := NewDefaultOptions()
+		WithCompression(codec)(&o)
+		if err := o.Validate(); err != nil {
+			t.Fatalf("Validate() with codec %q error = %v", codec, err)
+		}
+	}
+
+	o := NewDefaultOptions()
+	o.CompressionOptions.Codec = "Flate"
+	if got := violations(t, o.Validate()); !slices.Equal(got, []string{"compressionOptions.codec"}) {
+		t.Fatalf("Validate() violations = %v", got)
+	}
+}
@@ -0,0 +1,65 @@
+package options
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// Change describes a setting whose value differs between two Options.
+type Change struct {
+	Field string // JSON path of the setting, such as "cacheOptions.size".
+	Old   any    // Value before the change.
+	New   any    // Value after the change.
+}
+
+// Clone returns a copy of o with its own sections, which can be changed without
+// affecting o.
+func (o Options) Clone() Options {
+	cloneSections(&o)
+	return o
+}
+
+// Values returns every setting that LoadFile, FromEnv and Parse understand, keyed by
+// its JSON path. Sizes are uint64, durations time.Duration, flags bool and the rest
+// strings. KeyProvider is not a setting and is not included.
+func (o *Options) Values() map[string]any {
+	data, _ := json.Marshal(o)
+
+	doc := make(map[string]any)
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	decoder.Decode(&doc)
+
+	values := make(map[string]any, len(settings))
+	for _, s := range settings {
+		value := lookup(doc, s.path)
+		if number, ok := value.(json.Number); ok {
+			switch s.kind {
+			case kindDuration:
+				n, _ := number.Int64()
+				value = time.Duration(n)
+			default:
+				n, _ := strconv.ParseUint(number.String(), 10, 64)
+				value = n
+			}
+		}
+		values[s.path] = value
+	}
+	return values
+}
+
+// Diff returns the settings whose values differ between old and new, in the order
+// the settings are listed by FromEnv. KeyProvider is not compared.
+func Diff(old, new *Options) []Change {
+	before, after := old.Values(), new.Values()
+
+	var changes []Change
+	for _, s := range settings {
+		if before[s.path] != after[s.path] {
+			changes = append(changes, Change{Field: s.path, Old: before[s.path], New: after[s.path]})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,134 @@
+package options
+
+import (
+	stdErrors "errors"
+	"fmt"
+	"strings"
+
+	"github.com/iamNilotpal/ignite/pkg/errors"
+	"go.uber.org/zap/zapcore"
+)
+
+// Validate checks every setting against its allowed values and returns nil when the
+// options are usable. Otherwise it returns every violation found, joined with
+// errors.Join, each a *errors.ValidationError naming the field by its JSON path (as
+// configuration files spell it) along with the rule it breaks, the value provided
+// and the value expected. ignite.NewInstance calls Validate before opening the store.
+func (o *Options) Validate() error {
+	var errs []error
+
+	errs = append(errs, required("dataDir", o.DataDir)...)
+	if o.CompactInterval < MinCompactInterval {
+		errs = append(errs, errors.NewFieldRangeError("compactInterval", o.CompactInterval, MinCompactInterval, nil).
+			WithExpected("at least "+MinCompactInterval.String()))
+	}
+
+	if o.SegmentOptions == nil {
+		errs = append(errs, errors.NewRequiredFieldError("segmentOptions"))
+	} else {
+		if size := o.SegmentOptions.Size; size < MinSegmentSize || size > MaxSegmentSize {
+			errs = append(errs, errors.NewFieldRangeError("segmentOptions.maxSegmentSize", size, MinSegmentSize, MaxSegmentSize).
+				WithExpected(fmt.Sprintf("between %d and %d bytes", MinSegmentSize, MaxSegmentSize)))
+		}
+		errs = append(errs, required("segmentOptions.directory", o.SegmentOptions.Directory)...)
+		errs = append(errs, required("segmentOptions.prefix", o.SegmentOptions.Prefix)...)
+	}
+
+	if o.CacheOptions == nil {
+		errs = append(errs, errors.NewRequiredFieldError("cacheOptions"))
+	} else if o.CacheOptions.Shards < 1 {
+		errs = append(errs, errors.NewFieldRangeError("cacheOptions.shards", o.CacheOptions.Shards, 1, nil).
+			WithExpected("at least 1"))
+	}
+
+	if o.ReadOptions == nil {
+		errs = append(errs, errors.NewRequiredFieldError("readOptions"))
+	} else {
+		if mode := o.ReadOptions.Mode; mode != ReadModeStandard && mode != ReadModeMmap {
+			errs = append(errs, errors.NewConfigurationValidationError("readOptions.mode", "unknown read mode").
+				WithRule("enum").WithProvided(mode).WithExpected([]ReadMode{ReadModeStandard, ReadModeMmap}))
+		}
+		if o.ReadOptions.MaxOpenFiles < 1 {
+			errs = append(errs, errors.NewFieldRangeError("readOptions.maxOpenFiles", o.ReadOptions.MaxOpenFiles, 1, nil).
+				WithExpected("at least 1"))
+		}
+	}
+
+	if o.CompressionOptions == nil {
+		errs = append(errs, errors.NewRequiredFieldError("compressionOptions"))
+	} else {
+		switch codec := o.CompressionOptions.Codec; codec {
+		case "", CompressionNone, CompressionFlate:
+		default:
+			errs = append(errs, errors.NewConfigurationValidationError("compressionOptions.codec", "unknown compression codec").
+				WithRule("enum").WithProvided(codec).WithExpected([]Compression{CompressionNone, CompressionFlate}))
+		}
+	}
+
+	if o.BlobOptions == nil {
+		errs = append(errs, errors.NewRequiredFieldError("blobOptions"))
+	} else {
+		errs = append(errs, required("blobOptions.directory", o.BlobOptions.Directory)...)
+	}
+
+	if o.SyncOptions == nil {
+		errs = append(errs, errors.NewRequiredFieldError("syncOptions"))
+	} else {
+		switch o.SyncOptions.Policy {
+		case SyncNone, SyncAlways:
+		case SyncInterval:
+			if o.SyncOptions.Interval <= 0 {
+				errs = append(errs, errors.NewFieldRangeError("syncOptions.interval", o.SyncOptions.Interval, 0, nil).
+					WithExpected("a positive duration with the interval policy"))
+			}
+		default:
+			errs = append(errs, errors.NewConfigurationValidationError("syncOptions.policy", "unknown sync policy").
+				WithRule("enum").WithProvided(o.SyncOptions.Policy).WithExpected([]SyncPolicy{SyncNone, SyncAlways, SyncInterval}))
+		}
+	}
+
+	if _, err := zapcore.ParseLevel(o.LogLevel); err != nil {
+		errs = append(errs, errors.NewConfigurationValidationError("logLevel", "unknown log level").
+			WithRule("enum").WithProvided(o.LogLevel).
+			WithExpected([]string{"debug", "info", "warn", "error", "dpanic", "panic", "fatal"}))
+	}
+
+	return stdErrors.Join(errs...)
+}
+
+// required reports a violation when the setting field holds no more than whitespace.
+func required(field, value string) []error {
+	if strings.TrimSpace(value) != "" {
+		return nil
+	}
+	return []error{errors.NewRequiredFieldError(field).WithProvided(value).WithExpected("a non-empty value")}
+}
+
+// cloneSections gives o its own copy of every options section, so that changes made
+// through o are not seen by other Options sharing the same sections.
+func cloneSections(o *Options) {
+	if o.SegmentOptions != nil {
+		section := *o.SegmentOptions
+		o.SegmentOptions = &section
+	}
+	if o.CacheOptions != nil {
+		section := *o.CacheOptions
+		o.CacheOptions = &section
+	}
+	if o.ReadOptions != nil {
+		section := *o.ReadOptions
+		o.ReadOptions = &section
+	}
+	if o.CompressionOptions != nil {
+		section := *o.CompressionOptions
+		o.CompressionOptions = &section
+	}
+	if o.BlobOptions != nil {
+		section := *o.BlobOptions
+		o.BlobOptions = &section
+	}
+	if o.SyncOptions != nil {
+		section := *o.SyncOptions
+		o.SyncOptions = &section
+	}
+}
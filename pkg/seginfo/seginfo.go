@@ -4,7 +4,7 @@
 //
 // Where:
 //   - prefix: A configurable string identifying the file type (e.g., "segment", "log", "backup").
-//   - NNNNN: A zero-padded 5-digit sequence number (00001, 00002, etc.).
+//   - NNNNN: A sequence number zero-padded to at least 5 digits (00001, 00002, ..., 123456).
 //   - timestamp: A nanosecond-precision Unix timestamp for uniqueness and traceability.
 //   - .seg: A fixed file extension (this could be made configurable in future versions).
 //
@@ -16,6 +16,7 @@
 package seginfo
 
 import (
+	"cmp"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -67,15 +68,40 @@ func GetLastSegmentInfo(dataDir, segmentDir, prefix string) (uint64, os.FileInfo
 }
 
 // GetLastSegmentName searches the segment directory and identifies the file with the highest sequence ID.
-// This function implements a lexicographic sorting strategy that works because segment filenames
-// use zero-padded IDs and monotonically increasing timestamps.
+// Files are ordered by their parsed numeric ID rather than by name, so the result stays correct
+// once IDs outgrow the zero padding used in filenames.
 //
 // Returns:
 //   - string: Full path to the segment file with the highest ID (empty if none found).
 //   - error: Detailed error if directory reading fails.
 func GetLastSegmentName(dataDir, segmentDir, prefix string) (string, error) {
+	matchingFiles, err := ListSegmentNames(dataDir, segmentDir, prefix)
+	if err != nil {
+		return "", err
+	}
+
+	// Handle the case where no segment files exist yet.
+	if len(matchingFiles) == 0 {
+		return "", nil
+	}
+
+	// Return the file with the highest ID (last in sorted order).
+	return matchingFiles[len(matchingFiles)-1], nil
+}
+
+// ListSegmentNames returns the full paths of every segment file in the segment directory,
+// ordered from the oldest to the most recent segment.
+//
+// Ordering is numeric on the segment ID embedded in each filename. Lexicographic ordering
+// only works while every ID fits in the zero-padded width, and would place segment 100000
+// before segment 99999.
+//
+// Returns:
+//   - []string: Full paths of all segment files sorted by ascending ID (empty if none found).
+//   - error: Detailed error if directory reading fails or a filename cannot be parsed.
+func ListSegmentNames(dataDir, segmentDir, prefix string) ([]string, error) {
 	if dataDir == "" || segmentDir == "" || prefix == "" {
-		return "", fmt.Errorf("all parameters (dataDir, segmentDir, prefix) must be non-empty")
+		return nil, fmt.Errorf("all parameters (dataDir, segmentDir, prefix) must be non-empty")
 	}
 
 	// Construct the search pattern for segment files.
@@ -85,22 +111,23 @@ func GetLastSegmentName(dataDir, segmentDir, prefix string) (string, error) {
 	// Safely read all matching files using our filesystem utility.
 	matchingFiles, err := filesys.ReadDir(searchPattern)
 	if err != nil {
-		return "", fmt.Errorf("failed to read segment directory with pattern %s: %w", searchPattern, err)
+		return nil, fmt.Errorf("failed to read segment directory with pattern %s: %w", searchPattern, err)
 	}
 
-	// Handle the case where no segment files exist yet.
-	if len(matchingFiles) == 0 {
-		return "", nil
+	ids := make(map[string]uint64, len(matchingFiles))
+	for _, file := range matchingFiles {
+		id, err := ParseSegmentID(file, prefix)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse segment ID from %s: %w", file, err)
+		}
+		ids[file] = id
 	}
 
-	// Sort files lexicographically. This works correctly because:
-	// 1. Segment IDs are zero-padded (00001, 00002, etc.).
-	// 2. Timestamps are monotonically increasing.
-	// 3. The filename format ensures proper sorting: prefix_ID_timestamp.seg.
-	slices.Sort(matchingFiles)
+	slices.SortFunc(matchingFiles, func(a, b string) int {
+		return cmp.Compare(ids[a], ids[b])
+	})
 
-	// Return the file with the highest ID (last in sorted order).
-	return matchingFiles[len(matchingFiles)-1], nil
+	return matchingFiles, nil
 }
 
 // GenerateName creates a properly formatted filename for a new segment file.
@@ -114,7 +141,9 @@ func GenerateName(id uint64, prefix string) string {
 	timestamp := time.Now().UnixNano()
 
 	// Format: prefix_NNNNN_timestamp.seg.
-	// %05d ensures zero-padding (00001, 00002, etc.) for proper lexicographic sorting.
+	// %05d zero-pads IDs to at least five digits (00001, 00002, etc.) so that directory
+	// listings read naturally. Larger IDs simply grow wider; discovery orders segments
+	// numerically, so the padding width is not a limit.
 	return fmt.Sprintf("%s_%05d_%d.seg", prefix, id, timestamp)
 }
 
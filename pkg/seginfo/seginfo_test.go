@@ -0,0 +1,87 @@
+package seginfo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// createSegments creates an empty segment file for each ID in dir/segments and
+// returns the directory.
+func createSegments(t *testing.T, prefix string, ids ...uint64) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "segments"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range ids {
+		path := filepath.Join(dir, "segments", GenerateName(id, prefix))
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestListSegmentNamesOrdersNumerically(t *testing.T) {
+	dir := createSegments(t, "segment", 100_000, 99_999, 5, 1_234_567, 100_001)
+
+	names, err := ListSegmentNames(dir, "segments", "segment")
+	if err != nil {
+		t.Fatalf("ListSegmentNames() error = %v", err)
+	}
+
+	want := []uint64{5, 99_999, 100_000, 100_001, 1_234_567}
+	if len(names) != len(want) {
+		t.Fatalf("ListSegmentNames() = %v, want %d files", names, len(want))
+	}
+	for i, name := range names {
+		id, err := ParseSegmentID(name, "segment")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != want[i] {
+			t.Fatalf("ListSegmentNames()[%d] = segment %d, want %d", i, id, want[i])
+		}
+	}
+
+	last, err := GetLastSegmentName(dir, "segments", "segment")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last != names[len(names)-1] {
+		t.Fatalf("GetLastSegmentName() = %s, want %s", last, names[len(names)-1])
+	}
+}
+
+func TestGenerateNamePadsAndGrows(t *testing.T) {
+	tests := map[uint64]string{
+		1:         "00001",
+		42:        "00042",
+		99_999:    "99999",
+		100_000:   "100000",
+		1_234_567: "1234567",
+	}
+	for id, digits := range tests {
+		name := GenerateName(id, "seg")
+		if got, _ := filepath.Match("seg_"+digits+"_*.seg", name); !got {
+			t.Fatalf("GenerateName(%d) = %s, want ID %s", id, name, digits)
+		}
+		if parsed, err := ParseSegmentID(name, "seg"); err != nil || parsed != id {
+			t.Fatalf("ParseSegmentID(%s) = %d, %v, want %d", name, parsed, err, id)
+		}
+	}
+}
+
+func TestListSegmentNamesRejectsMalformedNames(t *testing.T) {
+	dir := createSegments(t, "segment", 1)
+	bad := filepath.Join(dir, "segments", "segment_next_1.seg")
+	if err := os.WriteFile(bad, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ListSegmentNames(dir, "segments", "segment"); err == nil {
+		t.Fatal("ListSegmentNames() accepted a file without a numeric ID")
+	}
+}